@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/fzzy/radix/redis"
+)
+
+// How each type of key is sized, and what its size is counted in
+var sizeCmds = map[string]struct{ cmd, unit string }{
+	"string": {"STRLEN", "bytes"},
+	"list":   {"LLEN", "items"},
+	"set":    {"SCARD", "members"},
+	"hash":   {"HLEN", "fields"},
+	"zset":   {"ZCARD", "members"},
+	"stream": {"XLEN", "entries"},
+}
+
+type typeStats struct {
+	keys    int
+	total   int64
+	biggest string
+	size    int64
+}
+
+// findBigKeys scans the whole keyspace, and writes out the biggest key of each
+// type and how many keys of each type there are, the same as redis-cli's
+// --bigkeys. Each batch of keys from SCAN has its types and then its sizes
+// fetched in a pipeline. If ctx is done the keys scanned so far are summarised.
+func findBigKeys(ctx context.Context, c *redis.Client, out io.Writer) error {
+	fmt.Fprintln(out, "# Scanning the entire keyspace to find biggest keys")
+	stats := map[string]*typeStats{}
+	sampled := 0
+	err := scanAll(c, "*", func(keys []string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		types, err := keyTypes(c, keys)
+		if err != nil {
+			return err
+		}
+		sizes, err := keySizes(c, keys, types)
+		if err != nil {
+			return err
+		}
+		for i, key := range keys {
+			// Keys may have expired or been deleted since they were scanned,
+			// and modules can add types which can't be sized
+			if _, ok := sizeCmds[types[i]]; !ok || sizes[i] < 0 {
+				continue
+			}
+			sampled++
+			s := stats[types[i]]
+			if s == nil {
+				s = &typeStats{size: -1}
+				stats[types[i]] = s
+			}
+			s.keys++
+			s.total += sizes[i]
+			if sizes[i] > s.size {
+				s.biggest, s.size = key, sizes[i]
+				fmt.Fprintf(
+					out, "[%d keys sampled] Biggest %-6s found so far %s with %d %s\n",
+					sampled, types[i], repr([]byte(key)), sizes[i], sizeCmds[types[i]].unit,
+				)
+			}
+		}
+		return nil
+	})
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+	writeBigKeysSummary(out, stats, sampled)
+	return nil
+}
+
+func keyTypes(c *redis.Client, keys []string) ([]string, error) {
+	for _, key := range keys {
+		c.Append("TYPE", key)
+	}
+	types := make([]string, len(keys))
+	for i := range keys {
+		r := c.GetReply()
+		if _, ok := r.Err.(*redis.ConnError); ok {
+			return nil, r.Err
+		}
+		types[i], _ = r.Str()
+	}
+	return types, nil
+}
+
+// keySizes returns the size of each key, or -1 for keys which couldn't be
+// sized
+func keySizes(c *redis.Client, keys, types []string) ([]int64, error) {
+	sizes := make([]int64, len(keys))
+	var sent []int
+	for i, key := range keys {
+		sizes[i] = -1
+		if sc, ok := sizeCmds[types[i]]; ok {
+			c.Append(sc.cmd, key)
+			sent = append(sent, i)
+		}
+	}
+	for _, i := range sent {
+		r := c.GetReply()
+		if _, ok := r.Err.(*redis.ConnError); ok {
+			return nil, r.Err
+		}
+		if n, err := r.Int64(); err == nil {
+			sizes[i] = n
+		}
+	}
+	return sizes, nil
+}
+
+func writeBigKeysSummary(out io.Writer, stats map[string]*typeStats, sampled int) {
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "-------- summary -------")
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Sampled %d keys in the keyspace!\n", sampled)
+	if sampled == 0 {
+		return
+	}
+	fmt.Fprintln(out)
+
+	types := make([]string, 0, len(stats))
+	for typ := range stats {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	for _, typ := range types {
+		s := stats[typ]
+		fmt.Fprintf(
+			out, "Biggest %6s found %s has %d %s\n",
+			typ, repr([]byte(s.biggest)), s.size, sizeCmds[typ].unit,
+		)
+	}
+	fmt.Fprintln(out)
+	for _, typ := range types {
+		s := stats[typ]
+		fmt.Fprintf(
+			out, "%d %ss with %d %s (%.2f%% of keys, avg size %.2f)\n",
+			s.keys, typ, s.total, sizeCmds[typ].unit,
+			float64(s.keys)*100/float64(sampled), float64(s.total)/float64(s.keys),
+		)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"strings"
+	. "testing"
+)
+
+func TestScanKeys(t *T) {
+	c := dial(t)
+	defer c.Close()
+	for _, key := range []string{"a:1", "a:2", "b:1"} {
+		assert.Nil(t, c.Cmd("SET", key, "x").Err)
+	}
+
+	var out bytes.Buffer
+	assert.Nil(t, scanKeys(c, "a:*", &out))
+	keys := strings.Fields(out.String())
+	sort.Strings(keys)
+	assert.Equal(t, []string{"a:1", "a:2"}, keys)
+}
+
+func TestFindBigKeys(t *T) {
+	c := dial(t)
+	defer c.Close()
+	assert.Nil(t, c.Cmd("SET", "small", "x").Err)
+	assert.Nil(t, c.Cmd("SET", "big", "xxxxxxxxxx").Err)
+	assert.Nil(t, c.Cmd("RPUSH", "list", "a", "b", "c").Err)
+	assert.Nil(t, c.Cmd("HSET", "hash", "f", "v").Err)
+
+	var out bytes.Buffer
+	assert.Nil(t, findBigKeys(context.Background(), c, &out))
+	summary := out.String()[strings.Index(out.String(), "-------- summary"):]
+	expected := "" +
+		"-------- summary -------\n\n" +
+		"Sampled 4 keys in the keyspace!\n\n" +
+		"Biggest   hash found \"hash\" has 1 fields\n" +
+		"Biggest   list found \"list\" has 3 items\n" +
+		"Biggest string found \"big\" has 10 bytes\n\n" +
+		"1 hashs with 1 fields (25.00% of keys, avg size 1.00)\n" +
+		"1 lists with 3 items (25.00% of keys, avg size 3.00)\n" +
+		"2 strings with 11 bytes (50.00% of keys, avg size 5.50)\n"
+	assert.Equal(t, expected, summary)
+}
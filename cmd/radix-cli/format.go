@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/fzzy/radix/redis"
+)
+
+// writeReply writes r the way redis-cli does, or if raw is set as just the
+// values with no quoting or types, one per line
+func writeReply(w io.Writer, r *redis.Reply, raw bool) {
+	if raw {
+		writeRaw(w, r)
+		return
+	}
+	io.WriteString(w, formatReply(r, ""))
+}
+
+// formatReply formats r the way redis-cli does. indent is what each line of a
+// nested reply's elements are prefixed with.
+func formatReply(r *redis.Reply, indent string) string {
+	switch r.Type {
+	case redis.StatusReply:
+		s, _ := r.Str()
+		return s + "\n"
+	case redis.ErrorReply:
+		return "(error) " + r.Err.Error() + "\n"
+	case redis.IntegerReply:
+		n, _ := r.Int64()
+		return "(integer) " + strconv.FormatInt(n, 10) + "\n"
+	case redis.NilReply:
+		return "(nil)\n"
+	case redis.BulkReply:
+		b, _ := r.Bytes()
+		return repr(b) + "\n"
+	}
+
+	if len(r.Elems) == 0 {
+		return "(empty array)\n"
+	}
+	// Elements are numbered, with the numbers right aligned, and the
+	// elements of nested replies are indented past them
+	width := len(strconv.Itoa(len(r.Elems)))
+	var buf bytes.Buffer
+	for i, e := range r.Elems {
+		if i > 0 {
+			buf.WriteString(indent)
+		}
+		num := fmt.Sprintf("%*d) ", width, i+1)
+		buf.WriteString(num)
+		buf.WriteString(formatReply(e, indent+strings.Repeat(" ", len(num))))
+	}
+	return buf.String()
+}
+
+func writeRaw(w io.Writer, r *redis.Reply) {
+	switch r.Type {
+	case redis.ErrorReply:
+		fmt.Fprintln(w, r.Err)
+	case redis.NilReply:
+		fmt.Fprintln(w)
+	case redis.IntegerReply:
+		n, _ := r.Int64()
+		fmt.Fprintln(w, n)
+	case redis.MultiReply:
+		for _, e := range r.Elems {
+			writeRaw(w, e)
+		}
+	default:
+		b, _ := r.Bytes()
+		w.Write(b)
+		fmt.Fprintln(w)
+	}
+}
+
+// repr quotes b, escaping it the same way redis-cli and MONITOR do
+func repr(b []byte) string {
+	var buf bytes.Buffer
+	buf.WriteByte('"')
+	for _, c := range b {
+		switch c {
+		case '\\', '"':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\a':
+			buf.WriteString(`\a`)
+		case '\b':
+			buf.WriteString(`\b`)
+		default:
+			if c < ' ' || c > '~' {
+				fmt.Fprintf(&buf, `\x%02x`, c)
+			} else {
+				buf.WriteByte(c)
+			}
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
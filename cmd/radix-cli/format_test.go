@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"github.com/fzzy/radix/redis"
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func TestFormatReply(t *T) {
+	format := func(v interface{}) string {
+		return formatReply(redis.NewReply(v), "")
+	}
+	assert.Equal(t, "OK\n", formatReply(redis.NewStatusReply("OK"), ""))
+	assert.Equal(t, "(integer) 42\n", format(42))
+	assert.Equal(t, "(nil)\n", format(nil))
+	assert.Equal(t, `"a\"b\n\x01"`+"\n", format("a\"b\n\x01"))
+	assert.Equal(t, "(error) ERR bad\n", format(errors.New("ERR bad")))
+	assert.Equal(t, "(empty array)\n", format([]string{}))
+
+	// The numbers are right aligned, and nested elements indented past them
+	elems := []interface{}{"x", "x", "x", "x", "x", "x", "x", "x", "x", []string{"a", "b"}}
+	expected := "" +
+		` 1) "x"` + "\n" + ` 2) "x"` + "\n" + ` 3) "x"` + "\n" +
+		` 4) "x"` + "\n" + ` 5) "x"` + "\n" + ` 6) "x"` + "\n" +
+		` 7) "x"` + "\n" + ` 8) "x"` + "\n" + ` 9) "x"` + "\n" +
+		`10) 1) "a"` + "\n" + `    2) "b"` + "\n"
+	assert.Equal(t, expected, format(elems))
+}
+
+func TestWriteRaw(t *T) {
+	var buf bytes.Buffer
+	writeReply(&buf, redis.NewReply([]interface{}{"a\"b", 3, nil}), true)
+	assert.Equal(t, "a\"b\n3\n\n", buf.String())
+}
@@ -0,0 +1,159 @@
+// radix-cli is a command line client for redis, in the style of redis-cli,
+// built on the radix client. Given a command it sends it and prints the reply:
+//
+//	radix-cli -h 127.0.0.1 -p 6379 SET foo bar
+//
+// Without one it reads commands from stdin, prompting for them if stdin is a
+// terminal. It can also list keys (--scan, optionally with --pattern), find
+// the biggest key of each type (--bigkeys), or print every command the server
+// runs (--monitor). Use --raw to print replies without quotes or types.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fzzy/radix/redis"
+)
+
+// How often long running modes check whether they've been interrupted
+const pollInterval = 100 * time.Millisecond
+
+// How many keys are asked for at a time by --scan and --bigkeys
+const scanCount = 1000
+
+func main() {
+	host := flag.String("h", "127.0.0.1", "server hostname")
+	port := flag.Int("p", 6379, "server port")
+	socket := flag.String("s", "", "server socket (overrides hostname and port)")
+	user := flag.String("user", "", "username to AUTH with, for redis 6 ACLs")
+	pass := flag.String("a", "", "password to AUTH with")
+	db := flag.Int("n", 0, "database number")
+	timeout := flag.Duration("t", 0, "timeout for connecting, and for each reply")
+	raw := flag.Bool("raw", false, "print replies without quotes or types")
+	scan := flag.Bool("scan", false, "list all the keys using SCAN")
+	pattern := flag.String("pattern", "*", "the keys to list with --scan")
+	bigkeys := flag.Bool("bigkeys", false, "find the biggest key of each type")
+	mon := flag.Bool("monitor", false, "print every command the server runs")
+	flag.Parse()
+
+	cfg := redis.Config{
+		Network:      "tcp",
+		Addr:         net.JoinHostPort(*host, strconv.Itoa(*port)),
+		DialTimeout:  *timeout,
+		ReadTimeout:  *timeout,
+		WriteTimeout: *timeout,
+		Username:     *user,
+		Password:     *pass,
+		DB:           *db,
+		Reconnect:    true,
+	}
+	if *socket != "" {
+		cfg.Network, cfg.Addr = "unix", *socket
+	}
+	c, err := redis.DialConfig(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not connect to redis at %s: %s\n", cfg.Addr, err)
+		os.Exit(1)
+	}
+	defer c.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	out := os.Stdout
+
+	switch {
+	case *scan:
+		err = scanKeys(c, *pattern, out)
+	case *bigkeys:
+		err = findBigKeys(ctx, c, out)
+	case *mon:
+		err = monitor(ctx, c, out)
+	case flag.NArg() > 0:
+		err = run(ctx, c, flag.Args(), out, *raw)
+	default:
+		prompt := ""
+		if isTerminal(os.Stdin) {
+			prompt = cfg.Addr + "> "
+		}
+		err = repl(ctx, c, os.Stdin, out, prompt, *raw)
+	}
+	if err != nil && ctx.Err() == nil {
+		if _, ok := err.(*redis.ConnError); !ok {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// scanKeys writes every key matching pattern to out, one per line
+func scanKeys(c *redis.Client, pattern string, out io.Writer) error {
+	return scanAll(c, pattern, func(keys []string) error {
+		for _, key := range keys {
+			fmt.Fprintln(out, key)
+		}
+		return nil
+	})
+}
+
+// scanAll calls fn with each batch of keys matching pattern returned by SCAN
+func scanAll(c *redis.Client, pattern string, fn func([]string) error) error {
+	cursor := "0"
+	for {
+		r := c.Cmd("SCAN", cursor, "MATCH", pattern, "COUNT", scanCount)
+		if r.Err != nil {
+			return r.Err
+		}
+		if len(r.Elems) != 2 {
+			return fmt.Errorf("malformed SCAN reply")
+		}
+		var err error
+		if cursor, err = r.Elems[0].Str(); err != nil {
+			return err
+		}
+		keys, err := r.Elems[1].List()
+		if err != nil {
+			return err
+		}
+		if err = fn(keys); err != nil {
+			return err
+		}
+		if cursor == "0" {
+			return nil
+		}
+	}
+}
+
+// monitor writes every command the server runs to out, the same way MONITOR
+// itself formats them, until ctx is done
+func monitor(ctx context.Context, c *redis.Client, out io.Writer) error {
+	fmt.Fprintln(out, "OK")
+	err := c.Monitor(ctx, func(e redis.MonitorEntry) {
+		args := make([]string, 0, len(e.Args)+1)
+		for _, arg := range append([]string{e.Cmd}, e.Args...) {
+			args = append(args, repr([]byte(arg)))
+		}
+		fmt.Fprintf(
+			out, "%d.%06d [%d %s] %s\n",
+			e.Time.Unix(), e.Time.Nanosecond()/1000, e.DB, e.Addr,
+			strings.Join(args, " "),
+		)
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
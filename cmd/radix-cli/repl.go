@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/fzzy/radix/redis"
+)
+
+// splitArgs splits a line into arguments the way redis-cli does: on spaces,
+// except within double quotes, which may contain escapes (e.g. \n or \x41), or
+// single quotes, which may only contain \'
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	for {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			return args, nil
+		}
+		var arg []byte
+		var err error
+		switch line[0] {
+		case '"':
+			arg, line, err = splitQuoted(line[1:])
+		case '\'':
+			arg, line, err = splitSingleQuoted(line[1:])
+		default:
+			end := strings.IndexAny(line, " \t")
+			if end < 0 {
+				end = len(line)
+			}
+			arg, line = []byte(line[:end]), line[end:]
+		}
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, string(arg))
+	}
+}
+
+// The same message redis-cli gives
+var invalidArgsError = errors.New("Invalid argument(s)")
+
+// splitQuoted reads a double quoted argument, whose opening quote has already
+// been read, returning it and the rest of the line
+func splitQuoted(line string) ([]byte, string, error) {
+	var arg []byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if c == '"' {
+			// The closing quote must end the argument
+			if i+1 < len(line) && line[i+1] != ' ' && line[i+1] != '\t' {
+				return nil, "", invalidArgsError
+			}
+			return arg, line[i+1:], nil
+		}
+		if c != '\\' || i+1 == len(line) {
+			arg = append(arg, c)
+			continue
+		}
+		i++
+		switch line[i] {
+		case 'n':
+			arg = append(arg, '\n')
+		case 'r':
+			arg = append(arg, '\r')
+		case 't':
+			arg = append(arg, '\t')
+		case 'b':
+			arg = append(arg, '\b')
+		case 'a':
+			arg = append(arg, '\a')
+		case 'x':
+			if i+2 < len(line) {
+				if n, err := strconv.ParseUint(line[i+1:i+3], 16, 8); err == nil {
+					arg = append(arg, byte(n))
+					i += 2
+					continue
+				}
+			}
+			arg = append(arg, 'x')
+		default:
+			arg = append(arg, line[i])
+		}
+	}
+	return nil, "", invalidArgsError
+}
+
+// splitSingleQuoted is like splitQuoted, for single quotes
+func splitSingleQuoted(line string) ([]byte, string, error) {
+	var arg []byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if c == '\\' && i+1 < len(line) && line[i+1] == '\'' {
+			arg = append(arg, '\'')
+			i++
+			continue
+		}
+		if c == '\'' {
+			if i+1 < len(line) && line[i+1] != ' ' && line[i+1] != '\t' {
+				return nil, "", invalidArgsError
+			}
+			return arg, line[i+1:], nil
+		}
+		arg = append(arg, c)
+	}
+	return nil, "", invalidArgsError
+}
+
+// repl reads commands from in, one per line, and writes their replies to out,
+// until in ends or "quit" or "exit" is read. If prompt isn't empty it's
+// written before each line is read.
+func repl(ctx context.Context, c *redis.Client, in io.Reader, out io.Writer, prompt string, raw bool) error {
+	s := bufio.NewScanner(in)
+	for {
+		if prompt != "" {
+			io.WriteString(out, prompt)
+		}
+		if !s.Scan() {
+			return s.Err()
+		}
+		args, err := splitArgs(s.Text())
+		if err != nil {
+			fmt.Fprintln(out, err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		switch strings.ToLower(args[0]) {
+		case "quit", "exit":
+			return nil
+		}
+		err = run(ctx, c, args, out, raw)
+		if ctx.Err() != nil {
+			return nil
+		} else if _, ok := err.(*redis.ConnError); !ok && err != nil {
+			fmt.Fprintln(out, "(error)", err)
+		}
+		// A connection error has been written out already, and the client
+		// reconnects for the next command
+	}
+}
+
+// run sends a single command and writes its reply to out. MONITOR and the
+// subscribe commands keep writing what they're sent until ctx is done, or the
+// connection fails. Connection errors are written out as the reply, and then
+// returned as well; other errors in the reply are only written out.
+func run(ctx context.Context, c *redis.Client, args []string, out io.Writer, raw bool) error {
+	cmd := strings.ToUpper(args[0])
+	if cmd == "MONITOR" {
+		return monitor(ctx, c, out)
+	}
+
+	cmdArgs := make([]interface{}, len(args)-1)
+	for i, arg := range args[1:] {
+		cmdArgs[i] = arg
+	}
+	r := c.Cmd(args[0], cmdArgs...)
+	writeReply(out, r, raw)
+	if _, ok := r.Err.(*redis.ConnError); ok {
+		return r.Err
+	}
+
+	switch cmd {
+	case "SUBSCRIBE", "PSUBSCRIBE", "SSUBSCRIBE":
+	default:
+		return nil
+	}
+	if r.Err != nil {
+		return nil
+	}
+	// Every subscription gets a confirmation, the first of which was the
+	// reply above, and then messages keep coming
+	rc := c.WithTimeout(pollInterval)
+	for ctx.Err() == nil {
+		r := rc.ReadReply()
+		if errors.Is(r.Err, redis.TimeoutError) {
+			continue
+		}
+		writeReply(out, r, raw)
+		if r.Err != nil {
+			return r.Err
+		}
+	}
+	return nil
+}
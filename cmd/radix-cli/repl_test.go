@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"github.com/fzzy/radix/redis"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	. "testing"
+)
+
+// dial connects to the test server on a database of its own, which is emptied
+// first, since --bigkeys looks at every key
+func dial(t *T) *redis.Client {
+	c, err := redis.DialConfig(redis.Config{Network: "tcp", Addr: "127.0.0.1:6379", DB: 9})
+	assert.Nil(t, err)
+	assert.Nil(t, c.Cmd("FLUSHDB").Err)
+	return c
+}
+
+func TestSplitArgs(t *T) {
+	args, err := splitArgs(`  SET  "a b\n\x41\"" 'it\'s' c`)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"SET", "a b\nA\"", "it's", "c"}, args)
+
+	args, err = splitArgs(`GET ""`)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"GET", ""}, args)
+
+	args, err = splitArgs("  ")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(args))
+
+	for _, line := range []string{`GET "foo`, `GET 'foo`, `GET "foo"bar`, `GET 'foo'bar`} {
+		_, err = splitArgs(line)
+		assert.Equal(t, invalidArgsError, err, line)
+	}
+}
+
+func TestRepl(t *T) {
+	c := dial(t)
+	defer c.Close()
+
+	in := strings.NewReader("SET foo \"bar baz\"\n\nGET foo\nINCR foo\nGET \"foo\nRPUSH l a b\nLRANGE l 0 -1\nquit\nGET foo\n")
+	var out bytes.Buffer
+	assert.Nil(t, repl(context.Background(), c, in, &out, "> ", false))
+	expected := "" +
+		"> OK\n" +
+		"> " +
+		"> \"bar baz\"\n" +
+		"> (error) ERR value is not an integer or out of range\n" +
+		"> Invalid argument(s)\n" +
+		"> (integer) 2\n" +
+		"> 1) \"a\"\n2) \"b\"\n" +
+		"> "
+	assert.Equal(t, expected, out.String())
+
+	out.Reset()
+	assert.Nil(t, run(context.Background(), c, []string{"LRANGE", "l", "0", "-1"}, &out, true))
+	assert.Equal(t, "a\nb\n", out.String())
+}
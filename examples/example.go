@@ -96,6 +96,20 @@ func main() {
 	errHndlr(err)
 	fmt.Println("multikey:", s)
 
+	//* Optimistic transactions
+
+	// Doubles mycounter, retrying up to 5 times if someone else changes it
+	// between the GET and the EXEC
+	_, err = c.Cas("mycounter", 5, func(old *redis.Reply, b *redis.Batch) error {
+		n, err := old.Int64OrDefault(1)
+		if err != nil {
+			return err
+		}
+		b.Add("set", "mycounter", n*2)
+		return nil
+	})
+	errHndlr(err)
+
 	//* Publish/Subscribe
 
 	// Subscribe
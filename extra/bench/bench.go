@@ -0,0 +1,372 @@
+// Package bench drives a configurable mix of commands against a redis server
+// and reports the throughput and latencies seen, so the performance of the
+// client itself can be measured reproducibly, and regressions caught:
+//
+//	res, err := bench.Run(bench.Options{
+//		Config:   redis.Config{Network: "tcp", Addr: "127.0.0.1:6379"},
+//		Mode:     bench.Pipelined,
+//		Parallel: 8,
+//		Requests: 100000,
+//	})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Println(res)
+//
+// Results are only comparable between runs against the same server, on the
+// same machine, with the same Options.
+package bench
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fzzy/radix/redis"
+)
+
+// Mode is how the commands are sent, see Options
+type Mode int
+
+const (
+	// Each routine sends one command at a time on a connection of its own,
+	// waiting for each reply before sending the next
+	Sequential Mode = iota
+
+	// Each routine sends Options.Pipeline commands at a time on a connection
+	// of its own, pipelined with Append and GetReply
+	Pipelined
+
+	// All the routines share a single redis.AsyncClient, each sending
+	// Options.Pipeline commands at a time before waiting for their replies
+	Async
+
+	// All the routines share a single redis.Mux, each sending one command at
+	// a time
+	Mux
+)
+
+var modeNames = map[Mode]string{
+	Sequential: "sequential", Pipelined: "pipelined", Async: "async", Mux: "mux",
+}
+
+func (m Mode) String() string {
+	if s, ok := modeNames[m]; ok {
+		return s
+	}
+	return "Mode(" + strconv.Itoa(int(m)) + ")"
+}
+
+// Op is a command in the mix sent by Run
+type Op struct {
+	Cmd string
+
+	// How often the command is sent relative to the others in the mix
+	Weight int
+
+	// Returns the command's arguments given a random key, from a space of
+	// Options.Keys keys, and a value of Options.ValueSize bytes. If nil the key
+	// alone is used.
+	Args func(key string, value []byte) []interface{}
+}
+
+// KeyValue gives the key and value as the arguments, for commands like SET
+func KeyValue(key string, value []byte) []interface{} {
+	return []interface{}{key, value}
+}
+
+// DefaultMix is nine GETs to every SET
+var DefaultMix = []Op{
+	{Cmd: "GET", Weight: 9},
+	{Cmd: "SET", Weight: 1, Args: KeyValue},
+}
+
+// Options describe a benchmark run, see Run
+type Options struct {
+	// The server to run against. Connections are made with redis.DialConfig.
+	Config redis.Config
+
+	Mode Mode
+
+	// How many routines send commands at once. Defaults to 1.
+	Parallel int
+
+	// How many commands are sent at a time in the Pipelined and Async modes.
+	// Defaults to 10.
+	Pipeline int
+
+	// How many commands to send in total. If Duration is set commands are
+	// sent until it's passed instead. Defaults to 10000.
+	Requests int
+	Duration time.Duration
+
+	// The commands to send, chosen at random by weight. Defaults to DefaultMix.
+	Mix []Op
+
+	// How many distinct keys are used, and how big the values are. Keys all
+	// start with KeyPrefix, which defaults to "bench:". Keys defaults to 1000,
+	// and ValueSize to 16.
+	Keys      int
+	ValueSize int
+	KeyPrefix string
+
+	// If set, the random choices of commands and keys are seeded with this,
+	// so runs send the same commands. Zero seeds each run differently.
+	Seed int64
+}
+
+// Result is what was seen during a run
+type Result struct {
+	Mode     Mode
+	Requests int
+	Errors   int
+	Elapsed  time.Duration
+
+	// Each command's latency, sorted. In the Pipelined and Async modes each
+	// command counts as taking as long as the batch it was sent in.
+	Latencies []time.Duration
+}
+
+// Throughput returns how many commands were sent per second
+func (r *Result) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Requests) / r.Elapsed.Seconds()
+}
+
+// Percentile returns the latency which p percent of the commands were at least
+// as fast as, e.g. 99 for the 99th percentile
+func (r *Result) Percentile(p float64) time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	i := int(float64(len(r.Latencies))*p/100+0.5) - 1
+	if i < 0 {
+		i = 0
+	} else if i >= len(r.Latencies) {
+		i = len(r.Latencies) - 1
+	}
+	return r.Latencies[i]
+}
+
+func (r *Result) String() string {
+	return fmt.Sprintf(
+		"%s: %d requests (%d errors) in %s, %.0f/s, p50 %s, p90 %s, p99 %s, max %s",
+		r.Mode, r.Requests, r.Errors, r.Elapsed, r.Throughput(),
+		r.Percentile(50), r.Percentile(90), r.Percentile(99), r.Percentile(100),
+	)
+}
+
+func (o *Options) setDefaults() {
+	if o.Parallel <= 0 {
+		o.Parallel = 1
+	}
+	if o.Pipeline <= 0 {
+		o.Pipeline = 10
+	}
+	if o.Requests <= 0 {
+		o.Requests = 10000
+	}
+	if len(o.Mix) == 0 {
+		o.Mix = DefaultMix
+	}
+	if o.Keys <= 0 {
+		o.Keys = 1000
+	}
+	if o.ValueSize <= 0 {
+		o.ValueSize = 16
+	}
+	if o.KeyPrefix == "" {
+		o.KeyPrefix = "bench:"
+	}
+	if o.Seed == 0 {
+		o.Seed = time.Now().UnixNano()
+	}
+}
+
+// Run sends commands as described by opts, and returns what was seen. An error
+// is only returned if connecting failed; errors replied to commands are
+// counted in the Result.
+func Run(opts Options) (*Result, error) {
+	opts.setDefaults()
+	var send sender
+	var closers []func() error
+	defer func() {
+		for _, close := range closers {
+			close()
+		}
+	}()
+	switch opts.Mode {
+	case Async:
+		c, err := redis.DialConfig(opts.Config)
+		if err != nil {
+			return nil, err
+		}
+		a := redis.NewAsyncClient(c)
+		closers = append(closers, a.Close)
+		send = func(cmds []redis.Cmd, _ int) []*redis.Reply {
+			return sendAsync(a, cmds)
+		}
+	case Mux:
+		m, err := redis.NewMux(opts.Config)
+		if err != nil {
+			return nil, err
+		}
+		closers = append(closers, m.Close)
+		send = func(cmds []redis.Cmd, _ int) []*redis.Reply {
+			return []*redis.Reply{m.Cmd(cmds[0].Name, cmds[0].Args...)}
+		}
+	default:
+		conns := make([]*redis.Client, opts.Parallel)
+		for i := range conns {
+			c, err := redis.DialConfig(opts.Config)
+			if err != nil {
+				return nil, err
+			}
+			conns[i] = c
+			closers = append(closers, c.Close)
+		}
+		send = func(cmds []redis.Cmd, worker int) []*redis.Reply {
+			if opts.Mode == Sequential {
+				c := conns[worker]
+				return []*redis.Reply{c.Cmd(cmds[0].Name, cmds[0].Args...)}
+			}
+			return redis.Batch(cmds).Pipeline(conns[worker])
+		}
+	}
+
+	batch := 1
+	if opts.Mode == Pipelined || opts.Mode == Async {
+		batch = opts.Pipeline
+	}
+	r := &runner{opts: &opts, send: send, batch: batch, left: int64(opts.Requests)}
+	return r.run(), nil
+}
+
+// sender sends the given commands, on behalf of the given worker routine, and
+// returns their replies
+type sender func(cmds []redis.Cmd, worker int) []*redis.Reply
+
+func sendAsync(a *redis.AsyncClient, cmds []redis.Cmd) []*redis.Reply {
+	futs := make([]*redis.Future, len(cmds))
+	for i, cmd := range cmds {
+		futs[i] = a.Cmd(cmd.Name, cmd.Args...)
+	}
+	replies := make([]*redis.Reply, len(futs))
+	for i, f := range futs {
+		replies[i] = f.Reply()
+	}
+	return replies
+}
+
+type runner struct {
+	// How many requests are still to be sent, if there's no Duration. It's
+	// first so it's aligned for the atomic operations.
+	left int64
+
+	opts     *Options
+	send     sender
+	batch    int
+	deadline time.Time
+}
+
+func (r *runner) run() *Result {
+	start := time.Now()
+	if r.opts.Duration > 0 {
+		r.deadline = start.Add(r.opts.Duration)
+	}
+
+	results := make([]Result, r.opts.Parallel)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r.work(i, &results[i])
+		}(i)
+	}
+	wg.Wait()
+
+	res := &Result{Mode: r.opts.Mode, Elapsed: time.Since(start)}
+	for _, wr := range results {
+		res.Requests += wr.Requests
+		res.Errors += wr.Errors
+		res.Latencies = append(res.Latencies, wr.Latencies...)
+	}
+	sort.Slice(res.Latencies, func(i, j int) bool {
+		return res.Latencies[i] < res.Latencies[j]
+	})
+	return res
+}
+
+// take returns how many commands the worker should send next, or 0 if it's
+// done
+func (r *runner) take() int {
+	if !r.deadline.IsZero() {
+		if time.Now().After(r.deadline) {
+			return 0
+		}
+		return r.batch
+	}
+	// What was left before this batch was taken
+	n := atomic.AddInt64(&r.left, -int64(r.batch)) + int64(r.batch)
+	if n <= 0 {
+		return 0
+	} else if n < int64(r.batch) {
+		return int(n)
+	}
+	return r.batch
+}
+
+func (r *runner) work(worker int, res *Result) {
+	rnd := rand.New(rand.NewSource(r.opts.Seed + int64(worker)))
+	value := make([]byte, r.opts.ValueSize)
+	rnd.Read(value)
+	total := 0
+	for _, op := range r.opts.Mix {
+		total += op.Weight
+	}
+
+	cmds := make([]redis.Cmd, 0, r.batch)
+	for n := r.take(); n > 0; n = r.take() {
+		cmds = cmds[:0]
+		for i := 0; i < n; i++ {
+			cmds = append(cmds, r.cmd(rnd, total, value))
+		}
+		start := time.Now()
+		replies := r.send(cmds, worker)
+		took := time.Since(start)
+		for _, reply := range replies {
+			if reply.Err != nil {
+				res.Errors++
+			}
+			res.Latencies = append(res.Latencies, took)
+		}
+		res.Requests += len(replies)
+	}
+}
+
+// cmd returns a random command from the mix, whose weights add up to total
+func (r *runner) cmd(rnd *rand.Rand, total int, value []byte) redis.Cmd {
+	op := r.opts.Mix[0]
+	if total > 0 {
+		w := rnd.Intn(total)
+		for _, o := range r.opts.Mix {
+			if w < o.Weight {
+				op = o
+				break
+			}
+			w -= o.Weight
+		}
+	}
+	key := r.opts.KeyPrefix + strconv.Itoa(rnd.Intn(r.opts.Keys))
+	if op.Args == nil {
+		return redis.Cmd{Name: op.Cmd, Args: []interface{}{key}}
+	}
+	return redis.Cmd{Name: op.Cmd, Args: op.Args(key, value)}
+}
@@ -0,0 +1,75 @@
+package bench
+
+import (
+	"github.com/fzzy/radix/redis"
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+var testConfig = redis.Config{Network: "tcp", Addr: "127.0.0.1:6379"}
+
+func TestRun(t *T) {
+	for _, mode := range []Mode{Sequential, Pipelined, Async, Mux} {
+		res, err := Run(Options{
+			Config:   testConfig,
+			Mode:     mode,
+			Parallel: 3,
+			Pipeline: 7,
+			Requests: 100,
+			Keys:     10,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, mode, res.Mode)
+		assert.Equal(t, 100, res.Requests, "%s", mode)
+		assert.Equal(t, 0, res.Errors)
+		assert.Equal(t, 100, len(res.Latencies))
+		assert.True(t, res.Throughput() > 0)
+		assert.True(t, res.Percentile(50) <= res.Percentile(99))
+	}
+
+	// Errors from the server are counted rather than stopping the run
+	res, err := Run(Options{
+		Config:   testConfig,
+		Requests: 10,
+		Mix:      []Op{{Cmd: "NOTACOMMAND", Weight: 1}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 10, res.Errors)
+
+	_, err = Run(Options{Config: redis.Config{Network: "tcp", Addr: "127.0.0.1:1"}})
+	assert.NotNil(t, err)
+}
+
+func TestRunDuration(t *T) {
+	start := time.Now()
+	res, err := Run(Options{
+		Config:   testConfig,
+		Mode:     Pipelined,
+		Duration: 50 * time.Millisecond,
+		Mix: []Op{
+			{Cmd: "SET", Weight: 1, Args: KeyValue},
+			{Cmd: "INCR", Weight: 1, Args: func(key string, _ []byte) []interface{} {
+				return []interface{}{key + ":n"}
+			}},
+		},
+	})
+	assert.Nil(t, err)
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+	assert.True(t, res.Requests > 0)
+	assert.Equal(t, 0, res.Errors)
+}
+
+func TestPercentile(t *T) {
+	res := &Result{Requests: 4, Elapsed: 2 * time.Second}
+	assert.Equal(t, time.Duration(0), res.Percentile(50))
+	for i := 1; i <= 100; i++ {
+		res.Latencies = append(res.Latencies, time.Duration(i))
+	}
+	assert.Equal(t, time.Duration(50), res.Percentile(50))
+	assert.Equal(t, time.Duration(99), res.Percentile(99))
+	assert.Equal(t, time.Duration(100), res.Percentile(100))
+	assert.Equal(t, time.Duration(1), res.Percentile(0))
+	assert.Equal(t, float64(2), res.Throughput())
+	assert.Equal(t, "mux", Mux.String())
+}
@@ -0,0 +1,74 @@
+package cluster
+
+import (
+	"strings"
+
+	"github.com/fzzy/radix/redis"
+)
+
+// Batch runs all the commands in the batch, splitting them up by the node
+// which holds each one's key (the first argument) and pipelining each node's
+// commands on its connection. The replies are returned in the same order as
+// the commands. Any command which gets redirected, or fails due to a
+// connection problem, is retried on its own through Cmd, which deals with
+// those. Commands with no key get a BadCmdNoKey error, as with Cmd.
+//
+// The commands sent to a single node are pipelined in order, but there's no
+// ordering between nodes, so commands which depend on each other's effects
+// should use keys in the same slot (see hash tags).
+func (c *Cluster) Batch(b redis.Batch) []*redis.Reply {
+	replies := make([]*redis.Reply, len(b))
+
+	type nodeBatch struct {
+		client  *redis.Client
+		indexes []int
+		batch   redis.Batch
+	}
+	nodes := map[string]*nodeBatch{}
+	var addrs []string
+	for i, cmd := range b {
+		key, err := cmdKey(cmd.Name, cmd.Args)
+		if err != nil {
+			replies[i] = errorReply(err)
+			continue
+		}
+		client, addr, err := c.ClientForKey(key)
+		if err != nil {
+			replies[i] = errorReply(err)
+			continue
+		}
+		nb, ok := nodes[addr]
+		if !ok {
+			nb = &nodeBatch{client: client}
+			nodes[addr] = nb
+			addrs = append(addrs, addr)
+		}
+		nb.indexes = append(nb.indexes, i)
+		nb.batch = append(nb.batch, cmd)
+	}
+
+	for _, addr := range addrs {
+		nb := nodes[addr]
+		for j, r := range nb.batch.Pipeline(nb.client) {
+			i := nb.indexes[j]
+			if needsRetry(r) {
+				r = c.Cmd(b[i].Name, b[i].Args...)
+			}
+			replies[i] = r
+		}
+	}
+	return replies
+}
+
+// needsRetry returns whether a reply from a pipelined command means the
+// command should be run again through Cmd
+func needsRetry(r *redis.Reply) bool {
+	if r.Err == nil {
+		return false
+	}
+	if _, ok := r.Err.(*redis.CmdError); !ok {
+		return true
+	}
+	msg := r.Err.Error()
+	return strings.HasPrefix(msg, "MOVED ") || strings.HasPrefix(msg, "ASK ")
+}
@@ -0,0 +1,31 @@
+package cluster
+
+import (
+	"github.com/fzzy/radix/redis"
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func TestBatch(t *T) {
+	cluster := getCluster(t)
+
+	var b redis.Batch
+	b.Add("SET", "foo", "bar")
+	b.Add("SET", "bar", "foo")
+	b.Add("GET", "foo")
+	b.Add("GET", "bar")
+	b.Add("PING")
+	replies := cluster.Batch(b)
+	assert.Equal(t, 5, len(replies))
+
+	for _, r := range replies[:2] {
+		assert.Nil(t, r.Err)
+	}
+	s, err := replies[2].Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "bar", s)
+	s, err = replies[3].Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", s)
+	assert.NotNil(t, replies[4].Err)
+}
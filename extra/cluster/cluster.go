@@ -226,15 +226,13 @@ func (c *Cluster) Reset() error {
 
 // Cmd performs the given command on the correct cluster node and gives back the
 // command's reply. The command *must* have a key parameter (i.e. len(args) >=
-// 1). If any MOVED or ASK errors are returned they will be transparently
-// handled by this method. This method will also increment the Misses field on
-// the Cluster struct whenever a redirection occurs
+// 1). For EVAL and EVALSHA the first of the script's keys is used, so scripts
+// must be given at least one. If any MOVED or ASK errors are returned they
+// will be transparently handled by this method. This method will also
+// increment the Misses field on the Cluster struct whenever a redirection
+// occurs
 func (c *Cluster) Cmd(cmd string, args ...interface{}) *redis.Reply {
-	if len(args) < 1 {
-		return errorReply(BadCmdNoKey)
-	}
-
-	key, err := keyFromArg(args[0])
+	key, err := cmdKey(cmd, args)
 	if err != nil {
 		return errorReply(err)
 	}
@@ -397,6 +395,26 @@ func redirectInfo(msg string) (int, string) {
 	return slot, addr
 }
 
+// cmdKey returns the key the command should be routed by
+func cmdKey(cmd string, args []interface{}) (string, error) {
+	if len(args) < 1 {
+		return "", BadCmdNoKey
+	}
+	switch strings.ToUpper(cmd) {
+	case "EVAL", "EVALSHA", "EVAL_RO", "EVALSHA_RO":
+		// EVAL script numkeys key [key ...] arg [arg ...]
+		if len(args) < 3 {
+			return "", BadCmdNoKey
+		}
+		numKeys, err := keyFromArg(args[1])
+		if err != nil || numKeys == "0" {
+			return "", BadCmdNoKey
+		}
+		return keyFromArg(args[2])
+	}
+	return keyFromArg(args[0])
+}
+
 // We unfortunately support some weird stuff for command arguments, such as
 // automatically flattening slices and things like that. So this gets
 // complicated. Usually the user will do something normal like pass in a string
@@ -9,6 +9,8 @@ import (
 	"github.com/fzzy/radix/redis"
 )
 
+var _ redis.Commander = &Cluster{}
+
 // These tests assume there is a cluster running on ports 7000 and 7001, with
 // the first half of the slots assigned to 7000 and the second half assigned to
 // 7001. Calling `make up` inside of extra/cluster/testconfs will set this up
@@ -35,6 +37,21 @@ func TestKeyFromArg(t *T) {
 	}
 }
 
+func TestCmdKey(t *T) {
+	key, err := cmdKey("GET", []interface{}{"foo"})
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", key)
+
+	key, err = cmdKey("evalsha", []interface{}{"abc123", 2, "foo", "bar", "baz"})
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", key)
+
+	_, err = cmdKey("EVAL", []interface{}{"return 1", 0})
+	assert.Equal(t, BadCmdNoKey, err)
+	_, err = cmdKey("PING", nil)
+	assert.Equal(t, BadCmdNoKey, err)
+}
+
 func getCluster(t *T) *Cluster {
 	cluster, err := NewCluster("127.0.0.1:7000")
 	if err != nil {
@@ -0,0 +1,109 @@
+package cluster
+
+import (
+	"fmt"
+
+	"github.com/fzzy/radix/redis"
+)
+
+// SlotRange is a range of slots, from Start to End inclusive, which are all
+// served by the node at Addr. Keys is the number of keys in the range, as
+// counted by SlotKeyCounts.
+type SlotRange struct {
+	Start, End int
+	Addr       string
+	Keys       int64
+}
+
+// slotRanges splits the mapping into ranges of consecutive slots served by the
+// same node. Slots which aren't served by any node are skipped.
+func (m *mapping) slotRanges() []SlotRange {
+	var ranges []SlotRange
+	for i := 0; i < NUM_SLOTS; i++ {
+		if m[i] == "" {
+			continue
+		}
+		if l := len(ranges) - 1; l >= 0 &&
+			ranges[l].End == i-1 && ranges[l].Addr == m[i] {
+			ranges[l].End = i
+			continue
+		}
+		ranges = append(ranges, SlotRange{Start: i, End: i, Addr: m[i]})
+	}
+	return ranges
+}
+
+// clientForSlot returns the client for the node which serves the given slot
+func (c *Cluster) clientForSlot(slot int) (*redis.Client, error) {
+	if slot < 0 || slot >= NUM_SLOTS {
+		return nil, fmt.Errorf("slot %d out of range", slot)
+	}
+	addr := c.mapping[slot]
+	if addr == "" {
+		return nil, fmt.Errorf("slot %d is not served by any known node", slot)
+	}
+	return c.getClient(addr, false)
+}
+
+// CountKeysInSlot returns the number of keys in the given slot, using CLUSTER
+// COUNTKEYSINSLOT on the node which serves it
+func (c *Cluster) CountKeysInSlot(slot int) (int64, error) {
+	client, err := c.clientForSlot(slot)
+	if err != nil {
+		return 0, err
+	}
+	return client.Cmd("CLUSTER", "COUNTKEYSINSLOT", slot).Int64()
+}
+
+// GetKeysInSlot returns up to count of the keys in the given slot, using
+// CLUSTER GETKEYSINSLOT on the node which serves it
+func (c *Cluster) GetKeysInSlot(slot, count int) ([]string, error) {
+	client, err := c.clientForSlot(slot)
+	if err != nil {
+		return nil, err
+	}
+	return client.Cmd("CLUSTER", "GETKEYSINSLOT", slot, count).List()
+}
+
+// SlotKeyCounts returns every range of consecutive slots served by the same
+// node, along with the number of keys in it. The counts for each range are
+// gathered in a single pipeline to its node. This is useful for deciding which
+// slots to move before resharding.
+func (c *Cluster) SlotKeyCounts() ([]SlotRange, error) {
+	ranges := c.slotRanges()
+	for i := range ranges {
+		client, err := c.getClient(ranges[i].Addr, false)
+		if err != nil {
+			return nil, err
+		}
+		for slot := ranges[i].Start; slot <= ranges[i].End; slot++ {
+			client.Append("CLUSTER", "COUNTKEYSINSLOT", slot)
+		}
+		for slot := ranges[i].Start; slot <= ranges[i].End; slot++ {
+			n, err := client.GetReply().Int64()
+			if err != nil {
+				// Drain the rest of the pipeline so the client stays usable
+				for ; slot < ranges[i].End; slot++ {
+					client.GetReply()
+				}
+				return nil, err
+			}
+			ranges[i].Keys += n
+		}
+	}
+	return ranges, nil
+}
+
+// NodeKeyCounts returns the number of keys on each node, by address, as
+// counted by SlotKeyCounts
+func (c *Cluster) NodeKeyCounts() (map[string]int64, error) {
+	ranges, err := c.SlotKeyCounts()
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]int64{}
+	for _, r := range ranges {
+		m[r.Addr] += r.Keys
+	}
+	return m, nil
+}
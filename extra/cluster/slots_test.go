@@ -0,0 +1,44 @@
+package cluster
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func TestSlotRanges(t *T) {
+	m := mapping{}
+	for i := 0; i < 100; i++ {
+		m[i] = "a"
+	}
+	for i := 100; i < 200; i++ {
+		m[i] = "b"
+	}
+	for i := 300; i < NUM_SLOTS; i++ {
+		m[i] = "a"
+	}
+	assert.Equal(t, []SlotRange{
+		{Start: 0, End: 99, Addr: "a"},
+		{Start: 100, End: 199, Addr: "b"},
+		{Start: 300, End: NUM_SLOTS - 1, Addr: "a"},
+	}, m.slotRanges())
+}
+
+func TestSlotKeyCounts(t *T) {
+	cluster := getCluster(t)
+	key := "slotcount"
+	slot := int(CRC16([]byte(key)) % NUM_SLOTS)
+	assert.Nil(t, cluster.Cmd("SET", key, "foo").Err)
+
+	n, err := cluster.CountKeysInSlot(slot)
+	assert.Nil(t, err)
+	assert.True(t, n >= 1)
+
+	keys, err := cluster.GetKeysInSlot(slot, 100)
+	assert.Nil(t, err)
+	assert.True(t, len(keys) >= 1)
+
+	counts, err := cluster.NodeKeyCounts()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(counts))
+	assert.True(t, counts["127.0.0.1:7000"]+counts["127.0.0.1:7001"] >= 1)
+}
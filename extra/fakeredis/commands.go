@@ -0,0 +1,986 @@
+package fakeredis
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fzzy/radix/redis/resp"
+)
+
+var (
+	okStatus     = resp.NewSimpleString("OK")
+	queuedStatus = resp.NewSimpleString("QUEUED")
+	pongStatus   = resp.NewSimpleString("PONG")
+)
+
+var (
+	wrongTypeError = errors.New(
+		"WRONGTYPE Operation against a key holding the wrong kind of value",
+	)
+	notIntError     = errors.New("ERR value is not an integer or out of range")
+	syntaxError     = errors.New("ERR syntax error")
+	noKeyError      = errors.New("ERR no such key")
+	outOfRangeError = errors.New("ERR index out of range")
+)
+
+// command is a command the server knows how to run. As with redis's COMMAND
+// INFO, a positive arity is the exact number of arguments (including the
+// command name) and a negative one is the minimum. fn is given the upper-cased
+// name of the command, so that similar commands can share one.
+type command struct {
+	arity int
+	fn    func(s *Server, c *client, name string, args []string) interface{}
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		// Connection and server
+		"PING":     {-1, cmdPing},
+		"ECHO":     {2, cmdEcho},
+		"QUIT":     {1, cmdOK},
+		"AUTH":     {-2, cmdOK},
+		"SELECT":   {2, cmdSelect},
+		"CLIENT":   {-2, cmdClient},
+		"DBSIZE":   {1, cmdDBSize},
+		"FLUSHDB":  {-1, cmdFlushDB},
+		"FLUSHALL": {-1, cmdFlushAll},
+
+		// Keys
+		"DEL":     {-2, cmdDel},
+		"EXISTS":  {-2, cmdExists},
+		"TYPE":    {2, cmdType},
+		"KEYS":    {2, cmdKeys},
+		"SCAN":    {-2, cmdScan},
+		"RENAME":  {3, cmdRename},
+		"EXPIRE":  {3, cmdExpire},
+		"PEXPIRE": {3, cmdExpire},
+		"TTL":     {2, cmdTTL},
+		"PTTL":    {2, cmdTTL},
+		"PERSIST": {2, cmdPersist},
+
+		// Strings
+		"GET":    {2, cmdGet},
+		"SET":    {-3, cmdSet},
+		"SETNX":  {3, cmdSetNX},
+		"SETEX":  {4, cmdSetEX},
+		"GETSET": {3, cmdGetSet},
+		"GETDEL": {2, cmdGetDel},
+		"MGET":   {-2, cmdMGet},
+		"MSET":   {-3, cmdMSet},
+		"INCR":   {2, cmdIncr},
+		"INCRBY": {3, cmdIncr},
+		"DECR":   {2, cmdIncr},
+		"DECRBY": {3, cmdIncr},
+		"APPEND": {3, cmdAppend},
+		"STRLEN": {2, cmdStrlen},
+
+		// Hashes
+		"HSET":    {-4, cmdHSet},
+		"HMSET":   {-4, cmdHSet},
+		"HSETNX":  {4, cmdHSetNX},
+		"HGET":    {3, cmdHGet},
+		"HMGET":   {-3, cmdHMGet},
+		"HDEL":    {-3, cmdHDel},
+		"HEXISTS": {3, cmdHExists},
+		"HLEN":    {2, cmdHLen},
+		"HGETALL": {2, cmdHGetAll},
+		"HKEYS":   {2, cmdHGetAll},
+		"HVALS":   {2, cmdHGetAll},
+		"HINCRBY": {4, cmdHIncrBy},
+
+		// Lists
+		"LPUSH":  {-3, cmdPush},
+		"RPUSH":  {-3, cmdPush},
+		"LPOP":   {2, cmdPop},
+		"RPOP":   {2, cmdPop},
+		"LLEN":   {2, cmdLLen},
+		"LRANGE": {4, cmdLRange},
+		"LINDEX": {3, cmdLIndex},
+		"LSET":   {4, cmdLSet},
+		"LTRIM":  {4, cmdLTrim},
+
+		// Sets
+		"SADD":      {-3, cmdSAdd},
+		"SREM":      {-3, cmdSRem},
+		"SISMEMBER": {3, cmdSIsMember},
+		"SMEMBERS":  {2, cmdSMembers},
+		"SCARD":     {2, cmdSCard},
+	}
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+//* Connection and server
+
+func cmdOK(s *Server, c *client, name string, args []string) interface{} {
+	return okStatus
+}
+
+func cmdPing(s *Server, c *client, name string, args []string) interface{} {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return pongStatus
+}
+
+func cmdEcho(s *Server, c *client, name string, args []string) interface{} {
+	return args[0]
+}
+
+func cmdSelect(s *Server, c *client, name string, args []string) interface{} {
+	db, err := strconv.Atoi(args[0])
+	if err != nil || db < 0 || db > 15 {
+		return errors.New("ERR DB index is out of range")
+	}
+	c.db = db
+	return okStatus
+}
+
+func cmdClient(s *Server, c *client, name string, args []string) interface{} {
+	switch strings.ToUpper(args[0]) {
+	case "SETNAME":
+		if len(args) != 2 {
+			return syntaxError
+		}
+		c.name = args[1]
+		return okStatus
+	case "GETNAME":
+		if c.name == "" {
+			return nil
+		}
+		return c.name
+	}
+	return errors.New("ERR unknown subcommand '" + args[0] + "'")
+}
+
+func cmdDBSize(s *Server, c *client, name string, args []string) interface{} {
+	return int64(len(s.keys(c)))
+}
+
+func cmdFlushDB(s *Server, c *client, name string, args []string) interface{} {
+	delete(s.dbs, c.db)
+	return okStatus
+}
+
+func cmdFlushAll(s *Server, c *client, name string, args []string) interface{} {
+	s.dbs = map[int]map[string]*entry{}
+	return okStatus
+}
+
+//* Keys
+
+func cmdDel(s *Server, c *client, name string, args []string) interface{} {
+	var n int64
+	for _, key := range args {
+		if s.lookup(c, key) != nil {
+			delete(s.db(c), key)
+			n++
+		}
+	}
+	return n
+}
+
+func cmdExists(s *Server, c *client, name string, args []string) interface{} {
+	var n int64
+	for _, key := range args {
+		if s.lookup(c, key) != nil {
+			n++
+		}
+	}
+	return n
+}
+
+func typeName(e *entry) string {
+	switch e.value.(type) {
+	case string:
+		return "string"
+	case map[string]string:
+		return "hash"
+	case []string:
+		return "list"
+	case map[string]struct{}:
+		return "set"
+	}
+	return "none"
+}
+
+func cmdType(s *Server, c *client, name string, args []string) interface{} {
+	e := s.lookup(c, args[0])
+	if e == nil {
+		return resp.NewSimpleString("none")
+	}
+	return resp.NewSimpleString(typeName(e))
+}
+
+func cmdKeys(s *Server, c *client, name string, args []string) interface{} {
+	keys := []string{}
+	for _, key := range s.keys(c) {
+		if match(args[0], key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// cmdScan treats the cursor as an index into the sorted keys, so keys which are
+// added or removed during a scan may be missed or returned twice
+func cmdScan(s *Server, c *client, name string, args []string) interface{} {
+	cursor, err := strconv.Atoi(args[0])
+	if err != nil || cursor < 0 {
+		return errors.New("ERR invalid cursor")
+	}
+	pattern, count := "*", 10
+	for i := 1; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			return syntaxError
+		}
+		switch strings.ToUpper(args[i]) {
+		case "MATCH":
+			pattern = args[i+1]
+		case "COUNT":
+			if count, err = strconv.Atoi(args[i+1]); err != nil || count < 1 {
+				return syntaxError
+			}
+		default:
+			return syntaxError
+		}
+	}
+
+	keys := s.keys(c)
+	sort.Strings(keys)
+	found := []string{}
+	next := cursor
+	for ; next < len(keys) && next < cursor+count; next++ {
+		if match(pattern, keys[next]) {
+			found = append(found, keys[next])
+		}
+	}
+	if next >= len(keys) {
+		next = 0
+	}
+	return []interface{}{strconv.Itoa(next), found}
+}
+
+func cmdRename(s *Server, c *client, name string, args []string) interface{} {
+	e := s.lookup(c, args[0])
+	if e == nil {
+		return noKeyError
+	}
+	db := s.db(c)
+	delete(db, args[0])
+	db[args[1]] = e
+	return okStatus
+}
+
+func cmdExpire(s *Server, c *client, name string, args []string) interface{} {
+	n, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return notIntError
+	}
+	e := s.lookup(c, args[0])
+	if e == nil {
+		return int64(0)
+	}
+	d := time.Duration(n) * time.Second
+	if name == "PEXPIRE" {
+		d = time.Duration(n) * time.Millisecond
+	}
+	e.expires = s.now().Add(d)
+	s.lookup(c, args[0])
+	return int64(1)
+}
+
+func cmdTTL(s *Server, c *client, name string, args []string) interface{} {
+	e := s.lookup(c, args[0])
+	if e == nil {
+		return int64(-2)
+	}
+	if e.expires.IsZero() {
+		return int64(-1)
+	}
+	d := e.expires.Sub(s.now())
+	if name == "PTTL" {
+		return int64((d + time.Millisecond - 1) / time.Millisecond)
+	}
+	return int64((d + time.Second - 1) / time.Second)
+}
+
+func cmdPersist(s *Server, c *client, name string, args []string) interface{} {
+	e := s.lookup(c, args[0])
+	if e == nil || e.expires.IsZero() {
+		return int64(0)
+	}
+	e.expires = time.Time{}
+	return int64(1)
+}
+
+//* Strings
+
+// getString returns the string at key, and whether there was one
+func (s *Server) getString(c *client, key string) (string, bool, error) {
+	e := s.lookup(c, key)
+	if e == nil {
+		return "", false, nil
+	}
+	str, ok := e.value.(string)
+	if !ok {
+		return "", false, wrongTypeError
+	}
+	return str, true, nil
+}
+
+func cmdGet(s *Server, c *client, name string, args []string) interface{} {
+	str, ok, err := s.getString(c, args[0])
+	if err != nil {
+		return err
+	} else if !ok {
+		return nil
+	}
+	return str
+}
+
+func cmdSet(s *Server, c *client, name string, args []string) interface{} {
+	var expires time.Time
+	var nx, xx, keepTTL bool
+	for i := 2; i < len(args); i++ {
+		switch opt := strings.ToUpper(args[i]); opt {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "KEEPTTL":
+			keepTTL = true
+		case "EX", "PX":
+			if i+1 >= len(args) {
+				return syntaxError
+			}
+			i++
+			n, err := strconv.ParseInt(args[i], 10, 64)
+			if err != nil {
+				return notIntError
+			} else if n <= 0 {
+				return errors.New("ERR invalid expire time in 'set' command")
+			}
+			unit := time.Second
+			if opt == "PX" {
+				unit = time.Millisecond
+			}
+			expires = s.now().Add(time.Duration(n) * unit)
+		default:
+			return syntaxError
+		}
+	}
+	if nx && xx {
+		return syntaxError
+	}
+
+	e := s.lookup(c, args[0])
+	if (nx && e != nil) || (xx && e == nil) {
+		return nil
+	}
+	if keepTTL && e != nil {
+		expires = e.expires
+	}
+	s.db(c)[args[0]] = &entry{value: args[1], expires: expires}
+	return okStatus
+}
+
+func cmdSetNX(s *Server, c *client, name string, args []string) interface{} {
+	if s.lookup(c, args[0]) != nil {
+		return int64(0)
+	}
+	s.db(c)[args[0]] = &entry{value: args[1]}
+	return int64(1)
+}
+
+func cmdSetEX(s *Server, c *client, name string, args []string) interface{} {
+	return cmdSet(s, c, "SET", []string{args[0], args[2], "EX", args[1]})
+}
+
+func cmdGetSet(s *Server, c *client, name string, args []string) interface{} {
+	r := cmdGet(s, c, "GET", args[:1])
+	if _, ok := r.(error); !ok {
+		s.db(c)[args[0]] = &entry{value: args[1]}
+	}
+	return r
+}
+
+func cmdGetDel(s *Server, c *client, name string, args []string) interface{} {
+	r := cmdGet(s, c, "GET", args)
+	if r != nil {
+		if _, ok := r.(error); !ok {
+			delete(s.db(c), args[0])
+		}
+	}
+	return r
+}
+
+func cmdMGet(s *Server, c *client, name string, args []string) interface{} {
+	vals := make([]interface{}, len(args))
+	for i, key := range args {
+		// Keys which hold something other than a string are returned as nil
+		if str, ok, _ := s.getString(c, key); ok {
+			vals[i] = str
+		}
+	}
+	return vals
+}
+
+func cmdMSet(s *Server, c *client, name string, args []string) interface{} {
+	if len(args)%2 != 0 {
+		return errors.New("ERR wrong number of arguments for 'mset' command")
+	}
+	for i := 0; i < len(args); i += 2 {
+		s.db(c)[args[i]] = &entry{value: args[i+1]}
+	}
+	return okStatus
+}
+
+func cmdIncr(s *Server, c *client, name string, args []string) interface{} {
+	by := int64(1)
+	if len(args) > 1 {
+		var err error
+		if by, err = strconv.ParseInt(args[1], 10, 64); err != nil {
+			return notIntError
+		}
+	}
+	if name == "DECR" || name == "DECRBY" {
+		by = -by
+	}
+
+	str, ok, err := s.getString(c, args[0])
+	if err != nil {
+		return err
+	}
+	var n int64
+	if ok {
+		if n, err = strconv.ParseInt(str, 10, 64); err != nil {
+			return notIntError
+		}
+	}
+	n += by
+	if e := s.lookup(c, args[0]); e != nil {
+		e.value = strconv.FormatInt(n, 10)
+	} else {
+		s.db(c)[args[0]] = &entry{value: strconv.FormatInt(n, 10)}
+	}
+	return n
+}
+
+func cmdAppend(s *Server, c *client, name string, args []string) interface{} {
+	str, ok, err := s.getString(c, args[0])
+	if err != nil {
+		return err
+	}
+	str += args[1]
+	if ok {
+		s.lookup(c, args[0]).value = str
+	} else {
+		s.db(c)[args[0]] = &entry{value: str}
+	}
+	return int64(len(str))
+}
+
+func cmdStrlen(s *Server, c *client, name string, args []string) interface{} {
+	str, _, err := s.getString(c, args[0])
+	if err != nil {
+		return err
+	}
+	return int64(len(str))
+}
+
+//* Hashes
+
+// getHash returns the hash at key. If there isn't one and create is true a new
+// one is stored there, otherwise nil is returned.
+func (s *Server) getHash(c *client, key string, create bool) (
+	map[string]string, error,
+) {
+	e := s.lookup(c, key)
+	if e == nil {
+		if !create {
+			return nil, nil
+		}
+		h := map[string]string{}
+		s.db(c)[key] = &entry{value: h}
+		return h, nil
+	}
+	h, ok := e.value.(map[string]string)
+	if !ok {
+		return nil, wrongTypeError
+	}
+	return h, nil
+}
+
+func cmdHSet(s *Server, c *client, name string, args []string) interface{} {
+	if len(args)%2 != 1 {
+		return errors.New(
+			"ERR wrong number of arguments for '" + strings.ToLower(name) +
+				"' command",
+		)
+	}
+	h, err := s.getHash(c, args[0], true)
+	if err != nil {
+		return err
+	}
+	var n int64
+	for i := 1; i < len(args); i += 2 {
+		if _, ok := h[args[i]]; !ok {
+			n++
+		}
+		h[args[i]] = args[i+1]
+	}
+	if name == "HMSET" {
+		return okStatus
+	}
+	return n
+}
+
+func cmdHSetNX(s *Server, c *client, name string, args []string) interface{} {
+	h, err := s.getHash(c, args[0], true)
+	if err != nil {
+		return err
+	}
+	if _, ok := h[args[1]]; ok {
+		return int64(0)
+	}
+	h[args[1]] = args[2]
+	return int64(1)
+}
+
+func cmdHGet(s *Server, c *client, name string, args []string) interface{} {
+	h, err := s.getHash(c, args[0], false)
+	if err != nil {
+		return err
+	}
+	if v, ok := h[args[1]]; ok {
+		return v
+	}
+	return nil
+}
+
+func cmdHMGet(s *Server, c *client, name string, args []string) interface{} {
+	h, err := s.getHash(c, args[0], false)
+	if err != nil {
+		return err
+	}
+	vals := make([]interface{}, len(args)-1)
+	for i, field := range args[1:] {
+		if v, ok := h[field]; ok {
+			vals[i] = v
+		}
+	}
+	return vals
+}
+
+func cmdHDel(s *Server, c *client, name string, args []string) interface{} {
+	h, err := s.getHash(c, args[0], false)
+	if err != nil {
+		return err
+	}
+	var n int64
+	for _, field := range args[1:] {
+		if _, ok := h[field]; ok {
+			delete(h, field)
+			n++
+		}
+	}
+	if h != nil && len(h) == 0 {
+		delete(s.db(c), args[0])
+	}
+	return n
+}
+
+func cmdHExists(s *Server, c *client, name string, args []string) interface{} {
+	h, err := s.getHash(c, args[0], false)
+	if err != nil {
+		return err
+	}
+	_, ok := h[args[1]]
+	return boolInt(ok)
+}
+
+func cmdHLen(s *Server, c *client, name string, args []string) interface{} {
+	h, err := s.getHash(c, args[0], false)
+	if err != nil {
+		return err
+	}
+	return int64(len(h))
+}
+
+// cmdHGetAll implements HGETALL, HKEYS and HVALS. Fields are returned in sorted
+// order.
+func cmdHGetAll(s *Server, c *client, name string, args []string) interface{} {
+	h, err := s.getHash(c, args[0], false)
+	if err != nil {
+		return err
+	}
+	fields := make([]string, 0, len(h))
+	for field := range h {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	ret := make([]string, 0, len(h)*2)
+	for _, field := range fields {
+		if name != "HVALS" {
+			ret = append(ret, field)
+		}
+		if name != "HKEYS" {
+			ret = append(ret, h[field])
+		}
+	}
+	return ret
+}
+
+func cmdHIncrBy(s *Server, c *client, name string, args []string) interface{} {
+	by, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return notIntError
+	}
+	h, err := s.getHash(c, args[0], true)
+	if err != nil {
+		return err
+	}
+	var n int64
+	if v, ok := h[args[1]]; ok {
+		if n, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return errors.New("ERR hash value is not an integer")
+		}
+	}
+	n += by
+	h[args[1]] = strconv.FormatInt(n, 10)
+	return n
+}
+
+//* Lists
+
+// getList returns the list at key, and its entry, or nils if there isn't one
+func (s *Server) getList(c *client, key string) ([]string, *entry, error) {
+	e := s.lookup(c, key)
+	if e == nil {
+		return nil, nil, nil
+	}
+	l, ok := e.value.([]string)
+	if !ok {
+		return nil, nil, wrongTypeError
+	}
+	return l, e, nil
+}
+
+// setList stores l at key, deleting the key if l is empty, as redis does
+func (s *Server) setList(c *client, key string, e *entry, l []string) {
+	if len(l) == 0 {
+		delete(s.db(c), key)
+		return
+	}
+	if e == nil {
+		s.db(c)[key] = &entry{value: l}
+		return
+	}
+	e.value = l
+}
+
+func cmdPush(s *Server, c *client, name string, args []string) interface{} {
+	l, e, err := s.getList(c, args[0])
+	if err != nil {
+		return err
+	}
+	for _, v := range args[1:] {
+		if name == "LPUSH" {
+			l = append([]string{v}, l...)
+		} else {
+			l = append(l, v)
+		}
+	}
+	s.setList(c, args[0], e, l)
+	return int64(len(l))
+}
+
+func cmdPop(s *Server, c *client, name string, args []string) interface{} {
+	l, e, err := s.getList(c, args[0])
+	if err != nil {
+		return err
+	} else if len(l) == 0 {
+		return nil
+	}
+	var v string
+	if name == "LPOP" {
+		v, l = l[0], l[1:]
+	} else {
+		v, l = l[len(l)-1], l[:len(l)-1]
+	}
+	s.setList(c, args[0], e, l)
+	return v
+}
+
+func cmdLLen(s *Server, c *client, name string, args []string) interface{} {
+	l, _, err := s.getList(c, args[0])
+	if err != nil {
+		return err
+	}
+	return int64(len(l))
+}
+
+// listRange converts the start and stop indexes of LRANGE or LTRIM, which may
+// be negative, into the bounds of a slice of a list of length n
+func listRange(startStr, stopStr string, n int) (int, int, error) {
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return 0, 0, notIntError
+	}
+	stop, err := strconv.Atoi(stopStr)
+	if err != nil {
+		return 0, 0, notIntError
+	}
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+	if start > stop {
+		return 0, 0, nil
+	}
+	return start, stop + 1, nil
+}
+
+func cmdLRange(s *Server, c *client, name string, args []string) interface{} {
+	l, _, err := s.getList(c, args[0])
+	if err != nil {
+		return err
+	}
+	start, end, err := listRange(args[1], args[2], len(l))
+	if err != nil {
+		return err
+	}
+	ret := make([]string, end-start)
+	copy(ret, l[start:end])
+	return ret
+}
+
+func cmdLTrim(s *Server, c *client, name string, args []string) interface{} {
+	l, e, err := s.getList(c, args[0])
+	if err != nil {
+		return err
+	}
+	start, end, err := listRange(args[1], args[2], len(l))
+	if err != nil {
+		return err
+	}
+	s.setList(c, args[0], e, append([]string{}, l[start:end]...))
+	return okStatus
+}
+
+// listIndex returns the index into a list of length n for a LINDEX or LSET
+// index, which may be negative, and whether it's in range
+func listIndex(indexStr string, n int) (int, bool, error) {
+	i, err := strconv.Atoi(indexStr)
+	if err != nil {
+		return 0, false, notIntError
+	}
+	if i < 0 {
+		i += n
+	}
+	return i, i >= 0 && i < n, nil
+}
+
+func cmdLIndex(s *Server, c *client, name string, args []string) interface{} {
+	l, _, err := s.getList(c, args[0])
+	if err != nil {
+		return err
+	}
+	i, ok, err := listIndex(args[1], len(l))
+	if err != nil {
+		return err
+	} else if !ok {
+		return nil
+	}
+	return l[i]
+}
+
+func cmdLSet(s *Server, c *client, name string, args []string) interface{} {
+	l, e, err := s.getList(c, args[0])
+	if err != nil {
+		return err
+	} else if e == nil {
+		return noKeyError
+	}
+	i, ok, err := listIndex(args[1], len(l))
+	if err != nil {
+		return err
+	} else if !ok {
+		return outOfRangeError
+	}
+	l[i] = args[2]
+	return okStatus
+}
+
+//* Sets
+
+// getSet returns the set at key. If there isn't one and create is true a new
+// one is stored there, otherwise nil is returned.
+func (s *Server) getSet(c *client, key string, create bool) (
+	map[string]struct{}, error,
+) {
+	e := s.lookup(c, key)
+	if e == nil {
+		if !create {
+			return nil, nil
+		}
+		set := map[string]struct{}{}
+		s.db(c)[key] = &entry{value: set}
+		return set, nil
+	}
+	set, ok := e.value.(map[string]struct{})
+	if !ok {
+		return nil, wrongTypeError
+	}
+	return set, nil
+}
+
+func cmdSAdd(s *Server, c *client, name string, args []string) interface{} {
+	set, err := s.getSet(c, args[0], true)
+	if err != nil {
+		return err
+	}
+	var n int64
+	for _, m := range args[1:] {
+		if _, ok := set[m]; !ok {
+			set[m] = struct{}{}
+			n++
+		}
+	}
+	return n
+}
+
+func cmdSRem(s *Server, c *client, name string, args []string) interface{} {
+	set, err := s.getSet(c, args[0], false)
+	if err != nil {
+		return err
+	}
+	var n int64
+	for _, m := range args[1:] {
+		if _, ok := set[m]; ok {
+			delete(set, m)
+			n++
+		}
+	}
+	if set != nil && len(set) == 0 {
+		delete(s.db(c), args[0])
+	}
+	return n
+}
+
+func cmdSIsMember(s *Server, c *client, name string, args []string) interface{} {
+	set, err := s.getSet(c, args[0], false)
+	if err != nil {
+		return err
+	}
+	_, ok := set[args[1]]
+	return boolInt(ok)
+}
+
+// cmdSMembers returns the members in sorted order
+func cmdSMembers(s *Server, c *client, name string, args []string) interface{} {
+	set, err := s.getSet(c, args[0], false)
+	if err != nil {
+		return err
+	}
+	ms := make([]string, 0, len(set))
+	for m := range set {
+		ms = append(ms, m)
+	}
+	sort.Strings(ms)
+	return ms
+}
+
+func cmdSCard(s *Server, c *client, name string, args []string) interface{} {
+	set, err := s.getSet(c, args[0], false)
+	if err != nil {
+		return err
+	}
+	return int64(len(set))
+}
+
+// match reports whether s matches the glob-style pattern, as used by KEYS and
+// SCAN. It supports *, ?, [...] (including ranges and ^ for negation), and \
+// for escaping.
+func match(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if match(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			end := strings.IndexByte(pattern[1:], ']')
+			if end < 0 {
+				return pattern == s
+			}
+			class := pattern[1 : end+1]
+			pattern = pattern[end+1:]
+			negate := strings.HasPrefix(class, "^")
+			if negate {
+				class = class[1:]
+			}
+			matched := false
+			for i := 0; i < len(class); i++ {
+				if i+2 < len(class) && class[i+1] == '-' {
+					if class[i] <= s[0] && s[0] <= class[i+2] {
+						matched = true
+					}
+					i += 2
+				} else if class[i] == s[0] {
+					matched = true
+				}
+			}
+			if matched == negate {
+				return false
+			}
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+		}
+		pattern, s = pattern[1:], s[1:]
+	}
+	return len(s) == 0
+}
@@ -0,0 +1,237 @@
+package fakeredis
+
+import (
+	"github.com/fzzy/radix/redis"
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func TestKeys(t *T) {
+	s, c := dial(t)
+	defer s.Close()
+
+	c.Cmd("MSET", "a:1", 1, "a:2", 2, "b:1", 3)
+	l, _ := c.Cmd("KEYS", "a:*").List()
+	assert.Equal(t, []string{"a:1", "a:2"}, l)
+
+	n, _ := c.Cmd("EXISTS", "a:1", "a:2", "c").Int()
+	assert.Equal(t, 2, n)
+	str, _ := c.Cmd("TYPE", "a:1").Str()
+	assert.Equal(t, "string", str)
+	str, _ = c.Cmd("TYPE", "c").Str()
+	assert.Equal(t, "none", str)
+
+	var keys []string
+	cursor := "0"
+	for {
+		r := c.Cmd("SCAN", cursor, "MATCH", "*:1", "COUNT", 1)
+		cursor, _ = r.Elems[0].Str()
+		l, _ = r.Elems[1].List()
+		keys = append(keys, l...)
+		if cursor == "0" {
+			break
+		}
+	}
+	assert.Equal(t, []string{"a:1", "b:1"}, keys)
+
+	assert.Nil(t, c.Cmd("RENAME", "b:1", "b:2").Err)
+	str, _ = c.Cmd("GET", "b:2").Str()
+	assert.Equal(t, "3", str)
+	assert.NotNil(t, c.Cmd("RENAME", "b:1", "b:3").Err)
+
+	n, _ = c.Cmd("DEL", "a:1", "a:2", "c").Int()
+	assert.Equal(t, 2, n)
+	n, _ = c.Cmd("DBSIZE").Int()
+	assert.Equal(t, 1, n)
+
+	assert.Nil(t, c.Cmd("FLUSHDB").Err)
+	n, _ = c.Cmd("DBSIZE").Int()
+	assert.Equal(t, 0, n)
+}
+
+func TestExpiry(t *T) {
+	s, c := dial(t)
+	defer s.Close()
+
+	c.Cmd("SET", "foo", "bar")
+	ttl, _ := c.Cmd("TTL", "foo").Int()
+	assert.Equal(t, -1, ttl)
+	ttl, _ = c.Cmd("TTL", "nothing").Int()
+	assert.Equal(t, -2, ttl)
+
+	n, _ := c.Cmd("PEXPIRE", "foo", 1500).Int()
+	assert.Equal(t, 1, n)
+	ttl, _ = c.Cmd("PTTL", "foo").Int()
+	assert.True(t, ttl > 1000 && ttl <= 1500)
+	ttl, _ = c.Cmd("TTL", "foo").Int()
+	assert.Equal(t, 2, ttl)
+
+	n, _ = c.Cmd("PERSIST", "foo").Int()
+	assert.Equal(t, 1, n)
+	ttl, _ = c.Cmd("TTL", "foo").Int()
+	assert.Equal(t, -1, ttl)
+
+	c.Cmd("EXPIRE", "foo", 0)
+	assert.Equal(t, redis.NilReply, c.Cmd("GET", "foo").Type)
+	n, _ = c.Cmd("EXPIRE", "foo", 10).Int()
+	assert.Equal(t, 0, n)
+}
+
+func TestStrings(t *T) {
+	s, c := dial(t)
+	defer s.Close()
+
+	assert.Equal(t, redis.NilReply, c.Cmd("GET", "foo").Type)
+	assert.Equal(t, redis.NilReply, c.Cmd("SET", "foo", "bar", "XX").Type)
+	assert.Nil(t, c.Cmd("SET", "foo", "bar", "NX").Err)
+	assert.Equal(t, redis.NilReply, c.Cmd("SET", "foo", "baz", "NX").Type)
+	assert.NotNil(t, c.Cmd("SET", "foo", "baz", "BOGUS").Err)
+
+	c.Cmd("SETEX", "foo", 10, "baz")
+	c.Cmd("SET", "foo", "qux", "KEEPTTL")
+	ttl, _ := c.Cmd("TTL", "foo").Int()
+	assert.Equal(t, 10, ttl)
+	c.Cmd("SET", "foo", "qux")
+	ttl, _ = c.Cmd("TTL", "foo").Int()
+	assert.Equal(t, -1, ttl)
+
+	n, _ := c.Cmd("SETNX", "foo", "x").Int()
+	assert.Equal(t, 0, n)
+	str, _ := c.Cmd("GETSET", "foo", "a").Str()
+	assert.Equal(t, "qux", str)
+	n, _ = c.Cmd("APPEND", "foo", "bc").Int()
+	assert.Equal(t, 3, n)
+	n, _ = c.Cmd("STRLEN", "foo").Int()
+	assert.Equal(t, 3, n)
+	str, _ = c.Cmd("GETDEL", "foo").Str()
+	assert.Equal(t, "abc", str)
+	assert.Equal(t, redis.NilReply, c.Cmd("GET", "foo").Type)
+
+	n, _ = c.Cmd("INCR", "n").Int()
+	assert.Equal(t, 1, n)
+	n, _ = c.Cmd("INCRBY", "n", 10).Int()
+	assert.Equal(t, 11, n)
+	n, _ = c.Cmd("DECR", "n").Int()
+	assert.Equal(t, 10, n)
+	n, _ = c.Cmd("DECRBY", "n", 4).Int()
+	assert.Equal(t, 6, n)
+
+	c.Cmd("SET", "s", "notanumber")
+	assert.NotNil(t, c.Cmd("INCR", "s").Err)
+
+	c.Cmd("HSET", "h", "f", "v")
+	err := c.Cmd("GET", "h").Err
+	assert.NotNil(t, err)
+	assert.Equal(t, "WRONGTYPE", err.Error()[:9])
+
+	l, _ := c.Cmd("MGET", "n", "h", "nothing").ListBytes()
+	assert.Equal(t, [][]byte{[]byte("6"), nil, nil}, l)
+}
+
+func TestHashes(t *T) {
+	s, c := dial(t)
+	defer s.Close()
+
+	n, _ := c.Cmd("HSET", "h", "a", 1, "b", 2).Int()
+	assert.Equal(t, 2, n)
+	assert.Nil(t, c.Cmd("HMSET", "h", "c", 3).Err)
+	n, _ = c.Cmd("HSETNX", "h", "a", 5).Int()
+	assert.Equal(t, 0, n)
+
+	str, _ := c.Cmd("HGET", "h", "a").Str()
+	assert.Equal(t, "1", str)
+	assert.Equal(t, redis.NilReply, c.Cmd("HGET", "h", "z").Type)
+	l, _ := c.Cmd("HMGET", "h", "a", "z", "c").List()
+	assert.Equal(t, []string{"1", "", "3"}, l)
+
+	m, _ := c.Cmd("HGETALL", "h").Hash()
+	assert.Equal(t, map[string]string{"a": "1", "b": "2", "c": "3"}, m)
+	l, _ = c.Cmd("HKEYS", "h").List()
+	assert.Equal(t, []string{"a", "b", "c"}, l)
+	l, _ = c.Cmd("HVALS", "h").List()
+	assert.Equal(t, []string{"1", "2", "3"}, l)
+
+	n, _ = c.Cmd("HINCRBY", "h", "a", 5).Int()
+	assert.Equal(t, 6, n)
+	n, _ = c.Cmd("HEXISTS", "h", "a").Int()
+	assert.Equal(t, 1, n)
+	n, _ = c.Cmd("HDEL", "h", "a", "b", "z").Int()
+	assert.Equal(t, 2, n)
+	n, _ = c.Cmd("HLEN", "h").Int()
+	assert.Equal(t, 1, n)
+
+	// Removing the last field removes the key
+	c.Cmd("HDEL", "h", "c")
+	n, _ = c.Cmd("EXISTS", "h").Int()
+	assert.Equal(t, 0, n)
+}
+
+func TestLists(t *T) {
+	s, c := dial(t)
+	defer s.Close()
+
+	n, _ := c.Cmd("RPUSH", "l", "b", "c").Int()
+	assert.Equal(t, 2, n)
+	n, _ = c.Cmd("LPUSH", "l", "a", "z").Int()
+	assert.Equal(t, 4, n)
+
+	l, _ := c.Cmd("LRANGE", "l", 0, -1).List()
+	assert.Equal(t, []string{"z", "a", "b", "c"}, l)
+	l, _ = c.Cmd("LRANGE", "l", -2, 10).List()
+	assert.Equal(t, []string{"b", "c"}, l)
+	l, _ = c.Cmd("LRANGE", "l", 3, 1).List()
+	assert.Equal(t, []string{}, l)
+
+	str, _ := c.Cmd("LINDEX", "l", -1).Str()
+	assert.Equal(t, "c", str)
+	assert.Equal(t, redis.NilReply, c.Cmd("LINDEX", "l", 10).Type)
+	assert.Nil(t, c.Cmd("LSET", "l", 0, "y").Err)
+	assert.NotNil(t, c.Cmd("LSET", "l", 10, "y").Err)
+
+	str, _ = c.Cmd("LPOP", "l").Str()
+	assert.Equal(t, "y", str)
+	str, _ = c.Cmd("RPOP", "l").Str()
+	assert.Equal(t, "c", str)
+	n, _ = c.Cmd("LLEN", "l").Int()
+	assert.Equal(t, 2, n)
+
+	c.Cmd("LTRIM", "l", 1, 1)
+	l, _ = c.Cmd("LRANGE", "l", 0, -1).List()
+	assert.Equal(t, []string{"b"}, l)
+
+	c.Cmd("RPOP", "l")
+	n, _ = c.Cmd("EXISTS", "l").Int()
+	assert.Equal(t, 0, n)
+	assert.Equal(t, redis.NilReply, c.Cmd("LPOP", "l").Type)
+}
+
+func TestSets(t *T) {
+	s, c := dial(t)
+	defer s.Close()
+
+	n, _ := c.Cmd("SADD", "s", "b", "a", "b").Int()
+	assert.Equal(t, 2, n)
+	l, _ := c.Cmd("SMEMBERS", "s").List()
+	assert.Equal(t, []string{"a", "b"}, l)
+	n, _ = c.Cmd("SISMEMBER", "s", "a").Int()
+	assert.Equal(t, 1, n)
+	n, _ = c.Cmd("SCARD", "s").Int()
+	assert.Equal(t, 2, n)
+	n, _ = c.Cmd("SREM", "s", "a", "z").Int()
+	assert.Equal(t, 1, n)
+	assert.NotNil(t, c.Cmd("LPUSH", "s", "x").Err)
+}
+
+func TestMatch(t *T) {
+	assert.True(t, match("*", ""))
+	assert.True(t, match("a*", "abc"))
+	assert.True(t, match("*c", "abc"))
+	assert.True(t, match("a?c", "abc"))
+	assert.False(t, match("a?c", "ac"))
+	assert.True(t, match("h[ae]llo", "hello"))
+	assert.False(t, match("h[^e]llo", "hello"))
+	assert.True(t, match("h[a-e]llo", "hcllo"))
+	assert.True(t, match(`a\*`, "a*"))
+	assert.False(t, match(`a\*`, "ab"))
+	assert.True(t, match("user/*", "user/1/name"))
+}
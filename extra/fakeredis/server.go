@@ -0,0 +1,300 @@
+// The fakeredis package implements a lightweight, in-process fake of a redis
+// server, for running tests which use redis hermetically and quickly. The
+// server listens on a random local port and speaks the redis protocol, so it's
+// used through a normal redis.Client (or a pool, etc...), just as a real
+// server would be.
+//
+//	s, err := fakeredis.NewServer()
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer s.Close()
+//
+//	c, err := redis.Dial("tcp", s.Addr())
+//
+// Only the most common commands are implemented: those for strings, hashes,
+// lists, sets and expiry, along with the basic key, connection and MULTI/EXEC
+// commands. Any other command returns an "unknown command" error. Keys expire
+// based on the server's own clock, which can be moved on with FastForward to
+// test expiry without waiting.
+package fakeredis
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fzzy/radix/redis/resp"
+)
+
+// Server is a fake redis server. All commands are run one at a time, so
+// they're atomic, as they would be on a real server.
+type Server struct {
+	l net.Listener
+
+	mu     sync.Mutex
+	dbs    map[int]map[string]*entry
+	offset time.Duration
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// entry is the value stored at a key. value is a string, map[string]string
+// (hash), []string (list) or map[string]struct{} (set).
+type entry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// client is the per-connection state
+type client struct {
+	db       int
+	name     string
+	multi    bool
+	multiErr bool
+	queued   [][]string
+}
+
+// NewServer starts a fake server listening on a random port on 127.0.0.1
+func NewServer() (*Server, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		l:     l,
+		dbs:   map[int]map[string]*entry{},
+		conns: map[net.Conn]struct{}{},
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the address the server is listening on, for passing to
+// redis.Dial
+func (s *Server) Addr() string {
+	return s.l.Addr().String()
+}
+
+// Close stops the server and closes all connections to it
+func (s *Server) Close() {
+	s.mu.Lock()
+	s.closed = true
+	s.l.Close()
+	for nc := range s.conns {
+		nc.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// FastForward moves the server's clock on by d, expiring any keys whose TTL
+// runs out in that time
+func (s *Server) FastForward(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offset += d
+}
+
+// FlushAll deletes every key in every database
+func (s *Server) FlushAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dbs = map[int]map[string]*entry{}
+}
+
+func (s *Server) now() time.Time {
+	return time.Now().Add(s.offset)
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		nc, err := s.l.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			nc.Close()
+			return
+		}
+		s.conns[nc] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+		go s.handle(nc)
+	}
+}
+
+var protocolError = errors.New("ERR Protocol error")
+
+func (s *Server) handle(nc net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, nc)
+		s.mu.Unlock()
+		nc.Close()
+	}()
+
+	br := bufio.NewReader(nc)
+	c := &client{}
+	for {
+		m, err := resp.ReadMessage(br)
+		if err != nil {
+			return
+		}
+		args, err := messageArgs(m)
+		if err != nil {
+			resp.WriteArbitrary(nc, protocolError)
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if err = resp.WriteArbitrary(nc, s.exec(c, args)); err != nil {
+			return
+		}
+		if strings.EqualFold(args[0], "QUIT") {
+			return
+		}
+	}
+}
+
+// messageArgs returns the arguments of a request, which must be an array of
+// bulk strings
+func messageArgs(m *resp.Message) ([]string, error) {
+	ms, err := m.Array()
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, len(ms))
+	for i := range ms {
+		b, err := ms[i].Bytes()
+		if err != nil {
+			return nil, err
+		}
+		args[i] = string(b)
+	}
+	return args, nil
+}
+
+// exec runs a single request from the client, dealing with MULTI blocks, and
+// returns the reply to write back
+func (s *Server) exec(c *client, args []string) interface{} {
+	name := strings.ToUpper(args[0])
+	switch name {
+	case "MULTI":
+		if c.multi {
+			return errors.New("ERR MULTI calls can not be nested")
+		}
+		c.multi = true
+		return okStatus
+	case "DISCARD":
+		if !c.multi {
+			return errors.New("ERR DISCARD without MULTI")
+		}
+		c.multi, c.multiErr, c.queued = false, false, nil
+		return okStatus
+	case "EXEC":
+		if !c.multi {
+			return errors.New("ERR EXEC without MULTI")
+		}
+		queued, failed := c.queued, c.multiErr
+		c.multi, c.multiErr, c.queued = false, false, nil
+		if failed {
+			return errors.New(
+				"EXECABORT Transaction discarded because of previous errors.",
+			)
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		replies := make([]interface{}, len(queued))
+		for i := range queued {
+			replies[i] = s.run(c, queued[i])
+		}
+		return replies
+	}
+
+	if c.multi {
+		if err := checkCommand(name, args); err != nil {
+			c.multiErr = true
+			return err
+		}
+		c.queued = append(c.queued, args)
+		return queuedStatus
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.run(c, args)
+}
+
+// run runs a single command. s.mu must be held.
+func (s *Server) run(c *client, args []string) interface{} {
+	name := strings.ToUpper(args[0])
+	if err := checkCommand(name, args); err != nil {
+		return err
+	}
+	return commands[name].fn(s, c, name, args[1:])
+}
+
+// checkCommand returns an error if the command doesn't exist or has the wrong
+// number of arguments
+func checkCommand(name string, args []string) error {
+	cmd, ok := commands[name]
+	if !ok {
+		return errors.New("ERR unknown command '" + args[0] + "'")
+	}
+	if (cmd.arity > 0 && len(args) != cmd.arity) ||
+		(cmd.arity < 0 && len(args) < -cmd.arity) {
+		return errors.New(
+			"ERR wrong number of arguments for '" + strings.ToLower(name) +
+				"' command",
+		)
+	}
+	return nil
+}
+
+// db returns the client's current database
+func (s *Server) db(c *client) map[string]*entry {
+	db, ok := s.dbs[c.db]
+	if !ok {
+		db = map[string]*entry{}
+		s.dbs[c.db] = db
+	}
+	return db
+}
+
+// lookup returns the entry at key, or nil if there isn't one. Expired keys are
+// deleted when they're looked up.
+func (s *Server) lookup(c *client, key string) *entry {
+	db := s.db(c)
+	e, ok := db[key]
+	if !ok {
+		return nil
+	}
+	if !e.expires.IsZero() && !s.now().Before(e.expires) {
+		delete(db, key)
+		return nil
+	}
+	return e
+}
+
+// keys returns all the unexpired keys in the client's database
+func (s *Server) keys(c *client) []string {
+	db := s.db(c)
+	keys := make([]string, 0, len(db))
+	for key := range db {
+		if s.lookup(c, key) != nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,133 @@
+package fakeredis
+
+import (
+	"github.com/fzzy/radix/extra/pool"
+	"github.com/fzzy/radix/redis"
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+func dial(t *T) (*Server, *redis.Client) {
+	s, err := NewServer()
+	assert.Nil(t, err)
+	c, err := redis.DialTimeout("tcp", s.Addr(), 5*time.Second)
+	assert.Nil(t, err)
+	return s, c
+}
+
+func TestServer(t *T) {
+	s, c := dial(t)
+	defer s.Close()
+
+	r := c.Cmd("PING")
+	assert.Equal(t, redis.StatusReply, r.Type)
+	str, _ := r.Str()
+	assert.Equal(t, "PONG", str)
+
+	err := c.Cmd("NOTACOMMAND").Err
+	_, ok := err.(*redis.CmdError)
+	assert.True(t, ok)
+	assert.Equal(t, "ERR unknown command 'NOTACOMMAND'", err.Error())
+	assert.NotNil(t, c.Cmd("GET").Err)
+
+	// Pipelining
+	c.Append("SET", "foo", "bar")
+	c.Append("GET", "foo")
+	assert.Nil(t, c.GetReply().Err)
+	str, _ = c.GetReply().Str()
+	assert.Equal(t, "bar", str)
+
+	// Databases are separate
+	assert.Nil(t, c.Cmd("SELECT", 1).Err)
+	assert.Equal(t, redis.NilReply, c.Cmd("GET", "foo").Type)
+	assert.Nil(t, c.Cmd("SELECT", 0).Err)
+	str, _ = c.Cmd("GET", "foo").Str()
+	assert.Equal(t, "bar", str)
+
+	s.FlushAll()
+	assert.Equal(t, redis.NilReply, c.Cmd("GET", "foo").Type)
+
+	s.Close()
+	assert.NotNil(t, c.Cmd("PING").Err)
+}
+
+func TestMulti(t *T) {
+	s, c := dial(t)
+	defer s.Close()
+
+	c.Cmd("MULTI")
+	str, _ := c.Cmd("INCR", "n").Str()
+	assert.Equal(t, "QUEUED", str)
+	c.Cmd("INCR", "n")
+	r := c.Cmd("EXEC")
+	assert.Equal(t, redis.MultiReply, r.Type)
+	assert.Equal(t, 2, len(r.Elems))
+	n, _ := r.Elems[1].Int()
+	assert.Equal(t, 2, n)
+
+	// A bad command aborts the whole block
+	c.Cmd("MULTI")
+	c.Cmd("INCR", "n")
+	assert.NotNil(t, c.Cmd("GET").Err)
+	assert.NotNil(t, c.Cmd("EXEC").Err)
+	n, _ = c.Cmd("GET", "n").Int()
+	assert.Equal(t, 2, n)
+
+	c.Cmd("MULTI")
+	c.Cmd("INCR", "n")
+	assert.Nil(t, c.Cmd("DISCARD").Err)
+	n, _ = c.Cmd("GET", "n").Int()
+	assert.Equal(t, 2, n)
+	assert.NotNil(t, c.Cmd("EXEC").Err)
+}
+
+func TestFastForward(t *T) {
+	s, c := dial(t)
+	defer s.Close()
+
+	c.Cmd("SET", "foo", "bar", "EX", 10)
+	ttl, _ := c.Cmd("TTL", "foo").Int()
+	assert.Equal(t, 10, ttl)
+
+	s.FastForward(5 * time.Second)
+	ttl, _ = c.Cmd("TTL", "foo").Int()
+	assert.Equal(t, 5, ttl)
+
+	s.FastForward(5 * time.Second)
+	assert.Equal(t, redis.NilReply, c.Cmd("GET", "foo").Type)
+	n, _ := c.Cmd("DBSIZE").Int()
+	assert.Equal(t, 0, n)
+}
+
+func TestPool(t *T) {
+	s, err := NewServer()
+	assert.Nil(t, err)
+	defer s.Close()
+
+	p, err := pool.NewPool("tcp", s.Addr(), 5)
+	assert.Nil(t, err)
+	defer p.Close()
+
+	done := make(chan bool)
+	for i := 0; i < 10; i++ {
+		go func() {
+			conn, err := p.Get()
+			assert.Nil(t, err)
+			for j := 0; j < 10; j++ {
+				assert.Nil(t, conn.Cmd("INCR", "n").Err)
+			}
+			p.Put(conn)
+			done <- true
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		<-done
+	}
+
+	conn, err := p.Get()
+	assert.Nil(t, err)
+	n, _ := conn.Cmd("GET", "n").Int()
+	assert.Equal(t, 100, n)
+	p.Put(conn)
+}
@@ -0,0 +1,21 @@
+// Package redistest holds helpers shared by the tests of the extra packages.
+package redistest
+
+import (
+	"testing"
+
+	"github.com/fzzy/radix/redis"
+)
+
+// Addr is the address of the redis server the live tests run against
+const Addr = "localhost:6379"
+
+// Dial connects to the redis server at Addr, failing the test straight away if
+// it can't be reached
+func Dial(t testing.TB) *redis.Client {
+	c, err := redis.Dial("tcp", Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
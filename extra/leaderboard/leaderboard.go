@@ -0,0 +1,191 @@
+// The leaderboard package implements a leaderboard on top of a redis sorted
+// set, with the highest scores ranked first:
+//
+//	lb := leaderboard.NewLeaderboard(client, "game:scores")
+//	lb.Incr("alice", 10)
+//	top, err := lb.Top(10)
+//	near, err := lb.Around("alice", 5)
+//
+// Members with the same score share the same rank, which is one more than the
+// number of members with a higher score (so scores of 10, 8, 8 and 5 are
+// ranked 1, 2, 2 and 4). Tied members are listed in the order redis keeps them,
+// which is in reverse lexicographical order of the members, so the same ties
+// are always listed the same way. Pages are read atomically along with their
+// ranks, so a page is consistent even while scores are being changed.
+package leaderboard
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/fzzy/radix/redis"
+)
+
+// Entry is a member of a leaderboard, along with its score and its rank
+// (starting at 1)
+type Entry struct {
+	Member string
+	Score  float64
+	Rank   int
+}
+
+// Leaderboard ranks members by score using the sorted set at a key
+type Leaderboard struct {
+	c   redis.Commander
+	key string
+}
+
+// NewLeaderboard returns the Leaderboard stored in the sorted set at key
+func NewLeaderboard(c redis.Commander, key string) *Leaderboard {
+	return &Leaderboard{c: c, key: key}
+}
+
+// Set sets the member's score, adding it if it isn't on the leaderboard
+func (lb *Leaderboard) Set(member string, score float64) error {
+	return lb.c.Cmd("ZADD", lb.key, score, member).Err
+}
+
+// SetIfHigher sets the member's score only if it's higher than its current one,
+// or it isn't on the leaderboard yet, returning whether it was set. This is
+// what's usually wanted for keeping a player's best score. It needs redis 6.2.
+func (lb *Leaderboard) SetIfHigher(member string, score float64) (bool, error) {
+	return lb.c.Cmd("ZADD", lb.key, "GT", "CH", score, member).Bool()
+}
+
+// Incr adds by to the member's score, adding it to the leaderboard with a score
+// of by if it isn't there, and returns its new score
+func (lb *Leaderboard) Incr(member string, by float64) (float64, error) {
+	s, err := lb.c.Cmd("ZINCRBY", lb.key, by, member).Str()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// Remove removes the members from the leaderboard
+func (lb *Leaderboard) Remove(members ...string) error {
+	if len(members) == 0 {
+		return nil
+	}
+	return lb.c.Cmd("ZREM", lb.key, members).Err
+}
+
+// Len returns the number of members on the leaderboard
+func (lb *Leaderboard) Len() (int, error) {
+	return lb.c.Cmd("ZCARD", lb.key).Int()
+}
+
+// Returns the rank of a member with the given score. Numbers are returned as
+// strings, so the scripts' replies are all bulk strings.
+const rankLib = `
+local function rank(score)
+	return tostring(redis.call('ZCOUNT', KEYS[1], '(' .. score, '+inf') + 1)
+end
+`
+
+// Returns the member's score and rank, or nil if it isn't on the leaderboard
+var entryScript = redis.NewScript(rankLib + `
+local score = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not score then return nil end
+return {score, rank(score)}
+`)
+
+// Get returns the member's entry, or nil if it isn't on the leaderboard
+func (lb *Leaderboard) Get(member string) (*Entry, error) {
+	r := entryScript.Cmd(lb.c, []string{lb.key}, member)
+	if r.Type == redis.NilReply {
+		return nil, nil
+	}
+	l, err := r.List()
+	if err != nil {
+		return nil, err
+	} else if len(l) != 2 {
+		return nil, MalformedReplyError
+	}
+	score, err := strconv.ParseFloat(l[0], 64)
+	if err != nil {
+		return nil, err
+	}
+	rank, err := strconv.Atoi(l[1])
+	if err != nil {
+		return nil, err
+	}
+	return &Entry{Member: member, Score: score, Rank: rank}, nil
+}
+
+// Returns the position of the first member read and its rank, followed by the
+// members and scores from positions ARGV[1] to ARGV[2] (from the top). If
+// ARGV[3] is set, these are instead relative to the position of that member,
+// and nil is returned if it isn't on the leaderboard.
+var pageScript = redis.NewScript(rankLib + `
+local start, stop = tonumber(ARGV[1]), tonumber(ARGV[2])
+if ARGV[3] then
+	local pos = redis.call('ZREVRANK', KEYS[1], ARGV[3])
+	if not pos then return nil end
+	start, stop = math.max(pos + start, 0), pos + stop
+end
+local page = redis.call('ZREVRANGE', KEYS[1], start, stop, 'WITHSCORES')
+local res = {tostring(start), '0'}
+if #page > 0 then res[2] = rank(page[2]) end
+for _, v in ipairs(page) do table.insert(res, v) end
+return res
+`)
+
+// MalformedReplyError is returned if a script's reply isn't what was expected
+var MalformedReplyError = errors.New("malformed leaderboard reply")
+
+func (lb *Leaderboard) page(start, stop int, around ...interface{}) ([]Entry, error) {
+	args := append([]interface{}{start, stop}, around...)
+	r := pageScript.Cmd(lb.c, []string{lb.key}, args...)
+	if r.Type == redis.NilReply {
+		return nil, nil
+	}
+	l, err := r.List()
+	if err != nil {
+		return nil, err
+	} else if len(l) < 2 || len(l)%2 != 0 {
+		return nil, MalformedReplyError
+	}
+	if start, err = strconv.Atoi(l[0]); err != nil {
+		return nil, err
+	}
+	rank, err := strconv.Atoi(l[1])
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(l)/2-1)
+	for i := 2; i < len(l); i += 2 {
+		score, err := strconv.ParseFloat(l[i+1], 64)
+		if err != nil {
+			return nil, err
+		}
+		if n := len(entries); n > 0 && score != entries[n-1].Score {
+			rank = start + n + 1
+		}
+		entries = append(entries, Entry{Member: l[i], Score: score, Rank: rank})
+	}
+	return entries, nil
+}
+
+// Top returns the n highest ranked entries
+func (lb *Leaderboard) Top(n int) ([]Entry, error) {
+	return lb.Page(0, n)
+}
+
+// Page returns up to limit entries, skipping the first offset
+func (lb *Leaderboard) Page(offset, limit int) ([]Entry, error) {
+	if offset < 0 || limit <= 0 {
+		return []Entry{}, nil
+	}
+	return lb.page(offset, offset+limit-1)
+}
+
+// Around returns the member's entry along with up to n entries either side of
+// it, or nil if the member isn't on the leaderboard
+func (lb *Leaderboard) Around(member string, n int) ([]Entry, error) {
+	if n < 0 {
+		n = 0
+	}
+	return lb.page(-n, n, member)
+}
@@ -0,0 +1,86 @@
+package leaderboard
+
+import (
+	"github.com/fzzy/radix/extra/internal/redistest"
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func testLeaderboard(t *T, key string) *Leaderboard {
+	c := redistest.Dial(t)
+	c.Cmd("DEL", key)
+	lb := NewLeaderboard(c, key)
+	for m, s := range map[string]float64{"a": 10, "b": 8, "c": 8, "d": 5, "e": 1} {
+		assert.Nil(t, lb.Set(m, s))
+	}
+	return lb
+}
+
+func TestTop(t *T) {
+	lb := testLeaderboard(t, "leaderboardtest:top")
+	entries, err := lb.Top(10)
+	assert.Nil(t, err)
+	assert.Equal(t, []Entry{
+		{"a", 10, 1}, {"c", 8, 2}, {"b", 8, 2}, {"d", 5, 4}, {"e", 1, 5},
+	}, entries)
+
+	n, err := lb.Len()
+	assert.Nil(t, err)
+	assert.Equal(t, 5, n)
+}
+
+func TestPage(t *T) {
+	lb := testLeaderboard(t, "leaderboardtest:page")
+
+	// A page starting part way through a tie still gets the tie's rank
+	entries, err := lb.Page(2, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []Entry{{"b", 8, 2}, {"d", 5, 4}}, entries)
+
+	entries, err = lb.Page(10, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(entries))
+}
+
+func TestGetAndIncr(t *T) {
+	lb := testLeaderboard(t, "leaderboardtest:get")
+	e, err := lb.Get("b")
+	assert.Nil(t, err)
+	assert.Equal(t, &Entry{"b", 8, 2}, e)
+
+	e, err = lb.Get("z")
+	assert.Nil(t, err)
+	assert.Nil(t, e)
+
+	score, err := lb.Incr("d", 10.5)
+	assert.Nil(t, err)
+	assert.Equal(t, 15.5, score)
+	e, _ = lb.Get("d")
+	assert.Equal(t, 1, e.Rank)
+
+	set, err := lb.SetIfHigher("a", 9)
+	assert.Nil(t, err)
+	assert.False(t, set)
+	set, err = lb.SetIfHigher("a", 20)
+	assert.Nil(t, err)
+	assert.True(t, set)
+
+	assert.Nil(t, lb.Remove("a", "d"))
+	e, _ = lb.Get("c")
+	assert.Equal(t, 1, e.Rank)
+}
+
+func TestAround(t *T) {
+	lb := testLeaderboard(t, "leaderboardtest:around")
+	entries, err := lb.Around("d", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []Entry{{"b", 8, 2}, {"d", 5, 4}, {"e", 1, 5}}, entries)
+
+	entries, err = lb.Around("a", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []Entry{{"a", 10, 1}, {"c", 8, 2}, {"b", 8, 2}}, entries)
+
+	entries, err = lb.Around("z", 2)
+	assert.Nil(t, err)
+	assert.Nil(t, entries)
+}
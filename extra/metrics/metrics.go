@@ -0,0 +1,199 @@
+// The metrics package serves the statistics kept by radix's connection pools,
+// circuit breakers and replica clients over HTTP, in the OpenMetrics text
+// format. It has no dependencies outside of radix and the standard library, so
+// it can be scraped by Prometheus without using the Prometheus client library.
+//
+//	h := metrics.NewHandler()
+//	h.AddPool("cache", cachePool)
+//	http.Handle("/metrics", h)
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fzzy/radix/extra/pool"
+	"github.com/fzzy/radix/extra/replica"
+)
+
+// The Content-Type the metrics are served with
+const ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// Handler is an http.Handler serving the metrics of everything added to it
+type Handler struct {
+	mu       sync.Mutex
+	pools    map[string]*pool.Pool
+	breakers map[string]*pool.Breaker
+	replicas map[string]*replica.Client
+}
+
+// NewHandler returns an empty Handler
+func NewHandler() *Handler {
+	return &Handler{
+		pools:    map[string]*pool.Pool{},
+		breakers: map[string]*pool.Breaker{},
+		replicas: map[string]*replica.Client{},
+	}
+}
+
+// AddPool serves the PoolStats of the given pool, labeled with the given name
+func (h *Handler) AddPool(name string, p *pool.Pool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pools[name] = p
+}
+
+// AddBreaker serves the state of the given circuit breaker, labeled with the
+// given name
+func (h *Handler) AddBreaker(name string, b *pool.Breaker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.breakers[name] = b
+}
+
+// AddReplicaClient serves the latencies of the given replica client's
+// replicas, labeled with the given name
+func (h *Handler) AddReplicaClient(name string, c *replica.Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.replicas[name] = c
+}
+
+// Remove stops serving the metrics of anything added with the given name
+func (h *Handler) Remove(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.pools, name)
+	delete(h.breakers, name)
+	delete(h.replicas, name)
+}
+
+// ServeHTTP writes out all the metrics
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	w.Write(h.Render())
+}
+
+// family is a metric family being rendered
+type family struct {
+	name, typ, help string
+	samples         []string
+}
+
+func (f *family) add(labels string, v interface{}) {
+	name := f.name
+	if f.typ == "counter" {
+		name += "_total"
+	}
+	f.samples = append(f.samples, fmt.Sprintf("%s{%s} %v", name, labels, v))
+}
+
+// label formats a single label for a sample
+func label(name, value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, `"`, `\"`, -1)
+	value = strings.Replace(value, "\n", `\n`, -1)
+	return name + `="` + value + `"`
+}
+
+func sortedNames(m map[string]struct{}) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render returns all the metrics in the OpenMetrics text format
+func (h *Handler) Render() []byte {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	active := &family{name: "radix_pool_active_connections", typ: "gauge",
+		help: "Connections retrieved from the pool and not yet given back."}
+	idle := &family{name: "radix_pool_idle_connections", typ: "gauge",
+		help: "Connections waiting in the pool to be used."}
+	waits := &family{name: "radix_pool_waits", typ: "counter",
+		help: "Times the pool had no idle connection and a new one was made."}
+	waitSecs := &family{name: "radix_pool_wait_seconds", typ: "counter",
+		help: "Total time spent waiting for new connections."}
+	timeouts := &family{name: "radix_pool_dial_timeouts", typ: "counter",
+		help: "New connections which failed due to a timeout."}
+	closed := &family{name: "radix_pool_closed_connections", typ: "counter",
+		help: "Connections closed by the pool."}
+	names := map[string]struct{}{}
+	for name := range h.pools {
+		names[name] = struct{}{}
+	}
+	for _, name := range sortedNames(names) {
+		s := h.pools[name].Stats()
+		l := label("pool", name)
+		active.add(l, s.Active)
+		idle.add(l, s.Idle)
+		waits.add(l, s.WaitCount)
+		waitSecs.add(l, strconv.FormatFloat(s.WaitDuration.Seconds(), 'f', -1, 64))
+		timeouts.add(l, s.Timeouts)
+		closed.add(l, s.Closed)
+	}
+
+	breaker := &family{name: "radix_breaker_state", typ: "stateset",
+		help: "The state of the circuit breaker."}
+	names = map[string]struct{}{}
+	for name := range h.breakers {
+		names[name] = struct{}{}
+	}
+	for _, name := range sortedNames(names) {
+		state := h.breakers[name].State()
+		for _, st := range []pool.BreakerState{
+			pool.BreakerClosed, pool.BreakerOpen, pool.BreakerHalfOpen,
+		} {
+			v := 0
+			if st == state {
+				v = 1
+			}
+			breaker.add(label("breaker", name)+","+
+				label("radix_breaker_state", st.String()), v)
+		}
+	}
+
+	latency := &family{name: "radix_replica_latency_seconds", typ: "gauge",
+		help: "The recent average latency of commands sent to the replica."}
+	names = map[string]struct{}{}
+	for name := range h.replicas {
+		names[name] = struct{}{}
+	}
+	for _, name := range sortedNames(names) {
+		lats := h.replicas[name].Latencies()
+		addrs := map[string]struct{}{}
+		for addr := range lats {
+			addrs[addr] = struct{}{}
+		}
+		for _, addr := range sortedNames(addrs) {
+			latency.add(label("client", name)+","+label("addr", addr),
+				strconv.FormatFloat(lats[addr].Seconds(), 'f', -1, 64))
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	for _, f := range []*family{
+		active, idle, waits, waitSecs, timeouts, closed, breaker, latency,
+	} {
+		if len(f.samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(buf, "# TYPE %s %s\n", f.name, f.typ)
+		fmt.Fprintf(buf, "# HELP %s %s\n", f.name, f.help)
+		for _, s := range f.samples {
+			buf.WriteString(s)
+			buf.WriteByte('\n')
+		}
+	}
+	buf.WriteString("# EOF\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"github.com/fzzy/radix/extra/pool"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	. "testing"
+	"time"
+)
+
+func TestHandler(t *T) {
+	p, err := pool.NewPool("tcp", "localhost:6379", 2)
+	assert.Nil(t, err)
+	defer p.Close()
+	conn, err := p.Get()
+	assert.Nil(t, err)
+	defer p.Put(conn)
+
+	h := NewHandler()
+	h.AddPool("main", p)
+	h.AddBreaker("main", pool.NewBreaker(1, time.Second, nil))
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	resp, err := srv.Client().Get(srv.URL)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, ContentType, resp.Header.Get("Content-Type"))
+	b, err := ioutil.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	body := string(b)
+
+	for _, line := range []string{
+		"# TYPE radix_pool_active_connections gauge",
+		`radix_pool_active_connections{pool="main"} 1`,
+		`radix_pool_idle_connections{pool="main"} 1`,
+		"# TYPE radix_pool_waits counter",
+		`radix_pool_waits_total{pool="main"} 0`,
+		`radix_breaker_state{breaker="main",radix_breaker_state="closed"} 1`,
+		`radix_breaker_state{breaker="main",radix_breaker_state="open"} 0`,
+	} {
+		assert.True(t, strings.Contains(body, line+"\n"), line)
+	}
+	assert.True(t, strings.HasSuffix(body, "# EOF\n"))
+
+	h.Remove("main")
+	assert.Equal(t, "# EOF\n", string(h.Render()))
+}
+
+func TestLabel(t *T) {
+	assert.Equal(t, `a="b\"c\\d\n"`, label("a", "b\"c\\d\n"))
+}
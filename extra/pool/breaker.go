@@ -0,0 +1,140 @@
+package pool
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/fzzy/radix/redis"
+)
+
+// BreakerState is the state of a Breaker
+type BreakerState int
+
+const (
+	// Dials are attempted as normal
+	BreakerClosed BreakerState = iota
+
+	// Dials fail immediately with BreakerOpenError
+	BreakerOpen
+
+	// A single dial is being attempted to see if the server is back, while
+	// any others fail immediately
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Returned by a DialFunc wrapped by a Breaker while the breaker is open
+var BreakerOpenError error = errors.New("circuit breaker is open")
+
+// A Breaker is a circuit breaker for connecting to a redis server. Once a
+// number of dials in a row have failed it opens, and all dials fail
+// immediately for a cool-down period, rather than each one waiting on its own
+// dial timeout. After the cool-down a single dial is let through as a probe; if
+// it succeeds the breaker closes again, otherwise it reopens for another
+// cool-down.
+//
+// A Breaker is used by wrapping a DialFunc with it:
+//
+//	b := pool.NewBreaker(5, 10*time.Second, nil)
+//	df := b.DialFunc(pool.TimeoutDialFunc(time.Second))
+//	p := pool.NewOrEmptyCustomPool("tcp", "127.0.0.1:6379", 10, df)
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	onChange  func(from, to BreakerState)
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+}
+
+// NewBreaker returns a Breaker which opens after threshold dials in a row have
+// failed, and stays open for the given cool-down. If onChange isn't nil it is
+// called whenever the breaker changes state. It must not use the breaker
+// itself, since it's called with the breaker locked.
+func NewBreaker(
+	threshold int, cooldown time.Duration, onChange func(from, to BreakerState),
+) *Breaker {
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		onChange:  onChange,
+	}
+}
+
+// State returns the breaker's current state
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// DialFunc returns a DialFunc which calls df, unless the breaker is open
+func (b *Breaker) DialFunc(df DialFunc) DialFunc {
+	return func(network, addr string) (*redis.Client, error) {
+		if !b.allow() {
+			return nil, BreakerOpenError
+		}
+		client, err := df(network, addr)
+		b.record(err == nil)
+		return client, err
+	}
+}
+
+func (b *Breaker) setState(s BreakerState) {
+	if b.state == s {
+		return
+	}
+	from := b.state
+	b.state = s
+	if b.onChange != nil {
+		b.onChange(from, s)
+	}
+}
+
+// allow returns whether a dial may be attempted
+func (b *Breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.setState(BreakerHalfOpen)
+		return true
+	case BreakerHalfOpen:
+		// A probe is already in progress
+		return false
+	}
+	return true
+}
+
+// record updates the breaker with the result of a dial
+func (b *Breaker) record(ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if ok {
+		b.failures = 0
+		b.setState(BreakerClosed)
+		return
+	}
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		b.setState(BreakerOpen)
+	}
+}
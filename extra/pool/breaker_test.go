@@ -0,0 +1,71 @@
+package pool
+
+import (
+	"errors"
+	"github.com/fzzy/radix/redis"
+	. "testing"
+	"time"
+)
+
+func TestBreaker(t *T) {
+	var changes []BreakerState
+	b := NewBreaker(3, 50*time.Millisecond, func(from, to BreakerState) {
+		changes = append(changes, to)
+	})
+
+	down := true
+	dials := 0
+	df := b.DialFunc(func(network, addr string) (*redis.Client, error) {
+		dials++
+		if down {
+			return nil, errors.New("connection refused")
+		}
+		return redis.Dial(network, addr)
+	})
+
+	for i := 0; i < 3; i++ {
+		if _, err := df("tcp", "localhost:6379"); err == BreakerOpenError {
+			t.Fatalf("breaker opened after %d failures", i)
+		}
+	}
+	if b.State() != BreakerOpen {
+		t.Fatalf("breaker is %s, not open", b.State())
+	}
+	if _, err := df("tcp", "localhost:6379"); err != BreakerOpenError {
+		t.Fatalf("expected BreakerOpenError, got %v", err)
+	}
+	if dials != 3 {
+		t.Fatalf("expected 3 dials, got %d", dials)
+	}
+
+	// A failed probe reopens the breaker
+	time.Sleep(60 * time.Millisecond)
+	df("tcp", "localhost:6379")
+	if b.State() != BreakerOpen {
+		t.Fatalf("breaker is %s, not open", b.State())
+	}
+
+	// A successful probe closes it
+	down = false
+	time.Sleep(60 * time.Millisecond)
+	conn, err := df("tcp", "localhost:6379")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if b.State() != BreakerClosed {
+		t.Fatalf("breaker is %s, not closed", b.State())
+	}
+
+	expected := []BreakerState{
+		BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed,
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected changes %v, got %v", expected, changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Fatalf("expected changes %v, got %v", expected, changes)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package pool
+
+import (
+	"sync"
+
+	"github.com/fzzy/radix/redis"
+)
+
+// CommandTableDialFunc returns a DialFunc which calls df, and has every
+// connection it makes check commands before sending them (see
+// redis.Client.SetCommandTable). The table is fetched using COMMAND on the
+// first connection made, and shared by all the connections after it, so a
+// pool using the DialFunc only fetches it once:
+//
+//	p, err := pool.NewCustomPool("tcp", addr, 10,
+//		pool.CommandTableDialFunc(redis.Dial))
+//
+// If fetching the table fails the connection is closed and the error
+// returned, and the next connection tries again.
+func CommandTableDialFunc(df DialFunc) DialFunc {
+	var mu sync.Mutex
+	var table redis.CommandTable
+	return func(network, addr string) (*redis.Client, error) {
+		conn, err := df(network, addr)
+		if err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if table == nil {
+			if table, err = conn.CommandTable(); err != nil {
+				conn.Close()
+				return nil, err
+			}
+		}
+		conn.SetCommandTable(table)
+		return conn, nil
+	}
+}
@@ -0,0 +1,39 @@
+package pool
+
+import (
+	"github.com/fzzy/radix/redis"
+	. "testing"
+)
+
+func TestCommandTableDialFunc(t *T) {
+	fetches := 0
+	df := CommandTableDialFunc(func(network, addr string) (*redis.Client, error) {
+		conn, err := redis.Dial(network, addr)
+		if err == nil {
+			fetches++
+		}
+		return conn, err
+	})
+	p, err := NewCustomPool("tcp", "localhost:6379", 3, df)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Empty()
+
+	for i := 0; i < 3; i++ {
+		conn, err := p.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := conn.Cmd("GET").Err.(*redis.CmdError); !ok {
+			t.Fatal("expected a CmdError for GET without a key")
+		}
+		if err = conn.Cmd("PING").Err; err != nil {
+			t.Fatal(err)
+		}
+		defer p.Put(conn)
+	}
+	if fetches != 3 {
+		t.Fatalf("expected 3 dials, got %d", fetches)
+	}
+}
@@ -0,0 +1,99 @@
+package pool
+
+import (
+	"time"
+
+	"github.com/fzzy/radix/redis"
+)
+
+// alive PINGs the connection, closing it and returning false if that fails
+func (p *Pool) alive(conn *redis.Client) bool {
+	if err := conn.Cmd("PING").Err; err != nil {
+		p.closeConn(conn)
+		return false
+	}
+	return true
+}
+
+// SetTestOnBorrow makes Get PING any connection which has been idle in the
+// pool for at least the given duration before returning it, and discard it
+// if the PING fails. This avoids handing out connections which were dropped by
+// a NAT or load balancer while idle. Zero, the default, disables this. This
+// should be called before the pool is used.
+func (p *Pool) SetTestOnBorrow(idle time.Duration) {
+	p.borrowIdle = idle
+}
+
+// SetHealthCheck starts a background routine which PINGs all idle connections
+// in the pool every interval, discarding the ones which fail. Calling it again
+// replaces the previous interval, and zero stops the health checks. Close also
+// stops them.
+func (p *Pool) SetHealthCheck(interval time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopHealthCheck()
+	if interval <= 0 {
+		return
+	}
+	p.stopCh = make(chan struct{})
+	go p.healthCheck(interval, p.stopCh)
+}
+
+// stopHealthCheck must be called with p.mu held
+func (p *Pool) stopHealthCheck() {
+	if p.stopCh != nil {
+		close(p.stopCh)
+		p.stopCh = nil
+	}
+}
+
+func (p *Pool) healthCheck(interval time.Duration, stopCh chan struct{}) {
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+			p.checkIdle()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// checkIdle PINGs each of the connections currently idle in the pool, putting
+// back the ones which are still alive. They keep the time they went idle, so
+// being checked doesn't stop them timing out (see SetIdleTimeout) or being
+// tested on borrow.
+func (p *Pool) checkIdle() {
+	n := len(p.pool)
+	for i := 0; i < n; i++ {
+		select {
+		case ic := <-p.pool:
+			if p.alive(ic.client) {
+				p.requeue(ic)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// requeue puts a connection taken out of the pool while idle back as it was,
+// or closes it if the pool has filled up in the meantime
+func (p *Pool) requeue(ic idleConn) {
+	select {
+	case p.pool <- ic:
+	default:
+		p.closeConn(ic.client)
+	}
+}
+
+// Close stops any background routines started by the pool and empties it
+func (p *Pool) Close() {
+	p.mu.Lock()
+	p.stopHealthCheck()
+	p.stopReaping()
+	p.stopFilling()
+	p.mu.Unlock()
+	p.Empty()
+}
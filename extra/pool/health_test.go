@@ -0,0 +1,73 @@
+package pool
+
+import (
+	. "testing"
+	"time"
+)
+
+func TestTestOnBorrow(t *T) {
+	pool, err := NewPool("tcp", "localhost:6379", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	pool.SetTestOnBorrow(time.Nanosecond)
+
+	// Simulate the connection dying while idle
+	conn, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	pool.Put(conn)
+
+	if conn, err = pool.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if err = conn.Cmd("PING").Err; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHealthCheck(t *T) {
+	pool, err := NewPool("tcp", "localhost:6379", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	pool.Put(conn)
+
+	pool.SetHealthCheck(10 * time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	pool.Close()
+
+	if n := len(pool.pool); n != 0 {
+		t.Fatalf("expected empty pool after Close, got %d", n)
+	}
+}
+
+func TestHealthCheckKeepsIdleTime(t *T) {
+	pool, err := NewPool("tcp", "localhost:6379", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	ic := <-pool.pool
+	since := ic.since.Add(-time.Hour)
+	ic.since = since
+	pool.pool <- ic
+
+	pool.checkIdle()
+	if n := len(pool.pool); n != 1 {
+		t.Fatalf("expected 1 idle connection after the check, got %d", n)
+	}
+	ic = <-pool.pool
+	if !ic.since.Equal(since) {
+		t.Fatalf("expected idle since %v to be kept, got %v", since, ic.since)
+	}
+}
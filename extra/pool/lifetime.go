@@ -0,0 +1,136 @@
+package pool
+
+import (
+	"time"
+
+	"github.com/fzzy/radix/redis"
+)
+
+// SetMaxConnLifetime makes the pool close connections once they're older than
+// the given duration, rather than handing them out or taking them back. This
+// lets connections be moved over gracefully when redis is restarted behind a
+// load balancer. Connections created before this is called are counted as new
+// when the pool first sees them. Zero, the default, means connections can live
+// forever.
+//
+// Expired idle connections are also closed by a background routine, which is
+// stopped by Close.
+func (p *Pool) SetMaxConnLifetime(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.maxLifetime = d
+	if d > 0 && p.born == nil {
+		p.born = map[*redis.Client]time.Time{}
+	} else if d <= 0 {
+		p.born = nil
+	}
+	p.restartReaping()
+}
+
+// SetIdleTimeout makes the pool close connections which have been idle in it
+// for longer than the given duration, so they aren't left to go stale behind a
+// NAT. Zero, the default, disables this. As with SetMaxConnLifetime a
+// background routine closes them, which is stopped by Close.
+func (p *Pool) SetIdleTimeout(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.idleTimeout = d
+	p.restartReaping()
+}
+
+// restartReaping must be called with p.mu held
+func (p *Pool) restartReaping() {
+	p.stopReaping()
+	interval := p.idleTimeout
+	if interval <= 0 || (p.maxLifetime > 0 && p.maxLifetime < interval) {
+		interval = p.maxLifetime
+	}
+	if interval <= 0 {
+		return
+	}
+	p.reapStopCh = make(chan struct{})
+	go p.reap(interval/2, p.reapStopCh)
+}
+
+// stopReaping must be called with p.mu held
+func (p *Pool) stopReaping() {
+	if p.reapStopCh != nil {
+		close(p.reapStopCh)
+		p.reapStopCh = nil
+	}
+}
+
+func (p *Pool) reap(interval time.Duration, stopCh chan struct{}) {
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+			p.reapIdle()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// reapIdle closes all idle connections which are expired
+func (p *Pool) reapIdle() {
+	now := time.Now()
+	n := len(p.pool)
+	for i := 0; i < n; i++ {
+		select {
+		case ic := <-p.pool:
+			if p.expired(ic, now) {
+				p.closeConn(ic.client)
+				continue
+			}
+			p.requeue(ic)
+		default:
+			return
+		}
+	}
+}
+
+// expired returns whether an idle connection has been idle for too long or is
+// too old
+func (p *Pool) expired(ic idleConn, now time.Time) bool {
+	p.mu.Lock()
+	idleTimeout := p.idleTimeout
+	p.mu.Unlock()
+	if idleTimeout > 0 && now.Sub(ic.since) >= idleTimeout {
+		return true
+	}
+	return p.tooOld(ic.client, now)
+}
+
+// tooOld returns whether the connection is older than the max lifetime
+func (p *Pool) tooOld(conn *redis.Client, now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.born == nil {
+		return false
+	}
+	born, ok := p.born[conn]
+	if !ok {
+		p.born[conn] = now
+		return false
+	}
+	return now.Sub(born) >= p.maxLifetime
+}
+
+// setBorn records when conn was created, if the max lifetime is being tracked
+func (p *Pool) setBorn(conn *redis.Client, t time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.born != nil {
+		p.born[conn] = t
+	}
+}
+
+func (p *Pool) forget(conn *redis.Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.born != nil {
+		delete(p.born, conn)
+	}
+}
@@ -0,0 +1,45 @@
+package pool
+
+import (
+	. "testing"
+	"time"
+)
+
+func TestMaxConnLifetime(t *T) {
+	pool, err := NewPool("tcp", "localhost:6379", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	pool.SetMaxConnLifetime(time.Hour)
+
+	a, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.Put(a)
+	if b, _ := pool.Get(); b != a {
+		t.Fatal("connection was not reused")
+	}
+
+	// Pretend the connection is old
+	pool.setBorn(a, time.Now().Add(-2*time.Hour))
+	pool.Put(a)
+	if s := pool.Stats(); s.Idle != 0 || s.Closed != 1 {
+		t.Fatalf("expired connection was not closed: %+v", s)
+	}
+}
+
+func TestIdleTimeout(t *T) {
+	pool, err := NewPool("tcp", "localhost:6379", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	pool.SetIdleTimeout(20 * time.Millisecond)
+
+	time.Sleep(60 * time.Millisecond)
+	if s := pool.Stats(); s.Idle != 0 || s.Closed != 2 {
+		t.Fatalf("idle connections were not reaped: %+v", s)
+	}
+}
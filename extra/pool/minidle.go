@@ -0,0 +1,66 @@
+package pool
+
+import (
+	"time"
+)
+
+// How often the pool is topped up to its minimum number of idle connections
+var minIdleInterval = time.Second
+
+// SetMinIdle makes the pool keep at least n idle connections ready, so that a
+// burst of traffic doesn't have to wait for each connection to be dialed (and
+// set up by the DialFunc). The pool is filled up to n immediately, returning
+// the first dial error if that fails, and is then topped up by a background
+// routine every second. n is capped by the pool's size. Zero stops the top ups,
+// as does Close.
+func (p *Pool) SetMinIdle(n int) error {
+	if n > cap(p.pool) {
+		n = cap(p.pool)
+	}
+	p.mu.Lock()
+	p.minIdle = n
+	p.stopFilling()
+	if n > 0 {
+		p.fillStopCh = make(chan struct{})
+		go p.fillLoop(p.fillStopCh)
+	}
+	p.mu.Unlock()
+	return p.fill()
+}
+
+// stopFilling must be called with p.mu held
+func (p *Pool) stopFilling() {
+	if p.fillStopCh != nil {
+		close(p.fillStopCh)
+		p.fillStopCh = nil
+	}
+}
+
+func (p *Pool) fillLoop(stopCh chan struct{}) {
+	tick := time.NewTicker(minIdleInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+			p.fill()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// fill dials new connections until the pool has at least minIdle idle ones
+func (p *Pool) fill() error {
+	p.mu.Lock()
+	n := p.minIdle
+	p.mu.Unlock()
+	for len(p.pool) < n {
+		conn, err := p.df(p.network, p.addr)
+		if err != nil {
+			return err
+		}
+		p.setBorn(conn, time.Now())
+		p.putIdle(conn)
+	}
+	return nil
+}
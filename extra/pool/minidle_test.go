@@ -0,0 +1,30 @@
+package pool
+
+import (
+	. "testing"
+	"time"
+)
+
+func TestMinIdle(t *T) {
+	pool := NewOrEmptyCustomPool("tcp", "localhost:6379", 3, TimeoutDialFunc(time.Second))
+	defer pool.Close()
+	minIdleInterval = 10 * time.Millisecond
+	if err := pool.SetMinIdle(2); err != nil {
+		t.Fatal(err)
+	}
+	if n := pool.Stats().Idle; n < 2 {
+		t.Fatalf("expected at least 2 idle connections, got %d", n)
+	}
+
+	// Using up the idle connections gets them topped up again
+	a, _ := pool.Get()
+	b, _ := pool.Get()
+	c, _ := pool.Get()
+	time.Sleep(50 * time.Millisecond)
+	if n := pool.Stats().Idle; n < 2 {
+		t.Fatalf("expected pool to be topped up to 2, got %d", n)
+	}
+	a.Close()
+	b.Close()
+	c.Close()
+}
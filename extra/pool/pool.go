@@ -3,6 +3,10 @@
 package pool
 
 import (
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/fzzy/radix/redis"
 )
 
@@ -10,10 +14,39 @@ import (
 // and if more connections are needed they will be created on demand. If a
 // connection is returned and the pool is full it will be closed.
 type Pool struct {
+	// Counters for Stats, accessed atomically. These are kept first so they're
+	// 64-bit aligned.
+	active, waitCount, waitNanos, timeouts, closed int64
+
 	network string
 	addr    string
-	pool    chan *redis.Client
+	pool    chan idleConn
 	df      DialFunc
+
+	// See SetTestOnBorrow
+	borrowIdle time.Duration
+
+	// Closed to stop the background health checks, see SetHealthCheck
+	stopCh chan struct{}
+	mu     sync.Mutex
+
+	// See SetMaxConnLifetime and SetIdleTimeout. born holds when each
+	// connection was created, and is only kept while maxLifetime is set.
+	// reapStopCh is closed to stop the background reaping.
+	maxLifetime time.Duration
+	idleTimeout time.Duration
+	born        map[*redis.Client]time.Time
+	reapStopCh  chan struct{}
+
+	// See SetMinIdle. fillStopCh is closed to stop the background top ups.
+	minIdle    int
+	fillStopCh chan struct{}
+}
+
+// idleConn is a connection waiting in the pool, and when it was put there
+type idleConn struct {
+	client *redis.Client
+	since  time.Time
 }
 
 // A function which can be passed into NewCustomPool
@@ -57,11 +90,12 @@ func NewCustomPool(network, addr string, size int, df DialFunc) (*Pool, error) {
 	p := Pool{
 		network: network,
 		addr:    addr,
-		pool:    make(chan *redis.Client, len(pool)),
+		pool:    make(chan idleConn, len(pool)),
 		df:      df,
 	}
+	now := time.Now()
 	for i := range pool {
-		p.pool <- pool[i]
+		p.pool <- idleConn{pool[i], now}
 	}
 	return &p, nil
 }
@@ -73,18 +107,37 @@ func NewPool(network, addr string, size int) (*Pool, error) {
 	return NewCustomPool(network, addr, size, redis.Dial)
 }
 
+// Same as NewPool, but all connections will be created using
+// redis.DialTimeout with the given timeout
+func NewPoolTimeout(network, addr string, size int, timeout time.Duration) (*Pool, error) {
+	return NewCustomPool(network, addr, size, TimeoutDialFunc(timeout))
+}
+
+// TimeoutDialFunc returns a DialFunc which creates connections using
+// redis.DialTimeout with the given timeout
+func TimeoutDialFunc(timeout time.Duration) DialFunc {
+	return func(network, addr string) (*redis.Client, error) {
+		return redis.DialTimeout(network, addr, timeout)
+	}
+}
+
 // Calls NewPool, but if there is an error it return a pool of the same size but
 // without any connections pre-initialized (can be used the same way, but if
 // this happens there might be something wrong with the redis instance you're
 // connecting to)
 func NewOrEmptyPool(network, addr string, size int) *Pool {
-	pool, err := NewPool(network, addr, size)
+	return NewOrEmptyCustomPool(network, addr, size, redis.Dial)
+}
+
+// Same as NewOrEmptyPool, but uses NewCustomPool with the given DialFunc
+func NewOrEmptyCustomPool(network, addr string, size int, df DialFunc) *Pool {
+	pool, err := NewCustomPool(network, addr, size, df)
 	if err != nil {
 		pool = &Pool{
 			network: network,
 			addr:    addr,
-			pool:    make(chan *redis.Client, size),
-			df:      redis.Dial,
+			pool:    make(chan idleConn, size),
+			df:      df,
 		}
 	}
 	return pool
@@ -93,23 +146,47 @@ func NewOrEmptyPool(network, addr string, size int) *Pool {
 // Retrieves an available redis client. If there are none available it will
 // create a new one on the fly
 func (p *Pool) Get() (*redis.Client, error) {
-	select {
-	case conn := <-p.pool:
-		return conn, nil
-	default:
-		return p.df(p.network, p.addr)
+	for {
+		select {
+		case ic := <-p.pool:
+			if p.expired(ic, time.Now()) {
+				p.closeConn(ic.client)
+				continue
+			}
+			if p.borrowIdle > 0 && time.Since(ic.since) >= p.borrowIdle {
+				if !p.alive(ic.client) {
+					continue
+				}
+			}
+			atomic.AddInt64(&p.active, 1)
+			return ic.client, nil
+		default:
+			return p.dial()
+		}
 	}
 }
 
 // Returns a client back to the pool. If the pool is full the client is closed
 // instead. If the client is already closed (due to connection failure or
-// what-have-you) it should not be put back in the pool. The pool will create
-// more connections as needed.
+// what-have-you) it should not be put back in the pool, though one which
+// closed itself because of an error (see redis.Client.Broken) is discarded
+// rather than kept. The pool will create more connections as needed.
 func (p *Pool) Put(conn *redis.Client) {
+	atomic.AddInt64(&p.active, -1)
+	p.putIdle(conn)
+}
+
+// putIdle puts conn in the pool, or closes it if the pool is full, it's past
+// its max lifetime or it's broken
+func (p *Pool) putIdle(conn *redis.Client) {
+	if conn.Broken() || p.tooOld(conn, time.Now()) {
+		p.closeConn(conn)
+		return
+	}
 	select {
-	case p.pool <- conn:
+	case p.pool <- idleConn{conn, time.Now()}:
 	default:
-		conn.Close()
+		p.closeConn(conn)
 	}
 }
 
@@ -142,6 +219,8 @@ func (p *Pool) CarefullyPut(conn *redis.Client, potentialErr *error) {
 		// We don't care about command errors, they don't indicate anything
 		// about the connection integrity
 		if _, ok := (*potentialErr).(*redis.CmdError); !ok {
+			atomic.AddInt64(&p.active, -1)
+			p.closeConn(conn)
 			return
 		}
 	}
@@ -152,11 +231,10 @@ func (p *Pool) CarefullyPut(conn *redis.Client, potentialErr *error) {
 // Assuming there are no other connections waiting to be Put back this method
 // effectively closes and cleans up the pool.
 func (p *Pool) Empty() {
-	var conn *redis.Client
 	for {
 		select {
-		case conn = <-p.pool:
-			conn.Close()
+		case ic := <-p.pool:
+			p.closeConn(ic.client)
 		default:
 			return
 		}
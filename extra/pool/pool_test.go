@@ -3,6 +3,7 @@ package pool
 import (
 	"github.com/fzzy/radix/redis"
 	. "testing"
+	"time"
 )
 
 func TestPool(t *T) {
@@ -24,3 +25,41 @@ func TestPool(t *T) {
 
 	pool.Empty()
 }
+
+func TestPoolTimeout(t *T) {
+	pool, err := NewPoolTimeout("tcp", "localhost:6379", 2, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = conn.Cmd("PING").Err; err != nil {
+		t.Fatal(err)
+	}
+	pool.Put(conn)
+	pool.Empty()
+}
+
+func TestPoolBroken(t *T) {
+	pool, err := NewPool("tcp", "localhost:6379", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A connection which closed itself after a timeout isn't kept
+	conn.WithTimeout(10*time.Millisecond).Cmd("BLPOP", "pool:nothing", 1)
+	if !conn.Broken() {
+		t.Fatal("Connection should be broken")
+	}
+	pool.Put(conn)
+	if conn2, _ := pool.Get(); conn2 == conn {
+		t.Fatal("Broken connection was put back in the pool")
+	}
+	pool.Empty()
+}
@@ -0,0 +1,69 @@
+package pool
+
+import (
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/fzzy/radix/redis"
+)
+
+// PoolStats describes the state of a Pool and what it has done so far, for
+// diagnosing capacity problems at runtime
+type PoolStats struct {
+	// The number of connections which have been retrieved with Get and not yet
+	// given back with Put or CarefullyPut. Connections which are never given
+	// back are counted here forever.
+	Active int64
+
+	// The number of connections waiting in the pool to be used
+	Idle int64
+
+	// The number of times Get found no idle connection and had to wait for a
+	// new one to be created, and the total time spent waiting. A high count
+	// relative to the number of commands means the pool is too small.
+	WaitCount    int64
+	WaitDuration time.Duration
+
+	// The number of new connections which failed due to a timeout
+	Timeouts int64
+
+	// The number of connections the pool has closed, because it was full, they
+	// failed a health check, or they were emptied out of it
+	Closed int64
+}
+
+// Stats returns the pool's current PoolStats
+func (p *Pool) Stats() PoolStats {
+	return PoolStats{
+		Active:       atomic.LoadInt64(&p.active),
+		Idle:         int64(len(p.pool)),
+		WaitCount:    atomic.LoadInt64(&p.waitCount),
+		WaitDuration: time.Duration(atomic.LoadInt64(&p.waitNanos)),
+		Timeouts:     atomic.LoadInt64(&p.timeouts),
+		Closed:       atomic.LoadInt64(&p.closed),
+	}
+}
+
+// dial creates a new connection for Get, keeping track of how long it took
+func (p *Pool) dial() (*redis.Client, error) {
+	start := time.Now()
+	conn, err := p.df(p.network, p.addr)
+	atomic.AddInt64(&p.waitCount, 1)
+	atomic.AddInt64(&p.waitNanos, int64(time.Since(start)))
+	if err != nil {
+		if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+			atomic.AddInt64(&p.timeouts, 1)
+		}
+		return nil, err
+	}
+	p.setBorn(conn, time.Now())
+	atomic.AddInt64(&p.active, 1)
+	return conn, nil
+}
+
+func (p *Pool) closeConn(conn *redis.Client) {
+	atomic.AddInt64(&p.closed, 1)
+	p.forget(conn)
+	conn.Close()
+}
@@ -0,0 +1,35 @@
+package pool
+
+import (
+	"errors"
+	"github.com/fzzy/radix/redis"
+	. "testing"
+)
+
+func TestStats(t *T) {
+	pool, err := NewPool("tcp", "localhost:6379", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	a, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := pool.Stats()
+	if s.Active != 2 || s.Idle != 0 || s.WaitCount != 1 || s.WaitDuration <= 0 {
+		t.Fatalf("unexpected stats after Get: %+v", s)
+	}
+
+	pool.Put(a)
+	var cerr error = &redis.CmdError{Err: errors.New("ERR")}
+	pool.CarefullyPut(b, &cerr)
+	if s = pool.Stats(); s.Active != 0 || s.Idle != 1 || s.Closed != 1 {
+		t.Fatalf("unexpected stats after Put: %+v", s)
+	}
+}
@@ -0,0 +1,131 @@
+package pubsub
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy decides what happens to a message which arrives while the
+// dispatch buffer is full, see WithDispatch
+type OverflowPolicy int
+
+const (
+	// Wait for there to be room in the buffer. Nothing is dropped, but no more
+	// replies are read from the connection in the meantime, so redis buffers
+	// them instead (and disconnects the client if its output buffer limit for
+	// pubsub clients is reached).
+	OverflowBlock OverflowPolicy = iota
+
+	// Drop the oldest message in the buffer to make room
+	OverflowDropOldest
+
+	// Drop the message which just arrived
+	OverflowDropNew
+)
+
+// DispatchOptions describe how Listen hands messages to its handler, see
+// WithDispatch
+type DispatchOptions struct {
+	// How many messages can be waiting for the handler. Defaults to 1000.
+	BufferSize int
+
+	// What to do with messages once BufferSize are waiting
+	Overflow OverflowPolicy
+
+	// How many routines call the handler. With more than one, messages may be
+	// handled out of the order they arrived in. Defaults to 1.
+	Workers int
+}
+
+// WithDispatch makes Listen hand each message to a pool of worker routines
+// which call its handler (see WithHandler and SubscribeFunc), through a
+// bounded buffer, rather than calling it directly. A slow handler then doesn't
+// hold up reading from the connection (so PINGs are still answered, see
+// WithPingInterval), and a backlog of messages can't grow without limit. When the buffer is full
+// the Overflow policy decides what happens; messages which are dropped are
+// counted, see Dropped. It has no effect on WithChannel, whose channel is
+// already a bounded buffer.
+//
+// When Listen returns it waits for the workers to finish handling the messages
+// already in the buffer.
+func WithDispatch(opts DispatchOptions) Option {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 1000
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	return func(o *options) {
+		o.dispatch = &opts
+	}
+}
+
+// Dropped returns how many messages have been dropped because the dispatch
+// buffer was full, see WithDispatch
+func (c *SubClient) Dropped() int64 {
+	return atomic.LoadInt64(&c.dropped)
+}
+
+// dispatcher is the buffer and workers used by one call to Listen
+type dispatcher struct {
+	c     *SubClient
+	queue chan *SubReply
+	wg    sync.WaitGroup
+}
+
+func (c *SubClient) startDispatch() *dispatcher {
+	opts := c.opts.dispatch
+	d := &dispatcher{c: c, queue: make(chan *SubReply, opts.BufferSize)}
+	d.wg.Add(opts.Workers)
+	for i := 0; i < opts.Workers; i++ {
+		go func() {
+			defer d.wg.Done()
+			for sr := range d.queue {
+				if h := c.handlerFor(sr); h != nil {
+					h(sr)
+				}
+			}
+		}()
+	}
+	return d
+}
+
+// dispatch puts the message in the buffer, following the overflow policy if
+// it's full. done is closed if the message should be given up on while
+// blocking.
+func (d *dispatcher) dispatch(sr *SubReply, done <-chan struct{}) {
+	select {
+	case d.queue <- sr:
+		return
+	default:
+	}
+
+	switch d.c.opts.dispatch.Overflow {
+	case OverflowDropNew:
+		atomic.AddInt64(&d.c.dropped, 1)
+	case OverflowDropOldest:
+		for {
+			select {
+			case <-d.queue:
+				atomic.AddInt64(&d.c.dropped, 1)
+			default:
+			}
+			select {
+			case d.queue <- sr:
+				return
+			default:
+			}
+		}
+	default:
+		select {
+		case d.queue <- sr:
+		case <-done:
+		}
+	}
+}
+
+// stop waits for the workers to handle what's left in the buffer
+func (d *dispatcher) stop() {
+	close(d.queue)
+	d.wg.Wait()
+}
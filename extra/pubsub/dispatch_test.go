@@ -0,0 +1,129 @@
+package pubsub
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDispatchDropNew(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	handled := make(chan string, 10)
+	h := func(sr *SubReply) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		mu.Lock()
+		got = append(got, sr.Message)
+		mu.Unlock()
+		handled <- sr.Message
+	}
+	_, sub := dialSub(t, WithHandler(h),
+		WithDispatch(DispatchOptions{BufferSize: 2, Overflow: OverflowDropNew}))
+	if sr := sub.Subscribe("dispatchDropNew"); sr.Err != nil {
+		t.Fatal(sr.Err)
+	}
+	stop := listen(sub)
+
+	// The first message is taken by the worker, which blocks, then two fill
+	// the buffer and the other two are dropped
+	pub, _ := dialSub(t)
+	pub.Cmd("PUBLISH", "dispatchDropNew", "1")
+	<-started
+	for i := 2; i <= 5; i++ {
+		pub.Cmd("PUBLISH", "dispatchDropNew", strconv.Itoa(i))
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for sub.Dropped() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(release)
+	for i := 0; i < 3; i++ {
+		<-handled
+	}
+	stop()
+
+	if n := sub.Dropped(); n != 2 {
+		t.Fatalf("Expected 2 dropped, got %d", n)
+	}
+	if len(got) != 3 || got[0] != "1" || got[1] != "2" || got[2] != "3" {
+		t.Fatalf("Unexpected messages handled: %v", got)
+	}
+}
+
+func TestDispatchDropOldest(t *testing.T) {
+	var got []string
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	h := func(sr *SubReply) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		got = append(got, sr.Message)
+	}
+	_, sub := dialSub(t, WithHandler(h),
+		WithDispatch(DispatchOptions{BufferSize: 2, Overflow: OverflowDropOldest}))
+	if sr := sub.Subscribe("dispatchDropOldest"); sr.Err != nil {
+		t.Fatal(sr.Err)
+	}
+	stop := listen(sub)
+
+	pub, _ := dialSub(t)
+	pub.Cmd("PUBLISH", "dispatchDropOldest", "1")
+	<-started
+	for i := 2; i <= 5; i++ {
+		pub.Cmd("PUBLISH", "dispatchDropOldest", strconv.Itoa(i))
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for sub.Dropped() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(release)
+	stop()
+
+	if len(got) != 3 || got[0] != "1" || got[1] != "4" || got[2] != "5" {
+		t.Fatalf("Unexpected messages handled: %v", got)
+	}
+}
+
+func TestDispatchWorkers(t *testing.T) {
+	var wg sync.WaitGroup
+	wg.Add(4)
+	h := func(sr *SubReply) {
+		// Every handler must be running at once for any of them to finish
+		wg.Done()
+		wg.Wait()
+	}
+	_, sub := dialSub(t, WithHandler(h), WithDispatch(DispatchOptions{Workers: 4}))
+	if sr := sub.Subscribe("dispatchWorkers"); sr.Err != nil {
+		t.Fatal(sr.Err)
+	}
+	stop := listen(sub)
+
+	pub, _ := dialSub(t)
+	for i := 0; i < 4; i++ {
+		pub.Cmd("PUBLISH", "dispatchWorkers", "hi")
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Handlers weren't run concurrently")
+	}
+	stop()
+	if n := sub.Dropped(); n != 0 {
+		t.Fatalf("Expected nothing dropped, got %d", n)
+	}
+}
@@ -0,0 +1,87 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/fzzy/radix/redis"
+)
+
+// How often WaitForChange checks whether its context is done
+var waitPollInterval = 100 * time.Millisecond
+
+// KeyspaceChannel returns the channel redis publishes keyspace notifications
+// for the given key in the given database on
+func KeyspaceChannel(db int, key string) string {
+	return "__keyspace@" + strconv.Itoa(db) + "__:" + key
+}
+
+// WaitForChange blocks until the given key in the given database changes, and
+// returns the name of the event which changed it (e.g. "set", "del" or
+// "expired"). If ctx is done first its error is returned instead.
+//
+// This relies on keyspace notifications, which redis has off by default, so
+// notify-keyspace-events must include "K" along with the classes of events to
+// wait for (e.g. "KA" for all of them). If it doesn't WaitForChange will wait
+// until ctx is done.
+//
+// client is subscribed to the key's keyspace channel while waiting, so it must
+// not be used by anything else in the meantime. It's unsubscribed again before
+// returning, unless there was a connection error.
+//
+// Like WATCH, this can be used to wait for a key to change from a value which
+// was already read, without missing a change made in between. If unchanged
+// isn't nil it's called once the subscription is in place, and should return
+// whether the key still has the value which was read; if it returns false
+// WaitForChange returns immediately with an empty event.
+func WaitForChange(
+	ctx context.Context, client *redis.Client, db int, key string,
+	unchanged func() bool,
+) (
+	string, error,
+) {
+	channel := KeyspaceChannel(db, key)
+	sub := NewSubClient(client.WithTimeout(waitPollInterval))
+	if sr := sub.Subscribe(channel); sr.Err != nil {
+		return "", sr.Err
+	}
+
+	event, err := waitForEvent(ctx, sub, channel, unchanged)
+	if err == nil || err == ctx.Err() {
+		if sr := sub.Unsubscribe(channel); sr.Err != nil {
+			return "", sr.Err
+		}
+	}
+	return event, err
+}
+
+func waitForEvent(
+	ctx context.Context, sub *SubClient, channel string, unchanged func() bool,
+) (
+	string, error,
+) {
+	if unchanged != nil && !unchanged() {
+		return "", nil
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		default:
+		}
+
+		sr := sub.Receive()
+		if sr.Timeout() {
+			continue
+		} else if sr.Err != nil {
+			return "", sr.Err
+		} else if sr.Type != MessageReply {
+			return "", errors.New("unexpected reply while waiting for change")
+		}
+		if sr.Channel == channel {
+			return sr.Message, nil
+		}
+	}
+}
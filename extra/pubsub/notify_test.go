@@ -0,0 +1,55 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fzzy/radix/redis"
+)
+
+func TestWaitForChange(t *testing.T) {
+	pub, err := redis.DialTimeout("tcp", "localhost:6379", 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := redis.DialTimeout("tcp", "localhost:6379", 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Publish the notification by hand, in case the server doesn't have
+	// keyspace notifications turned on
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		pub.Cmd("PUBLISH", KeyspaceChannel(0, "other"), "set")
+		pub.Cmd("PUBLISH", KeyspaceChannel(0, "notifyKey"), "set")
+	}()
+	event, err := WaitForChange(context.Background(), client, 0, "notifyKey", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event != "set" {
+		t.Fatalf("expected set event, got %q", event)
+	}
+
+	// The client is usable again afterwards
+	if err = client.Cmd("PING").Err; err != nil {
+		t.Fatal(err)
+	}
+
+	event, err = WaitForChange(context.Background(), client, 0, "notifyKey",
+		func() bool { return false })
+	if err != nil || event != "" {
+		t.Fatalf("expected immediate return, got %q, %v", event, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	if _, err = WaitForChange(ctx, client, 0, "notifyKey", nil); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if err = client.Cmd("PING").Err; err != nil {
+		t.Fatal(err)
+	}
+}
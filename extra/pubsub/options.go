@@ -0,0 +1,280 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fzzy/radix/redis"
+	"github.com/fzzy/radix/redis/resp"
+)
+
+// Option changes the behavior of a SubClient, see NewSubClient
+type Option func(*options)
+
+type options struct {
+	bufferSize   int
+	handler      Handler
+	ch           chan<- *SubReply
+	reconnect    *ReconnectPolicy
+	pingInterval time.Duration
+	dispatch     *DispatchOptions
+	confirmed    func(Confirmation)
+	liveness     func(rtt time.Duration, err error)
+}
+
+// ReconnectPolicy describes how Listen re-establishes a connection which failed,
+// see WithReconnect
+type ReconnectPolicy struct {
+	// How many times in a row to try reconnecting before giving up. Zero means
+	// no limit.
+	MaxAttempts int
+
+	// How long to wait before each attempt
+	Backoff time.Duration
+}
+
+// PingTimeoutError is passed to the liveness callback when a PING isn't
+// answered in time, see WithLiveness
+var PingTimeoutError = errors.New("pubsub: no reply to PING")
+
+// NoDeliveryError is returned by Listen if the SubClient was created without
+// WithHandler or WithChannel, and has no handlers for particular channels or
+// patterns
+var NoDeliveryError = errors.New("pubsub: no handler or channel to deliver to")
+
+// How often Listen checks whether its context is done while waiting for
+// messages
+var listenPollInterval = 100 * time.Millisecond
+
+// WithBufferSize limits how many messages which arrive while waiting for the
+// reply to a (un)subscribe are kept for Receive to return later. Once the
+// buffer is full the oldest messages are dropped. By default there's no limit.
+func WithBufferSize(n int) Option {
+	return func(o *options) {
+		o.bufferSize = n
+	}
+}
+
+// WithHandler makes Listen call h with every message received. h is called
+// from the routine calling Listen, so the next message isn't read until it
+// returns, unless WithDispatch is also used.
+func WithHandler(h Handler) Option {
+	return func(o *options) {
+		o.handler = h
+	}
+}
+
+// WithChannel makes Listen send every message received on ch. If ch is full
+// Listen blocks until there's room for the message, or its context is done.
+func WithChannel(ch chan<- *SubReply) Option {
+	return func(o *options) {
+		o.ch = ch
+	}
+}
+
+// WithReconnect makes Listen reconnect to redis if the connection fails, and
+// subscribe again to all the channels and patterns which were subscribed to
+// before, rather than returning the error. Messages published while the
+// connection was down are lost.
+func WithReconnect(policy ReconnectPolicy) Option {
+	return func(o *options) {
+		o.reconnect = &policy
+	}
+}
+
+// WithPingInterval makes Listen send a PING whenever no message has been
+// received for the given interval. If nothing comes back within another
+// interval the connection is considered to have failed, see WithReconnect.
+// This catches connections which have been silently dropped, e.g. by a NAT or
+// load balancer. See WithLiveness to be told the result of each check.
+func WithPingInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.pingInterval = d
+	}
+}
+
+// WithLiveness makes Listen call fn with the result of every health check made
+// using WithPingInterval: the round trip time when a PING is answered, or the
+// error when the connection is found to have failed (PingTimeoutError if a
+// PING went unanswered). fn is called from the routine calling Listen, before
+// any reconnecting is done, so it should return quickly.
+func WithLiveness(fn func(rtt time.Duration, err error)) Option {
+	return func(o *options) {
+		o.liveness = fn
+	}
+}
+
+// WithConfirmations makes the SubClient call fn with every confirmation of a
+// subscribe or unsubscribe as it's received, including those for the
+// subscriptions made again after reconnecting (see WithReconnect), so callers
+// can tell when a subscription is active again.
+func WithConfirmations(fn func(Confirmation)) Option {
+	return func(o *options) {
+		o.confirmed = fn
+	}
+}
+
+// Listen delivers every message received to the handler registered for its
+// channel or pattern (see SubscribeFunc and PSubscribeFunc), or otherwise to
+// the handler or channel given to NewSubClient, until ctx is done or the
+// connection fails (and can't be re-established, see WithReconnect). Messages
+// with nowhere to go are dropped. Subscriptions should be made before calling
+// Listen, since the client must not be used by anything else while Listen is
+// running. Listen always returns a non-nil error, which is ctx's error if it's
+// done.
+func (c *SubClient) Listen(ctx context.Context) error {
+	if c.opts.handler == nil && c.opts.ch == nil && !c.hasHandlers() {
+		return NoDeliveryError
+	}
+	var d *dispatcher
+	if c.opts.dispatch != nil {
+		d = c.startDispatch()
+		defer d.stop()
+	}
+
+	// pingSent is cleared by any reply, since that shows the connection is
+	// alive, but pingAt is only cleared by the PONG, so the round trip can be
+	// measured
+	var pingSent, pingAt time.Time
+	last := time.Now()
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if c.opts.pingInterval > 0 && pingSent.IsZero() &&
+			time.Since(last) >= c.opts.pingInterval {
+			if err := c.ping(); err != nil {
+				c.alive(0, err)
+				if err = c.recover(ctx, err); err != nil {
+					return err
+				}
+				last = time.Now()
+				continue
+			}
+			pingSent = time.Now()
+			pingAt = pingSent
+		}
+
+		sr := c.receiveTimeout(listenPollInterval)
+		switch {
+		case sr.Timeout():
+			if !pingSent.IsZero() && time.Since(pingSent) >= c.opts.pingInterval {
+				c.alive(0, PingTimeoutError)
+				c.Client.Close()
+				if err := c.recover(ctx, sr.Err); err != nil {
+					return err
+				}
+				pingSent, pingAt, last = time.Time{}, time.Time{}, time.Now()
+			}
+			continue
+		case sr.Err != nil:
+			if _, ok := sr.Err.(*redis.CmdError); ok {
+				return sr.Err
+			}
+			c.alive(0, sr.Err)
+			if err := c.recover(ctx, sr.Err); err != nil {
+				return err
+			}
+			pingSent, pingAt, last = time.Time{}, time.Time{}, time.Now()
+			continue
+		}
+
+		pingSent, last = time.Time{}, time.Now()
+		if sr.Type == PongReply && !pingAt.IsZero() {
+			c.alive(time.Since(pingAt), nil)
+			pingAt = time.Time{}
+		}
+		if sr.Type != MessageReply {
+			continue
+		}
+		h := c.handlerFor(sr)
+		if h != nil && d != nil {
+			d.dispatch(sr, ctx.Done())
+		} else if h != nil {
+			h(sr)
+		} else if c.opts.ch != nil {
+			select {
+			case c.opts.ch <- sr:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// alive passes the result of a health check to the liveness callback, if
+// there is one
+func (c *SubClient) alive(rtt time.Duration, err error) {
+	if c.opts.liveness != nil {
+		c.opts.liveness(rtt, err)
+	}
+}
+
+// receiveTimeout is like Receive, but waits at most the given time for a reply
+func (c *SubClient) receiveTimeout(timeout time.Duration) *SubReply {
+	if c.messages.Len() > 0 {
+		return c.messages.Remove(c.messages.Front()).(*SubReply)
+	}
+	return c.parseReply(c.Client.WithTimeout(timeout).ReadReply())
+}
+
+// ping sends a PING without waiting for the reply, which Listen reads along
+// with the messages
+func (c *SubClient) ping() error {
+	return resp.WriteArbitraryAsFlattenedStrings(c.Client.Conn, []interface{}{"PING"})
+}
+
+// recover reconnects after the connection failed with err, following the
+// ReconnectPolicy, and subscribes to everything which was subscribed to
+// before. It returns err if reconnecting isn't enabled or gave up.
+func (c *SubClient) recover(ctx context.Context, err error) error {
+	policy := c.opts.reconnect
+	if policy == nil {
+		return err
+	}
+	for attempt := 1; policy.MaxAttempts == 0 || attempt <= policy.MaxAttempts; attempt++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(policy.Backoff):
+		}
+		if err = c.resubscribe(); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (c *SubClient) resubscribe() error {
+	if err := c.Client.Reconnect(); err != nil {
+		return err
+	}
+	c.messages.Init()
+	channels, patterns := c.channels, c.patterns
+	c.channels, c.patterns = map[string]bool{}, map[string]bool{}
+	for _, sub := range []struct {
+		cmd   string
+		names map[string]bool
+	}{{"SUBSCRIBE", channels}, {"PSUBSCRIBE", patterns}} {
+		if len(sub.names) == 0 {
+			continue
+		}
+		names := make([]interface{}, 0, len(sub.names))
+		for name := range sub.names {
+			names = append(names, name)
+		}
+		if sr := c.filterMessages(sub.cmd, names...); sr.Err != nil {
+			// Keep the rest so the next attempt subscribes to them
+			for name := range channels {
+				c.channels[name] = true
+			}
+			for name := range patterns {
+				c.patterns[name] = true
+			}
+			return sr.Err
+		}
+	}
+	return nil
+}
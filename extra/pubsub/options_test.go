@@ -0,0 +1,248 @@
+package pubsub
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fzzy/radix/redis"
+)
+
+func dialSub(t *testing.T, opts ...Option) (*redis.Client, *SubClient) {
+	pub, err := redis.DialTimeout("tcp", "localhost:6379", 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := redis.DialTimeout("tcp", "localhost:6379", 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pub, NewSubClient(client, opts...)
+}
+
+// listen runs Listen in the background, returning a function which stops it
+// and returns its error
+func listen(sub *SubClient) func() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- sub.Listen(ctx)
+	}()
+	return func() error {
+		cancel()
+		return <-errCh
+	}
+}
+
+func expectMessage(t *testing.T, ch <-chan *SubReply, message string) {
+	select {
+	case sr := <-ch:
+		if sr.Message != message {
+			t.Fatalf("Expected message %q, got %q", message, sr.Message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Took too long to receive message")
+	}
+}
+
+func TestBufferSize(t *testing.T) {
+	pub, sub := dialSub(t, WithBufferSize(1))
+	if sr := sub.Subscribe("optsBufferA"); sr.Err != nil {
+		t.Fatal(sr.Err)
+	}
+	for _, msg := range []string{"1", "2", "3"} {
+		if r := pub.Cmd("PUBLISH", "optsBufferA", msg); r.Err != nil {
+			t.Fatal(r.Err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	// The messages arrive while waiting for the subscribe reply, and only the
+	// last one is kept
+	if sr := sub.Subscribe("optsBufferB"); sr.Err != nil {
+		t.Fatal(sr.Err)
+	}
+	if sr := sub.Receive(); sr.Message != "3" {
+		t.Fatalf("Expected message 3, got %q", sr.Message)
+	}
+}
+
+func TestListenHandler(t *testing.T) {
+	ch := make(chan *SubReply, 1)
+	pub, sub := dialSub(t, WithHandler(func(sr *SubReply) {
+		ch <- sr
+	}))
+	if sr := sub.Subscribe("optsHandler"); sr.Err != nil {
+		t.Fatal(sr.Err)
+	}
+	stop := listen(sub)
+
+	pub.Cmd("PUBLISH", "optsHandler", "hello")
+	expectMessage(t, ch, "hello")
+	if err := stop(); err != context.Canceled {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestListenNoDelivery(t *testing.T) {
+	_, sub := dialSub(t)
+	if err := sub.Listen(context.Background()); err != NoDeliveryError {
+		t.Fatalf("Expected NoDeliveryError, got %v", err)
+	}
+}
+
+func TestListenPing(t *testing.T) {
+	ch := make(chan *SubReply)
+	pub, sub := dialSub(t, WithChannel(ch), WithPingInterval(20*time.Millisecond))
+	if sr := sub.PSubscribe("optsPing*"); sr.Err != nil {
+		t.Fatal(sr.Err)
+	}
+	stop := listen(sub)
+
+	// Several pings are sent and answered while nothing is published
+	time.Sleep(300 * time.Millisecond)
+	pub.Cmd("PUBLISH", "optsPingA", "hello")
+	expectMessage(t, ch, "hello")
+	if err := stop(); err != context.Canceled {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestListenReconnect(t *testing.T) {
+	ch := make(chan *SubReply, 10)
+	pub, sub := dialSub(t,
+		WithChannel(ch),
+		WithReconnect(ReconnectPolicy{MaxAttempts: 3, Backoff: 10 * time.Millisecond}),
+	)
+	if sr := sub.Subscribe("optsReconnect"); sr.Err != nil {
+		t.Fatal(sr.Err)
+	}
+	stop := listen(sub)
+	sub.Client.Conn.Close()
+
+	// Keep publishing until the subscription has been re-established
+	deadline := time.After(5 * time.Second)
+	for {
+		pub.Cmd("PUBLISH", "optsReconnect", "hello")
+		select {
+		case sr := <-ch:
+			if sr.Message != "hello" {
+				t.Fatalf("Expected message hello, got %q", sr.Message)
+			}
+			if err := stop(); err != context.Canceled {
+				t.Fatalf("Expected context.Canceled, got %v", err)
+			}
+			return
+		case <-time.After(20 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("Took too long to receive message after reconnecting")
+		}
+	}
+}
+
+// frozenProxy forwards connections to redis until freeze is called, after
+// which nothing more is sent back from redis
+type frozenProxy struct {
+	l      net.Listener
+	frozen int32
+}
+
+func newFrozenProxy(t *testing.T) *frozenProxy {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &frozenProxy{l: l}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			upstream, err := net.Dial("tcp", "localhost:6379")
+			if err != nil {
+				conn.Close()
+				continue
+			}
+			go io.Copy(upstream, conn)
+			go func() {
+				b := make([]byte, 1024)
+				for {
+					n, err := upstream.Read(b)
+					if err != nil {
+						conn.Close()
+						return
+					}
+					if atomic.LoadInt32(&p.frozen) == 0 {
+						conn.Write(b[:n])
+					}
+				}
+			}()
+		}
+	}()
+	return p
+}
+
+func (p *frozenProxy) freeze() {
+	atomic.StoreInt32(&p.frozen, 1)
+}
+
+func TestListenLiveness(t *testing.T) {
+	p := newFrozenProxy(t)
+	defer p.l.Close()
+	client, err := redis.DialTimeout("tcp", p.l.Addr().String(), 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type check struct {
+		rtt time.Duration
+		err error
+	}
+	checks := make(chan check, 100)
+	sub := NewSubClient(client,
+		WithChannel(make(chan *SubReply)),
+		WithPingInterval(20*time.Millisecond),
+		WithLiveness(func(rtt time.Duration, err error) {
+			checks <- check{rtt, err}
+		}),
+	)
+	if sr := sub.Subscribe("optsLiveness"); sr.Err != nil {
+		t.Fatal(sr.Err)
+	}
+	stop := listen(sub)
+
+	select {
+	case c := <-checks:
+		if c.err != nil || c.rtt <= 0 {
+			t.Fatalf("Unexpected check: %v %v", c.rtt, c.err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Took too long to check liveness")
+	}
+
+	// Once the connection stops answering it's reported dead, and Listen
+	// gives up since it can't reconnect
+	p.freeze()
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case c := <-checks:
+			if c.err == nil {
+				continue
+			}
+			if c.err != PingTimeoutError {
+				t.Fatalf("Expected PingTimeoutError, got %v", c.err)
+			}
+			if err := stop(); err == nil || err == context.Canceled {
+				t.Fatalf("Expected the connection's error, got %v", err)
+			}
+			return
+		case <-deadline:
+			t.Fatal("Took too long to notice the connection is dead")
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package pubsub
+
+import (
+	"github.com/fzzy/radix/redis"
+)
+
+// Publish publishes the payload on the channel, returning how many subscribers
+// received it. The payload is sent as it is, so binary encodings like protobuf
+// or msgpack arrive intact; subscribers can get them back without a copy using
+// SubReply.PayloadBytes. c may be any Commander, but not a client which is
+// subscribed to anything.
+func Publish(c redis.Commander, channel string, payload []byte) (int, error) {
+	return c.Cmd("PUBLISH", channel, payload).Int()
+}
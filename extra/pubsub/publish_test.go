@@ -0,0 +1,44 @@
+package pubsub
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/fzzy/radix/redis"
+)
+
+func TestPublishBinary(t *testing.T) {
+	pub, err := redis.DialTimeout("tcp", "localhost:6379", 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := redis.DialTimeout("tcp", "localhost:6379", 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := NewSubClient(client)
+	if sr := sub.Subscribe("publishBinary"); sr.Err != nil {
+		t.Fatal(sr.Err)
+	}
+
+	payload := []byte{0, 0xff, '\r', '\n', 0x80, 'x'}
+	n, err := Publish(pub, "publishBinary", payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("Expected 1 receiver, got %d", n)
+	}
+
+	sr := sub.Receive()
+	if sr.Err != nil {
+		t.Fatal(sr.Err)
+	}
+	if !bytes.Equal(sr.PayloadBytes(), payload) {
+		t.Fatalf("Expected payload %q, got %q", payload, sr.PayloadBytes())
+	}
+	if sr.Message != string(payload) {
+		t.Fatalf("Expected message %q, got %q", payload, sr.Message)
+	}
+}
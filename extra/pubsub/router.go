@@ -0,0 +1,270 @@
+package pubsub
+
+import (
+	"sync"
+)
+
+// A Handler is called with a MessageReply which was routed to it
+type Handler func(r *SubReply)
+
+// Router dispatches message replies to handlers based on the reply's channel.
+// Handlers are registered under glob-style patterns which follow the same rules
+// redis uses for PSUBSCRIBE (*, ?, [abc], [^abc], [a-z] and \ for escaping).
+//
+// Patterns are stored in a trie, so patterns sharing a prefix are only matched
+// against once, and routing a message doesn't get slower for every pattern
+// added. It is safe to use a Router from multiple routines at once.
+type Router struct {
+	mu   sync.RWMutex
+	root *routeNode
+}
+
+// NewRouter returns an empty Router
+func NewRouter() *Router {
+	return &Router{root: &routeNode{}}
+}
+
+type tokenKind uint8
+
+const (
+	literalToken tokenKind = iota
+	anyToken               // ?
+	starToken              // *
+	classToken             // [...]
+)
+
+type token struct {
+	kind tokenKind
+	b    byte   // literalToken
+	spec string // classToken, the contents of the brackets
+}
+
+type routeNode struct {
+	literal map[byte]*routeNode
+	any     *routeNode
+	star    *routeNode
+	classes map[string]*routeNode
+
+	handler Handler
+}
+
+func (n *routeNode) child(t token, create bool) *routeNode {
+	var next **routeNode
+	switch t.kind {
+	case literalToken:
+		if c, ok := n.literal[t.b]; ok || !create {
+			return c
+		}
+		if n.literal == nil {
+			n.literal = map[byte]*routeNode{}
+		}
+		c := &routeNode{}
+		n.literal[t.b] = c
+		return c
+	case classToken:
+		if c, ok := n.classes[t.spec]; ok || !create {
+			return c
+		}
+		if n.classes == nil {
+			n.classes = map[string]*routeNode{}
+		}
+		c := &routeNode{}
+		n.classes[t.spec] = c
+		return c
+	case anyToken:
+		next = &n.any
+	default:
+		next = &n.star
+	}
+	if *next == nil && create {
+		*next = &routeNode{}
+	}
+	return *next
+}
+
+func (n *routeNode) removeChild(t token) {
+	switch t.kind {
+	case literalToken:
+		delete(n.literal, t.b)
+	case classToken:
+		delete(n.classes, t.spec)
+	case anyToken:
+		n.any = nil
+	default:
+		n.star = nil
+	}
+}
+
+func (n *routeNode) empty() bool {
+	return n.handler == nil && len(n.literal) == 0 && len(n.classes) == 0 &&
+		n.any == nil && n.star == nil
+}
+
+// tokenize splits a pattern up into the tokens it's made of. Consecutive stars
+// are collapsed into one, since they match the same things.
+func tokenize(pattern string) []token {
+	tokens := make([]token, 0, len(pattern))
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*':
+			if l := len(tokens); l == 0 || tokens[l-1].kind != starToken {
+				tokens = append(tokens, token{kind: starToken})
+			}
+		case '?':
+			tokens = append(tokens, token{kind: anyToken})
+		case '[':
+			// Find the closing bracket, skipping escaped characters. An
+			// unclosed bracket runs to the end of the pattern, as it does in
+			// redis
+			j := i + 1
+			for ; j < len(pattern) && pattern[j] != ']'; j++ {
+				if pattern[j] == '\\' && j+1 < len(pattern) {
+					j++
+				}
+			}
+			tokens = append(tokens, token{kind: classToken, spec: pattern[i+1 : j]})
+			i = j
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			tokens = append(tokens, token{kind: literalToken, b: pattern[i]})
+		default:
+			tokens = append(tokens, token{kind: literalToken, b: pattern[i]})
+		}
+	}
+	return tokens
+}
+
+// classMatch returns whether b is matched by the contents of a [...] token
+func classMatch(spec string, b byte) bool {
+	not := len(spec) > 0 && spec[0] == '^'
+	if not {
+		spec = spec[1:]
+	}
+	match := false
+	for i := 0; i < len(spec) && !match; i++ {
+		switch {
+		case spec[i] == '\\' && i+1 < len(spec):
+			i++
+			match = spec[i] == b
+		case i+2 < len(spec) && spec[i+1] == '-':
+			start, end := spec[i], spec[i+2]
+			if start > end {
+				start, end = end, start
+			}
+			match = start <= b && b <= end
+			i += 2
+		default:
+			match = spec[i] == b
+		}
+	}
+	return match != not
+}
+
+// Handle registers h for all channels matching the given pattern, replacing
+// any handler previously registered for the exact same pattern
+func (r *Router) Handle(pattern string, h Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := r.root
+	for _, t := range tokenize(pattern) {
+		n = n.child(t, true)
+	}
+	n.handler = h
+}
+
+// Remove unregisters the handler for the given pattern, if there is one
+func (r *Router) Remove(pattern string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tokens := tokenize(pattern)
+	path := make([]*routeNode, 0, len(tokens)+1)
+	n := r.root
+	path = append(path, n)
+	for _, t := range tokens {
+		if n = n.child(t, false); n == nil {
+			return
+		}
+		path = append(path, n)
+	}
+	n.handler = nil
+
+	// Prune any nodes which no longer lead to a handler
+	for i := len(tokens) - 1; i >= 0 && path[i+1].empty(); i-- {
+		path[i].removeChild(tokens[i])
+	}
+}
+
+// Match returns the handlers for all patterns which match the given channel
+func (r *Router) Match(channel string) []Handler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m := matcher{channel: channel}
+	m.match(r.root, 0)
+	return m.handlers
+}
+
+// Route calls every handler whose pattern matches the channel of the given
+// reply, and returns the number of handlers called
+func (r *Router) Route(sr *SubReply) int {
+	handlers := r.Match(sr.Channel)
+	for _, h := range handlers {
+		h(sr)
+	}
+	return len(handlers)
+}
+
+type matchState struct {
+	n   *routeNode
+	pos int
+}
+
+type matcher struct {
+	channel  string
+	handlers []Handler
+
+	// Only used once a star has been encountered, to make sure that no node is
+	// ever visited twice at the same position (which would both be slow and
+	// cause a handler to be returned twice)
+	seen map[matchState]bool
+}
+
+func (m *matcher) match(n *routeNode, pos int) {
+	if m.seen != nil {
+		s := matchState{n, pos}
+		if m.seen[s] {
+			return
+		}
+		m.seen[s] = true
+	}
+
+	if n.star != nil {
+		if m.seen == nil {
+			m.seen = map[matchState]bool{}
+		}
+		for i := pos; i <= len(m.channel); i++ {
+			m.match(n.star, i)
+		}
+	}
+
+	if pos == len(m.channel) {
+		if n.handler != nil {
+			m.handlers = append(m.handlers, n.handler)
+		}
+		return
+	}
+
+	b := m.channel[pos]
+	if c, ok := n.literal[b]; ok {
+		m.match(c, pos+1)
+	}
+	if n.any != nil {
+		m.match(n.any, pos+1)
+	}
+	for spec, c := range n.classes {
+		if classMatch(spec, b) {
+			m.match(c, pos+1)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package pubsub
+
+import (
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func matchedPatterns(r *Router, channel string) []string {
+	var matched []string
+	for _, h := range r.Match(channel) {
+		sr := &SubReply{Channel: channel}
+		h(sr)
+		matched = append(matched, sr.Message)
+	}
+	sort.Strings(matched)
+	return matched
+}
+
+func TestRouterMatch(t *testing.T) {
+	r := NewRouter()
+	patterns := []string{
+		"foo", "foo*", "f*o", "*", "f?o", "f[aeiou]o", "f[^o]o", "b[a-c]r",
+		"foo\\*", "**bar*", "*.*.baz",
+	}
+	for _, p := range patterns {
+		p := p
+		r.Handle(p, func(sr *SubReply) { sr.Message = p })
+	}
+
+	tests := map[string][]string{
+		"foo":       {"*", "f*o", "f?o", "f[aeiou]o", "foo", "foo*"},
+		"fao":       {"*", "f*o", "f?o", "f[^o]o", "f[aeiou]o"},
+		"foo*":      {"*", "foo*", "foo\\*"},
+		"fo":        {"*", "f*o"},
+		"bbr":       {"*", "b[a-c]r"},
+		"bar":       {"**bar*", "*", "b[a-c]r"},
+		"foobarbaz": {"**bar*", "*", "foo*"},
+		"a.b.baz":   {"*", "*.*.baz"},
+		"a.baz":     {"*"},
+		"":          {"*"},
+	}
+	for channel, expected := range tests {
+		matched := matchedPatterns(r, channel)
+		sort.Strings(expected)
+		if len(matched) != len(expected) {
+			t.Fatalf("%q matched %v, expected %v", channel, matched, expected)
+		}
+		for i := range matched {
+			if matched[i] != expected[i] {
+				t.Fatalf("%q matched %v, expected %v", channel, matched, expected)
+			}
+		}
+	}
+}
+
+func TestRouterRemove(t *testing.T) {
+	r := NewRouter()
+	called := 0
+	h := func(sr *SubReply) { called++ }
+	r.Handle("foo.*", h)
+	r.Handle("foo.bar", h)
+
+	if n := r.Route(&SubReply{Channel: "foo.bar"}); n != 2 || called != 2 {
+		t.Fatalf("routed to %d handlers, called %d", n, called)
+	}
+
+	r.Remove("foo.*")
+	r.Remove("not.there")
+	if n := r.Route(&SubReply{Channel: "foo.bar"}); n != 1 {
+		t.Fatalf("routed to %d handlers after remove", n)
+	}
+
+	r.Remove("foo.bar")
+	if !r.root.empty() {
+		t.Fatal("trie not pruned after all patterns were removed")
+	}
+}
+
+func BenchmarkRouter(b *testing.B) {
+	r := NewRouter()
+	h := func(sr *SubReply) {}
+	for i := 0; i < 500; i++ {
+		r.Handle("events."+strconv.Itoa(i)+".*", h)
+	}
+	sr := &SubReply{Channel: "events.250.created"}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Route(sr)
+	}
+}
@@ -5,9 +5,11 @@ package pubsub
 import (
 	"container/list"
 	"errors"
-	"net"
+	"fmt"
+	"sync"
 
 	"github.com/fzzy/radix/redis"
+	"github.com/fzzy/radix/redis/resp"
 )
 
 type SubReplyType uint8
@@ -17,22 +19,84 @@ const (
 	SubscribeReply
 	UnsubscribeReply
 	MessageReply
+	PongReply
 )
 
 // SubClient wraps a Redis client to provide convenience methods for Pub/Sub functionality.
 type SubClient struct {
+	// The number of messages dropped, see Dropped. Kept first so it's 64-bit
+	// aligned.
+	dropped int64
+
 	Client   *redis.Client
 	messages *list.List
+	opts     options
+
+	// The channels and patterns currently subscribed to, so they can be
+	// subscribed to again after reconnecting, see WithReconnect
+	channels map[string]bool
+	patterns map[string]bool
+
+	// The handlers for particular channels and patterns, see SubscribeFunc.
+	// These are read by the routines started by WithDispatch.
+	handlersMu      sync.RWMutex
+	channelHandlers map[string]Handler
+	patternHandlers map[string]Handler
 }
 
 // SubReply wraps a Redis reply and provides convienient access to Pub/Sub info.
 type SubReply struct {
 	Type     SubReplyType // SubReply type
-	Channel  string       // Channel reply is on (MessageReply)
+	Channel  string       // Channel reply is on (MessageReply), or (un)subscribed from (SubscribeReply or UnsubscribeReply)
+	Pattern  string       // Pattern matched (MessageReply from PSUBSCRIBE), or (un)subscribed from (SubscribeReply or UnsubscribeReply)
 	SubCount int          // Count of subs active after this action (SubscribeReply or UnsubscribeReply)
 	Message  string       // Publish message (MessageReply)
 	Err      error        // SubReply error (ErrorReply)
 	Reply    *redis.Reply // Original Redis reply (MessageReply)
+
+	// The published message as it was received, see PayloadBytes
+	payload []byte
+
+	// Every confirmation redis sent for the (un)subscribe command which
+	// returned this reply, in order, one per channel or pattern. This reply is
+	// the last of them.
+	Confirmations []Confirmation
+}
+
+// Confirmation is redis acknowledging that a channel or pattern was subscribed
+// or unsubscribed from. Once a subscription is confirmed, messages published
+// to it are guaranteed to be received.
+type Confirmation struct {
+	// The channel, or pattern if Pattern is true
+	Name    string
+	Pattern bool
+
+	// Whether this confirms a subscribe rather than an unsubscribe
+	Subscribed bool
+
+	// How many channels and patterns the connection is subscribed to now
+	Count int
+}
+
+// PayloadBytes returns the published message of a MessageReply as bytes. This
+// is the same buffer the message was read into, so unlike converting Message it
+// doesn't copy it; it shares its memory with Reply.
+func (r *SubReply) PayloadBytes() []byte {
+	return r.payload
+}
+
+// confirmation returns the Confirmation for a SubscribeReply or
+// UnsubscribeReply
+func (r *SubReply) confirmation() Confirmation {
+	cf := Confirmation{
+		Name:       r.Channel,
+		Subscribed: r.Type == SubscribeReply,
+		Count:      r.SubCount,
+	}
+	if r.Pattern != "" {
+		cf.Name, cf.Pattern = r.Pattern, true
+	}
+	return cf
 }
 
 // Timeout determines if this SubReply is an error type
@@ -41,12 +105,31 @@ func (r *SubReply) Timeout() bool {
 	if r.Err == nil {
 		return false
 	}
-	t, ok := r.Err.(*net.OpError)
-	return ok && t.Timeout()
+	return errors.Is(r.Err, redis.TimeoutError)
 }
 
-func NewSubClient(client *redis.Client) *SubClient {
-	return &SubClient{client, &list.List{}}
+// NewSubClient wraps the given client, which shouldn't be used for anything
+// else afterwards. Its behavior can be changed using Options, e.g.
+//
+//	sub := pubsub.NewSubClient(client,
+//		pubsub.WithHandler(handle),
+//		pubsub.WithPingInterval(30*time.Second),
+//		pubsub.WithReconnect(pubsub.ReconnectPolicy{Backoff: time.Second}),
+//	)
+func NewSubClient(client *redis.Client, opts ...Option) *SubClient {
+	c := &SubClient{
+		Client:   client,
+		messages: &list.List{},
+		channels: map[string]bool{},
+		patterns: map[string]bool{},
+
+		channelHandlers: map[string]Handler{},
+		patternHandlers: map[string]Handler{},
+	}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+	return c
 }
 
 // Subscribe makes a Redis "SUBSCRIBE" command on the provided channels
@@ -59,6 +142,56 @@ func (c *SubClient) PSubscribe(patterns ...interface{}) *SubReply {
 	return c.filterMessages("PSUBSCRIBE", patterns...)
 }
 
+// SubscribeFunc subscribes to the channel, and has Listen call h with every
+// message received on it instead of the SubClient's handler or channel. The
+// handler is removed again when the channel is unsubscribed from.
+func (c *SubClient) SubscribeFunc(channel string, h Handler) *SubReply {
+	sr := c.Subscribe(channel)
+	if sr.Err == nil {
+		c.handlersMu.Lock()
+		c.channelHandlers[channel] = h
+		c.handlersMu.Unlock()
+	}
+	return sr
+}
+
+// PSubscribeFunc subscribes to the pattern, and has Listen call h with every
+// message received through it instead of the SubClient's handler or channel.
+// A message published to a channel matching several patterns which are
+// subscribed to is received once for each of them, with the Pattern it matched
+// set, so each pattern's handler is called once. The handler is removed again
+// when the pattern is unsubscribed from.
+func (c *SubClient) PSubscribeFunc(pattern string, h Handler) *SubReply {
+	sr := c.PSubscribe(pattern)
+	if sr.Err == nil {
+		c.handlersMu.Lock()
+		c.patternHandlers[pattern] = h
+		c.handlersMu.Unlock()
+	}
+	return sr
+}
+
+// handlerFor returns the handler a message should be given to, or nil if it
+// should go on the SubClient's channel, if it has one
+func (c *SubClient) handlerFor(sr *SubReply) Handler {
+	c.handlersMu.RLock()
+	defer c.handlersMu.RUnlock()
+	if sr.Pattern != "" {
+		if h, ok := c.patternHandlers[sr.Pattern]; ok {
+			return h
+		}
+	} else if h, ok := c.channelHandlers[sr.Channel]; ok {
+		return h
+	}
+	return c.opts.handler
+}
+
+func (c *SubClient) hasHandlers() bool {
+	c.handlersMu.RLock()
+	defer c.handlersMu.RUnlock()
+	return len(c.channelHandlers) > 0 || len(c.patternHandlers) > 0
+}
+
 // Unsubscribe makes a Redis "UNSUBSCRIBE" command on the provided channels
 func (c *SubClient) Unsubscribe(channels ...interface{}) *SubReply {
 	return c.filterMessages("UNSUBSCRIBE", channels...)
@@ -90,25 +223,106 @@ func (c *SubClient) receive(skipBuffer bool) *SubReply {
 func (c *SubClient) filterMessages(cmd string, names ...interface{}) *SubReply {
 	r := c.Client.Cmd(cmd, names...)
 	var sr *SubReply
-	for i := 0; i < len(names); i++ {
+	var confirmations []Confirmation
+	for i, n := 0, c.expectedReplies(cmd, names); i < n; i++ {
 		// If nil we know this is the first loop
 		if sr == nil {
 			sr = c.parseReply(r)
 		} else {
 			sr = c.receive(true)
 		}
+		if sr.Err != nil {
+			break
+		}
 		if sr.Type == MessageReply {
-			c.messages.PushBack(sr)
+			c.buffer(sr)
 			i--
+			continue
+		} else if sr.Type == PongReply {
+			i--
+			continue
+		}
+		cf := sr.confirmation()
+		confirmations = append(confirmations, cf)
+		if c.opts.confirmed != nil {
+			c.opts.confirmed(cf)
 		}
 	}
+	if sr.Err == nil {
+		c.track(cmd, names)
+		sr.Confirmations = confirmations
+	}
 	return sr
 }
 
+// expectedReplies returns how many replies redis sends for the (un)subscribe
+// command. Unsubscribing from everything gets a reply for every channel or
+// pattern subscribed to, or a single one if there weren't any.
+func (c *SubClient) expectedReplies(cmd string, names []interface{}) int {
+	if n := len(resp.Flatten(names)); n > 0 {
+		return n
+	}
+	set := c.channels
+	if cmd == "PUNSUBSCRIBE" {
+		set = c.patterns
+	}
+	if len(set) > 0 {
+		return len(set)
+	}
+	return 1
+}
+
+// buffer keeps a message which arrived while waiting for a (un)subscribe
+// reply, for Receive to return later. If the buffer is full the oldest message
+// is dropped.
+func (c *SubClient) buffer(sr *SubReply) {
+	if c.opts.bufferSize > 0 && c.messages.Len() >= c.opts.bufferSize {
+		c.messages.Remove(c.messages.Front())
+	}
+	c.messages.PushBack(sr)
+}
+
+// track remembers the channels and patterns which were (un)subscribed from by
+// a successful command
+func (c *SubClient) track(cmd string, names []interface{}) {
+	set, handlers := c.channels, c.channelHandlers
+	if cmd == "PSUBSCRIBE" || cmd == "PUNSUBSCRIBE" {
+		set, handlers = c.patterns, c.patternHandlers
+	}
+	flat := resp.Flatten(names)
+	switch cmd {
+	case "SUBSCRIBE", "PSUBSCRIBE":
+		for _, name := range flat {
+			set[nameString(name)] = true
+		}
+	case "UNSUBSCRIBE", "PUNSUBSCRIBE":
+		c.handlersMu.Lock()
+		defer c.handlersMu.Unlock()
+		if len(flat) == 0 {
+			for name := range set {
+				delete(set, name)
+			}
+			for name := range handlers {
+				delete(handlers, name)
+			}
+		}
+		for _, name := range flat {
+			delete(set, nameString(name))
+			delete(handlers, nameString(name))
+		}
+	}
+}
+
 func (c *SubClient) parseReply(reply *redis.Reply) *SubReply {
 	sr := &SubReply{Reply: reply}
 	switch reply.Type {
 	case redis.MultiReply:
+		if len(reply.Elems) == 2 {
+			if s, _ := reply.Elems[0].Str(); s == "pong" {
+				sr.Type = PongReply
+				return sr
+			}
+		}
 		if len(reply.Elems) < 3 {
 			sr.Err = errors.New("reply is not formatted as a subscription reply")
 			return sr
@@ -130,6 +344,14 @@ func (c *SubClient) parseReply(reply *redis.Reply) *SubReply {
 
 	//first element
 	switch rtype {
+	case "subscribe", "unsubscribe":
+		// The name is nil when unsubscribing from everything while not
+		// subscribed to anything
+		sr.Channel, _ = reply.Elems[1].Str()
+	case "psubscribe", "punsubscribe":
+		sr.Pattern, _ = reply.Elems[1].Str()
+	}
+	switch rtype {
 	case "subscribe", "psubscribe":
 		sr.Type = SubscribeReply
 		count, err := reply.Elems[2].Int()
@@ -155,6 +377,7 @@ func (c *SubClient) parseReply(reply *redis.Reply) *SubReply {
 			chanI, msgI = 1, 2
 		} else { // "pmessage"
 			chanI, msgI = 2, 3
+			sr.Pattern, _ = reply.Elems[1].Str()
 		}
 
 		sr.Type = MessageReply
@@ -165,12 +388,12 @@ func (c *SubClient) parseReply(reply *redis.Reply) *SubReply {
 			return sr
 		}
 		sr.Channel = channel
-		msg, err := reply.Elems[msgI].Str()
+		msg, err := reply.Elems[msgI].Bytes()
 		if err != nil {
 			sr.Err = errors.New("message reply does not have string value for body")
 			sr.Type = ErrorReply
 		} else {
-			sr.Message = msg
+			sr.Message, sr.payload = string(msg), msg
 		}
 	default:
 		sr.Err = errors.New("suscription multireply has invalid type: " + rtype)
@@ -178,3 +401,10 @@ func (c *SubClient) parseReply(reply *redis.Reply) *SubReply {
 	}
 	return sr
 }
+
+func nameString(name interface{}) string {
+	if b, ok := name.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(name)
+}
@@ -1,6 +1,7 @@
 package pubsub
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -147,3 +148,127 @@ func TestPSubscribe(t *testing.T) {
 		t.Fatal(fmt.Sprintf("Unexpected subscription count, Expected: 0, Found: %d", sr.SubCount))
 	}
 }
+
+func TestConfirmations(t *testing.T) {
+	client, err := redis.DialTimeout("tcp", "localhost:6379", 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seen []Confirmation
+	sub := NewSubClient(client, WithConfirmations(func(cf Confirmation) {
+		seen = append(seen, cf)
+	}))
+
+	sr := sub.Subscribe("subConfirmA", "subConfirmB")
+	if sr.Err != nil {
+		t.Fatal(sr.Err)
+	}
+	expected := []Confirmation{
+		{Name: "subConfirmA", Subscribed: true, Count: 1},
+		{Name: "subConfirmB", Subscribed: true, Count: 2},
+	}
+	if fmt.Sprint(sr.Confirmations) != fmt.Sprint(expected) {
+		t.Fatalf("Unexpected confirmations: %v", sr.Confirmations)
+	}
+	if sr.Channel != "subConfirmB" {
+		t.Fatalf("Unexpected channel: %q", sr.Channel)
+	}
+
+	sr = sub.PSubscribe("subConfirm*")
+	expected = []Confirmation{{Name: "subConfirm*", Pattern: true, Subscribed: true, Count: 3}}
+	if sr.Err != nil || fmt.Sprint(sr.Confirmations) != fmt.Sprint(expected) {
+		t.Fatalf("Unexpected confirmations: %v %v", sr.Err, sr.Confirmations)
+	}
+
+	// Unsubscribing from everything gets a confirmation for each channel
+	sr = sub.Unsubscribe()
+	if sr.Err != nil || len(sr.Confirmations) != 2 || sr.SubCount != 1 {
+		t.Fatalf("Unexpected confirmations: %v %v", sr.Err, sr.Confirmations)
+	}
+	sr = sub.Unsubscribe()
+	if sr.Err != nil || len(sr.Confirmations) != 1 || sr.Channel != "" {
+		t.Fatalf("Unexpected confirmations: %v %v", sr.Err, sr.Confirmations)
+	}
+	sr = sub.PUnsubscribe()
+	expected = []Confirmation{{Name: "subConfirm*", Pattern: true}}
+	if sr.Err != nil || fmt.Sprint(sr.Confirmations) != fmt.Sprint(expected) {
+		t.Fatalf("Unexpected confirmations: %v %v", sr.Err, sr.Confirmations)
+	}
+
+	if len(seen) != 7 {
+		t.Fatalf("Expected 7 confirmations to be seen, got %v", seen)
+	}
+}
+
+func TestSubscribeFunc(t *testing.T) {
+	pub, err := redis.DialTimeout("tcp", "localhost:6379", 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := redis.DialTimeout("tcp", "localhost:6379", 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan *SubReply, 10)
+	sub := NewSubClient(client, WithChannel(ch))
+
+	got := make(chan string, 10)
+	if sr := sub.SubscribeFunc("subFuncA", func(sr *SubReply) {
+		got <- "A " + sr.Message
+	}); sr.Err != nil {
+		t.Fatal(sr.Err)
+	}
+	if sr := sub.PSubscribeFunc("subFunc.*", func(sr *SubReply) {
+		got <- sr.Pattern + " " + sr.Channel + " " + sr.Message
+	}); sr.Err != nil {
+		t.Fatal(sr.Err)
+	}
+	if sr := sub.Subscribe("subFuncOther"); sr.Err != nil {
+		t.Fatal(sr.Err)
+	}
+	stop := listen(sub)
+	defer stop()
+
+	pub.Cmd("PUBLISH", "subFuncA", "1")
+	pub.Cmd("PUBLISH", "subFunc.x", "2")
+	pub.Cmd("PUBLISH", "subFuncOther", "3")
+	for _, expected := range []string{"A 1", "subFunc.* subFunc.x 2"} {
+		select {
+		case s := <-got:
+			if s != expected {
+				t.Fatalf("Expected %q, got %q", expected, s)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Took too long to handle message")
+		}
+	}
+	expectMessage(t, ch, "3")
+}
+
+func TestSubscribeFuncUnsubscribe(t *testing.T) {
+	client, err := redis.DialTimeout("tcp", "localhost:6379", 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := NewSubClient(client)
+	h := func(*SubReply) {}
+	sub.SubscribeFunc("subFuncUnsubA", h)
+	sub.SubscribeFunc("subFuncUnsubB", h)
+	sub.PSubscribeFunc("subFuncUnsub*", h)
+
+	sub.Unsubscribe("subFuncUnsubA")
+	if sub.handlerFor(&SubReply{Channel: "subFuncUnsubA"}) != nil {
+		t.Fatal("Handler wasn't removed")
+	}
+	if sub.handlerFor(&SubReply{Channel: "subFuncUnsubB"}) == nil {
+		t.Fatal("Handler was removed")
+	}
+	sub.Unsubscribe()
+	sub.PUnsubscribe()
+	if sub.hasHandlers() {
+		t.Fatal("Handlers weren't removed")
+	}
+	if err := sub.Listen(context.Background()); err != NoDeliveryError {
+		t.Fatalf("Expected NoDeliveryError, got %v", err)
+	}
+}
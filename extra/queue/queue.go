@@ -0,0 +1,276 @@
+// The queue package implements a reliable work queue on top of redis lists.
+// Items are moved atomically from the queue to a processing list as they're
+// taken, and only removed from it once they've been acknowledged, so an item
+// taken by a consumer which crashes isn't lost: once its visibility timeout
+// passes it's put back on the queue for another consumer to take.
+//
+//	q := queue.NewQueue(client, "emails", queue.Options{})
+//	q.Enqueue("hello@example.com")
+//
+//	// elsewhere
+//	err := q.Consume(ctx, func(item *queue.Item) error {
+//		return send(item.Body)
+//	})
+//
+// Items are delivered at least once, so handlers should be idempotent.
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fzzy/radix/redis"
+)
+
+// Options are the options for NewQueue
+type Options struct {
+	// How long a consumer has to acknowledge an item before it's considered
+	// stale and put back on the queue. Defaults to 30 seconds.
+	VisibilityTimeout time.Duration
+
+	// How long Consume blocks waiting for an item before checking whether its
+	// context is done, and for stale items. Defaults to one second.
+	PollTimeout time.Duration
+}
+
+// Queue is a reliable queue stored in redis. It's safe to use from multiple
+// routines at once if its Commander is, but Consume blocks the connection it's
+// using while waiting for items, so it should normally be given a connection
+// of its own.
+type Queue struct {
+	c    redis.Commander
+	opts Options
+
+	// The list of items waiting to be taken, the list of items being
+	// processed, and the sorted set of when each item being processed becomes
+	// stale, in milliseconds
+	pending, processing, deadlines string
+}
+
+// Item is an item taken from a Queue
+type Item struct {
+	// A random ID given to the item when it was enqueued
+	ID   string
+	Body string
+
+	// The item as it's stored, which is the ID and the body
+	raw string
+}
+
+// MalformedItemError is returned when an item in the queue isn't one which was
+// enqueued by Enqueue
+var MalformedItemError = errors.New("malformed queue item")
+
+// NewQueue returns the Queue with the given name, which is stored in keys
+// starting with "queue:{name}". The braces make the name a hash tag, so on a
+// redis cluster all of a queue's keys are in the same slot.
+func NewQueue(c redis.Commander, name string, opts Options) *Queue {
+	if opts.VisibilityTimeout <= 0 {
+		opts.VisibilityTimeout = 30 * time.Second
+	}
+	if opts.PollTimeout <= 0 {
+		opts.PollTimeout = time.Second
+	}
+	prefix := "queue:{" + name + "}:"
+	return &Queue{
+		c:          c,
+		opts:       opts,
+		pending:    prefix + "pending",
+		processing: prefix + "processing",
+		deadlines:  prefix + "deadlines",
+	}
+}
+
+func newItemID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func parseItem(raw string) (*Item, error) {
+	i := strings.IndexByte(raw, ':')
+	if i < 0 {
+		return nil, MalformedItemError
+	}
+	return &Item{ID: raw[:i], Body: raw[i+1:], raw: raw}, nil
+}
+
+// Enqueue adds the items to the back of the queue
+func (q *Queue) Enqueue(bodies ...string) error {
+	if len(bodies) == 0 {
+		return nil
+	}
+	raws := make([]string, len(bodies))
+	for i, body := range bodies {
+		id, err := newItemID()
+		if err != nil {
+			return err
+		}
+		raws[i] = id + ":" + body
+	}
+	return q.c.Cmd("LPUSH", q.pending, raws).Err
+}
+
+// Len returns the number of items waiting to be taken, and the number being
+// processed
+func (q *Queue) Len() (pending, processing int, err error) {
+	if pending, err = q.c.Cmd("LLEN", q.pending).Int(); err != nil {
+		return 0, 0, err
+	}
+	if processing, err = q.c.Cmd("LLEN", q.processing).Int(); err != nil {
+		return 0, 0, err
+	}
+	return pending, processing, nil
+}
+
+// Sets the deadline of an item, which was just moved to the processing list,
+// ARGV[2] milliseconds from now
+var deadlineScript = redis.NewScript(`
+redis.replicate_commands()
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+redis.call('ZADD', KEYS[1], now + tonumber(ARGV[2]), ARGV[1])
+return 1
+`)
+
+// Dequeue takes the item at the front of the queue, blocking for up to timeout
+// (zero meaning forever) if it's empty, in which case nil is returned. The item
+// must be acknowledged using Ack or Nack within the visibility timeout, or it
+// will be put back on the queue by RequeueStale.
+func (q *Queue) Dequeue(timeout time.Duration) (*Item, error) {
+	var raw string
+	var ok bool
+	var err error
+	if c, isClient := q.c.(*redis.Client); isClient {
+		raw, ok, err = c.BRPopLPush(q.pending, q.processing, timeout)
+	} else {
+		r := q.c.Cmd("BRPOPLPUSH", q.pending, q.processing,
+			int64((timeout+time.Second-1)/time.Second))
+		if r.Type != redis.NilReply {
+			raw, err = r.Str()
+			ok = err == nil
+		}
+	}
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	// An item which was moved but didn't get a deadline, because this failed,
+	// is given one by RequeueStale so it isn't stuck forever
+	visibility := int64(q.opts.VisibilityTimeout / time.Millisecond)
+	if err = deadlineScript.Cmd(q.c, []string{q.deadlines}, raw, visibility).Err; err != nil {
+		return nil, err
+	}
+	return parseItem(raw)
+}
+
+// Removes an item from the processing list and its deadline, and if ARGV[2] is
+// set puts it back on the queue. Returns whether the item was being processed.
+var ackScript = redis.NewScript(`
+local removed = redis.call('LREM', KEYS[1], 1, ARGV[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+if removed == 1 and ARGV[2] == '1' then
+	redis.call('RPUSH', KEYS[3], ARGV[1])
+end
+return removed
+`)
+
+// ItemNotProcessingError is returned by Ack and Nack for an item which isn't
+// being processed anymore, most likely because it went stale and was put back
+// on the queue
+var ItemNotProcessingError = errors.New("queue item is not being processed")
+
+// Ack acknowledges that the item was processed, removing it from the queue for
+// good
+func (q *Queue) Ack(item *Item) error {
+	return q.ack(item, false)
+}
+
+// Nack gives up on processing the item, putting it back at the front of the
+// queue straight away for another consumer to take
+func (q *Queue) Nack(item *Item) error {
+	return q.ack(item, true)
+}
+
+func (q *Queue) ack(item *Item, requeue bool) error {
+	keys := []string{q.processing, q.deadlines, q.pending}
+	n, err := ackScript.Cmd(q.c, keys, item.raw, requeue).Int()
+	if err != nil {
+		return err
+	} else if n == 0 {
+		return ItemNotProcessingError
+	}
+	return nil
+}
+
+// Puts the items being processed whose deadlines have passed back at the front
+// of the queue, and gives items without a deadline one ARGV[1] milliseconds
+// from now. Returns the number of items put back.
+var requeueScript = redis.NewScript(`
+redis.replicate_commands()
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local requeued = 0
+for _, raw in ipairs(redis.call('LRANGE', KEYS[1], 0, -1)) do
+	local deadline = redis.call('ZSCORE', KEYS[2], raw)
+	if not deadline then
+		redis.call('ZADD', KEYS[2], now + tonumber(ARGV[1]), raw)
+	elseif tonumber(deadline) <= now then
+		redis.call('LREM', KEYS[1], 1, raw)
+		redis.call('ZREM', KEYS[2], raw)
+		redis.call('RPUSH', KEYS[3], raw)
+		requeued = requeued + 1
+	end
+end
+return requeued
+`)
+
+// RequeueStale puts items which weren't acknowledged within the visibility
+// timeout back at the front of the queue, returning how many there were.
+// Consume calls this regularly, but it may be called by anything else as well.
+func (q *Queue) RequeueStale() (int, error) {
+	keys := []string{q.processing, q.deadlines, q.pending}
+	visibility := int64(q.opts.VisibilityTimeout / time.Millisecond)
+	return requeueScript.Cmd(q.c, keys, visibility).Int()
+}
+
+// Consume takes items from the queue and calls handler with each one until ctx
+// is done, returning ctx's error. An item is acknowledged if handler returns
+// nil, and put back on the queue if it returns an error. Stale items are
+// requeued every half a visibility timeout. Errors from redis stop Consume and
+// are returned; it can be called again to carry on.
+func (q *Queue) Consume(ctx context.Context, handler func(*Item) error) error {
+	var lastSweep time.Time
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if time.Since(lastSweep) >= q.opts.VisibilityTimeout/2 {
+			if _, err := q.RequeueStale(); err != nil {
+				return err
+			}
+			lastSweep = time.Now()
+		}
+
+		item, err := q.Dequeue(q.opts.PollTimeout)
+		if err != nil {
+			return err
+		} else if item == nil {
+			continue
+		}
+		if handler(item) == nil {
+			err = q.Ack(item)
+		} else {
+			err = q.Nack(item)
+		}
+		if err != nil && err != ItemNotProcessingError {
+			return err
+		}
+	}
+}
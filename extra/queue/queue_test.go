@@ -0,0 +1,124 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"github.com/fzzy/radix/extra/internal/redistest"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	. "testing"
+	"time"
+)
+
+func testQueue(t *T, name string, opts Options) *Queue {
+	c := redistest.Dial(t)
+	q := NewQueue(c, name, opts)
+	c.Cmd("DEL", q.pending, q.processing, q.deadlines)
+	return q
+}
+
+func TestParseItem(t *T) {
+	item, err := parseItem("abc:foo:bar")
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", item.ID)
+	assert.Equal(t, "foo:bar", item.Body)
+
+	_, err = parseItem("nope")
+	assert.Equal(t, MalformedItemError, err)
+}
+
+func TestQueue(t *T) {
+	q := testQueue(t, "queuetest", Options{})
+	assert.Equal(t, "queue:{queuetest}:pending", q.pending)
+	assert.Nil(t, q.Enqueue("a", "b"))
+	assert.Nil(t, q.Enqueue("c"))
+
+	item, err := q.Dequeue(time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", item.Body)
+	pending, processing, err := q.Len()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, pending)
+	assert.Equal(t, 1, processing)
+
+	assert.Nil(t, q.Ack(item))
+	assert.Equal(t, ItemNotProcessingError, q.Ack(item))
+
+	// A nacked item goes back to the front of the queue
+	item, _ = q.Dequeue(time.Second)
+	assert.Equal(t, "b", item.Body)
+	assert.Nil(t, q.Nack(item))
+	item2, _ := q.Dequeue(time.Second)
+	assert.Equal(t, item.ID, item2.ID)
+	q.Ack(item2)
+
+	item, _ = q.Dequeue(time.Second)
+	assert.Equal(t, "c", item.Body)
+	q.Ack(item)
+
+	item, err = q.Dequeue(100 * time.Millisecond)
+	assert.Nil(t, err)
+	assert.Nil(t, item)
+	pending, processing, _ = q.Len()
+	assert.Equal(t, 0, pending+processing)
+}
+
+func TestRequeueStale(t *T) {
+	q := testQueue(t, "queuestale", Options{VisibilityTimeout: 100 * time.Millisecond})
+	q.Enqueue("a", "b")
+
+	item, _ := q.Dequeue(time.Second)
+	n, err := q.RequeueStale()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+
+	// An item taken without getting a deadline gets one, rather than being
+	// requeued straight away
+	q.c.Cmd("RPOPLPUSH", q.pending, q.processing)
+	n, _ = q.RequeueStale()
+	assert.Equal(t, 0, n)
+
+	time.Sleep(150 * time.Millisecond)
+	n, err = q.RequeueStale()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, ItemNotProcessingError, q.Ack(item))
+
+	item, _ = q.Dequeue(time.Second)
+	assert.Equal(t, "a", item.Body)
+}
+
+func TestConsume(t *T) {
+	q := testQueue(t, "queueconsume", Options{PollTimeout: 100 * time.Millisecond})
+	q.Enqueue("a", "b", "fail")
+
+	var mu sync.Mutex
+	var got []string
+	failed := false
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- q.Consume(ctx, func(item *Item) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, item.Body)
+			if item.Body == "fail" && !failed {
+				failed = true
+				return errors.New("try again")
+			}
+			if len(got) == 4 {
+				cancel()
+			}
+			return nil
+		})
+	}()
+	select {
+	case err := <-done:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Consume took too long")
+	}
+	assert.Equal(t, []string{"a", "b", "fail", "fail"}, got)
+	pending, processing, _ := q.Len()
+	assert.Equal(t, 0, pending+processing)
+}
@@ -0,0 +1,227 @@
+// The ratelimit package implements rate limiting on top of redis, so that a
+// limit can be shared by any number of processes, e.g. the instances of an API
+// gateway:
+//
+//	l := ratelimit.NewLimiter(client)
+//	res, err := l.Allow("user:"+id, ratelimit.PerMinute(100))
+//	if err != nil {
+//		// handle err
+//	} else if !res.Allowed {
+//		w.Header().Set("Retry-After", strconv.Itoa(int(res.RetryAfter.Seconds())+1))
+//		w.WriteHeader(http.StatusTooManyRequests)
+//	}
+//
+// Allow uses a token bucket (implemented with the generic cell rate algorithm,
+// so each key only needs a single value), which lets bursts through up to the
+// bucket's size. AllowWindow uses a sliding window log instead, which is exact
+// but stores every request made within the window. Both are done atomically
+// by lua scripts, using the redis server's clock so the clocks of the clients
+// don't matter.
+package ratelimit
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/fzzy/radix/redis"
+)
+
+// Rate is a number of requests allowed per period
+type Rate struct {
+	Limit  int
+	Period time.Duration
+
+	// The most requests Allow lets through at once, after a quiet spell.
+	// Defaults to Limit. Not used by AllowWindow.
+	Burst int
+}
+
+// PerSecond returns a Rate of n requests per second
+func PerSecond(n int) Rate {
+	return Rate{Limit: n, Period: time.Second}
+}
+
+// PerMinute returns a Rate of n requests per minute
+func PerMinute(n int) Rate {
+	return Rate{Limit: n, Period: time.Minute}
+}
+
+// PerHour returns a Rate of n requests per hour
+func PerHour(n int) Rate {
+	return Rate{Limit: n, Period: time.Hour}
+}
+
+func (r Rate) burst() int {
+	if r.Burst > 0 {
+		return r.Burst
+	}
+	return r.Limit
+}
+
+// Result is the outcome of asking whether requests are allowed
+type Result struct {
+	Allowed bool
+
+	// How many more requests would be allowed right now
+	Remaining int
+
+	// If the requests weren't allowed, how long until they would be
+	RetryAfter time.Duration
+
+	// How long until the limit is back to its full capacity, as if no requests
+	// had been made
+	ResetAfter time.Duration
+}
+
+// InvalidRateError is returned for a Rate without a positive Limit and
+// Period, or when asking for more requests at once than the Rate could ever
+// allow
+var InvalidRateError = errors.New("invalid rate")
+
+// Limiter checks rate limits stored in redis. It's safe to use from multiple
+// routines at once if its Commander is (e.g. a cluster.Cluster or a
+// replica.Client).
+type Limiter struct {
+	c      redis.Commander
+	prefix string
+}
+
+// NewLimiter returns a Limiter using c. Limits are stored under keys starting
+// with "ratelimit:".
+func NewLimiter(c redis.Commander) *Limiter {
+	return &Limiter{c: c, prefix: "ratelimit:"}
+}
+
+// SetPrefix changes the prefix of the keys the limits are stored under, which
+// is "ratelimit:" by default
+func (l *Limiter) SetPrefix(prefix string) {
+	l.prefix = prefix
+}
+
+// Takes ARGV[4] tokens from the bucket at KEYS[1], which holds ARGV[1] tokens
+// and refills at ARGV[2] tokens per ARGV[3] milliseconds. The key holds the
+// "theoretical arrival time" of the next request, in milliseconds.
+var bucketScript = redis.NewScript(`
+redis.replicate_commands()
+local burst = tonumber(ARGV[1])
+local emission = tonumber(ARGV[3]) / tonumber(ARGV[2])
+local cost = tonumber(ARGV[4])
+local tolerance = emission * burst
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + tonumber(t[2]) / 1000
+local tat = tonumber(redis.call('GET', KEYS[1])) or now
+if tat < now then tat = now end
+
+local newtat = tat + emission * cost
+local diff = now - (newtat - tolerance)
+if diff < 0 then
+	local remaining = math.floor((now - (tat - tolerance)) / emission)
+	return {0, remaining, math.ceil(-diff), math.ceil(tat - now)}
+end
+redis.call('SET', KEYS[1], newtat, 'PX', math.ceil(newtat - now))
+return {1, math.floor(diff / emission), 0, math.ceil(newtat - now)}
+`)
+
+// Allow checks whether one request for key is allowed at the given rate, and
+// counts it if it is
+func (l *Limiter) Allow(key string, rate Rate) (Result, error) {
+	return l.AllowN(key, rate, 1)
+}
+
+// AllowN is like Allow, but for n requests at once. Either all of them are
+// allowed and counted, or none are.
+func (l *Limiter) AllowN(key string, rate Rate, n int) (Result, error) {
+	if rate.Limit <= 0 || rate.Period <= 0 || n < 1 || n > rate.burst() {
+		return Result{}, InvalidRateError
+	}
+	r := bucketScript.Cmd(l.c, []string{l.prefix + key},
+		rate.burst(), rate.Limit, int64(rate.Period/time.Millisecond), n)
+	return parseResult(r)
+}
+
+// Keeps a log of the requests made in the last ARGV[2] milliseconds in the
+// sorted set at KEYS[1], allowing ARGV[3] more if that takes it to no more than
+// ARGV[1]. The requests are logged with ARGV[4] and a counter as their members.
+var windowScript = redis.NewScript(`
+redis.replicate_commands()
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+
+if count + cost > limit then
+	-- Requests are allowed again once enough of the oldest have left the window
+	local oldest = redis.call('ZRANGE', KEYS[1], count + cost - limit - 1,
+		count + cost - limit - 1, 'WITHSCORES')
+	local retry = tonumber(oldest[2]) + window - now
+	local newest = redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')
+	local reset = tonumber(newest[2]) + window - now
+	return {0, limit - count, retry, reset}
+end
+for i = 1, cost do
+	redis.call('ZADD', KEYS[1], now, ARGV[4] .. ':' .. i)
+end
+redis.call('PEXPIRE', KEYS[1], window)
+return {1, limit - count - cost, 0, window}
+`)
+
+// AllowWindow checks whether one request for key is allowed at the given
+// rate, and counts it if it is, using a sliding window: no more than
+// rate.Limit requests are allowed in any rate.Period long stretch of time.
+// Limits checked with AllowWindow don't share state with those checked by
+// Allow, even for the same key.
+func (l *Limiter) AllowWindow(key string, rate Rate) (Result, error) {
+	return l.AllowWindowN(key, rate, 1)
+}
+
+// AllowWindowN is like AllowWindow, but for n requests at once. Either all of
+// them are allowed and counted, or none are.
+func (l *Limiter) AllowWindowN(key string, rate Rate, n int) (Result, error) {
+	if rate.Limit <= 0 || rate.Period <= 0 || n < 1 || n > rate.Limit {
+		return Result{}, InvalidRateError
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return Result{}, err
+	}
+	r := windowScript.Cmd(l.c, []string{l.prefix + "window:" + key},
+		rate.Limit, int64(rate.Period/time.Millisecond), n, hex.EncodeToString(b))
+	return parseResult(r)
+}
+
+// Reset clears the limits for key, as if no requests had been made
+func (l *Limiter) Reset(key string) error {
+	return l.c.Cmd("DEL", l.prefix+key, l.prefix+"window:"+key).Err
+}
+
+// parseResult parses the reply of either script, which is a multi bulk of
+// whether the requests were allowed, how many remain, and the retry and reset
+// times in milliseconds
+func parseResult(r *redis.Reply) (Result, error) {
+	if r.Err != nil {
+		return Result{}, r.Err
+	}
+	if r.Type != redis.MultiReply || len(r.Elems) != 4 {
+		return Result{}, errors.New("malformed rate limit reply")
+	}
+	var vals [4]int64
+	for i, e := range r.Elems {
+		var err error
+		if vals[i], err = e.Int64(); err != nil {
+			return Result{}, err
+		}
+	}
+	return Result{
+		Allowed:    vals[0] == 1,
+		Remaining:  int(vals[1]),
+		RetryAfter: time.Duration(vals[2]) * time.Millisecond,
+		ResetAfter: time.Duration(vals[3]) * time.Millisecond,
+	}, nil
+}
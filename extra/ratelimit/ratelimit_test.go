@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"github.com/fzzy/radix/extra/internal/redistest"
+	"github.com/fzzy/radix/redis"
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+func limiter(t *T, key string) *Limiter {
+	l := NewLimiter(redistest.Dial(t))
+	l.SetPrefix("ratelimittest:")
+	assert.Nil(t, l.Reset(key))
+	return l
+}
+
+func TestAllow(t *T) {
+	l := limiter(t, "bucket")
+	rate := Rate{Limit: 10, Period: time.Second, Burst: 3}
+
+	for i := 2; i >= 0; i-- {
+		res, err := l.Allow("bucket", rate)
+		assert.Nil(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, i, res.Remaining)
+		assert.Equal(t, time.Duration(0), res.RetryAfter)
+	}
+
+	res, err := l.Allow("bucket", rate)
+	assert.Nil(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+	assert.True(t, res.RetryAfter > 0 && res.RetryAfter <= 100*time.Millisecond, res.RetryAfter)
+	assert.True(t, res.ResetAfter > 200*time.Millisecond && res.ResetAfter <= 300*time.Millisecond)
+
+	// A token comes back every 100ms
+	time.Sleep(res.RetryAfter + 10*time.Millisecond)
+	res, err = l.Allow("bucket", rate)
+	assert.Nil(t, err)
+	assert.True(t, res.Allowed)
+
+	res, err = l.AllowN("bucket", rate, 2)
+	assert.Nil(t, err)
+	assert.False(t, res.Allowed)
+
+	_, err = l.AllowN("bucket", rate, 4)
+	assert.Equal(t, InvalidRateError, err)
+	_, err = l.Allow("bucket", Rate{})
+	assert.Equal(t, InvalidRateError, err)
+}
+
+func TestAllowWindow(t *T) {
+	l := limiter(t, "window")
+	rate := Rate{Limit: 3, Period: 200 * time.Millisecond}
+
+	res, err := l.AllowWindowN("window", rate, 2)
+	assert.Nil(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 1, res.Remaining)
+
+	time.Sleep(50 * time.Millisecond)
+	res, err = l.AllowWindow("window", rate)
+	assert.Nil(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	// Until the first two leave the window nothing else is allowed
+	res, err = l.AllowWindow("window", rate)
+	assert.Nil(t, err)
+	assert.False(t, res.Allowed)
+	assert.True(t, res.RetryAfter > 100*time.Millisecond && res.RetryAfter <= 150*time.Millisecond, res.RetryAfter)
+	assert.True(t, res.ResetAfter > res.RetryAfter)
+
+	time.Sleep(res.RetryAfter + 10*time.Millisecond)
+	res, err = l.AllowWindowN("window", rate, 2)
+	assert.Nil(t, err)
+	assert.True(t, res.Allowed)
+
+	_, err = l.AllowWindowN("window", rate, 4)
+	assert.Equal(t, InvalidRateError, err)
+}
+
+func TestParseResult(t *T) {
+	res, err := parseResult(redis.NewReply([]interface{}{
+		int64(0), int64(2), int64(150), int64(900),
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, Result{
+		Remaining:  2,
+		RetryAfter: 150 * time.Millisecond,
+		ResetAfter: 900 * time.Millisecond,
+	}, res)
+
+	_, err = parseResult(redis.NewReply([]interface{}{int64(1)}))
+	assert.NotNil(t, err)
+}
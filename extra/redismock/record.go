@@ -0,0 +1,276 @@
+package redismock
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/fzzy/radix/redis"
+)
+
+// Recorder is a redis.Commander which passes every command on to another
+// Commander (usually a real server), and records the command along with its
+// reply. The recording can later be played back by a Replayer, so that a test
+// can be run deterministically without a server. Each command is written as a
+// line of JSON.
+//
+// A test can choose between recording and replaying using a flag:
+//
+//	var record = flag.Bool("record", false, "record redis interactions")
+//
+//	func TestThing(t *testing.T) {
+//		var c redis.Commander
+//		if *record {
+//			conn, _ := redis.Dial("tcp", "127.0.0.1:6379")
+//			rec, _ := redismock.RecordFile(conn, "testdata/thing.redis")
+//			defer rec.Close()
+//			c = rec
+//		} else {
+//			c, _ = redismock.ReplayFile("testdata/thing.redis")
+//		}
+//		...
+//	}
+type Recorder struct {
+	c   redis.Commander
+	mu  sync.Mutex
+	w   io.Writer
+	f   *os.File
+	err error
+}
+
+// NewRecorder returns a Recorder passing commands on to c, and writing the
+// recording to w
+func NewRecorder(c redis.Commander, w io.Writer) *Recorder {
+	return &Recorder{c: c, w: w}
+}
+
+// RecordFile returns a Recorder passing commands on to c, and writing the
+// recording to a new file at path. Close must be called once recording's done.
+func RecordFile(c redis.Commander, path string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	r := NewRecorder(c, f)
+	r.f = f
+	return r, nil
+}
+
+// recording is a single recorded command and its reply
+type recording struct {
+	Cmd   string        `json:"cmd"`
+	Args  []string      `json:"args"`
+	Reply recordedReply `json:"reply"`
+}
+
+// recordedReply is a redis.Reply as it's recorded. Bulk strings which aren't
+// valid UTF-8 are kept in Bytes, since JSON strings can't hold them.
+type recordedReply struct {
+	Type   string          `json:"type"`
+	Str    string          `json:"str,omitempty"`
+	Bytes  []byte          `json:"bytes,omitempty"`
+	Int    int64           `json:"int,omitempty"`
+	Elems  []recordedReply `json:"elems,omitempty"`
+	CmdErr bool            `json:"cmd_err,omitempty"`
+}
+
+func recordReply(r *redis.Reply) recordedReply {
+	switch r.Type {
+	case redis.StatusReply:
+		s, _ := r.Str()
+		return recordedReply{Type: "status", Str: s}
+	case redis.ErrorReply:
+		_, cmdErr := r.Err.(*redis.CmdError)
+		return recordedReply{Type: "error", Str: r.Err.Error(), CmdErr: cmdErr}
+	case redis.IntegerReply:
+		i, _ := r.Int64()
+		return recordedReply{Type: "int", Int: i}
+	case redis.NilReply:
+		return recordedReply{Type: "nil"}
+	case redis.BulkReply:
+		b, _ := r.Bytes()
+		if utf8.Valid(b) {
+			return recordedReply{Type: "bulk", Str: string(b)}
+		}
+		return recordedReply{Type: "bulk", Bytes: b}
+	case redis.MultiReply:
+		rr := recordedReply{Type: "multi", Elems: make([]recordedReply, len(r.Elems))}
+		for i := range r.Elems {
+			rr.Elems[i] = recordReply(r.Elems[i])
+		}
+		return rr
+	}
+	return recordedReply{Type: "nil"}
+}
+
+func (rr recordedReply) reply() *redis.Reply {
+	switch rr.Type {
+	case "status":
+		return redis.NewStatusReply(rr.Str)
+	case "error":
+		if rr.CmdErr {
+			err := &redis.CmdError{Err: errors.New(rr.Str)}
+			return &redis.Reply{Type: redis.ErrorReply, Err: err}
+		}
+		return redis.NewReply(errors.New(rr.Str))
+	case "int":
+		return redis.NewReply(rr.Int)
+	case "bulk":
+		if rr.Bytes != nil {
+			return redis.NewReply(rr.Bytes)
+		}
+		return redis.NewReply(rr.Str)
+	case "multi":
+		elems := make([]interface{}, len(rr.Elems))
+		for i := range rr.Elems {
+			elems[i] = rr.Elems[i].reply()
+		}
+		return redis.NewReply(elems)
+	}
+	return redis.NewReply(nil)
+}
+
+// Cmd runs the command on the underlying Commander and records it
+func (r *Recorder) Cmd(cmd string, args ...interface{}) *redis.Reply {
+	reply := r.c.Cmd(cmd, args...)
+	c := newCall(cmd, args)
+	b, err := json.Marshal(recording{c.Cmd, c.Args, recordReply(reply)})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil && r.err == nil {
+		_, err = r.w.Write(append(b, '\n'))
+	}
+	if err != nil && r.err == nil {
+		r.err = err
+	}
+	return reply
+}
+
+// Err returns the first error hit while writing the recording, if any
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Close closes the file opened by RecordFile, returning any error hit while
+// writing the recording. It does nothing for a Recorder made with NewRecorder.
+func (r *Recorder) Close() error {
+	if r.f == nil {
+		return r.Err()
+	}
+	err := r.f.Close()
+	if rerr := r.Err(); rerr != nil {
+		return rerr
+	}
+	return err
+}
+
+// ReplayMismatchError is the error in the reply to a command given to a
+// Replayer which isn't the next one in the recording
+type ReplayMismatchError struct {
+	Expected, Got Call
+}
+
+func (e *ReplayMismatchError) Error() string {
+	return fmt.Sprintf("redismock: expected call %s, got %s", e.Expected, e.Got)
+}
+
+// Replayer is a redis.Commander which plays back a recording made by a
+// Recorder. Commands must be given in the same order as they were recorded,
+// and each gets the reply it got while recording. A command which doesn't
+// match the recording gets a *ReplayMismatchError, and one given after the end
+// of the recording an *UnexpectedCallError.
+type Replayer struct {
+	mu         sync.Mutex
+	recordings []recording
+	next       int
+}
+
+// NewReplayer reads a whole recording from r, and returns a Replayer for it
+func NewReplayer(r io.Reader) (*Replayer, error) {
+	rp := &Replayer{}
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 64*1024*1024)
+	for s.Scan() {
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+		var rec recording
+		if err := json.Unmarshal(s.Bytes(), &rec); err != nil {
+			return nil, err
+		}
+		rp.recordings = append(rp.recordings, rec)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return rp, nil
+}
+
+// ReplayFile returns a Replayer for the recording in the file at path
+func ReplayFile(path string) (*Replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewReplayer(f)
+}
+
+// normalize returns the call as it would be after being recorded, since
+// arguments which aren't valid UTF-8 don't survive JSON encoding unchanged
+func (c Call) normalize() Call {
+	for i := range c.Args {
+		if !utf8.ValidString(c.Args[i]) {
+			b, _ := json.Marshal(c.Args[i])
+			json.Unmarshal(b, &c.Args[i])
+		}
+	}
+	return c
+}
+
+// Cmd returns the recorded reply for the command
+func (r *Replayer) Cmd(cmd string, args ...interface{}) *redis.Reply {
+	c := newCall(cmd, args).normalize()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.next >= len(r.recordings) {
+		return redis.NewReply(&UnexpectedCallError{c})
+	}
+	rec := r.recordings[r.next]
+	expected := Call{rec.Cmd, rec.Args}
+	if !expected.equal(c) {
+		return redis.NewReply(&ReplayMismatchError{expected, c})
+	}
+	r.next++
+	return rec.Reply.reply()
+}
+
+// equal reports whether the two calls are the same command, ignoring case, with
+// the same arguments
+func (c Call) equal(o Call) bool {
+	if !strings.EqualFold(c.Cmd, o.Cmd) || len(c.Args) != len(o.Args) {
+		return false
+	}
+	for i := range c.Args {
+		if c.Args[i] != o.Args[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Remaining returns how many recorded commands haven't been replayed yet
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.recordings) - r.next
+}
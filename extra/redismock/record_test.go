@@ -0,0 +1,81 @@
+package redismock
+
+import (
+	"bytes"
+	"github.com/fzzy/radix/redis"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	. "testing"
+)
+
+func TestRecordReplay(t *T) {
+	conn, err := redis.Dial("tcp", "127.0.0.1:6379")
+	assert.Nil(t, err)
+	defer conn.Close()
+	conn.Cmd("DEL", "record:l", "record:bin")
+
+	buf := new(bytes.Buffer)
+	rec := NewRecorder(conn, buf)
+	assert.Nil(t, rec.Cmd("RPUSH", "record:l", "a", "b", 3).Err)
+	assert.Nil(t, rec.Cmd("SET", "record:bin", []byte{0xff, 0x00}).Err)
+	assert.NotNil(t, rec.Cmd("INCR", "record:l").Err)
+	rec.Cmd("LRANGE", "record:l", 0, -1)
+	rec.Cmd("GET", "record:bin")
+	rec.Cmd("GET", "record:nothing")
+	assert.Nil(t, rec.Err())
+
+	rp, err := NewReplayer(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, 6, rp.Remaining())
+
+	n, err := rp.Cmd("RPUSH", "record:l", "a", "b", "3").Int()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+
+	r := rp.Cmd("SET", "record:bin", []byte{0xff, 0x00})
+	assert.Equal(t, redis.StatusReply, r.Type)
+
+	// Out of order commands aren't replayed
+	err = rp.Cmd("GET", "record:bin").Err
+	_, ok := err.(*ReplayMismatchError)
+	assert.True(t, ok)
+
+	err = rp.Cmd("INCR", "record:l").Err
+	_, ok = err.(*redis.CmdError)
+	assert.True(t, ok)
+
+	l, err := rp.Cmd("LRANGE", "record:l", 0, -1).List()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b", "3"}, l)
+
+	b, err := rp.Cmd("GET", "record:bin").Bytes()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0xff, 0x00}, b)
+
+	assert.Equal(t, redis.NilReply, rp.Cmd("GET", "record:nothing").Type)
+	assert.Equal(t, 0, rp.Remaining())
+	_, ok = rp.Cmd("GET", "record:nothing").Err.(*UnexpectedCallError)
+	assert.True(t, ok)
+}
+
+func TestRecordFile(t *T) {
+	dir, err := ioutil.TempDir("", "redismock")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.redis")
+
+	m := New()
+	m.Expect("GET", "foo").Return("bar")
+	rec, err := RecordFile(m, path)
+	assert.Nil(t, err)
+	rec.Cmd("GET", "foo")
+	assert.Nil(t, rec.Close())
+
+	rp, err := ReplayFile(path)
+	assert.Nil(t, err)
+	s, err := rp.Cmd("GET", "foo").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "bar", s)
+}
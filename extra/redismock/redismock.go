@@ -0,0 +1,210 @@
+// The redismock package implements a fake redis.Commander, for unit testing
+// code which talks to redis without needing a live server. Commands which are
+// expected are given scripted replies, and every command run is recorded so
+// that tests can check what was sent.
+//
+//	m := redismock.New()
+//	m.Expect("GET", "foo").Return("bar")
+//	m.Expect("INCR", "hits").Return(1).Return(2)
+//
+//	doSomething(m) // takes a redis.Commander
+//
+//	if err := m.ExpectationsMet(); err != nil {
+//		t.Fatal(err)
+//	}
+//
+// The package can also record the commands sent to a real server, along with
+// their replies, and replay them later without a server. See Recorder.
+package redismock
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/fzzy/radix/redis"
+	"github.com/fzzy/radix/redis/resp"
+)
+
+// Call is a command which was run on a Mock. The arguments are flattened and
+// converted to strings, the same way they would be sent to the server.
+type Call struct {
+	Cmd  string
+	Args []string
+}
+
+func (c Call) String() string {
+	return strings.TrimSpace(c.Cmd + " " + strings.Join(c.Args, " "))
+}
+
+// UnexpectedCallError is the error in the reply to a command which matches no
+// expectation
+type UnexpectedCallError struct {
+	Call Call
+}
+
+func (e *UnexpectedCallError) Error() string {
+	return "redismock: unexpected call " + e.Call.String()
+}
+
+// Expectation is a command a Mock expects, and the replies to give it. It's
+// created using Mock.Expect or Mock.ExpectAny.
+type Expectation struct {
+	call    Call
+	anyArgs bool
+	replies []*redis.Reply
+	times   int
+	used    int
+}
+
+// Return adds a reply for the expected command, which may be any value
+// accepted by redis.NewReply. If Return is called more than once the replies
+// are given in order, with the last one being repeated. Returning an error
+// fakes a connection error; use ReturnError for an application level error.
+func (e *Expectation) Return(v interface{}) *Expectation {
+	e.replies = append(e.replies, redis.NewReply(v))
+	return e
+}
+
+// ReturnStatus adds a status reply (e.g. "OK") for the expected command
+func (e *Expectation) ReturnStatus(status string) *Expectation {
+	e.replies = append(e.replies, redis.NewStatusReply(status))
+	return e
+}
+
+// ReturnError adds an application level error reply, a *redis.CmdError with
+// the given message, for the expected command
+func (e *Expectation) ReturnError(msg string) *Expectation {
+	err := &redis.CmdError{Err: errors.New(msg)}
+	e.replies = append(e.replies, &redis.Reply{Type: redis.ErrorReply, Err: err})
+	return e
+}
+
+// Times limits the number of times the expected command may be run. Without
+// it the command may be run any number of times, but at least once.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+func (e *Expectation) matches(c Call) bool {
+	if !strings.EqualFold(e.call.Cmd, c.Cmd) {
+		return false
+	}
+	if e.times > 0 && e.used >= e.times {
+		return false
+	}
+	return e.anyArgs || e.call.equal(c)
+}
+
+func (e *Expectation) reply() *redis.Reply {
+	e.used++
+	if len(e.replies) == 0 {
+		return redis.NewStatusReply("OK")
+	}
+	if e.used <= len(e.replies) {
+		return e.replies[e.used-1]
+	}
+	return e.replies[len(e.replies)-1]
+}
+
+// Mock is a redis.Commander which replies to commands as scripted. It can be
+// used from multiple routines at once.
+type Mock struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+	calls        []Call
+}
+
+// New returns a Mock with no expectations
+func New() *Mock {
+	return &Mock{}
+}
+
+func newCall(cmd string, args []interface{}) Call {
+	flat := resp.Flatten(args)
+	c := Call{Cmd: cmd, Args: make([]string, len(flat))}
+	for i := range flat {
+		if b, ok := flat[i].([]byte); ok {
+			c.Args[i] = string(b)
+		} else {
+			c.Args[i] = fmt.Sprint(flat[i])
+		}
+	}
+	return c
+}
+
+// Expect adds an expectation for the given command with exactly the given
+// arguments. Arguments are compared by their string form, so 1 and "1" are the
+// same. If no reply is given for it the command gets an "OK" status reply.
+func (m *Mock) Expect(cmd string, args ...interface{}) *Expectation {
+	e := &Expectation{call: newCall(cmd, args)}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+// ExpectAny adds an expectation for the given command with any arguments
+func (m *Mock) ExpectAny(cmd string) *Expectation {
+	e := &Expectation{call: Call{Cmd: cmd}, anyArgs: true}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+// Cmd records the command, and returns the reply of the first expectation it
+// matches which hasn't been used up. If there isn't one the reply holds an
+// *UnexpectedCallError.
+func (m *Mock) Cmd(cmd string, args ...interface{}) *redis.Reply {
+	c := newCall(cmd, args)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, c)
+	for _, e := range m.expectations {
+		if e.matches(c) {
+			return e.reply()
+		}
+	}
+	return &redis.Reply{Type: redis.ErrorReply, Err: &UnexpectedCallError{c}}
+}
+
+// Calls returns all the commands which have been run, in order
+func (m *Mock) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	calls := make([]Call, len(m.calls))
+	copy(calls, m.calls)
+	return calls
+}
+
+// ExpectationsMet returns an error describing the first expectation which
+// wasn't run, or which was run fewer times than given to Times
+func (m *Mock) ExpectationsMet() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.expectations {
+		call := e.call.String()
+		if e.anyArgs {
+			call += " ..."
+		}
+		if e.used == 0 {
+			return fmt.Errorf("redismock: expected call %s was not run", call)
+		}
+		if e.used < e.times {
+			return fmt.Errorf("redismock: expected call %s was run %d of %d times",
+				call, e.used, e.times)
+		}
+	}
+	return nil
+}
+
+// Reset removes all expectations and recorded calls
+func (m *Mock) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expectations = nil
+	m.calls = nil
+}
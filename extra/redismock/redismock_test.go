@@ -0,0 +1,77 @@
+package redismock
+
+import (
+	"github.com/fzzy/radix/redis"
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+var _ redis.Commander = New()
+
+func TestExpect(t *T) {
+	m := New()
+	m.Expect("GET", "foo").Return("bar")
+	m.Expect("INCR", "hits").Return(1).Return(2)
+	m.Expect("SET", "foo", 5)
+	m.Expect("LRANGE", "l", 0, -1).Return([]string{"a", "b"})
+	assert.NotNil(t, m.ExpectationsMet())
+
+	s, err := m.Cmd("get", "foo").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "bar", s)
+
+	for _, expected := range []int{1, 2, 2} {
+		i, err := m.Cmd("INCR", "hits").Int()
+		assert.Nil(t, err)
+		assert.Equal(t, expected, i)
+	}
+
+	s, err = m.Cmd("SET", "foo", "5").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "OK", s)
+
+	l, err := m.Cmd("LRANGE", "l", []int{0, -1}).List()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, l)
+
+	assert.Nil(t, m.ExpectationsMet())
+
+	err = m.Cmd("GET", "bar").Err
+	_, ok := err.(*UnexpectedCallError)
+	assert.True(t, ok)
+	assert.Equal(t, "redismock: unexpected call GET bar", err.Error())
+
+	calls := m.Calls()
+	assert.Equal(t, 7, len(calls))
+	assert.Equal(t, Call{"LRANGE", []string{"l", "0", "-1"}}, calls[5])
+
+	m.Reset()
+	assert.Equal(t, 0, len(m.Calls()))
+	_, ok = m.Cmd("GET", "foo").Err.(*UnexpectedCallError)
+	assert.True(t, ok)
+}
+
+func TestExpectAnyTimes(t *T) {
+	m := New()
+	m.ExpectAny("DEL").Times(2).Return(1)
+	m.ExpectAny("DEL").ReturnError("ERR boom")
+
+	assert.Nil(t, m.Cmd("DEL", "a").Err)
+	assert.NotNil(t, m.ExpectationsMet())
+	assert.Nil(t, m.Cmd("DEL", "b", "c").Err)
+
+	err := m.Cmd("DEL", "a").Err
+	_, ok := err.(*redis.CmdError)
+	assert.True(t, ok)
+	assert.Equal(t, "ERR boom", err.Error())
+	assert.Nil(t, m.ExpectationsMet())
+}
+
+func TestReturnStatus(t *T) {
+	m := New()
+	m.Expect("PING").ReturnStatus("PONG")
+	r := m.Cmd("PING")
+	assert.Equal(t, redis.StatusReply, r.Type)
+	s, _ := r.Str()
+	assert.Equal(t, "PONG", s)
+}
@@ -0,0 +1,73 @@
+package replica
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/fzzy/radix/redis"
+)
+
+// SetHedgeDelay turns on hedged reads: if a read-only command sent to a replica
+// hasn't returned within the given delay, the same command is also sent to a
+// different replica, and whichever reply comes back first is used. This cuts
+// down on tail latency at the cost of some extra load. Zero, the default,
+// turns hedging off. It has no effect with fewer than two replicas, and should
+// be called before the Client is used.
+func (c *Client) SetHedgeDelay(delay time.Duration) {
+	c.hedgeDelay = delay
+}
+
+// pickOther returns a replica other than n, preferring the lowest latency one
+// if that's the ReadPreference
+func (c *Client) pickOther(n *replicaNode) *replicaNode {
+	var best *replicaNode
+	var bestLatency int64
+	for i := range c.replicas {
+		o := c.replicas[(int(atomic.AddUint32(&c.next, 1))+i)%len(c.replicas)]
+		if o == n {
+			continue
+		}
+		if c.pref != LowestLatency {
+			return o
+		}
+		if l := atomic.LoadInt64(&o.latency); best == nil || l < bestLatency {
+			best, bestLatency = o, l
+		}
+	}
+	return best
+}
+
+// hedgedCmd sends the given command to n, and also to another replica if n
+// takes longer than the hedge delay to reply. Returns the first reply which
+// isn't a connection error, or nil if there isn't one.
+func (c *Client) hedgedCmd(
+	n *replicaNode, cmd string, args []interface{},
+) *redis.Reply {
+	// Buffered so that the slower attempt can always finish, and give back its
+	// connection, after the faster one has been returned
+	ch := make(chan *redis.Reply, 2)
+	go func() { ch <- n.cmd(cmd, args) }()
+
+	timer := time.NewTimer(c.hedgeDelay)
+	defer timer.Stop()
+	pending := 1
+	select {
+	case r := <-ch:
+		if r != nil {
+			return r
+		}
+		pending--
+	case <-timer.C:
+	}
+
+	if o := c.pickOther(n); o != nil {
+		pending++
+		go func() { ch <- o.cmd(cmd, args) }()
+	}
+	for ; pending > 0; pending-- {
+		if r := <-ch; r != nil {
+			return r
+		}
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+// The replica package implements a replication-aware client, which sends all
+// commands which may write to a master while spreading read-only commands
+// across that master's replicas.
+//
+// Replicas are chosen either round-robin or by lowest observed latency, see
+// ReadPreference. If a replica can't be reached, or returns an error which
+// isn't an application level error, the command is sent to the master
+// instead. Keep in mind that replication is asynchronous, so a read sent to a
+// replica may not yet see a write which was just made on the master.
+//
+// A Client can be used from multiple routines at once, since it keeps a
+// connection pool for the master and for each replica.
+package replica
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/fzzy/radix/extra/pool"
+	"github.com/fzzy/radix/redis"
+)
+
+// ReadPreference decides which replica a read-only command is sent to
+type ReadPreference int
+
+const (
+	// Cycle through the replicas in order
+	RoundRobin ReadPreference = iota
+
+	// Use the replica which has had the lowest latency recently
+	LowestLatency
+)
+
+// How much weight the latest latency measurement of a replica has, compared to
+// the ones before it
+const latencyWeight = 0.2
+
+type replicaNode struct {
+	addr string
+	pool *pool.Pool
+
+	// An exponentially weighted moving average of the time taken by commands
+	// sent to the replica, in nanoseconds. Accessed atomically.
+	latency int64
+}
+
+func (n *replicaNode) observe(d time.Duration) {
+	old := atomic.LoadInt64(&n.latency)
+	if old == 0 {
+		atomic.StoreInt64(&n.latency, int64(d))
+		return
+	}
+	l := float64(old)*(1-latencyWeight) + float64(d)*latencyWeight
+	atomic.StoreInt64(&n.latency, int64(l))
+}
+
+// Client sends writes to a master and read-only commands to its replicas
+type Client struct {
+	masterAddr string
+	master     *pool.Pool
+	replicas   []*replicaNode
+	pref       ReadPreference
+
+	// Used for round-robin, accessed atomically
+	next uint32
+
+	// See SetHedgeDelay
+	hedgeDelay time.Duration
+}
+
+// NewClient creates a Client for the given master and replica addresses, with a
+// connection pool of the given size for each. An error is only returned if the
+// master can't be connected to; replicas which are unavailable are skipped
+// until they come back.
+func NewClient(
+	masterAddr string, replicaAddrs []string, poolSize int, pref ReadPreference,
+) (
+	*Client, error,
+) {
+	return NewCustomClient(masterAddr, replicaAddrs, poolSize, pref, redis.Dial)
+}
+
+// NewCustomClient is like NewClient, but creates all connections using df (see
+// pool.NewCustomPool). READONLY is called on every new replica connection, in
+// case the replicas are part of a redis cluster.
+func NewCustomClient(
+	masterAddr string, replicaAddrs []string, poolSize int, pref ReadPreference,
+	df pool.DialFunc,
+) (
+	*Client, error,
+) {
+	master, err := pool.NewCustomPool("tcp", masterAddr, poolSize, df)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		masterAddr: masterAddr,
+		master:     master,
+		replicas:   make([]*replicaNode, len(replicaAddrs)),
+		pref:       pref,
+	}
+	rdf := readOnlyDialFunc(df)
+	for i, addr := range replicaAddrs {
+		c.replicas[i] = &replicaNode{
+			addr: addr,
+			pool: pool.NewOrEmptyCustomPool("tcp", addr, poolSize, rdf),
+		}
+	}
+	return c, nil
+}
+
+// WithReadPreference returns a client derived from this one which chooses
+// replicas using pref, for the reads which need a different preference than
+// the rest. It shares this client's connection pools and hedge delay, so
+// closing either of them closes both.
+func (c *Client) WithReadPreference(pref ReadPreference) *Client {
+	return &Client{
+		masterAddr: c.masterAddr,
+		master:     c.master,
+		replicas:   c.replicas,
+		pref:       pref,
+		hedgeDelay: c.hedgeDelay,
+	}
+}
+
+// readOnlyDialFunc wraps df so that READONLY is called on every connection it
+// makes. Replicas which aren't part of a cluster return an error for READONLY,
+// which is ignored.
+func readOnlyDialFunc(df pool.DialFunc) pool.DialFunc {
+	return func(network, addr string) (*redis.Client, error) {
+		conn, err := df(network, addr)
+		if err != nil {
+			return nil, err
+		}
+		if err = conn.Cmd("READONLY").Err; err != nil {
+			if _, ok := err.(*redis.CmdError); !ok {
+				conn.Close()
+				return nil, err
+			}
+		}
+		return conn, nil
+	}
+}
+
+// pickReplica returns the replica a read-only command should be sent to, or
+// nil if there are none
+func (c *Client) pickReplica() *replicaNode {
+	if len(c.replicas) == 0 {
+		return nil
+	}
+	if c.pref == LowestLatency {
+		best := c.replicas[0]
+		bestLatency := atomic.LoadInt64(&best.latency)
+		for _, n := range c.replicas[1:] {
+			if l := atomic.LoadInt64(&n.latency); l < bestLatency {
+				best, bestLatency = n, l
+			}
+		}
+		return best
+	}
+	i := atomic.AddUint32(&c.next, 1)
+	return c.replicas[int(i%uint32(len(c.replicas)))]
+}
+
+// isConnErr returns whether the error is due to the connection, rather than the
+// command
+func isConnErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	_, ok := err.(*redis.CmdError)
+	return !ok
+}
+
+func poolCmd(p *pool.Pool, cmd string, args []interface{}) *redis.Reply {
+	conn, err := p.Get()
+	if err != nil {
+		return &redis.Reply{Type: redis.ErrorReply, Err: err}
+	}
+	r := conn.Cmd(cmd, args...)
+	p.CarefullyPut(conn, &r.Err)
+	return r
+}
+
+// Cmd sends the given command to a replica if it's read-only (see
+// redis.ReadOnlyCommand), or to the master otherwise. A read-only command
+// which fails due to a connection problem with the replica is retried on the
+// master.
+func (c *Client) Cmd(cmd string, args ...interface{}) *redis.Reply {
+	if redis.ReadOnlyCommand(cmd) {
+		if n := c.pickReplica(); n != nil {
+			var r *redis.Reply
+			if c.hedgeDelay > 0 && len(c.replicas) > 1 {
+				r = c.hedgedCmd(n, cmd, args)
+			} else {
+				r = n.cmd(cmd, args)
+			}
+			if r != nil {
+				return r
+			}
+		}
+	}
+	return c.MasterCmd(cmd, args...)
+}
+
+// cmd sends the given command to the replica, returning nil if it failed due
+// to a connection problem
+func (n *replicaNode) cmd(cmd string, args []interface{}) *redis.Reply {
+	start := time.Now()
+	r := poolCmd(n.pool, cmd, args)
+	if isConnErr(r.Err) {
+		// Make sure a broken replica isn't the lowest latency one
+		n.observe(time.Minute)
+		return nil
+	}
+	n.observe(time.Since(start))
+	return r
+}
+
+// MasterCmd always sends the given command to the master, for reads which must
+// see the latest writes
+func (c *Client) MasterCmd(cmd string, args ...interface{}) *redis.Reply {
+	return poolCmd(c.master, cmd, args)
+}
+
+// GetMaster retrieves a connection to the master, which should be given back
+// using PutMaster. This can be used for things like MULTI/EXEC blocks which
+// need to keep using the same connection.
+func (c *Client) GetMaster() (*redis.Client, error) {
+	return c.master.Get()
+}
+
+// PutMaster gives back a connection retrieved using GetMaster. As with the pool
+// package, do not give back a connection which has had connectivity issues.
+func (c *Client) PutMaster(conn *redis.Client) {
+	c.master.Put(conn)
+}
+
+// Latencies returns the current average latency of each replica, by address. A
+// replica which hasn't been used yet has a latency of zero.
+func (c *Client) Latencies() map[string]time.Duration {
+	m := make(map[string]time.Duration, len(c.replicas))
+	for _, n := range c.replicas {
+		m[n.addr] = time.Duration(atomic.LoadInt64(&n.latency))
+	}
+	return m
+}
+
+// Close empties the connection pools for the master and all replicas
+func (c *Client) Close() {
+	c.master.Empty()
+	for _, n := range c.replicas {
+		n.pool.Empty()
+	}
+}
@@ -0,0 +1,113 @@
+package replica
+
+import (
+	"github.com/fzzy/radix/redis"
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+var _ redis.Commander = &Client{}
+
+// The live tests assume there is a redis instance on port 6379, which is used
+// as both the master and its replicas by giving it multiple addresses.
+
+func TestPickReplica(t *T) {
+	a, b := &replicaNode{addr: "a"}, &replicaNode{addr: "b"}
+	c := &Client{replicas: []*replicaNode{a, b}, pref: RoundRobin}
+	seen := map[string]int{}
+	for i := 0; i < 10; i++ {
+		seen[c.pickReplica().addr]++
+	}
+	assert.Equal(t, 5, seen["a"])
+	assert.Equal(t, 5, seen["b"])
+
+	c.pref = LowestLatency
+	a.observe(10 * time.Millisecond)
+	b.observe(time.Millisecond)
+	assert.Equal(t, "b", c.pickReplica().addr)
+	for i := 0; i < 20; i++ {
+		b.observe(100 * time.Millisecond)
+	}
+	assert.Equal(t, "a", c.pickReplica().addr)
+
+	c.replicas = nil
+	assert.Nil(t, c.pickReplica())
+}
+
+func TestWithReadPreference(t *T) {
+	a, b := &replicaNode{addr: "a"}, &replicaNode{addr: "b"}
+	c := &Client{replicas: []*replicaNode{a, b}, pref: RoundRobin}
+	d := c.WithReadPreference(LowestLatency)
+	a.observe(time.Millisecond)
+	b.observe(10 * time.Millisecond)
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, "a", d.pickReplica().addr)
+	}
+	assert.Equal(t, RoundRobin, c.pref)
+}
+
+func TestCmd(t *T) {
+	c, err := NewClient(
+		"127.0.0.1:6379", []string{"localhost:6379"}, 2, LowestLatency,
+	)
+	assert.Nil(t, err)
+	defer c.Close()
+
+	assert.Nil(t, c.Cmd("SET", "replica:foo", "bar").Err)
+	s, err := c.Cmd("GET", "replica:foo").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "bar", s)
+	assert.NotEqual(t, time.Duration(0), c.Latencies()["localhost:6379"])
+
+	s, err = c.MasterCmd("GET", "replica:foo").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "bar", s)
+
+	// Application level errors from a replica aren't retried on the master
+	r := c.Cmd("GET")
+	assert.NotNil(t, r.Err)
+}
+
+func TestFallback(t *T) {
+	c, err := NewClient("127.0.0.1:6379", []string{"127.0.0.1:1"}, 1, RoundRobin)
+	assert.Nil(t, err)
+	defer c.Close()
+
+	assert.Nil(t, c.Cmd("SET", "replica:foo", "baz").Err)
+	s, err := c.Cmd("GET", "replica:foo").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "baz", s)
+	assert.Equal(t, time.Minute, c.Latencies()["127.0.0.1:1"])
+}
+
+func TestHedgedCmd(t *T) {
+	c, err := NewClient(
+		"127.0.0.1:6379", []string{"localhost:6379", "127.0.0.1:6379"}, 2,
+		RoundRobin,
+	)
+	assert.Nil(t, err)
+	defer c.Close()
+	c.SetHedgeDelay(time.Nanosecond)
+
+	assert.Nil(t, c.Cmd("SET", "replica:foo", "hedged").Err)
+	for i := 0; i < 10; i++ {
+		s, err := c.Cmd("GET", "replica:foo").Str()
+		assert.Nil(t, err)
+		assert.Equal(t, "hedged", s)
+	}
+
+	// A replica which is down is hedged around, rather than falling back to
+	// the master
+	down, err := NewClient(
+		"127.0.0.1:6379", []string{"127.0.0.1:1", "127.0.0.1:6379"}, 1,
+		RoundRobin,
+	)
+	assert.Nil(t, err)
+	defer down.Close()
+	down.SetHedgeDelay(time.Nanosecond)
+	assert.Equal(t, "127.0.0.1:1", down.replicas[0].addr)
+	s, err := down.hedgedCmd(down.replicas[0], "GET", []interface{}{"replica:foo"}).Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "hedged", s)
+}
@@ -18,6 +18,7 @@ import (
 	"errors"
 	"github.com/fzzy/radix/redis"
 	"strings"
+	"time"
 
 	"github.com/fzzy/radix/extra/pool"
 	"github.com/fzzy/radix/extra/pubsub"
@@ -61,6 +62,7 @@ type switchMaster struct {
 
 type Client struct {
 	poolSize    int
+	df          pool.DialFunc
 	masterPools map[string]*pool.Pool
 	subClient   *pubsub.SubClient
 
@@ -83,14 +85,27 @@ func NewClient(
 ) (
 	*Client, error,
 ) {
+	return NewClientTimeout(network, address, poolSize, 0, names...)
+}
+
+// Same as NewClient, but the given timeout is used as the read/write timeout
+// of all connections, both to sentinel and to the masters. A timeout of zero
+// means no timeout.
+func NewClientTimeout(
+	network, address string, poolSize int, timeout time.Duration,
+	names ...string,
+) (
+	*Client, error,
+) {
 
 	// We use this to fetch initial details about masters before we upgrade it
 	// to a pubsub client
-	client, err := redis.Dial(network, address)
+	client, err := redis.DialTimeout(network, address, timeout)
 	if err != nil {
 		return nil, &ClientError{err: err}
 	}
 
+	df := pool.TimeoutDialFunc(timeout)
 	masterPools := map[string]*pool.Pool{}
 	for _, name := range names {
 		r := client.Cmd("SENTINEL", "MASTER", name)
@@ -99,7 +114,7 @@ func NewClient(
 			return nil, &ClientError{err: err, SentinelErr: true}
 		}
 		addr := l[3] + ":" + l[5]
-		pool, err := pool.NewPool("tcp", addr, poolSize)
+		pool, err := pool.NewCustomPool("tcp", addr, poolSize, df)
 		if err != nil {
 			return nil, &ClientError{err: err}
 		}
@@ -114,6 +129,7 @@ func NewClient(
 
 	c := &Client{
 		poolSize:       poolSize,
+		df:             df,
 		masterPools:    masterPools,
 		subClient:      subClient,
 		getCh:          make(chan *getReq),
@@ -184,7 +200,7 @@ func (c *Client) spin() {
 		case sm := <-c.switchMasterCh:
 			if p, ok := c.masterPools[sm.name]; ok {
 				p.Empty()
-				p = pool.NewOrEmptyPool("tcp", sm.addr, c.poolSize)
+				p = pool.NewOrEmptyCustomPool("tcp", sm.addr, c.poolSize, c.df)
 				c.masterPools[sm.name] = p
 			}
 
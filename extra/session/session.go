@@ -0,0 +1,303 @@
+// The session package implements an HTTP session store on top of redis. Each
+// session is a hash, at a key made from an opaque random ID which is given to
+// the client (e.g. in a cookie), and expires after it hasn't been used for the
+// store's TTL; reading or writing a session pushes its expiry back.
+//
+//	store := session.NewStore(client, 24*time.Hour)
+//
+//	s, err := store.New()
+//	s.Values["user"] = "alice"
+//	err = store.Save(s)
+//	http.SetCookie(w, &http.Cookie{Name: "session", Value: s.ID})
+//
+//	// later
+//	s, err = store.Get(cookie.Value)
+//	if s == nil {
+//		// the session doesn't exist, or has expired
+//	}
+//
+// Values are stored as strings. Other go values can be kept in a session using
+// Encode and Decode, which convert them with the store's redis.Codec (see
+// SetCodec):
+//
+//	store.SetCodec(redis.JSONCodec{})
+//	err = store.Encode(s, "cart", cart)
+//	...
+//	ok, err := store.Decode(s, "cart", &cart)
+//
+// Store also implements the Find, Commit and Delete methods of the session
+// store interface used by github.com/alexedwards/scs, so it can be plugged
+// into that (and the frameworks which build on it) as is. Those methods keep
+// the encoded session in a single field of the hash, and use the expiry they're
+// given instead of the store's TTL.
+package session
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/fzzy/radix/redis"
+)
+
+// The field of a session's hash holding when the session was created, in
+// milliseconds. Having it means a session with no values still has a hash.
+const createdField = "_created"
+
+// The field of a session's hash holding the data given to Commit
+const dataField = "_data"
+
+// Session is a session's ID and values
+type Session struct {
+	ID      string
+	Created time.Time
+	Values  map[string]string
+}
+
+// Store keeps sessions in redis. It's safe to use from multiple routines at
+// once if its Commander is.
+type Store struct {
+	c      redis.Commander
+	ttl    time.Duration
+	prefix string
+	codec  redis.Codec
+}
+
+// NewStore returns a Store whose sessions expire after going unused for ttl.
+// Sessions are kept in keys starting with "session:", see SetPrefix.
+func NewStore(c redis.Commander, ttl time.Duration) *Store {
+	return &Store{c: c, ttl: ttl, prefix: "session:"}
+}
+
+// SetPrefix changes the prefix of the keys the sessions are kept in
+func (s *Store) SetPrefix(prefix string) {
+	s.prefix = prefix
+}
+
+// StringCodec is the redis.Codec a Store uses by default. It only handles
+// strings and byte slices, which it passes through unchanged, so values are
+// stored exactly as given.
+type StringCodec struct{}
+
+func (StringCodec) Marshal(v interface{}) ([]byte, error) {
+	switch vt := v.(type) {
+	case string:
+		return []byte(vt), nil
+	case []byte:
+		return vt, nil
+	}
+	return nil, fmt.Errorf("StringCodec can not marshal %T", v)
+}
+
+func (StringCodec) Unmarshal(b []byte, v interface{}) error {
+	switch vt := v.(type) {
+	case *string:
+		*vt = string(b)
+	case *[]byte:
+		*vt = append((*vt)[:0], b...)
+	default:
+		return fmt.Errorf("StringCodec can not unmarshal into %T", v)
+	}
+	return nil
+}
+
+// SetCodec changes the Codec used by Encode, Decode and SetObject. A nil Codec
+// resets it to StringCodec.
+func (s *Store) SetCodec(codec redis.Codec) {
+	s.codec = codec
+}
+
+func (s *Store) getCodec() redis.Codec {
+	if s.codec == nil {
+		return StringCodec{}
+	}
+	return s.codec
+}
+
+// Encode marshals v using the store's Codec and puts it in the session's
+// values under name. Like any other change to Values it's stored by Save.
+func (s *Store) Encode(sess *Session, name string, v interface{}) error {
+	b, err := s.getCodec().Marshal(v)
+	if err != nil {
+		return err
+	}
+	if sess.Values == nil {
+		sess.Values = map[string]string{}
+	}
+	sess.Values[name] = string(b)
+	return nil
+}
+
+// Decode unmarshals the session's value under name into v using the store's
+// Codec, returning false if the session has no such value
+func (s *Store) Decode(sess *Session, name string, v interface{}) (bool, error) {
+	val, ok := sess.Values[name]
+	if !ok {
+		return false, nil
+	}
+	return true, s.getCodec().Unmarshal([]byte(val), v)
+}
+
+func (s *Store) key(id string) string {
+	return s.prefix + id
+}
+
+func (s *Store) ttlMillis() int64 {
+	return int64(s.ttl / time.Millisecond)
+}
+
+// newID returns a new session ID, with 256 bits of randomness so it can't be
+// guessed
+func newID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// New returns a new session with a random ID. It isn't stored until it's
+// saved.
+func (s *Store) New() (*Session, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	return &Session{ID: id, Created: time.Now(), Values: map[string]string{}}, nil
+}
+
+// Returns the hash at KEYS[1] and pushes its expiry back to ARGV[1]
+// milliseconds from now, or returns nil if it doesn't exist
+var getScript = redis.NewScript(`
+local h = redis.call('HGETALL', KEYS[1])
+if #h == 0 then return nil end
+redis.call('PEXPIRE', KEYS[1], ARGV[1])
+return h
+`)
+
+// Get returns the session with the given ID, pushing its expiry back, or nil if
+// it doesn't exist or has expired
+func (s *Store) Get(id string) (*Session, error) {
+	r := getScript.Cmd(s.c, []string{s.key(id)}, s.ttlMillis())
+	if r.Type == redis.NilReply {
+		return nil, nil
+	}
+	h, err := r.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	sess := &Session{ID: id, Values: map[string]string{}}
+	for k, v := range h {
+		switch k {
+		case createdField:
+			ms, _ := strconv.ParseInt(v, 10, 64)
+			sess.Created = time.Unix(0, ms*int64(time.Millisecond))
+		case dataField:
+		default:
+			sess.Values[k] = v
+		}
+	}
+	return sess, nil
+}
+
+// Replaces the hash at KEYS[1] with the fields and values in ARGV[2:], and
+// sets it to expire ARGV[1] milliseconds from now
+var saveScript = redis.NewScript(`
+redis.call('DEL', KEYS[1])
+for i = 2, #ARGV, 2 do
+	redis.call('HSET', KEYS[1], ARGV[i], ARGV[i+1])
+end
+redis.call('PEXPIRE', KEYS[1], ARGV[1])
+return 1
+`)
+
+// Save stores the session, replacing all of its values, and pushes its expiry
+// back
+func (s *Store) Save(sess *Session) error {
+	if sess.Created.IsZero() {
+		sess.Created = time.Now()
+	}
+	created := sess.Created.UnixNano() / int64(time.Millisecond)
+	args := make([]interface{}, 0, 3+2*len(sess.Values))
+	args = append(args, s.ttlMillis(), createdField, created)
+	for k, v := range sess.Values {
+		if k != createdField && k != dataField {
+			args = append(args, k, v)
+		}
+	}
+	return saveScript.Cmd(s.c, []string{s.key(sess.ID)}, args...).Err
+}
+
+// Sets a field of the hash at KEYS[1] if it exists, pushing its expiry back to
+// ARGV[1] milliseconds from now, and returns whether it existed
+var setScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then return 0 end
+redis.call('HSET', KEYS[1], ARGV[2], ARGV[3])
+redis.call('PEXPIRE', KEYS[1], ARGV[1])
+return 1
+`)
+
+// Set sets a single value of the stored session with the given ID, without
+// touching its other values, and pushes its expiry back. It returns false if
+// the session doesn't exist.
+func (s *Store) Set(id, name, value string) (bool, error) {
+	return setScript.Cmd(s.c, []string{s.key(id)}, s.ttlMillis(), name, value).Bool()
+}
+
+// SetObject is like Set, but marshals v using the store's Codec
+func (s *Store) SetObject(id, name string, v interface{}) (bool, error) {
+	b, err := s.getCodec().Marshal(v)
+	if err != nil {
+		return false, err
+	}
+	return setScript.Cmd(s.c, []string{s.key(id)}, s.ttlMillis(), name, b).Bool()
+}
+
+// Touch pushes the expiry of the session with the given ID back, returning
+// false if it doesn't exist
+func (s *Store) Touch(id string) (bool, error) {
+	return s.c.Cmd("PEXPIRE", s.key(id), s.ttlMillis()).Bool()
+}
+
+// Delete deletes the session with the given ID, e.g. when its user logs out
+func (s *Store) Delete(id string) error {
+	return s.c.Cmd("DEL", s.key(id)).Err
+}
+
+// Find returns the data committed for the session with the given ID, and
+// whether it was found. It doesn't push the session's expiry back. This is part
+// of the scs store interface.
+func (s *Store) Find(id string) ([]byte, bool, error) {
+	r := s.c.Cmd("HGET", s.key(id), dataField)
+	if r.Type == redis.NilReply {
+		return nil, false, nil
+	}
+	b, err := r.Bytes()
+	if err != nil {
+		return nil, false, err
+	}
+	return b, true, nil
+}
+
+// Sets the field ARGV[3] of the hash at KEYS[1] to the data in ARGV[4], and
+// ARGV[1] to when it was created, ARGV[2], if it's new. Then sets it to expire
+// at ARGV[5] milliseconds
+var commitScript = redis.NewScript(`
+redis.call('HSETNX', KEYS[1], ARGV[1], ARGV[2])
+redis.call('HSET', KEYS[1], ARGV[3], ARGV[4])
+redis.call('PEXPIREAT', KEYS[1], ARGV[5])
+return 1
+`)
+
+// Commit stores data for the session with the given ID, which expires at the
+// given time. This is part of the scs store interface.
+func (s *Store) Commit(id string, data []byte, expiry time.Time) error {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	at := expiry.UnixNano() / int64(time.Millisecond)
+	return commitScript.Cmd(
+		s.c, []string{s.key(id)}, createdField, now, dataField, data, at,
+	).Err
+}
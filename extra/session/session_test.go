@@ -0,0 +1,152 @@
+package session
+
+import (
+	"github.com/fzzy/radix/extra/internal/redistest"
+	"github.com/fzzy/radix/redis"
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+func testStore(t *T, ttl time.Duration) (*Store, *redis.Client) {
+	c := redistest.Dial(t)
+	s := NewStore(c, ttl)
+	s.SetPrefix("sessiontest:")
+	return s, c
+}
+
+func TestSession(t *T) {
+	s, c := testStore(t, time.Minute)
+	sess, err := s.New()
+	assert.Nil(t, err)
+	assert.Equal(t, 43, len(sess.ID))
+
+	got, err := s.Get(sess.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, got)
+
+	sess.Values["user"] = "alice"
+	assert.Nil(t, s.Save(sess))
+	got, err = s.Get(sess.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"user": "alice"}, got.Values)
+	assert.Equal(t, sess.Created.Unix(), got.Created.Unix())
+
+	ok, err := s.Set(sess.ID, "theme", "dark")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	got, _ = s.Get(sess.ID)
+	assert.Equal(t, map[string]string{"user": "alice", "theme": "dark"}, got.Values)
+
+	// Saving replaces all the values, and an empty session still exists
+	got.Values = map[string]string{}
+	assert.Nil(t, s.Save(got))
+	got, _ = s.Get(sess.ID)
+	assert.Equal(t, 0, len(got.Values))
+
+	assert.Nil(t, s.Delete(sess.ID))
+	got, _ = s.Get(sess.ID)
+	assert.Nil(t, got)
+	ok, err = s.Set(sess.ID, "user", "mallory")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	n, _ := c.Cmd("EXISTS", "sessiontest:"+sess.ID).Int()
+	assert.Equal(t, 0, n)
+}
+
+func TestSessionSliding(t *T) {
+	s, c := testStore(t, time.Minute)
+	sess, _ := s.New()
+	assert.Nil(t, s.Save(sess))
+	key := "sessiontest:" + sess.ID
+
+	// Each use pushes the expiry back
+	pttl := func() int {
+		n, _ := c.Cmd("PTTL", key).Int()
+		return n
+	}
+	c.Cmd("PEXPIRE", key, 1000)
+	got, err := s.Get(sess.ID)
+	assert.Nil(t, err)
+	assert.NotEqual(t, (*Session)(nil), got)
+	assert.True(t, pttl() > 50000)
+
+	c.Cmd("PEXPIRE", key, 1000)
+	s.Set(sess.ID, "user", "alice")
+	assert.True(t, pttl() > 50000)
+
+	c.Cmd("PEXPIRE", key, 1000)
+	ok, err := s.Touch(sess.ID)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.True(t, pttl() > 50000)
+
+	s.Delete(sess.ID)
+	ok, _ = s.Touch(sess.ID)
+	assert.False(t, ok)
+}
+
+func TestFindCommit(t *T) {
+	s, c := testStore(t, time.Minute)
+	_, found, err := s.Find("scs")
+	assert.Nil(t, err)
+	assert.False(t, found)
+
+	assert.Nil(t, s.Commit("scs", []byte("encoded"), time.Now().Add(time.Hour)))
+	b, found, err := s.Find("scs")
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []byte("encoded"), b)
+	ttl, _ := c.Cmd("PTTL", "sessiontest:scs").Int64()
+	assert.True(t, ttl > 3500000)
+
+	// The data isn't one of the session's values
+	sess, _ := s.Get("scs")
+	assert.Equal(t, 0, len(sess.Values))
+
+	assert.Nil(t, s.Delete("scs"))
+	_, found, _ = s.Find("scs")
+	assert.False(t, found)
+}
+
+func TestSessionCodec(t *T) {
+	store, _ := testStore(t, time.Minute)
+	sess, err := store.New()
+	assert.Nil(t, err)
+
+	// By default values are passed through as they are
+	assert.Nil(t, store.Encode(sess, "name", "alice"))
+	assert.Equal(t, "alice", sess.Values["name"])
+	assert.NotNil(t, store.Encode(sess, "n", 1))
+
+	type cart struct{ Items []string }
+	store.SetCodec(redis.JSONCodec{})
+	assert.Nil(t, store.Encode(sess, "cart", cart{[]string{"a", "b"}}))
+	assert.Nil(t, store.Save(sess))
+
+	got, err := store.Get(sess.ID)
+	assert.Nil(t, err)
+	var c cart
+	ok, err := store.Decode(got, "cart", &c)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"a", "b"}, c.Items)
+	ok, err = store.Decode(got, "missing", &c)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	ok, err = store.SetObject(sess.ID, "cart", cart{[]string{"c"}})
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	got, _ = store.Get(sess.ID)
+	_, err = store.Decode(got, "cart", &c)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"c"}, c.Items)
+
+	store.SetCodec(nil)
+	var name string
+	ok, err = store.Decode(got, "name", &name)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "alice", name)
+}
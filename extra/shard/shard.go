@@ -0,0 +1,203 @@
+// The shard package implements a client which spreads keys across a set of
+// standalone redis instances using consistent hashing, for classic client-side
+// sharding without redis cluster.
+//
+// Each key is hashed onto a ring on which every instance has many points, and
+// is sent to the instance owning the first point after it. Adding or removing
+// an instance therefore only moves the keys which hash near that instance's
+// points, rather than almost all of them. As with redis cluster, if a key
+// contains a non-empty {...} section only that section is hashed, which can be
+// used to force related keys onto the same instance:
+//
+//	// These will always be on the same instance
+//	s.Cmd("SET", "{user1000}.following", ...)
+//	s.Cmd("SET", "{user1000}.followers", ...)
+//
+// A ShardedClient can be used from multiple routines at once, since it keeps a
+// connection pool for each instance.
+package shard
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fzzy/radix/extra/pool"
+	"github.com/fzzy/radix/redis"
+)
+
+// The number of points each instance gets on the ring. More points means keys
+// are spread more evenly, at the cost of a larger ring to search.
+const POINTS_PER_ADDR = 160
+
+var BadCmdNoKey = &redis.CmdError{Err: errors.New("bad command, no key")}
+
+type point struct {
+	hash uint32
+	addr string
+}
+
+type ring []point
+
+func (r ring) Len() int           { return len(r) }
+func (r ring) Less(i, j int) bool { return r[i].hash < r[j].hash }
+func (r ring) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+
+func newRing(addrs []string) ring {
+	r := make(ring, 0, len(addrs)*POINTS_PER_ADDR)
+	seen := map[string]bool{}
+	for _, addr := range addrs {
+		if seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		for i := 0; i < POINTS_PER_ADDR; i++ {
+			h := crc32.ChecksumIEEE([]byte(addr + "-" + strconv.Itoa(i)))
+			r = append(r, point{h, addr})
+		}
+	}
+	sort.Sort(r)
+	return r
+}
+
+// ShardedClient routes commands to one of a set of redis instances based on
+// the key they act on
+type ShardedClient struct {
+	ring  ring
+	pools map[string]*pool.Pool
+}
+
+// NewShardedClient creates a ShardedClient for the given instance addresses,
+// creating a connection pool of the given size for each of them
+func NewShardedClient(addrs []string, poolSize int) (*ShardedClient, error) {
+	return NewCustomShardedClient(addrs, poolSize, redis.Dial)
+}
+
+// NewCustomShardedClient is like NewShardedClient, but creates all connections
+// using df, see pool.NewCustomPool
+func NewCustomShardedClient(
+	addrs []string, poolSize int, df pool.DialFunc,
+) (
+	*ShardedClient, error,
+) {
+	if len(addrs) == 0 {
+		return nil, errors.New("no addresses given")
+	}
+
+	s := &ShardedClient{
+		ring:  newRing(addrs),
+		pools: map[string]*pool.Pool{},
+	}
+	for _, addr := range addrs {
+		if _, ok := s.pools[addr]; ok {
+			continue
+		}
+		p, err := pool.NewCustomPool("tcp", addr, poolSize, df)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.pools[addr] = p
+	}
+	return s, nil
+}
+
+// hashTag returns the part of the key which should be hashed
+func hashTag(key string) string {
+	if start := strings.Index(key, "{"); start >= 0 {
+		if end := strings.Index(key[start+1:], "}"); end > 0 {
+			return key[start+1 : start+1+end]
+		}
+	}
+	return key
+}
+
+// AddrForKey returns the address of the instance the given key belongs on
+func (s *ShardedClient) AddrForKey(key string) string {
+	h := crc32.ChecksumIEEE([]byte(hashTag(key)))
+	i := sort.Search(len(s.ring), func(i int) bool { return s.ring[i].hash >= h })
+	if i == len(s.ring) {
+		i = 0
+	}
+	return s.ring[i].addr
+}
+
+// ClientForKey retrieves a connection to the instance the given key belongs
+// on, along with that instance's address. The connection must be given back
+// using Put once it's no longer being used. This can be used to run multiple
+// commands on the same instance, e.g. a MULTI/EXEC block on keys which share a
+// hash tag.
+func (s *ShardedClient) ClientForKey(key string) (*redis.Client, string, error) {
+	addr := s.AddrForKey(key)
+	conn, err := s.pools[addr].Get()
+	return conn, addr, err
+}
+
+// Put gives back a connection which was retrieved using ClientForKey. As with
+// the pool package, do not give back a connection which has had connectivity
+// issues.
+func (s *ShardedClient) Put(addr string, conn *redis.Client) {
+	if p, ok := s.pools[addr]; ok {
+		p.Put(conn)
+	}
+}
+
+// Cmd performs the given command on the instance its key belongs on. The
+// command *must* have a key parameter (i.e. len(args) >= 1), and that key is
+// what decides which instance is used. Commands acting on multiple keys are
+// only correct if all of the keys are on the same instance.
+func (s *ShardedClient) Cmd(cmd string, args ...interface{}) *redis.Reply {
+	if len(args) < 1 {
+		return &redis.Reply{Type: redis.ErrorReply, Err: BadCmdNoKey}
+	}
+	key, err := keyFromArg(args[0])
+	if err != nil {
+		return &redis.Reply{Type: redis.ErrorReply, Err: err}
+	}
+
+	conn, addr, err := s.ClientForKey(key)
+	if err != nil {
+		return &redis.Reply{Type: redis.ErrorReply, Err: err}
+	}
+	r := conn.Cmd(cmd, args...)
+	s.pools[addr].CarefullyPut(conn, &r.Err)
+	return r
+}
+
+// keyFromArg returns the key the given (first) command argument represents,
+// accounting for arguments being flattened into the command
+func keyFromArg(arg interface{}) (string, error) {
+	switch argv := arg.(type) {
+	case nil:
+		return "", BadCmdNoKey
+	case string:
+		return argv, nil
+	case []byte:
+		return string(argv), nil
+	default:
+		switch reflect.TypeOf(arg).Kind() {
+		case reflect.Slice:
+			argVal := reflect.ValueOf(arg)
+			if argVal.Len() < 1 {
+				return "", BadCmdNoKey
+			}
+			return keyFromArg(argVal.Index(0).Interface())
+		case reflect.Map:
+			// Maps have no order, we can't possibly choose a key out of one
+			return "", BadCmdNoKey
+		default:
+			return fmt.Sprint(arg), nil
+		}
+	}
+}
+
+// Close empties the connection pools of all instances
+func (s *ShardedClient) Close() {
+	for _, p := range s.pools {
+		p.Empty()
+	}
+}
@@ -0,0 +1,76 @@
+package shard
+
+import (
+	"github.com/fzzy/radix/redis"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	. "testing"
+)
+
+var _ redis.Commander = &ShardedClient{}
+
+// The live tests assume there is a redis instance on port 6379. The same
+// instance is used as two different shards by giving it two addresses.
+
+func TestHashTag(t *T) {
+	assert.Equal(t, "foo", hashTag("foo"))
+	assert.Equal(t, "user1000", hashTag("{user1000}.following"))
+	assert.Equal(t, "user1000", hashTag("foo{user1000}{bar}"))
+	assert.Equal(t, "foo{}bar", hashTag("foo{}bar"))
+	assert.Equal(t, "foo{bar", hashTag("foo{bar"))
+}
+
+func TestRing(t *T) {
+	addrs := []string{"a:6379", "b:6379", "c:6379"}
+	s := &ShardedClient{ring: newRing(addrs)}
+
+	counts := map[string]int{}
+	owners := map[string]string{}
+	for i := 0; i < 3000; i++ {
+		key := "key" + strconv.Itoa(i)
+		owners[key] = s.AddrForKey(key)
+		counts[owners[key]]++
+	}
+	// Every instance should get a reasonable share of the keys
+	for _, addr := range addrs {
+		assert.True(t, counts[addr] > 600, addr, counts[addr])
+	}
+
+	// Keys with the same hash tag always go to the same instance
+	assert.Equal(t, s.AddrForKey("{user}.a"), s.AddrForKey("{user}.b"))
+
+	// Adding an instance only moves keys onto that instance
+	s2 := &ShardedClient{ring: newRing(append(addrs, "d:6379"))}
+	moved := 0
+	for key, addr := range owners {
+		if newAddr := s2.AddrForKey(key); newAddr != addr {
+			assert.Equal(t, "d:6379", newAddr)
+			moved++
+		}
+	}
+	assert.True(t, moved < 1500, moved)
+}
+
+func TestCmd(t *T) {
+	s, err := NewShardedClient([]string{"127.0.0.1:6379", "localhost:6379"}, 2)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	for i := 0; i < 10; i++ {
+		key := "shard:" + strconv.Itoa(i)
+		assert.Nil(t, s.Cmd("SET", key, i).Err)
+		v, err := s.Cmd("GET", key).Int()
+		assert.Nil(t, err)
+		assert.Equal(t, i, v)
+	}
+
+	assert.Equal(t, BadCmdNoKey, s.Cmd("PING").Err)
+	assert.Equal(t, BadCmdNoKey, s.Cmd("GET", nil).Err)
+	assert.Equal(t, BadCmdNoKey, s.Cmd("MGET", []interface{}{nil, "shard:0"}).Err)
+
+	conn, addr, err := s.ClientForKey("shard:0")
+	assert.Nil(t, err)
+	assert.Equal(t, s.AddrForKey("shard:0"), addr)
+	assert.Nil(t, conn.Cmd("PING").Err)
+	s.Put(addr, conn)
+}
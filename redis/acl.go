@@ -0,0 +1,91 @@
+package redis
+
+import (
+	"errors"
+	"strings"
+)
+
+// ACLUser describes a user, as returned by ACL GETUSER
+type ACLUser struct {
+	// e.g. "on", "allkeys" or "nopass"
+	Flags []string
+
+	// The SHA256 hashes of the user's passwords
+	Passwords []string
+
+	// The user's command rules (e.g. "+@all -debug"), and the key and pub/sub
+	// channel patterns it can access (e.g. "~cache:*"), as space separated
+	// lists
+	Commands string
+	Keys     string
+	Channels string
+}
+
+// ACLWhoAmI returns the name of the user the connection is authenticated as
+func (c *Client) ACLWhoAmI() (string, error) {
+	return c.Cmd("ACL", "WHOAMI").Str()
+}
+
+// ACLList returns the rules for every user, in the format used in ACL files
+func (c *Client) ACLList() ([]string, error) {
+	return c.Cmd("ACL", "LIST").List()
+}
+
+// ACLSetUser creates or modifies the given user by applying the given rules,
+// e.g. ACLSetUser("app", "on", ">secret", "~app:*", "+@all")
+func (c *Client) ACLSetUser(name string, rules ...string) error {
+	return c.Cmd("ACL", "SETUSER", name, rules).Err
+}
+
+// ACLDelUser deletes the given users, returning how many existed
+func (c *Client) ACLDelUser(names ...string) (int, error) {
+	return c.Cmd("ACL", "DELUSER", names).Int()
+}
+
+// ACLGetUser returns the given user. If the user doesn't exist a nil ACLUser
+// is returned.
+func (c *Client) ACLGetUser(name string) (*ACLUser, error) {
+	r := c.Cmd("ACL", "GETUSER", name)
+	if r.Type == ErrorReply {
+		return nil, r.Err
+	} else if r.Type == NilReply {
+		return nil, nil
+	} else if r.Type != MultiReply || len(r.Elems)%2 != 0 {
+		return nil, errors.New("malformed ACL GETUSER reply")
+	}
+
+	u := &ACLUser{}
+	for i := 0; i < len(r.Elems); i += 2 {
+		field, err := r.Elems[i].Str()
+		if err != nil {
+			return nil, err
+		}
+		v := r.Elems[i+1]
+		switch field {
+		case "flags":
+			u.Flags, err = v.List()
+		case "passwords":
+			u.Passwords, err = v.List()
+		case "commands":
+			u.Commands, err = aclRules(v)
+		case "keys":
+			u.Keys, err = aclRules(v)
+		case "channels":
+			u.Channels, err = aclRules(v)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return u, nil
+}
+
+// aclRules returns a set of rules from ACL GETUSER as a space separated
+// string. Older versions of redis return some of them as a list instead.
+func aclRules(r *Reply) (string, error) {
+	if r.Type == MultiReply {
+		l, err := r.List()
+		return strings.Join(l, " "), err
+	}
+	return r.Str()
+}
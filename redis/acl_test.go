@@ -0,0 +1,58 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func TestACL(t *T) {
+	c := dial(t)
+	// ACL may not be supported by the test server
+	if isUnknownCommand(c.Cmd("ACL", "WHOAMI").Err) {
+		t.Skip("ACL not supported")
+	}
+
+	name, err := c.ACLWhoAmI()
+	assert.Nil(t, err)
+	assert.Equal(t, "default", name)
+
+	assert.Nil(t, c.ACLSetUser("radix-test", "reset", "on", ">secret", "~acl:*", "+@all"))
+	defer c.ACLDelUser("radix-test")
+	u, err := c.ACLGetUser("radix-test")
+	assert.Nil(t, err)
+	assert.NotNil(t, u)
+	assert.Equal(t, 1, len(u.Passwords))
+	assert.Equal(t, "~acl:*", u.Keys)
+
+	u, err = c.ACLGetUser("radix-nonexistant")
+	assert.Nil(t, err)
+	assert.True(t, u == nil)
+
+	l, err := c.ACLList()
+	assert.Nil(t, err)
+	assert.True(t, len(l) >= 2)
+
+	uc, err := DialConfig(Config{
+		Network:  "tcp",
+		Addr:     "127.0.0.1:6379",
+		Username: "radix-test",
+		Password: "secret",
+	})
+	assert.Nil(t, err)
+	defer uc.Close()
+	assert.Nil(t, uc.Reconnect())
+	name, err = uc.ACLWhoAmI()
+	assert.Nil(t, err)
+	assert.Equal(t, "radix-test", name)
+}
+
+func TestTrackAuth(t *T) {
+	c := &Client{connState: &connState{}}
+	ok := &Reply{Type: StatusReply, buf: []byte("OK")}
+	c.trackState(&request{cmd: "AUTH", args: []interface{}{"user", "pass"}}, ok)
+	assert.Equal(t, "user", c.cfg.Username)
+	assert.Equal(t, "pass", c.cfg.Password)
+	c.trackState(&request{cmd: "auth", args: []interface{}{"other"}}, ok)
+	assert.Equal(t, "", c.cfg.Username)
+	assert.Equal(t, "other", c.cfg.Password)
+}
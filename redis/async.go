@@ -0,0 +1,537 @@
+package redis
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// AsyncClosedError is the error in the reply of a command sent to an
+// AsyncClient after it was closed
+var AsyncClosedError = errors.New("async client is closed")
+
+// CanceledError is the error in the reply of a Future which was canceled
+var CanceledError = errors.New("command canceled")
+
+// QueueFullError is the error in the reply of a command sent to an AsyncClient
+// which already has AsyncOptions.MaxInFlight commands waiting for their
+// replies, if AsyncOptions.FailWhenFull is set
+var QueueFullError = errors.New("async queue is full")
+
+// Future is the eventual reply to a command sent using AsyncClient
+type Future struct {
+	a     *AsyncClient
+	cmd   Cmd
+	once  sync.Once
+	done  chan struct{}
+	reply *Reply
+
+	// Whether the command is sent on a dedicated connection, see
+	// AsyncClient.blocking
+	blocking bool
+
+	// The callbacks added by Then and OnError which are waiting for the reply
+	mu        sync.Mutex
+	callbacks []func(*Reply)
+}
+
+func newFuture(a *AsyncClient, cmd Cmd) *Future {
+	return &Future{a: a, cmd: cmd, done: make(chan struct{})}
+}
+
+// complete sets the future's reply, returning false if it already had one
+func (f *Future) complete(r *Reply) bool {
+	completed := false
+	f.once.Do(func() {
+		f.mu.Lock()
+		f.reply = r
+		close(f.done)
+		callbacks := f.callbacks
+		f.callbacks = nil
+		f.mu.Unlock()
+		for _, fn := range callbacks {
+			f.a.dispatch(fn, r)
+		}
+		completed = true
+	})
+	return completed
+}
+
+// Then has fn called with the reply once it's available, if it isn't an
+// error reply. Callbacks run on the AsyncClient's callback workers (see
+// AsyncOptions), not on the routine which added them, so a pipeline of
+// commands can be built up without a routine waiting on each reply:
+//
+//	a.Cmd("GET", "foo").Then(func(r *redis.Reply) {
+//		a.Cmd("SET", "bar", r)
+//	}).OnError(func(err error) {
+//		log.Print(err)
+//	})
+//
+// Then returns the future, so calls can be chained.
+func (f *Future) Then(fn func(*Reply)) *Future {
+	return f.addCallback(func(r *Reply) {
+		if r.Type != ErrorReply {
+			fn(r)
+		}
+	})
+}
+
+// OnError has fn called with the reply's error once it's available, if it's
+// an error reply, see Then. This includes futures which were canceled.
+func (f *Future) OnError(fn func(error)) *Future {
+	return f.addCallback(func(r *Reply) {
+		if r.Type == ErrorReply {
+			fn(r.Err)
+		}
+	})
+}
+
+func (f *Future) addCallback(fn func(*Reply)) *Future {
+	f.mu.Lock()
+	if !f.isDone() {
+		f.callbacks = append(f.callbacks, fn)
+		f.mu.Unlock()
+		return f
+	}
+	f.mu.Unlock()
+	f.a.dispatch(fn, f.reply)
+	return f
+}
+
+// Cancel completes the future straight away with CanceledError as its reply,
+// so that nothing is left waiting on it. If the command hasn't been sent yet it
+// never will be, otherwise its reply is discarded once it arrives. A blocking
+// command (e.g. BLPOP) is stopped by closing the dedicated connection it's
+// waiting on, so it can't go on to pop a value which would then be lost.
+// Cancel returns false if the future was already complete, or its reply had
+// already arrived.
+func (f *Future) Cancel() bool {
+	if f.blocking {
+		return f.a.cancelBlocking(f)
+	}
+	return f.complete(&Reply{Type: ErrorReply, Err: CanceledError})
+}
+
+func (f *Future) isDone() bool {
+	select {
+	case <-f.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Reply blocks until the reply to the command is available, and returns it
+func (f *Future) Reply() *Reply {
+	<-f.done
+	return f.reply
+}
+
+// Done returns a channel which is closed once the reply to the command is
+// available, after which Reply won't block. This allows waiting on several
+// futures at once, or giving up after a timeout or once a context is done:
+//
+//	select {
+//	case <-fut.Done():
+//		r := fut.Reply()
+//	case <-ctx.Done():
+//		return ctx.Err()
+//	}
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// AsyncClient sends commands on a Client without waiting for their replies,
+// returning a Future for each instead. Commands are sent in the order they
+// were given, and any which are waiting to be sent at the same time are
+// pipelined together, so many routines sending commands through one
+// AsyncClient get good throughput from a single connection. It's safe to use
+// from multiple routines at once.
+type AsyncClient struct {
+	c   *Client
+	cfg Config
+
+	// A copy of c made when it was wrapped, whose settings the dedicated
+	// connections for blocking commands are given, see dialLike
+	settings *Client
+
+	mu    sync.Mutex
+	cond  *sync.Cond
+	queue []*Future
+
+	// See AsyncOptions. queued is roughly how many bytes of commands are in
+	// queue.
+	flushInterval time.Duration
+	flushBytes    int
+	queued        int
+
+	// See AsyncOptions. space is signalled whenever commands finish.
+	maxInFlight  int
+	failWhenFull bool
+	space        *sync.Cond
+
+	// The futures whose commands are currently being sent
+	inflight []*Future
+	closed   bool
+	stopped  chan struct{}
+
+	// Callbacks are sent to the workers on callbacks. Once cbStop is closed
+	// the workers stop, and callbacks are run by whoever dispatches them.
+	callbacks chan func()
+	cbStop    chan struct{}
+	cbWG      sync.WaitGroup
+
+	// Blocking commands are sent on dedicated connections, see Cmd. idle
+	// holds the ones not currently in use, busy the ones which are, and
+	// blocked the futures still waiting on them, along with the connection
+	// each is on (nil while it's being dialed).
+	idle      []*Client
+	busy      map[*Client]bool
+	blocked   map[*Future]*Client
+	blockedWG sync.WaitGroup
+}
+
+// AsyncOptions are the options for NewAsyncClientOptions
+type AsyncOptions struct {
+	// How many routines run the callbacks added by Future.Then and
+	// Future.OnError. With a single worker callbacks run one at a time, in
+	// the order their replies arrived. Defaults to 1.
+	CallbackWorkers int
+
+	// The most commands which may be waiting to be sent or for their replies
+	// at once. Once there are that many, Cmd blocks until one of them is done,
+	// or if FailWhenFull is set returns a future with QueueFullError straight
+	// away. Zero means no limit.
+	MaxInFlight  int
+	FailWhenFull bool
+
+	// If FlushInterval is set, commands aren't sent as soon as the connection
+	// is free, but once FlushInterval has passed since the first of them was
+	// queued, or once FlushBytes worth of them are waiting (going roughly by
+	// the size of their arguments), whichever comes first. Waiting a little
+	// (e.g. 100µs) lets many more commands be pipelined together on a busy
+	// client, greatly increasing throughput at the cost of that much latency.
+	// Zero FlushBytes means only FlushInterval is waited for.
+	FlushInterval time.Duration
+	FlushBytes    int
+}
+
+// NewAsyncClient wraps c, which mustn't be used for anything else afterwards.
+// It uses the default AsyncOptions.
+func NewAsyncClient(c *Client) *AsyncClient {
+	return NewAsyncClientOptions(c, AsyncOptions{})
+}
+
+// NewAsyncClientOptions is like NewAsyncClient, but with the given options
+func NewAsyncClientOptions(c *Client, opts AsyncOptions) *AsyncClient {
+	a := &AsyncClient{
+		c:             c,
+		cfg:           c.cfg,
+		settings:      c.WithOptions(Options{}),
+		maxInFlight:   opts.MaxInFlight,
+		failWhenFull:  opts.FailWhenFull,
+		flushInterval: opts.FlushInterval,
+		flushBytes:    opts.FlushBytes,
+		stopped:       make(chan struct{}),
+		callbacks:     make(chan func()),
+		cbStop:        make(chan struct{}),
+		busy:          map[*Client]bool{},
+		blocked:       map[*Future]*Client{},
+	}
+	a.cond = sync.NewCond(&a.mu)
+	a.space = sync.NewCond(&a.mu)
+	go a.run()
+
+	workers := opts.CallbackWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+	a.cbWG.Add(workers)
+	for i := 0; i < workers; i++ {
+		go a.callbackWorker()
+	}
+	return a
+}
+
+func (a *AsyncClient) callbackWorker() {
+	defer a.cbWG.Done()
+	for {
+		select {
+		case fn := <-a.callbacks:
+			fn()
+		case <-a.cbStop:
+			return
+		}
+	}
+}
+
+// dispatch has fn called with r by a callback worker, or calls it straight
+// away if the workers have stopped
+func (a *AsyncClient) dispatch(fn func(*Reply), r *Reply) {
+	select {
+	case a.callbacks <- func() { fn(r) }:
+	case <-a.cbStop:
+		fn(r)
+	}
+}
+
+// Cmd queues the given command to be sent, returning a Future for its reply.
+// If the AsyncClient is closed the reply has AsyncClosedError. If there are
+// already AsyncOptions.MaxInFlight commands waiting for their replies Cmd
+// blocks until there's room, unless AsyncOptions.FailWhenFull is set.
+//
+// Commands which block on the server (see BlockingCommand) would hold up all
+// the others pipelined with them, so instead they're each sent on a dedicated
+// connection, made using the Config the wrapped client had and kept around for
+// reuse. They don't count towards MaxInFlight, and are read without a timeout
+// since redis enforces the command's own.
+func (a *AsyncClient) Cmd(cmd string, args ...interface{}) *Future {
+	f := newFuture(a, Cmd{cmd, args})
+	if BlockingCommand(cmd, args...) {
+		f.blocking = true
+		a.blocking(f)
+		return f
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for !a.closed && a.full() {
+		if a.failWhenFull {
+			f.complete(&Reply{Type: ErrorReply, Err: QueueFullError})
+			return f
+		}
+		a.space.Wait()
+	}
+	if a.closed {
+		f.complete(&Reply{Type: ErrorReply, Err: AsyncClosedError})
+		return f
+	}
+	a.queue = append(a.queue, f)
+	a.queued += cmdSize(f.cmd)
+	a.cond.Signal()
+	return f
+}
+
+// cmdSize returns roughly how many bytes cmd takes up when it's sent
+func cmdSize(cmd Cmd) int {
+	n := len(cmd.Name) + 16
+	for _, arg := range cmd.Args {
+		switch at := arg.(type) {
+		case string:
+			n += len(at) + 16
+		case []byte:
+			n += len(at) + 16
+		default:
+			n += 16
+		}
+	}
+	return n
+}
+
+// full returns whether there are as many commands in flight as are allowed.
+// a.mu must be held.
+func (a *AsyncClient) full() bool {
+	return a.maxInFlight > 0 && len(a.queue)+len(a.inflight) >= a.maxInFlight
+}
+
+func (a *AsyncClient) run() {
+	defer close(a.stopped)
+	for {
+		a.mu.Lock()
+		for len(a.queue) == 0 && !a.closed {
+			a.cond.Wait()
+		}
+		if a.flushInterval > 0 {
+			a.fill()
+		}
+		futs := make([]*Future, 0, len(a.queue))
+		for _, f := range a.queue {
+			if !f.isDone() {
+				futs = append(futs, f)
+			}
+		}
+		a.queue, a.inflight, a.queued = nil, futs, 0
+		closed := a.closed
+		a.mu.Unlock()
+
+		if len(futs) > 0 {
+			b := make(Batch, len(futs))
+			for i, f := range futs {
+				b[i] = f.cmd
+			}
+			replies := b.Pipeline(a.c)
+
+			// Room is made before completing the futures, since their
+			// callbacks may be waiting to send more commands
+			a.mu.Lock()
+			a.inflight = nil
+			a.space.Broadcast()
+			a.mu.Unlock()
+			for i, r := range replies {
+				futs[i].complete(r)
+			}
+		}
+		if closed {
+			return
+		}
+	}
+}
+
+// fill waits for more commands to be queued, until FlushInterval has passed or
+// FlushBytes have been queued, see AsyncOptions. a.mu must be held.
+func (a *AsyncClient) fill() {
+	expired := false
+	t := time.AfterFunc(a.flushInterval, func() {
+		a.mu.Lock()
+		expired = true
+		a.cond.Signal()
+		a.mu.Unlock()
+	})
+	defer t.Stop()
+	for !expired && !a.closed && len(a.queue) > 0 && !a.full() &&
+		(a.flushBytes <= 0 || a.queued < a.flushBytes) {
+		a.cond.Wait()
+	}
+}
+
+// blocking sends the future's command on a dedicated connection in the
+// background
+func (a *AsyncClient) blocking(f *Future) {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		f.complete(&Reply{Type: ErrorReply, Err: AsyncClosedError})
+		return
+	}
+	var conn *Client
+	if n := len(a.idle); n > 0 {
+		conn, a.idle = a.idle[n-1], a.idle[:n-1]
+		a.busy[conn] = true
+	}
+	a.blocked[f] = conn
+	a.blockedWG.Add(1)
+	a.mu.Unlock()
+
+	go func() {
+		defer a.blockedWG.Done()
+		if conn == nil {
+			var err error
+			if conn, err = a.settings.dialLike(a.cfg); err != nil {
+				if a.unblock(f) {
+					f.complete(&Reply{Type: ErrorReply, Err: err})
+				}
+				return
+			}
+			a.mu.Lock()
+			_, waiting := a.blocked[f]
+			switch {
+			case a.closed:
+				delete(a.blocked, f)
+				a.mu.Unlock()
+				conn.Close()
+				f.complete(&Reply{Type: ErrorReply, Err: AsyncClosedError})
+				return
+			case !waiting:
+				// Cancelled while dialing, the connection is still good
+				a.idle = append(a.idle, conn)
+				a.mu.Unlock()
+				return
+			}
+			a.blocked[f] = conn
+			a.busy[conn] = true
+			a.mu.Unlock()
+		}
+
+		r := conn.WithTimeout(NoTimeout).Cmd(f.cmd.Name, f.cmd.Args...)
+		a.mu.Lock()
+		_, waiting := a.blocked[f]
+		delete(a.blocked, f)
+		delete(a.busy, conn)
+		_, cmdErr := r.Err.(*CmdError)
+		if a.closed || !waiting || (r.Err != nil && !cmdErr) {
+			// A cancelled command's connection was closed by cancelBlocking
+			conn.Close()
+		} else {
+			a.idle = append(a.idle, conn)
+		}
+		a.mu.Unlock()
+		if waiting {
+			f.complete(r)
+		}
+	}()
+}
+
+// unblock removes f from the futures waiting on dedicated connections,
+// returning whether it was still waiting
+func (a *AsyncClient) unblock(f *Future) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, waiting := a.blocked[f]
+	delete(a.blocked, f)
+	return waiting
+}
+
+// cancelBlocking cancels a blocking command's future, see Future.Cancel. The
+// command's connection is closed, which fails the command and stops it from
+// waiting on the server any longer.
+func (a *AsyncClient) cancelBlocking(f *Future) bool {
+	a.mu.Lock()
+	conn, waiting := a.blocked[f]
+	delete(a.blocked, f)
+	a.mu.Unlock()
+	if !waiting {
+		return false
+	}
+	if conn != nil {
+		conn.Close()
+	}
+	return f.complete(&Reply{Type: ErrorReply, Err: CanceledError})
+}
+
+// CancelAll cancels all the futures which are still waiting for their reply,
+// see Future.Cancel
+func (a *AsyncClient) CancelAll() {
+	a.mu.Lock()
+	futs := append(a.queue, a.inflight...)
+	for f := range a.blocked {
+		futs = append(futs, f)
+	}
+	a.queue, a.queued = nil, 0
+	a.space.Broadcast()
+	a.mu.Unlock()
+
+	// Cancelling runs callbacks, which may well send more commands
+	for _, f := range futs {
+		f.Cancel()
+	}
+}
+
+// Close waits for the replies to all the commands which were already sent, and
+// for the callbacks running on the workers, and then closes the connection.
+// Blocking commands still waiting on their dedicated connections are not
+// waited for; the connections are closed, failing the commands.
+// Callbacks added after Close are run by the routine adding them. Close must
+// not be called from a callback.
+func (a *AsyncClient) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	a.cond.Signal()
+	a.space.Broadcast()
+	for _, conn := range a.idle {
+		conn.Close()
+	}
+	for conn := range a.busy {
+		conn.Close()
+	}
+	a.idle = nil
+	a.mu.Unlock()
+	<-a.stopped
+	a.blockedWG.Wait()
+	close(a.cbStop)
+	a.cbWG.Wait()
+	return a.c.Close()
+}
@@ -0,0 +1,374 @@
+package redis
+
+import (
+	"bufio"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	. "testing"
+	"time"
+)
+
+// stalledConn holds up reads until it's released, so that commands sent on it
+// look like they're waiting on redis
+type stalledConn struct {
+	net.Conn
+	release chan struct{}
+}
+
+func (s stalledConn) Read(b []byte) (int, error) {
+	<-s.release
+	return s.Conn.Read(b)
+}
+
+// stall has reads on c's connection wait until the returned function is called
+func stall(c *Client) func() {
+	s := stalledConn{c.Conn, make(chan struct{})}
+	c.Conn, c.conn, c.reader = s, s, bufio.NewReaderSize(s, bufSize)
+	var once sync.Once
+	return func() { once.Do(func() { close(s.release) }) }
+}
+
+func TestAsyncClient(t *T) {
+	a := NewAsyncClient(dial(t))
+	a.Cmd("DEL", "async:counter")
+
+	var wg sync.WaitGroup
+	futs := make([]*Future, 50)
+	for i := range futs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			futs[i] = a.Cmd("INCR", "async:counter")
+		}(i)
+	}
+	wg.Wait()
+
+	seen := map[int64]bool{}
+	for _, f := range futs {
+		n, err := f.Reply().Int64()
+		assert.Nil(t, err)
+		seen[n] = true
+	}
+	assert.Equal(t, 50, len(seen))
+
+	// The replies come back in the same order the commands were sent
+	for i := 0; i < 10; i++ {
+		a.Cmd("SET", "async:key", i)
+		s, err := a.Cmd("GET", "async:key").Reply().Str()
+		assert.Nil(t, err)
+		assert.Equal(t, strconv.Itoa(i), s)
+	}
+
+	a.Cmd("DEL", "async:counter", "async:key")
+	assert.Nil(t, a.Close())
+	assert.Equal(t, AsyncClosedError, a.Cmd("PING").Reply().Err)
+}
+
+func TestFutureDone(t *T) {
+	a := NewAsyncClient(dial(t))
+	defer a.Close()
+
+	f1 := a.Cmd("ECHO", "one")
+	f2 := a.Cmd("ECHO", "two")
+	got := map[string]bool{}
+	for len(got) < 2 {
+		select {
+		case <-f1.Done():
+			s, _ := f1.Reply().Str()
+			got[s] = true
+			f1 = &Future{}
+		case <-f2.Done():
+			s, _ := f2.Reply().Str()
+			got[s] = true
+			f2 = &Future{}
+		case <-time.After(5 * time.Second):
+			t.Fatal("futures took too long")
+		}
+	}
+	assert.True(t, got["one"] && got["two"])
+
+	// A command which blocks doesn't complete until it's done
+	f := a.Cmd("BLPOP", "async:empty", 1)
+	select {
+	case <-f.Done():
+		t.Fatal("BLPOP completed too soon")
+	case <-time.After(100 * time.Millisecond):
+	}
+	<-f.Done()
+	assert.Equal(t, NilReply, f.Reply().Type)
+}
+
+func TestFutureCancel(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "async:cancel")
+	release := stall(c)
+	a := NewAsyncClient(c)
+	defer a.Close()
+	defer release()
+
+	// Cancel a command which is waiting on redis
+	f := a.Cmd("INCR", "async:cancel")
+	time.Sleep(50 * time.Millisecond)
+	assert.True(t, f.Cancel())
+	assert.False(t, f.Cancel())
+	assert.Equal(t, CanceledError, f.Reply().Err)
+
+	// Commands queued behind it are canceled too, and never sent
+	queued := []*Future{a.Cmd("INCR", "async:cancel"), a.Cmd("INCR", "async:cancel")}
+	a.CancelAll()
+	for _, f := range queued {
+		assert.Equal(t, CanceledError, f.Reply().Err)
+	}
+
+	release()
+	s, _ := a.Cmd("GET", "async:cancel").Reply().Str()
+	assert.Equal(t, "1", s)
+
+	// A future which already has its reply can't be canceled
+	f = a.Cmd("PING")
+	<-f.Done()
+	assert.False(t, f.Cancel())
+	assert.Nil(t, f.Reply().Err)
+}
+
+func TestFutureCallbacks(t *T) {
+	a := NewAsyncClientOptions(dial(t), AsyncOptions{CallbackWorkers: 4})
+	a.Cmd("DEL", "async:then")
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var got []string
+	var errs []error
+	wg.Add(3)
+	a.Cmd("SET", "async:then", "foo")
+	a.Cmd("GET", "async:then").Then(func(r *Reply) {
+		// Callbacks can send more commands without blocking the client
+		a.Cmd("APPEND", "async:then", r).Then(func(r *Reply) {
+			defer wg.Done()
+			n, _ := r.Int()
+			mu.Lock()
+			got = append(got, strconv.Itoa(n))
+			mu.Unlock()
+		})
+	}).OnError(func(err error) {
+		t.Fatal(err)
+	})
+	a.Cmd("NOTACOMMAND").Then(func(*Reply) {
+		t.Fatal("Then called for an error reply")
+	}).OnError(func(err error) {
+		defer wg.Done()
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	})
+
+	// A callback added once the reply is already available still runs
+	f := a.Cmd("ECHO", "bar")
+	<-f.Done()
+	f.Then(func(r *Reply) {
+		defer wg.Done()
+		s, _ := r.Str()
+		mu.Lock()
+		got = append(got, s)
+		mu.Unlock()
+	})
+
+	wg.Wait()
+	assert.Equal(t, 2, len(got))
+	assert.True(t, (got[0] == "6" && got[1] == "bar") || (got[0] == "bar" && got[1] == "6"))
+	assert.Equal(t, 1, len(errs))
+	_, ok := errs[0].(*CmdError)
+	assert.True(t, ok)
+
+	a.Cmd("DEL", "async:then")
+	assert.Nil(t, a.Close())
+
+	// After Close callbacks are run straight away
+	ran := false
+	a.Cmd("PING").OnError(func(err error) {
+		ran = err == AsyncClosedError
+	})
+	assert.True(t, ran)
+}
+
+func TestAsyncMaxInFlight(t *T) {
+	c := dial(t)
+	release := stall(c)
+	a := NewAsyncClientOptions(c, AsyncOptions{
+		MaxInFlight:  1,
+		FailWhenFull: true,
+	})
+	f := a.Cmd("PING")
+	assert.Equal(t, QueueFullError, a.Cmd("PING").Reply().Err)
+	release()
+	<-f.Done()
+	assert.Nil(t, a.Cmd("PING").Reply().Err)
+	assert.Nil(t, a.Close())
+
+	c = dial(t)
+	release = stall(c)
+	a = NewAsyncClientOptions(c, AsyncOptions{MaxInFlight: 2})
+	defer a.Close()
+	start := time.Now()
+	a.Cmd("PING")
+	a.Cmd("PING")
+	time.AfterFunc(200*time.Millisecond, release)
+
+	// Blocks until the stalled commands are done
+	f = a.Cmd("PING")
+	assert.True(t, time.Since(start) >= 150*time.Millisecond)
+	assert.Nil(t, f.Reply().Err)
+
+	// Callbacks sending more commands don't hold up the replies they wait on
+	done := make(chan bool)
+	a.Cmd("ECHO", "foo").Then(func(r *Reply) {
+		a.Cmd("ECHO", r).Then(func(r *Reply) {
+			a.Cmd("ECHO", r).Then(func(r *Reply) {
+				done <- true
+			})
+		})
+	})
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("callbacks took too long")
+	}
+}
+
+// countedConn counts the writes made to it
+type countedConn struct {
+	net.Conn
+	writes *int32
+}
+
+func (c countedConn) Write(b []byte) (int, error) {
+	atomic.AddInt32(c.writes, 1)
+	return c.Conn.Write(b)
+}
+
+func TestAsyncFlushInterval(t *T) {
+	c := dial(t)
+	var writes int32
+	c.Conn = countedConn{c.Conn, &writes}
+	c.conn = c.Conn
+	a := NewAsyncClientOptions(c, AsyncOptions{FlushInterval: 100 * time.Millisecond})
+	defer a.Close()
+
+	// Everything sent within the interval goes out together
+	start := time.Now()
+	futs := make([]*Future, 20)
+	for i := range futs {
+		futs[i] = a.Cmd("ECHO", i)
+	}
+	for i, f := range futs {
+		s, err := f.Reply().Str()
+		assert.Nil(t, err)
+		assert.Equal(t, strconv.Itoa(i), s)
+	}
+	assert.True(t, time.Since(start) >= 100*time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&writes))
+
+	// Or as soon as FlushBytes worth have been queued
+	c = dial(t)
+	a = NewAsyncClientOptions(c, AsyncOptions{
+		FlushInterval: time.Minute,
+		FlushBytes:    100,
+	})
+	defer a.Close()
+	f := a.Cmd("ECHO", "foo")
+	select {
+	case <-f.Done():
+		t.Fatal("flushed too soon")
+	case <-time.After(10 * time.Millisecond):
+	}
+	start = time.Now()
+	a.Cmd("SET", "async:flush", make([]byte, 100))
+	assert.Nil(t, f.Reply().Err)
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestAsyncBlocking(t *T) {
+	a := NewAsyncClient(dial(t))
+	a.Cmd("DEL", "async:blocking").Reply()
+
+	// The BLPOP doesn't hold up the commands sent after it
+	blpop := a.Cmd("BLPOP", "async:blocking", 5)
+	assert.Nil(t, a.Cmd("RPUSH", "async:blocking", "x").Reply().Err)
+	l, err := blpop.Reply().List()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"async:blocking", "x"}, l)
+
+	// The dedicated connection is reused, and closed along with the client
+	// even while a command is blocked on it
+	blpop = a.Cmd("BLPOP", "async:blocking", 0)
+	time.Sleep(50 * time.Millisecond)
+	a.mu.Lock()
+	assert.Equal(t, 0, len(a.idle))
+	assert.Equal(t, 1, len(a.busy))
+	a.mu.Unlock()
+
+	// CancelAll includes commands on the dedicated connections
+	a.CancelAll()
+	assert.Equal(t, CanceledError, blpop.Reply().Err)
+
+	blpop = a.Cmd("BLPOP", "async:blocking", 0)
+	time.Sleep(50 * time.Millisecond)
+	assert.Nil(t, a.Close())
+	assert.NotNil(t, blpop.Reply().Err)
+	assert.Equal(t, AsyncClosedError, a.Cmd("BLPOP", "async:blocking", 0).Reply().Err)
+}
+
+func TestAsyncBlockingPrefix(t *T) {
+	c := dial(t)
+	c.SetKeyPrefix("async:prefix:")
+	a := NewAsyncClient(c)
+	defer a.Close()
+	a.Cmd("DEL", "list").Reply()
+
+	// The dedicated connection prefixes the key, and unprefixes the reply,
+	// the same as the wrapped client
+	blpop := a.Cmd("BLPOP", "list", 5)
+	assert.Nil(t, a.Cmd("RPUSH", "list", "x").Reply().Err)
+	l, err := blpop.Reply().List()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"list", "x"}, l)
+}
+
+func TestAsyncBlockingCancel(t *T) {
+	a := NewAsyncClient(dial(t))
+	defer a.Close()
+	a.Cmd("DEL", "async:cancel").Reply()
+
+	// Cancelling closes the command's connection, which stops it on the
+	// server (so it can't pop a value which would then be lost), and the
+	// connection isn't reused
+	blpop := a.Cmd("BLPOP", "async:cancel", 0)
+	time.Sleep(50 * time.Millisecond)
+	a.mu.Lock()
+	conn := a.blocked[blpop]
+	a.mu.Unlock()
+	assert.NotNil(t, conn)
+	assert.True(t, blpop.Cancel())
+	assert.Equal(t, CanceledError, blpop.Reply().Err)
+	assert.False(t, blpop.Cancel())
+	a.blockedWG.Wait()
+	_, err := conn.Conn.Write([]byte("PING\r\n"))
+	assert.NotNil(t, err)
+	a.mu.Lock()
+	assert.Equal(t, 0, len(a.idle))
+	assert.Equal(t, 0, len(a.busy))
+	assert.Equal(t, 0, len(a.blocked))
+	a.mu.Unlock()
+
+	// A command whose reply has already arrived can't be cancelled
+	a.Cmd("DEL", "async:cancel2").Reply()
+	assert.Nil(t, a.Cmd("RPUSH", "async:cancel2", "x").Reply().Err)
+	blpop = a.Cmd("BLPOP", "async:cancel2", 0)
+	l, err := blpop.Reply().List()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"async:cancel2", "x"}, l)
+	assert.False(t, blpop.Cancel())
+}
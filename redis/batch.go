@@ -0,0 +1,166 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+)
+
+// WatchConflictError is returned when a MULTI/EXEC block isn't run because a
+// key which was WATCHed was changed by someone else, see Batch.Multi and
+// Client.Transaction
+var WatchConflictError = errors.New("transaction aborted, a watched key was changed")
+
+// Cmd is a single command and its arguments, as held in a Batch
+type Cmd struct {
+	Name string
+	Args []interface{}
+}
+
+// Batch is a list of commands, which can be built up ahead of time and then
+// run in whichever way suits: pipelined on a single connection, atomically
+// in a MULTI/EXEC block, one at a time on any Commander, or split across the
+// nodes of a cluster (see cluster.Cluster.Batch).
+//
+//	var b redis.Batch
+//	b.Add("INCR", "hits")
+//	b.Add("HSET", "last", "page", page)
+//	replies := b.Pipeline(conn)
+type Batch []Cmd
+
+// Add adds a command to the end of the batch
+func (b *Batch) Add(cmd string, args ...interface{}) {
+	*b = append(*b, Cmd{cmd, args})
+}
+
+// Pipeline sends all the commands in the batch to c at once, and returns their
+// replies in the same order. Commands pipelined on c with Append are left
+// alone, their replies can still be read with GetReply afterwards. If a
+// connection error happens the rest of the replies have it as well.
+func (b Batch) Pipeline(c *Client) []*Reply {
+	replies := make([]*Reply, len(b))
+	if len(b) == 0 {
+		return replies
+	}
+	reqs := make([]*request, len(b))
+	for i, cmd := range b {
+		reqs[i] = c.newRequest(cmd.Name, cmd.Args)
+	}
+	err := c.prepare()
+	if err == nil {
+		replies, err = c.pipeline(reqs)
+	}
+	if err != nil {
+		replies = make([]*Reply, len(b))
+		for i := range replies {
+			replies[i] = &Reply{Type: ErrorReply, Err: err}
+		}
+		return replies
+	}
+	for i, r := range replies {
+		if _, ok := r.Err.(*CmdError); r.Err != nil && !ok {
+			for j := i + 1; j < len(replies); j++ {
+				replies[j] = &Reply{Type: ErrorReply, Err: r.Err}
+			}
+			break
+		}
+	}
+	return replies
+}
+
+// QueueError is returned by Batch.Multi when redis refused to queue one of the
+// commands in the batch (e.g. due to a wrong number of arguments), so none of
+// them were run
+type QueueError struct {
+	Index int    // The index in the batch of the first command refused
+	Cmd   string // That command's name
+	Err   error  // The error redis refused it with
+}
+
+func (e *QueueError) Error() string {
+	return fmt.Sprintf("command %d (%s) could not be queued: %s", e.Index, e.Cmd, e.Err)
+}
+
+// Unwrap returns the error the command was refused with, usually a *CmdError
+func (e *QueueError) Unwrap() error {
+	return e.Err
+}
+
+// Multi runs all the commands in the batch on c in a single MULTI/EXEC block,
+// so they're run atomically, and returns their replies. The whole block is
+// pipelined. Errors hit while running the commands (e.g. WRONGTYPE) are in
+// their replies as usual.
+//
+// If any of the commands can't be queued none of them are run, and a
+// *QueueError for the first one which couldn't be is returned. The replies are
+// returned as well, so each command's fate can be seen: those which were
+// refused have the error they were refused with, and the rest have the error
+// EXEC was aborted with.
+//
+//	replies, err := b.Multi(conn)
+//	if qerr, ok := err.(*redis.QueueError); ok {
+//		log.Printf("%s refused: %s", qerr.Cmd, replies[qerr.Index].Err)
+//	}
+//
+// If a key which was WATCHed beforehand was changed, so the block wasn't run,
+// WatchConflictError is returned. If c already has a MULTI block open nothing
+// is sent, and NestedMultiError is returned.
+func (b Batch) Multi(c *Client) ([]*Reply, error) {
+	if c.multi {
+		// Otherwise the batch would be queued into, and the EXEC would run,
+		// the block which is already open
+		return nil, NestedMultiError
+	}
+	mb := make(Batch, 0, len(b)+2)
+	mb.Add("MULTI")
+	mb = append(mb, b...)
+	mb.Add("EXEC")
+
+	replies := mb.Pipeline(c)
+	if err := replies[0].Err; err != nil {
+		return nil, err
+	}
+	queued := replies[1 : len(replies)-1]
+	exec := replies[len(replies)-1]
+	var queueErr *QueueError
+	for i, r := range queued {
+		if r.Err != nil && queueErr == nil {
+			queueErr = &QueueError{Index: i, Cmd: b[i].Name, Err: r.Err}
+		}
+	}
+	if queueErr != nil {
+		if _, ok := queueErr.Err.(*CmdError); !ok {
+			// A connection error, so the rest of the replies have it too
+			return nil, queueErr.Err
+		}
+		abortErr := exec.Err
+		if abortErr == nil {
+			abortErr = ExecAbortError
+		}
+		for i, r := range queued {
+			if r.Err == nil {
+				queued[i] = &Reply{Type: ErrorReply, Err: abortErr}
+			}
+		}
+		return queued, queueErr
+	}
+	if exec.Err != nil {
+		return nil, exec.Err
+	}
+	if exec.Type == NilReply {
+		return nil, WatchConflictError
+	}
+	if exec.Type != MultiReply || len(exec.Elems) != len(b) {
+		return nil, errors.New("EXEC reply does not have a reply for each command")
+	}
+	return exec.Elems, nil
+}
+
+// Run runs the commands in the batch one at a time on c, returning their
+// replies in the same order
+func (b Batch) Run(c Commander) []*Reply {
+	replies := make([]*Reply, len(b))
+	for i, cmd := range b {
+		replies[i] = c.Cmd(cmd.Name, cmd.Args...)
+	}
+	return replies
+}
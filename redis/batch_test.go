@@ -0,0 +1,134 @@
+package redis
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	. "testing"
+)
+
+func testBatch() Batch {
+	var b Batch
+	b.Add("DEL", "batch:n")
+	b.Add("INCR", "batch:n")
+	b.Add("INCRBY", "batch:n", 5)
+	b.Add("GET", "batch:n")
+	return b
+}
+
+func TestBatchPipeline(t *T) {
+	c := dial(t)
+	replies := testBatch().Pipeline(c)
+	assert.Equal(t, 4, len(replies))
+	n, _ := replies[2].Int()
+	assert.Equal(t, 6, n)
+	s, _ := replies[3].Str()
+	assert.Equal(t, "6", s)
+
+	// Command errors don't affect the rest
+	var b Batch
+	b.Add("INCR")
+	b.Add("ECHO", "foo")
+	replies = b.Pipeline(c)
+	_, ok := replies[0].Err.(*CmdError)
+	assert.True(t, ok)
+	s, _ = replies[1].Str()
+	assert.Equal(t, "foo", s)
+
+	// Commands pipelined with Append, read or not, are left alone
+	c.Append("ECHO", "first")
+	c.Append("ECHO", "second")
+	s, _ = c.GetReply().Str()
+	assert.Equal(t, "first", s)
+	replies = testBatch().Pipeline(c)
+	s, _ = replies[3].Str()
+	assert.Equal(t, "6", s)
+	s, _ = c.GetReply().Str()
+	assert.Equal(t, "second", s)
+	assert.Equal(t, PipelineQueueEmptyError, c.GetReply().Err)
+
+	// Connection errors do
+	c.Close()
+	replies = b.Pipeline(c)
+	assert.Equal(t, 2, len(replies))
+	for _, r := range replies {
+		_, ok = r.Err.(*CmdError)
+		assert.False(t, ok)
+		assert.NotNil(t, r.Err)
+	}
+}
+
+func TestPipelineChunks(t *T) {
+	c := dial(t)
+	var writes int32
+	c.Conn = countedConn{c.Conn, &writes}
+	c.conn = c.Conn
+	c.SetMaxPipelineSize(3)
+
+	var b Batch
+	b.Add("DEL", "batch:chunks")
+	for i := 0; i < 9; i++ {
+		b.Add("INCR", "batch:chunks")
+	}
+	b.Add("NOTACOMMAND")
+	replies := b.Pipeline(c)
+	assert.Equal(t, 11, len(replies))
+	for i := 1; i < 10; i++ {
+		n, err := replies[i].Int()
+		assert.Nil(t, err)
+		assert.Equal(t, i, n)
+	}
+	assert.NotNil(t, replies[10].Err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&writes))
+
+	// Without a limit it's all written at once
+	atomic.StoreInt32(&writes, 0)
+	c.SetMaxPipelineSize(-1)
+	replies = b.Pipeline(c)
+	n, _ := replies[9].Int()
+	assert.Equal(t, 9, n)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&writes))
+}
+
+func TestBatchMulti(t *T) {
+	c := dial(t)
+	replies, err := testBatch().Multi(c)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(replies))
+	s, _ := replies[3].Str()
+	assert.Equal(t, "6", s)
+
+	b := testBatch()
+	b.Add("INCRBY", "batch:n")
+	b.Add("GET", "batch:n")
+	replies, err = b.Multi(c)
+	qerr, ok := err.(*QueueError)
+	assert.True(t, ok)
+	assert.Equal(t, 4, qerr.Index)
+	assert.Equal(t, "INCRBY", qerr.Cmd)
+	assert.True(t, errors.Is(err, GenericError))
+	assert.Equal(t, 6, len(replies))
+	assert.Equal(t, qerr.Err, replies[4].Err)
+	for _, i := range []int{0, 1, 2, 3, 5} {
+		assert.True(t, errors.Is(replies[i].Err, ExecAbortError))
+	}
+	assert.Equal(t, "6", c.Cmd("GET", "batch:n").String())
+	assert.Nil(t, c.Cmd("PING").Err)
+
+	// Inside an already open block nothing is sent, so nothing is queued
+	// into it
+	assert.Nil(t, c.Cmd("MULTI").Err)
+	_, err = testBatch().Multi(c)
+	assert.Equal(t, NestedMultiError, err)
+	r := c.Cmd("EXEC")
+	assert.Nil(t, r.Err)
+	assert.Equal(t, 0, len(r.Elems))
+}
+
+func TestBatchRun(t *T) {
+	c := dial(t)
+	replies := testBatch().Run(c)
+	assert.Equal(t, 4, len(replies))
+	s, _ := replies[3].Str()
+	assert.Equal(t, "6", s)
+}
@@ -0,0 +1,140 @@
+package redis
+
+import (
+	"errors"
+	"strconv"
+)
+
+// BitFieldOverflow is an overflow policy for the increments of a BitField
+type BitFieldOverflow string
+
+const (
+	// Wrap around on overflow and underflow, the default
+	OverflowWrap BitFieldOverflow = "WRAP"
+
+	// Saturate at the minimum or maximum value of the type
+	OverflowSat BitFieldOverflow = "SAT"
+
+	// Leave the value unchanged, see BitFieldOverflowError
+	OverflowFail BitFieldOverflow = "FAIL"
+)
+
+// BitFieldOverflowError is returned by BitField.Run when an increment was not
+// done because it would have overflowed, with the OverflowFail policy
+var BitFieldOverflowError = errors.New("bitfield increment overflowed")
+
+// BitField builds up the operations of a BITFIELD command. Operations are run
+// in the order they're added, and each one's type is a signed (e.g. "i8", up to
+// "i64") or unsigned (e.g. "u4", up to "u63") integer of the given number of
+// bits. Offsets are in bits, from the start of the string.
+//
+//	vals, err := redis.NewBitField("counters").
+//		Overflow(redis.OverflowSat).
+//		IncrBy("u8", 0, 10).
+//		Get("u4", 8).
+//		Run(client)
+//
+// An invalid type, offset or policy is returned by Run without anything being
+// sent.
+type BitField struct {
+	key  string
+	args []interface{}
+	ops  int
+	err  error
+}
+
+// NewBitField returns an empty BitField for the string at key
+func NewBitField(key string) *BitField {
+	return &BitField{key: key}
+}
+
+func (b *BitField) add(typ string, offset int64, args ...interface{}) *BitField {
+	if b.err == nil {
+		if !bitFieldType(typ) {
+			b.err = errors.New("invalid bitfield type " + strconv.Quote(typ))
+		} else if offset < 0 {
+			b.err = errors.New("bitfield offset can not be negative")
+		}
+	}
+	b.args = append(b.args, args...)
+	b.ops++
+	return b
+}
+
+// bitFieldType returns whether typ is a type redis supports
+func bitFieldType(typ string) bool {
+	if len(typ) < 2 || (typ[0] != 'i' && typ[0] != 'u') {
+		return false
+	}
+	bits, err := strconv.Atoi(typ[1:])
+	if err != nil || bits < 1 {
+		return false
+	}
+	return (typ[0] == 'i' && bits <= 64) || (typ[0] == 'u' && bits <= 63)
+}
+
+// Get adds an operation returning the value at offset
+func (b *BitField) Get(typ string, offset int64) *BitField {
+	return b.add(typ, offset, "GET", typ, offset)
+}
+
+// Set adds an operation setting the value at offset, returning its old value
+func (b *BitField) Set(typ string, offset, value int64) *BitField {
+	return b.add(typ, offset, "SET", typ, offset, value)
+}
+
+// IncrBy adds an operation incrementing the value at offset, returning the new
+// value
+func (b *BitField) IncrBy(typ string, offset, incr int64) *BitField {
+	return b.add(typ, offset, "INCRBY", typ, offset, incr)
+}
+
+// Overflow sets the overflow policy for the increments added after it
+func (b *BitField) Overflow(policy BitFieldOverflow) *BitField {
+	switch policy {
+	case OverflowWrap, OverflowSat, OverflowFail:
+	default:
+		if b.err == nil {
+			b.err = errors.New("invalid bitfield overflow policy " + strconv.Quote(string(policy)))
+		}
+	}
+	b.args = append(b.args, "OVERFLOW", string(policy))
+	return b
+}
+
+// Run sends the BITFIELD command and returns the result of each Get, Set and
+// IncrBy in order. If an increment failed due to OverflowFail its result is
+// zero, and BitFieldOverflowError is returned along with all the results.
+func (b *BitField) Run(c *Client) ([]int64, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	r := c.Cmd("BITFIELD", append([]interface{}{b.key}, b.args...)...)
+	return parseBitField(r, b.ops)
+}
+
+func parseBitField(r *Reply, ops int) ([]int64, error) {
+	if r.Type == ErrorReply {
+		return nil, r.Err
+	}
+	if r.Type != MultiReply {
+		return nil, errors.New("reply type is not MultiReply")
+	}
+	if len(r.Elems) != ops {
+		return nil, errors.New("reply does not have a result for every operation")
+	}
+	vals := make([]int64, len(r.Elems))
+	var err error
+	for i, e := range r.Elems {
+		if e.Type == NilReply {
+			err = BitFieldOverflowError
+			continue
+		}
+		v, ierr := e.Int64()
+		if ierr != nil {
+			return nil, ierr
+		}
+		vals[i] = v
+	}
+	return vals, err
+}
@@ -0,0 +1,65 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func TestBitFieldArgs(t *T) {
+	b := NewBitField("bits").
+		Set("i8", 0, 100).
+		Overflow(OverflowFail).
+		IncrBy("u2", 8, 1).
+		Get("u63", 16)
+	assert.Nil(t, b.err)
+	assert.Equal(t, 3, b.ops)
+	assert.Equal(t, []interface{}{
+		"SET", "i8", int64(0), int64(100),
+		"OVERFLOW", "FAIL",
+		"INCRBY", "u2", int64(8), int64(1),
+		"GET", "u63", int64(16),
+	}, b.args)
+
+	for _, b := range []*BitField{
+		NewBitField("bits").Get("u64", 0),
+		NewBitField("bits").Get("i0", 0),
+		NewBitField("bits").Get("x8", 0),
+		NewBitField("bits").Get("i", 0),
+		NewBitField("bits").Get("i8", -1),
+		NewBitField("bits").Overflow("NOPE"),
+	} {
+		_, err := b.Run(nil)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestParseBitField(t *T) {
+	vals, err := parseBitField(NewReply([]interface{}{int64(1), int64(-2)}), 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{1, -2}, vals)
+
+	vals, err = parseBitField(NewReply([]interface{}{int64(1), nil}), 2)
+	assert.Equal(t, BitFieldOverflowError, err)
+	assert.Equal(t, []int64{1, 0}, vals)
+
+	_, err = parseBitField(NewReply([]interface{}{int64(1)}), 2)
+	assert.NotNil(t, err)
+}
+
+func TestBitField(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "bitfield:key")
+	vals, err := NewBitField("bitfield:key").
+		Set("u8", 0, 250).
+		Overflow(OverflowSat).
+		IncrBy("u8", 0, 10).
+		Overflow(OverflowFail).
+		IncrBy("u8", 0, 1).
+		Get("u4", 0).
+		Run(c)
+	if isUnknownCommand(err) {
+		t.Skip("BITFIELD is not supported")
+	}
+	assert.Equal(t, BitFieldOverflowError, err)
+	assert.Equal(t, []int64{0, 255, 0, 15}, vals)
+}
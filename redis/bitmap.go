@@ -0,0 +1,126 @@
+package redis
+
+// BitUnit is the unit of the start and end of a BitRange
+type BitUnit string
+
+const (
+	// Bytes are the default unit
+	BitUnitByte BitUnit = "BYTE"
+
+	// Redis 7.0 and up
+	BitUnitBit BitUnit = "BIT"
+)
+
+// BitRange limits BitCount and BitPos to part of a bitmap. Start and End are
+// inclusive, and negative values count back from the end. If Unit is empty no
+// unit is given, meaning bytes.
+type BitRange struct {
+	Start, End int64
+	Unit       BitUnit
+}
+
+func (r *BitRange) args() []interface{} {
+	if r == nil {
+		return nil
+	}
+	args := []interface{}{r.Start, r.End}
+	if r.Unit != "" {
+		args = append(args, string(r.Unit))
+	}
+	return args
+}
+
+// BitOpType is an operation for BitOp
+type BitOpType string
+
+const (
+	BitAnd BitOpType = "AND"
+	BitOr  BitOpType = "OR"
+	BitXor BitOpType = "XOR"
+	BitNot BitOpType = "NOT"
+)
+
+// SetBit sets or clears the bit at offset in the string at key, returning its
+// old value
+func (c *Client) SetBit(key string, offset int64, value bool) (bool, error) {
+	return c.Cmd("SETBIT", key, offset, value).Bool()
+}
+
+// GetBit returns the bit at offset in the string at key
+func (c *Client) GetBit(key string, offset int64) (bool, error) {
+	return c.Cmd("GETBIT", key, offset).Bool()
+}
+
+// BitCount returns the number of set bits in the string at key, or in the part
+// of it given by rng if it's not nil
+func (c *Client) BitCount(key string, rng *BitRange) (int64, error) {
+	return c.Cmd("BITCOUNT", key, rng.args()).Int64()
+}
+
+// BitPos returns the position of the first bit set to bit in the string at key,
+// or in the part of it given by rng if it's not nil. If there is none -1 is
+// returned, except when looking for a clear bit without an end to the range,
+// since redis then considers the string to be padded with clear bits.
+func (c *Client) BitPos(key string, bit bool, rng *BitRange) (int64, error) {
+	return c.Cmd("BITPOS", key, bit, rng.args()).Int64()
+}
+
+// BitOp stores the result of the bitwise operation on the strings at keys in
+// dest, returning the length of the result in bytes. BitNot takes a single key.
+func (c *Client) BitOp(op BitOpType, dest string, keys ...string) (int64, error) {
+	return c.Cmd("BITOP", string(op), dest, keys).Int64()
+}
+
+// Bitmap is the raw value of a string treated as a bitmap, as returned by
+// Client.Bitmap. Bits are numbered the same way as by SETBIT, starting with the
+// most significant bit of the first byte.
+type Bitmap []byte
+
+// Bitmap returns the whole string at key as a Bitmap. A missing key is an empty
+// Bitmap.
+func (c *Client) Bitmap(key string) (Bitmap, error) {
+	r := c.Cmd("GET", key)
+	if r.Type == NilReply {
+		return Bitmap{}, nil
+	}
+	b, err := r.Bytes()
+	return Bitmap(b), err
+}
+
+// Len returns the number of bits in the bitmap
+func (b Bitmap) Len() int64 {
+	return int64(len(b)) * 8
+}
+
+// Get returns the bit at offset, which is false past the end of the bitmap
+func (b Bitmap) Get(offset int64) bool {
+	if offset < 0 || offset >= b.Len() {
+		return false
+	}
+	return b[offset/8]&(0x80>>uint(offset%8)) != 0
+}
+
+// Bools returns every bit of the bitmap
+func (b Bitmap) Bools() []bool {
+	bools := make([]bool, b.Len())
+	for i := range bools {
+		bools[i] = b.Get(int64(i))
+	}
+	return bools
+}
+
+// Each calls fn with the offset of each set bit in order, stopping early if fn
+// returns false. Clear bytes are skipped over, so sparse bitmaps are cheap to
+// iterate.
+func (b Bitmap) Each(fn func(offset int64) bool) {
+	for i, by := range b {
+		if by == 0 {
+			continue
+		}
+		for j := int64(0); j < 8; j++ {
+			if by&(0x80>>uint(j)) != 0 && !fn(int64(i)*8+j) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func TestBitRangeArgs(t *T) {
+	var rng *BitRange
+	assert.Nil(t, rng.args())
+	assert.Equal(t, []interface{}{int64(0), int64(-1)}, (&BitRange{0, -1, ""}).args())
+	assert.Equal(t,
+		[]interface{}{int64(1), int64(9), "BIT"},
+		(&BitRange{1, 9, BitUnitBit}).args(),
+	)
+}
+
+func TestBitmapType(t *T) {
+	b := Bitmap{0x81, 0x00, 0x20}
+	assert.Equal(t, int64(24), b.Len())
+	assert.True(t, b.Get(0))
+	assert.True(t, b.Get(7))
+	assert.False(t, b.Get(8))
+	assert.True(t, b.Get(18))
+	assert.False(t, b.Get(100))
+	assert.False(t, b.Get(-1))
+
+	bools := b.Bools()
+	assert.Equal(t, 24, len(bools))
+	assert.True(t, bools[18])
+
+	var offsets []int64
+	b.Each(func(offset int64) bool {
+		offsets = append(offsets, offset)
+		return true
+	})
+	assert.Equal(t, []int64{0, 7, 18}, offsets)
+
+	offsets = nil
+	b.Each(func(offset int64) bool {
+		offsets = append(offsets, offset)
+		return len(offsets) < 2
+	})
+	assert.Equal(t, []int64{0, 7}, offsets)
+}
+
+func TestBitmap(t *T) {
+	c := dial(t)
+	key := "bitmap:key"
+	c.Cmd("DEL", key, "bitmap:dest")
+
+	old, err := c.SetBit(key, 7, true)
+	assert.Nil(t, err)
+	assert.False(t, old)
+	c.SetBit(key, 12, true)
+
+	set, err := c.GetBit(key, 7)
+	assert.Nil(t, err)
+	assert.True(t, set)
+
+	n, err := c.BitCount(key, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+	n, err = c.BitCount(key, &BitRange{Start: 1, End: -1})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	pos, err := c.BitPos(key, true, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), pos)
+	pos, err = c.BitPos(key, true, &BitRange{Start: 1, End: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(12), pos)
+
+	n, err = c.BitOp(BitNot, "bitmap:dest", key)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+
+	b, err := c.Bitmap(key)
+	assert.Nil(t, err)
+	var offsets []int64
+	b.Each(func(offset int64) bool {
+		offsets = append(offsets, offset)
+		return true
+	})
+	assert.Equal(t, []int64{7, 12}, offsets)
+
+	b, err = c.Bitmap("bitmap:missing")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), b.Len())
+}
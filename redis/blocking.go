@@ -0,0 +1,74 @@
+package redis
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fzzy/radix/redis/resp"
+)
+
+// How much longer than a blocking command's own timeout the client waits for
+// its reply, if the client doesn't have a read timeout to use for this
+const blockingMargin = time.Second
+
+// blockingCmd calls a command which blocks on the server for up to the given
+// timeout, zero meaning forever. The client's read timeout is replaced for just
+// this command by the timeout plus a margin (the client's read timeout, or
+// blockingMargin if it doesn't have one), so it isn't hit while redis is still
+// legitimately waiting, and none at all for a timeout of zero. The timeout is
+// appended to args in seconds, which is where most blocking commands take it.
+func (c *Client) blockingCmd(
+	timeout time.Duration, cmd string, args ...interface{},
+) *Reply {
+	return c.blockingCmdArgs(timeout, cmd, append(args, formatSeconds(timeout))...)
+}
+
+// blockingCmdArgs is like blockingCmd, but leaves it to the caller to put the
+// timeout in args
+func (c *Client) blockingCmdArgs(
+	timeout time.Duration, cmd string, args ...interface{},
+) *Reply {
+	d := c.WithOptions(Options{})
+	switch {
+	case timeout == 0:
+		d.readTimeout = NoTimeout
+	case d.readTimeout > 0:
+		d.readTimeout += timeout
+	default:
+		d.readTimeout = timeout + blockingMargin
+	}
+	return d.Cmd(cmd, args...)
+}
+
+// blockingCommands are the commands which may block on the server, and so
+// shouldn't be sent on a connection shared with other commands
+var blockingCommands = map[string]bool{
+	"BLPOP": true, "BRPOP": true, "BRPOPLPUSH": true, "BLMOVE": true,
+	"BLMPOP": true, "BZPOPMIN": true, "BZPOPMAX": true, "BZMPOP": true,
+	"WAIT": true, "WAITAOF": true,
+}
+
+// BlockingCommand returns whether the given command may block on the server
+// waiting for something to happen, including XREAD and XREADGROUP when given
+// BLOCK
+func BlockingCommand(cmd string, args ...interface{}) bool {
+	cmd = strings.ToUpper(cmd)
+	if blockingCommands[cmd] {
+		return true
+	}
+	if cmd == "XREAD" || cmd == "XREADGROUP" {
+		for _, arg := range resp.Flatten(args) {
+			if strings.EqualFold(argString(arg), "BLOCK") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// formatSeconds formats d as a number of seconds, with as much precision as is
+// needed
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
@@ -0,0 +1,14 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func TestBlockingCommand(t *T) {
+	assert.True(t, BlockingCommand("blpop", "a", 0))
+	assert.True(t, BlockingCommand("BZPOPMIN", "a", 0))
+	assert.True(t, BlockingCommand("XREAD", "block", 0, "STREAMS", "s", "$"))
+	assert.False(t, BlockingCommand("XREAD", "STREAMS", "s", "0"))
+	assert.False(t, BlockingCommand("LPOP", "a"))
+}
@@ -0,0 +1,155 @@
+package redis
+
+import (
+	"sync"
+	"time"
+)
+
+// CacheOptions are the options for NewCache
+type CacheOptions struct {
+	// How long the lock taken while loading a value is held for, at most.
+	// Callers which find the lock held wait up to this long for the value to
+	// appear before loading it themselves. Defaults to 5 seconds.
+	LockTTL time.Duration
+
+	// How often a caller waiting for someone else to load a value checks
+	// whether it's there yet. Defaults to 50 milliseconds.
+	PollInterval time.Duration
+}
+
+// Cache implements the cache-aside pattern: values are read from redis, and
+// loaded from somewhere else (e.g. a database) when they're missing, then set
+// in redis for next time.
+//
+// To stop many callers loading the same value at once when a hot key expires
+// (a cache stampede), only one routine per Cache loads a missing value while
+// the others wait for its result, and across processes each load is done
+// while holding a short lived Lock on the key (see NewLock, the lock's name is
+// the cached key). A caller which finds the lock held waits for the value to
+// appear, and only loads it itself if that takes longer than the lock's TTL.
+//
+// A Cache can be used from multiple routines at once if its Commander can be,
+// e.g. a cluster.Cluster or replica.Client.
+type Cache struct {
+	c    Commander
+	opts CacheOptions
+
+	mu    sync.Mutex
+	calls map[string]*cacheCall
+}
+
+// cacheCall is a load in progress, which other callers wanting the same key
+// wait on
+type cacheCall struct {
+	done chan struct{}
+	val  []byte
+	err  error
+}
+
+// NewCache returns a Cache storing its values using c
+func NewCache(c Commander, opts CacheOptions) *Cache {
+	if opts.LockTTL <= 0 {
+		opts.LockTTL = 5 * time.Second
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 50 * time.Millisecond
+	}
+	return &Cache{c: c, opts: opts, calls: map[string]*cacheCall{}}
+}
+
+// get returns the value at key, or nil if there isn't one
+func (ca *Cache) get(key string) ([]byte, error) {
+	r := ca.c.Cmd("GET", key)
+	if r.Type == NilReply {
+		return nil, nil
+	}
+	return r.Bytes()
+}
+
+// Get returns the value at key. If there isn't one, loader is called to load
+// it, and what it returns is set at key with the given TTL (zero meaning no
+// TTL) before being returned. If loader returns an error nothing is set, and
+// the error is returned to every caller which was waiting on that load.
+func (ca *Cache) Get(key string, ttl time.Duration, loader func() ([]byte, error)) (
+	[]byte, error,
+) {
+	if val, err := ca.get(key); err != nil || val != nil {
+		return val, err
+	}
+
+	ca.mu.Lock()
+	if call, ok := ca.calls[key]; ok {
+		ca.mu.Unlock()
+		<-call.done
+		return call.val, call.err
+	}
+	call := &cacheCall{done: make(chan struct{})}
+	ca.calls[key] = call
+	ca.mu.Unlock()
+
+	call.val, call.err = ca.load(key, ttl, loader)
+	ca.mu.Lock()
+	delete(ca.calls, key)
+	ca.mu.Unlock()
+	close(call.done)
+	return call.val, call.err
+}
+
+// load loads the value at key, either by waiting for whoever holds key's lock
+// to do it or by taking the lock and calling loader
+func (ca *Cache) load(key string, ttl time.Duration, loader func() ([]byte, error)) (
+	[]byte, error,
+) {
+	l := NewLock(ca.c, key, ca.opts.LockTTL)
+	ok, err := l.TryLock()
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		defer l.Unlock()
+		// The value may have been set between the first GET and taking the
+		// lock, by whoever held it before
+		if val, err := ca.get(key); err != nil || val != nil {
+			return val, err
+		}
+	} else {
+		deadline := time.Now().Add(ca.opts.LockTTL)
+		for time.Now().Before(deadline) {
+			time.Sleep(ca.opts.PollInterval)
+			if val, err := ca.get(key); err != nil || val != nil {
+				return val, err
+			}
+		}
+	}
+
+	val, err := loader()
+	if err != nil {
+		return nil, err
+	}
+	if val == nil {
+		val = []byte{}
+	}
+	if err = ca.Set(key, val, ttl); err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+// Set sets the value at key with the given TTL (zero meaning no TTL), e.g. to
+// update the cache straight away after changing the value at its source
+func (ca *Cache) Set(key string, val []byte, ttl time.Duration) error {
+	if ttl > 0 {
+		return ca.c.Cmd("SET", key, val, "PX", int64(ttl/time.Millisecond)).Err
+	}
+	return ca.c.Cmd("SET", key, val).Err
+}
+
+// Invalidate removes the values at the keys, so they're loaded again the next
+// time they're asked for
+func (ca *Cache) Invalidate(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return ca.c.Cmd("DEL", keys).Err
+}
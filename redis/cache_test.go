@@ -0,0 +1,114 @@
+package redis
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	. "testing"
+	"time"
+)
+
+// lockedCommander makes a Client safe to use from multiple routines
+type lockedCommander struct {
+	sync.Mutex
+	c *Client
+}
+
+func (l *lockedCommander) Cmd(cmd string, args ...interface{}) *Reply {
+	l.Lock()
+	defer l.Unlock()
+	return l.c.Cmd(cmd, args...)
+}
+
+func TestCache(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "cachetest")
+	ca := NewCache(c, CacheOptions{})
+
+	calls := 0
+	loader := func() ([]byte, error) {
+		calls++
+		return []byte("loaded"), nil
+	}
+	val, err := ca.Get("cachetest", time.Minute, loader)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("loaded"), val)
+	val, err = ca.Get("cachetest", time.Minute, loader)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("loaded"), val)
+	assert.Equal(t, 1, calls)
+	ttl, _ := c.Cmd("PTTL", "cachetest").Int()
+	assert.True(t, ttl > 50000)
+
+	assert.Nil(t, ca.Invalidate("cachetest"))
+	loadErr := errors.New("can't load")
+	_, err = ca.Get("cachetest", time.Minute, func() ([]byte, error) {
+		return nil, loadErr
+	})
+	assert.Equal(t, loadErr, err)
+	assert.Equal(t, NilReply, c.Cmd("GET", "cachetest").Type)
+	assert.Equal(t, NilReply, c.Cmd("GET", "lock:{cachetest}").Type)
+}
+
+func TestCacheSingleflight(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "cachetest:hot")
+	ca := NewCache(&lockedCommander{c: c}, CacheOptions{})
+
+	var calls int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			val, err := ca.Get("cachetest:hot", time.Minute, func() ([]byte, error) {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(50 * time.Millisecond)
+				return []byte("hot"), nil
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, []byte("hot"), val)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestCacheLock(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "cachetest:locked", "lock:{cachetest:locked}")
+
+	// Someone else is loading the value, so it's waited for
+	l := NewLock(dial(t), "cachetest:locked", time.Second)
+	ok, err := l.TryLock()
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	other := dial(t)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		time.Sleep(100 * time.Millisecond)
+		NewCache(other, CacheOptions{}).Set("cachetest:locked", []byte("theirs"), 0)
+		l.Unlock()
+	}()
+
+	ca := NewCache(c, CacheOptions{LockTTL: time.Second})
+	val, err := ca.Get("cachetest:locked", 0, func() ([]byte, error) {
+		return []byte("ours"), nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("theirs"), val)
+	<-done
+
+	// If they take too long it's loaded anyway
+	c.Cmd("DEL", "cachetest:locked")
+	l.TryLock()
+	ca = NewCache(c, CacheOptions{LockTTL: 100 * time.Millisecond})
+	val, err = ca.Get("cachetest:locked", 0, func() ([]byte, error) {
+		return []byte("ours"), nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("ours"), val)
+	l.Unlock()
+}
@@ -2,6 +2,7 @@ package redis
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"net"
 	"strings"
@@ -19,39 +20,96 @@ const (
 var LoadingError error = errors.New("server is busy loading dataset in memory")
 var PipelineQueueEmptyError error = errors.New("pipeline queue empty")
 
+// These are returned when MULTI, EXEC or DISCARD are used out of order. They
+// are detected by the client before anything is sent to redis, so the
+// connection is still usable afterwards.
+var NestedMultiError error = &CmdError{errors.New("ERR MULTI calls can not be nested")}
+var ExecWithoutMultiError error = &CmdError{errors.New("ERR EXEC without MULTI")}
+var DiscardWithoutMultiError error = &CmdError{errors.New("ERR DISCARD without MULTI")}
+
 //* Client
 
 // Client describes a Redis client.
 type Client struct {
 	// The connection the client talks to redis over. Don't touch this unless
 	// you know what you're doing.
-	Conn      net.Conn
-	timeout   time.Duration
+	Conn net.Conn
+	*connState
+
+	// The client this one was derived from using WithOptions, if any
+	parent *Client
+
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	compressor   Compressor
+	compressMin  int
+	codec        Codec
+	keyPrefix    string
+	retry        RetryPolicy
+	largeMin     int
+	largeHook    func(LargeValue)
+	hooks        []Hook
+	cmdTable     CommandTable
+
+	// The number of values written and read which were at least as large as
+	// the threshold given to SetLargeValueThreshold
+	LargeWrites uint64
+	LargeReads  uint64
+}
+
+// connState is the state of a Client's connection, which is shared with all of
+// the clients derived from it
+type connState struct {
 	reader    *bufio.Reader
 	pending   []*request
 	completed []*Reply
+	limits    resp.Limits
+
+	// See SetMaxPipelineSize
+	maxPipeline int
+
+	// How the connection was made, and the state to restore on reconnect. conn
+	// is the current connection, which Reconnect replaces, and broken is set
+	// once it's been closed due to an error.
+	cfg    Config
+	conn   net.Conn
+	broken bool
+
+	// What's left of the blocks replies are being allocated from, see
+	// parseFast
+	scratch scratch
+
+	// Whether or not a MULTI block is currently open on the connection, and
+	// the commands which have been queued in it so far
+	multi  bool
+	queued []string
 }
 
-// request describes a client's request to the redis server
+// request describes a client's request to the redis server. If err is set the
+// request is never sent, and err is returned as its reply instead
 type request struct {
 	cmd  string
 	args []interface{}
+	err  error
+
+	// The client which created the request, whose options apply to it
+	c *Client
+
+	// For an EXEC, the commands which were queued since the MULTI
+	queued []string
 }
 
 // Dial connects to the given Redis server with the given timeout, which will be
-// used as the read/write timeout when communicating with redis
+// used as the dial, read and write timeout. See DialConfig for setting them
+// separately.
 func DialTimeout(network, addr string, timeout time.Duration) (*Client, error) {
-	// establish a connection
-	conn, err := net.Dial(network, addr)
-	if err != nil {
-		return nil, err
-	}
-
-	c := new(Client)
-	c.Conn = conn
-	c.timeout = timeout
-	c.reader = bufio.NewReaderSize(conn, bufSize)
-	return c, nil
+	return DialConfig(Config{
+		Network:      network,
+		Addr:         addr,
+		DialTimeout:  timeout,
+		ReadTimeout:  timeout,
+		WriteTimeout: timeout,
+	})
 }
 
 // Dial connects to the given Redis server.
@@ -68,17 +126,31 @@ func (c *Client) Close() error {
 
 // Cmd calls the given Redis command.
 func (c *Client) Cmd(cmd string, args ...interface{}) *Reply {
-	err := c.writeRequest(&request{cmd, args})
+	if len(c.hooks) > 0 {
+		return c.hookedCmd(context.Background(), cmd, args)
+	}
+	return c.retryCmd(cmd, args)
+}
+
+func (c *Client) cmd(cmd string, args []interface{}) *Reply {
+	if err := c.prepare(); err != nil {
+		return &Reply{Type: ErrorReply, Err: err}
+	}
+	req := c.newRequest(cmd, args)
+	if req.err != nil {
+		return &Reply{Type: ErrorReply, Err: req.err}
+	}
+	err := c.writeRequest(req)
 	if err != nil {
 		return &Reply{Type: ErrorReply, Err: err}
 	}
-	return c.ReadReply()
+	return c.readRequestReply(req)
 }
 
 // Append adds the given call to the pipeline queue.
 // Use GetReply() to read the reply.
 func (c *Client) Append(cmd string, args ...interface{}) {
-	c.pending = append(c.pending, &request{cmd, args})
+	c.pending = append(c.pending, c.newRequest(cmd, args))
 }
 
 // GetReply returns the reply for the next request in the pipeline queue.
@@ -96,32 +168,85 @@ func (c *Client) GetReply() *Reply {
 		return &Reply{Type: ErrorReply, Err: PipelineQueueEmptyError}
 	}
 
-	nreqs := len(c.pending)
-	err := c.writeRequest(c.pending...)
+	if err := c.prepare(); err != nil {
+		return &Reply{Type: ErrorReply, Err: err}
+	}
+	reqs := c.pending
 	c.pending = nil
+	replies, err := c.pipeline(reqs)
 	if err != nil {
 		return &Reply{Type: ErrorReply, Err: err}
 	}
-	r := c.ReadReply()
-	c.completed = make([]*Reply, nreqs-1)
-	for i := 0; i < nreqs-1; i++ {
-		c.completed[i] = c.ReadReply()
+	c.completed = replies[1:]
+
+	return replies[0]
+}
+
+// pipeline writes the given requests and reads their replies, a chunk at a
+// time (see SetMaxPipelineSize). Each chunk is written before the replies to
+// the one before it are read, so redis always has commands to work on. Only
+// an error writing the first chunk is returned; the requests in later chunks
+// which couldn't be written get the error as their reply.
+func (c *Client) pipeline(reqs []*request) ([]*Reply, error) {
+	n := c.maxPipeline
+	if n == 0 {
+		n = DefaultMaxPipelineSize
+	}
+	if n < 0 || n > len(reqs) {
+		n = len(reqs)
+	}
+	if err := c.writeRequest(reqs[:n]...); err != nil {
+		return nil, err
 	}
 
-	return r
+	replies := make([]*Reply, len(reqs))
+	written := n
+	var writeErr error
+	for start := 0; start < len(reqs); start += n {
+		if written < len(reqs) && writeErr == nil {
+			next := written + n
+			if next > len(reqs) {
+				next = len(reqs)
+			}
+			if writeErr = c.writeRequest(reqs[written:next]...); writeErr == nil {
+				written = next
+			}
+		}
+		end := start + n
+		if end > len(reqs) {
+			end = len(reqs)
+		}
+		for i := start; i < end; i++ {
+			switch {
+			case reqs[i].err != nil:
+				replies[i] = &Reply{Type: ErrorReply, Err: reqs[i].err}
+			case i >= written:
+				replies[i] = &Reply{Type: ErrorReply, Err: writeErr}
+			default:
+				replies[i] = c.readRequestReply(reqs[i])
+			}
+		}
+	}
+	return replies, nil
 }
 
 //* Private methods
 
+// The deadlines are cleared when there's no timeout, since a client derived
+// using WithOptions may have set one on the shared connection
 func (c *Client) setReadTimeout() {
-	if c.timeout != 0 {
-		c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+	if c.readTimeout > 0 {
+		c.Conn.SetReadDeadline(time.Now().Add(c.readTimeout))
+	} else {
+		c.Conn.SetReadDeadline(time.Time{})
 	}
 }
 
 func (c *Client) setWriteTimeout() {
-	if c.timeout != 0 {
-		c.Conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	if c.writeTimeout > 0 {
+		c.Conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	} else {
+		c.Conn.SetWriteDeadline(time.Time{})
 	}
 }
 
@@ -132,7 +257,7 @@ func (c *Client) setWriteTimeout() {
 //
 //	r := conn.ReadReply()
 //	if r.Err != nil {
-//		if t, ok := r.Err.(*net.OpError); ok && t.Timeout() {
+//		if errors.Is(r.Err, redis.TimeoutError) {
 //			// Is timeout
 //		} else {
 //			// Not timeout
@@ -146,35 +271,178 @@ func (c *Client) ReadReply() *Reply {
 	return c.parse()
 }
 
+// newRequest creates a request for the given command, setting its err if the
+// command can't be sent for some reason
+func (c *Client) newRequest(cmd string, args []interface{}) *request {
+	req := &request{cmd: cmd, c: c}
+	if req.args, req.err = marshalArgs(cmd, args); req.err != nil {
+		return req
+	}
+	if c.cmdTable != nil {
+		if req.err = c.cmdTable.Validate(cmd, req.args...); req.err != nil {
+			return req
+		}
+	}
+	if req.err = c.checkMulti(req); req.err != nil {
+		return req
+	}
+	if c.keyPrefix != "" {
+		req.args = c.prefixArgs(cmd, req.args)
+	}
+	if c.compressor != nil {
+		if req.args, req.err = c.compressArgs(cmd, req.args); req.err != nil {
+			return req
+		}
+	}
+	if c.largeMin > 0 {
+		c.checkLargeArgs(req)
+	}
+	return req
+}
+
+// readRequestReply reads the reply for the given request off the connection,
+// and then does any processing of it the request calls for
+func (c *Client) readRequestReply(req *request) *Reply {
+	r := c.ReadReply()
+	if cerr, ok := r.Err.(*ConnError); ok && !c.broken {
+		// The reply timed out before any of it arrived, but it's still on its
+		// way, and would be taken as the reply to the next command
+		c.fail()
+		cerr.desync = true
+	}
+	c.multiReplied(req.cmd, r)
+	c.trackState(req, r)
+	if rc := req.c; rc != nil {
+		if rc.largeMin > 0 {
+			rc.checkLargeReply(req, r)
+		}
+		if rc.keyPrefix != "" {
+			rc.unprefixReply(req.cmd, req.queued, r)
+		}
+		if rc.compressor != nil {
+			rc.decompressReply(req.cmd, req.queued, r)
+		}
+	}
+	return r
+}
+
+// checkMulti returns an error if req would open a MULTI block inside of an
+// already open one, or would close a block which was never opened. Otherwise
+// it updates the client's MULTI state as if req will succeed.
+func (c *Client) checkMulti(req *request) error {
+	switch {
+	case strings.EqualFold(req.cmd, "MULTI"):
+		if c.multi {
+			return NestedMultiError
+		}
+		c.multi = true
+		c.queued = nil
+	case strings.EqualFold(req.cmd, "EXEC"):
+		if !c.multi {
+			return ExecWithoutMultiError
+		}
+		c.multi = false
+		req.queued, c.queued = c.queued, nil
+	case strings.EqualFold(req.cmd, "DISCARD"):
+		if !c.multi {
+			return DiscardWithoutMultiError
+		}
+		c.multi = false
+		c.queued = nil
+	case c.multi:
+		c.queued = append(c.queued, req.cmd)
+	}
+	return nil
+}
+
+// multiReplied corrects the client's MULTI state if redis refused to open the
+// MULTI block that checkMulti assumed would open
+func (c *Client) multiReplied(cmd string, r *Reply) {
+	if r.Type == ErrorReply && strings.EqualFold(cmd, "MULTI") {
+		c.multi = false
+	}
+}
+
+// writeRequest writes all the given requests (other than those with an err)
+// to the connection at once, see cmdWriter
 func (c *Client) writeRequest(requests ...*request) error {
 	c.setWriteTimeout()
+	cw := getCmdWriter(c.Conn)
+	defer cw.release()
 	for i := range requests {
-		req := make([]interface{}, 0, len(requests[i].args)+1)
-		req = append(req, requests[i].cmd)
-		req = append(req, requests[i].args...)
-		err := resp.WriteArbitraryAsFlattenedStrings(c.Conn, req)
-		if err != nil {
-			c.Close()
-			return err
+		if requests[i].err != nil {
+			continue
+		}
+		if err := cw.writeCommand(requests[i].cmd, requests[i].args); err != nil {
+			c.fail()
+			return connError(err)
 		}
 	}
+	if err := cw.flush(); err != nil {
+		c.fail()
+		return connError(err)
+	}
 	return nil
 }
 
+// DefaultMaxPipelineSize is how many commands are written at once by default,
+// see SetMaxPipelineSize
+const DefaultMaxPipelineSize = 10000
+
+// SetMaxPipelineSize limits how many pipelined commands (see Append and
+// Batch.Pipeline) are written to the connection at once. Larger pipelines are
+// split into chunks of that size, and each chunk is written while the replies
+// to the one before it are read. This keeps the client and server from
+// buffering the whole of a huge pipeline, or its replies, at once. Zero means
+// DefaultMaxPipelineSize, and a negative size means no limit.
+func (c *Client) SetMaxPipelineSize(n int) {
+	c.maxPipeline = n
+}
+
+// SetReplyLimits limits how deeply nested the replies read by the client may
+// be, and how many elements they may have in total, to protect against
+// malicious or buggy servers. A reply which goes over either limit is returned
+// as an ErrorReply with resp.DepthLimitError or resp.ElemLimitError, and the
+// connection is closed since the rest of the reply can't be read. Zero means
+// no limit, which is the default.
+func (c *Client) SetReplyLimits(maxDepth, maxElems int) {
+	c.limits = resp.Limits{MaxDepth: maxDepth, MaxElems: maxElems}
+}
+
+// parse reads a reply off the connection. A timeout before any of the reply
+// has arrived leaves the connection as it was, but any other error closes it,
+// since once part of a reply has been read the start of the next one can't be
+// found again.
 func (c *Client) parse() *Reply {
-	m, err := resp.ReadMessage(c.reader)
+	typ, err := c.reader.Peek(1)
 	if err != nil {
-		if t, ok := err.(*net.OpError); !ok || !t.Timeout() {
-			// close connection except timeout
-			c.Close()
+		if t, ok := err.(net.Error); !ok || !t.Timeout() {
+			c.fail()
 		}
-		return &Reply{Type: ErrorReply, Err: err}
+		return &Reply{Type: ErrorReply, Err: connError(err)}
 	}
-	r, err := messageToReply(m)
-	if err != nil {
-		return &Reply{Type: ErrorReply, Err: err}
+	r, ok, err := c.parseFast(typ[0])
+	if ok && err == nil {
+		return r
+	} else if !ok {
+		var m *resp.Message
+		if m, err = resp.ReadMessageLimits(c.reader, c.limits); err == nil {
+			if r, err = messageToReply(m); err == nil {
+				return r
+			}
+		}
 	}
-	return r
+	c.fail()
+	return &Reply{Type: ErrorReply, Err: desyncError(err)}
+}
+
+// Broken returns whether the client's connection was closed because of an
+// error, e.g. because a reply timed out or couldn't be parsed, leaving the
+// connection out of sync with the commands sent on it. A broken client
+// shouldn't be used again, unless its Config has Reconnect set, in which case
+// a new connection is made before the next command is sent.
+func (c *Client) Broken() bool {
+	return c.broken
 }
 
 // The error return parameter is for bubbling up parse errors and the like, if
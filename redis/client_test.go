@@ -3,6 +3,8 @@ package redis
 import (
 	"bufio"
 	"bytes"
+	"errors"
+	"github.com/fzzy/radix/redis/resp"
 	"github.com/stretchr/testify/assert"
 	. "testing"
 	"time"
@@ -104,3 +106,61 @@ func TestParse(t *T) {
 	}
 	assert.Equal(t, []byte("foobar"), r.Elems[4].buf)
 }
+
+func TestMulti(t *T) {
+	c := dial(t)
+
+	assert.Nil(t, c.Cmd("MULTI").Err)
+	assert.Equal(t, NestedMultiError, c.Cmd("MULTI").Err)
+	assert.Nil(t, c.Cmd("ECHO", "foo").Err)
+	r := c.Cmd("EXEC")
+	assert.Nil(t, r.Err)
+	assert.Equal(t, 1, len(r.Elems))
+
+	assert.Equal(t, ExecWithoutMultiError, c.Cmd("EXEC").Err)
+	assert.Equal(t, DiscardWithoutMultiError, c.Cmd("discard").Err)
+
+	// The same checks apply to pipelined commands, and the misused ones are
+	// never sent
+	c.Append("MULTI")
+	c.Append("MULTI")
+	c.Append("ECHO", "foo")
+	c.Append("EXEC")
+	c.Append("EXEC")
+	assert.Nil(t, c.GetReply().Err)
+	assert.Equal(t, NestedMultiError, c.GetReply().Err)
+	v, _ := c.GetReply().Str()
+	assert.Equal(t, "QUEUED", v)
+	r = c.GetReply()
+	assert.Nil(t, r.Err)
+	assert.Equal(t, 1, len(r.Elems))
+	assert.Equal(t, ExecWithoutMultiError, c.GetReply().Err)
+}
+
+func TestReplyLimits(t *T) {
+	c := dial(t)
+	c.SetReplyLimits(1, 3)
+
+	parseString := func(b string) *Reply {
+		c.reader = bufio.NewReader(bytes.NewBufferString(b))
+		return c.parse()
+	}
+
+	r := parseString("*3\r\n:1\r\n:2\r\n:3\r\n")
+	assert.Equal(t, MultiReply, r.Type)
+
+	r = parseString("*4\r\n:1\r\n:2\r\n:3\r\n:4\r\n")
+	assert.True(t, errors.Is(r.Err, resp.ElemLimitError))
+
+	r = parseString("*1\r\n*1\r\n:1\r\n")
+	assert.True(t, errors.Is(r.Err, resp.DepthLimitError))
+}
+
+func TestLenReaderArg(t *T) {
+	c := dial(t)
+	lr := resp.NewLenReader(bytes.NewBufferString("streamed value"), 14)
+	assert.Nil(t, c.Cmd("SET", "lenreader", lr).Err)
+	s, err := c.Cmd("GET", "lenreader").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "streamed value", s)
+}
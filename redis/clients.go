@@ -0,0 +1,186 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ClientInfo describes a connection to the server, as returned by CLIENT LIST
+type ClientInfo struct {
+	ID   int64
+	Addr string
+	Name string
+	DB   int
+
+	// How long the connection has been open, and how long since it last ran a
+	// command
+	Age  time.Duration
+	Idle time.Duration
+
+	// The flags describing the connection (e.g. "N" for a normal client, "S"
+	// for a replica), and the last command it ran
+	Flags string
+	Cmd   string
+
+	// All the fields CLIENT LIST returned for the connection, including those
+	// above
+	Fields map[string]string
+}
+
+// ClientList returns all the connections to the server, using CLIENT LIST
+func (c *Client) ClientList() ([]ClientInfo, error) {
+	return clientList(c)
+}
+
+func clientList(c Commander) ([]ClientInfo, error) {
+	s, err := c.Cmd("CLIENT", "LIST").Str()
+	if err != nil {
+		return nil, err
+	}
+	return parseClientList(s), nil
+}
+
+func parseClientList(s string) []ClientInfo {
+	var infos []ClientInfo
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		info := ClientInfo{Fields: map[string]string{}}
+		for _, field := range strings.Fields(line) {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) == 2 {
+				info.Fields[kv[0]] = kv[1]
+			}
+		}
+		info.ID, _ = strconv.ParseInt(info.Fields["id"], 10, 64)
+		info.Addr = info.Fields["addr"]
+		info.Name = info.Fields["name"]
+		info.DB, _ = strconv.Atoi(info.Fields["db"])
+		age, _ := strconv.ParseInt(info.Fields["age"], 10, 64)
+		info.Age = time.Duration(age) * time.Second
+		idle, _ := strconv.ParseInt(info.Fields["idle"], 10, 64)
+		info.Idle = time.Duration(idle) * time.Second
+		info.Flags = info.Fields["flags"]
+		info.Cmd = info.Fields["cmd"]
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// ClientEventType is the kind of change a ClientEvent is for
+type ClientEventType int
+
+const (
+	// A connection was opened
+	ClientConnected ClientEventType = iota
+
+	// A connection was closed
+	ClientDisconnected
+
+	// A connection has been idle for longer than MaxIdle
+	ClientIdle
+
+	// A connection has been open for longer than MaxAge
+	ClientOld
+)
+
+var clientEventTypeNames = map[ClientEventType]string{
+	ClientConnected:    "connected",
+	ClientDisconnected: "disconnected",
+	ClientIdle:         "idle",
+	ClientOld:          "old",
+}
+
+func (t ClientEventType) String() string {
+	return clientEventTypeNames[t]
+}
+
+// ClientEvent is a change to the server's connections seen by WatchClients. For
+// a ClientDisconnected event Client is the connection as it was last seen.
+type ClientEvent struct {
+	Type   ClientEventType
+	Client ClientInfo
+}
+
+// ClientWatchOptions are the options for WatchClients
+type ClientWatchOptions struct {
+	// How often to call CLIENT LIST. Defaults to 10 seconds.
+	Interval time.Duration
+
+	// If set, a ClientIdle event is emitted when a connection has been idle
+	// for longer than this. It's emitted again if the connection becomes
+	// active and then goes idle again.
+	MaxIdle time.Duration
+
+	// If set, a ClientOld event is emitted once when a connection has been open
+	// for longer than this
+	MaxAge time.Duration
+}
+
+// WatchClients polls CLIENT LIST on c until ctx is done, and calls fn with an
+// event for every connection which appears or disappears between polls, or
+// goes over one of the thresholds in opts. The connections seen on the first
+// poll don't get ClientConnected events, but they are checked against the
+// thresholds. Since connections are polled, those which come and go between
+// two polls aren't seen at all.
+//
+// WatchClients blocks, returning ctx's error once it's done, or the error from
+// CLIENT LIST if that fails. fn is called from the routine WatchClients is
+// called in, and c mustn't be used by anything else while it's running.
+func WatchClients(
+	ctx context.Context, c Commander, opts ClientWatchOptions,
+	fn func(ClientEvent),
+) error {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	var seen map[int64]ClientInfo
+	idle := map[int64]bool{}
+	old := map[int64]bool{}
+	for {
+		infos, err := clientList(c)
+		if err != nil {
+			return err
+		}
+
+		current := make(map[int64]ClientInfo, len(infos))
+		for _, info := range infos {
+			current[info.ID] = info
+			if _, ok := seen[info.ID]; seen != nil && !ok {
+				fn(ClientEvent{ClientConnected, info})
+			}
+			if opts.MaxIdle > 0 {
+				if info.Idle > opts.MaxIdle && !idle[info.ID] {
+					idle[info.ID] = true
+					fn(ClientEvent{ClientIdle, info})
+				} else if info.Idle <= opts.MaxIdle {
+					delete(idle, info.ID)
+				}
+			}
+			if opts.MaxAge > 0 && info.Age > opts.MaxAge && !old[info.ID] {
+				old[info.ID] = true
+				fn(ClientEvent{ClientOld, info})
+			}
+		}
+		for id, info := range seen {
+			if _, ok := current[id]; !ok {
+				delete(idle, id)
+				delete(old, id)
+				fn(ClientEvent{ClientDisconnected, info})
+			}
+		}
+		seen = current
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package redis
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	. "testing"
+	"time"
+)
+
+func TestParseClientList(t *T) {
+	infos := parseClientList(
+		"id=3 addr=127.0.0.1:5000 fd=8 name=web age=62 idle=5 flags=N db=2 cmd=get\n" +
+			"id=4 addr=127.0.0.1:5001 fd=9 name= age=1 idle=0 flags=S db=0 cmd=client|list\n",
+	)
+	assert.Equal(t, 2, len(infos))
+	assert.Equal(t, int64(3), infos[0].ID)
+	assert.Equal(t, "127.0.0.1:5000", infos[0].Addr)
+	assert.Equal(t, "web", infos[0].Name)
+	assert.Equal(t, 2, infos[0].DB)
+	assert.Equal(t, 62*time.Second, infos[0].Age)
+	assert.Equal(t, 5*time.Second, infos[0].Idle)
+	assert.Equal(t, "N", infos[0].Flags)
+	assert.Equal(t, "get", infos[0].Cmd)
+	assert.Equal(t, "8", infos[0].Fields["fd"])
+	assert.Equal(t, "", infos[1].Name)
+	assert.Equal(t, "client|list", infos[1].Cmd)
+}
+
+func TestClientList(t *T) {
+	c := dial(t)
+	infos, err := c.ClientList()
+	if cerr, ok := err.(*CmdError); ok &&
+		strings.HasPrefix(cerr.Error(), "ERR unknown subcommand") {
+		t.Skip("CLIENT LIST not supported")
+	}
+	assert.Nil(t, err)
+	assert.True(t, len(infos) > 0)
+}
+
+// clientListFake returns each of its lists in turn from CLIENT LIST, and
+// cancels the watch once they're used up
+type clientListFake struct {
+	lists  []string
+	cancel func()
+}
+
+func (f *clientListFake) Cmd(cmd string, args ...interface{}) *Reply {
+	l := f.lists[0]
+	if len(f.lists) > 1 {
+		f.lists = f.lists[1:]
+	} else {
+		f.cancel()
+	}
+	return NewReply(l)
+}
+
+func TestWatchClients(t *T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &clientListFake{
+		lists: []string{
+			"id=1 age=10 idle=0\nid=2 age=100 idle=0\n",
+			"id=1 age=11 idle=20\nid=3 age=0 idle=0\n",
+			"id=1 age=12 idle=30\n",
+			"id=1 age=13 idle=0\n",
+			"id=1 age=14 idle=40\n",
+		},
+		cancel: cancel,
+	}
+
+	type event struct {
+		typ ClientEventType
+		id  int64
+	}
+	var events []event
+	opts := ClientWatchOptions{
+		Interval: time.Millisecond,
+		MaxIdle:  15 * time.Second,
+		MaxAge:   50 * time.Second,
+	}
+	err := WatchClients(ctx, f, opts, func(e ClientEvent) {
+		events = append(events, event{e.Type, e.Client.ID})
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, []event{
+		{ClientOld, 2},
+		{ClientIdle, 1},
+		{ClientConnected, 3},
+		{ClientDisconnected, 2},
+		{ClientDisconnected, 3},
+		{ClientIdle, 1},
+	}, events)
+	assert.Equal(t, "disconnected", ClientDisconnected.String())
+}
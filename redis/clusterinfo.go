@@ -0,0 +1,316 @@
+package redis
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ClusterSlotRange is a range of cluster slots, from Start to End inclusive
+type ClusterSlotRange struct {
+	Start, End int
+}
+
+// ClusterNode describes a node of a redis cluster, as returned by CLUSTER
+// NODES
+type ClusterNode struct {
+	ID string
+
+	// The address clients connect to, the port of the cluster bus, and the
+	// node's announced hostname if it has one
+	Addr     string
+	BusPort  int
+	Hostname string
+
+	// e.g. "myself", "master", "slave", "fail?" or "fail"
+	Flags []string
+
+	// The ID of the master this node replicates, if it's a replica
+	MasterID string
+
+	PingSent    time.Time
+	PongRecv    time.Time
+	ConfigEpoch int64
+	Connected   bool
+
+	// The slots the node serves, if it's a master, and the slots it's
+	// migrating to or importing from other nodes, by slot and then the other
+	// node's ID
+	Slots     []ClusterSlotRange
+	Migrating map[int]string
+	Importing map[int]string
+}
+
+// HasFlag returns whether the node has the given flag, e.g. "master"
+func (n *ClusterNode) HasFlag(flag string) bool {
+	for _, f := range n.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// ClusterNodes returns the nodes of the cluster the server is part of, as seen
+// by the server, using CLUSTER NODES
+func (c *Client) ClusterNodes() ([]ClusterNode, error) {
+	s, err := c.Cmd("CLUSTER", "NODES").Str()
+	if err != nil {
+		return nil, err
+	}
+	return parseClusterNodes(s)
+}
+
+func parseClusterNodes(s string) ([]ClusterNode, error) {
+	var nodes []ClusterNode
+	for _, line := range strings.Split(s, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		} else if len(fields) < 8 {
+			return nil, errors.New("malformed CLUSTER NODES line: " + line)
+		}
+		n := ClusterNode{
+			ID:        fields[0],
+			Flags:     strings.Split(fields[2], ","),
+			Connected: fields[7] == "connected",
+			Migrating: map[int]string{},
+			Importing: map[int]string{},
+		}
+
+		// ip:port@cport[,hostname]
+		addr := fields[1]
+		if i := strings.Index(addr, ","); i >= 0 {
+			addr, n.Hostname = addr[:i], addr[i+1:]
+		}
+		if i := strings.Index(addr, "@"); i >= 0 {
+			n.BusPort, _ = strconv.Atoi(addr[i+1:])
+			addr = addr[:i]
+		}
+		n.Addr = addr
+		if fields[3] != "-" {
+			n.MasterID = fields[3]
+		}
+
+		if ms, err := strconv.ParseInt(fields[4], 10, 64); err == nil && ms > 0 {
+			n.PingSent = time.Unix(0, ms*int64(time.Millisecond))
+		}
+		if ms, err := strconv.ParseInt(fields[5], 10, 64); err == nil && ms > 0 {
+			n.PongRecv = time.Unix(0, ms*int64(time.Millisecond))
+		}
+		n.ConfigEpoch, _ = strconv.ParseInt(fields[6], 10, 64)
+
+		for _, slot := range fields[8:] {
+			if err := n.addSlot(slot); err != nil {
+				return nil, err
+			}
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+// addSlot adds a slot entry from CLUSTER NODES, which is either a single slot,
+// a range like "0-5460", or a migration like "[93->-<id>]" or "[93-<-<id>]"
+func (n *ClusterNode) addSlot(s string) error {
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		s = s[1 : len(s)-1]
+		m := n.Migrating
+		parts := strings.SplitN(s, "->-", 2)
+		if len(parts) != 2 {
+			m = n.Importing
+			parts = strings.SplitN(s, "-<-", 2)
+		}
+		if len(parts) != 2 {
+			return errors.New("malformed CLUSTER NODES slot: " + s)
+		}
+		slot, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return err
+		}
+		m[slot] = parts[1]
+		return nil
+	}
+
+	parts := strings.SplitN(s, "-", 2)
+	start, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return err
+	}
+	end := start
+	if len(parts) == 2 {
+		if end, err = strconv.Atoi(parts[1]); err != nil {
+			return err
+		}
+	}
+	n.Slots = append(n.Slots, ClusterSlotRange{start, end})
+	return nil
+}
+
+// ClusterShardNode is one of the nodes of a ClusterShard
+type ClusterShardNode struct {
+	ID       string
+	Endpoint string
+	IP       string
+	Hostname string
+	Port     int
+	TLSPort  int
+
+	// "master" or "replica", and "online", "failed" or "loading"
+	Role   string
+	Health string
+
+	ReplicationOffset int64
+}
+
+// ClusterShard is a master and its replicas, along with the slots they serve,
+// as returned by CLUSTER SHARDS
+type ClusterShard struct {
+	Slots []ClusterSlotRange
+	Nodes []ClusterShardNode
+}
+
+// ClusterShards returns the shards of the cluster the server is part of, using
+// CLUSTER SHARDS, which requires redis 7.0 or above
+func (c *Client) ClusterShards() ([]ClusterShard, error) {
+	r := c.Cmd("CLUSTER", "SHARDS")
+	if r.Type == ErrorReply {
+		return nil, r.Err
+	}
+	return parseClusterShards(r)
+}
+
+// pairs calls fn with each field name and value of a reply which is a map,
+// sent as a flat list of alternating names and values
+func pairs(r *Reply, fn func(name string, v *Reply) error) error {
+	if r.Type != MultiReply || len(r.Elems)%2 != 0 {
+		return errors.New("reply is not a map")
+	}
+	for i := 0; i < len(r.Elems); i += 2 {
+		name, err := r.Elems[i].Str()
+		if err != nil {
+			return err
+		}
+		if err = fn(name, r.Elems[i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseClusterShards(r *Reply) ([]ClusterShard, error) {
+	if r.Type != MultiReply {
+		return nil, errors.New("malformed CLUSTER SHARDS reply")
+	}
+	shards := make([]ClusterShard, len(r.Elems))
+	for i, sr := range r.Elems {
+		sh := &shards[i]
+		err := pairs(sr, func(name string, v *Reply) error {
+			switch name {
+			case "slots":
+				if len(v.Elems)%2 != 0 {
+					return errors.New("malformed CLUSTER SHARDS slots")
+				}
+				for j := 0; j < len(v.Elems); j += 2 {
+					start, err := v.Elems[j].Int()
+					if err != nil {
+						return err
+					}
+					end, err := v.Elems[j+1].Int()
+					if err != nil {
+						return err
+					}
+					sh.Slots = append(sh.Slots, ClusterSlotRange{start, end})
+				}
+			case "nodes":
+				for _, nr := range v.Elems {
+					n, err := parseClusterShardNode(nr)
+					if err != nil {
+						return err
+					}
+					sh.Nodes = append(sh.Nodes, n)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return shards, nil
+}
+
+func parseClusterShardNode(r *Reply) (ClusterShardNode, error) {
+	var n ClusterShardNode
+	err := pairs(r, func(name string, v *Reply) error {
+		var err error
+		switch name {
+		case "id":
+			n.ID, err = v.Str()
+		case "endpoint":
+			n.Endpoint, err = v.Str()
+		case "ip":
+			n.IP, err = v.Str()
+		case "hostname":
+			n.Hostname, err = v.Str()
+		case "port":
+			n.Port, err = v.Int()
+		case "tls-port":
+			n.TLSPort, err = v.Int()
+		case "role":
+			n.Role, err = v.Str()
+		case "health":
+			n.Health, err = v.Str()
+		case "replication-offset":
+			n.ReplicationOffset, err = v.Int64()
+		}
+		return err
+	})
+	return n, err
+}
+
+// ClusterInfoReply holds the fields returned by CLUSTER INFO
+type ClusterInfoReply struct {
+	State         string // "ok" or "fail"
+	SlotsAssigned int
+	SlotsOK       int
+	SlotsPFail    int
+	SlotsFail     int
+	KnownNodes    int
+	Size          int // The number of masters serving at least one slot
+	CurrentEpoch  int64
+	MyEpoch       int64
+
+	// All the fields CLUSTER INFO returned, including those above
+	Fields map[string]string
+}
+
+// ClusterInfo returns the state of the cluster the server is part of, using
+// CLUSTER INFO
+func (c *Client) ClusterInfo() (*ClusterInfoReply, error) {
+	s, err := c.Cmd("CLUSTER", "INFO").Str()
+	if err != nil {
+		return nil, err
+	}
+	return parseClusterInfo(s), nil
+}
+
+func parseClusterInfo(s string) *ClusterInfoReply {
+	fields := parseInfo(s)[""]
+	if fields == nil {
+		fields = map[string]string{}
+	}
+	return &ClusterInfoReply{
+		State:         fields["cluster_state"],
+		SlotsAssigned: int(infoInt(fields, "cluster_slots_assigned")),
+		SlotsOK:       int(infoInt(fields, "cluster_slots_ok")),
+		SlotsPFail:    int(infoInt(fields, "cluster_slots_pfail")),
+		SlotsFail:     int(infoInt(fields, "cluster_slots_fail")),
+		KnownNodes:    int(infoInt(fields, "cluster_known_nodes")),
+		Size:          int(infoInt(fields, "cluster_size")),
+		CurrentEpoch:  infoInt(fields, "cluster_current_epoch"),
+		MyEpoch:       infoInt(fields, "cluster_my_epoch"),
+		Fields:        fields,
+	}
+}
@@ -0,0 +1,100 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+func TestParseClusterNodes(t *T) {
+	nodes, err := parseClusterNodes(
+		"07c37dfeb235213a872192d90877d0cd55635b91 127.0.0.1:30004@31004,host-4 slave e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 0 1426238317239 4 connected\n" +
+			"e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 127.0.0.1:30001@31001 myself,master - 0 0 1 connected 0-5460 5462 [5461->-67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1] [5463-<-292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f]\n",
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(nodes))
+
+	r := nodes[0]
+	assert.Equal(t, "127.0.0.1:30004", r.Addr)
+	assert.Equal(t, 31004, r.BusPort)
+	assert.Equal(t, "host-4", r.Hostname)
+	assert.True(t, r.HasFlag("slave"))
+	assert.Equal(t, "e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca", r.MasterID)
+	assert.True(t, r.PingSent.IsZero())
+	assert.Equal(t, time.Unix(1426238317, 239e6), r.PongRecv)
+	assert.Equal(t, int64(4), r.ConfigEpoch)
+	assert.True(t, r.Connected)
+	assert.Equal(t, 0, len(r.Slots))
+
+	m := nodes[1]
+	assert.Equal(t, []string{"myself", "master"}, m.Flags)
+	assert.Equal(t, "", m.MasterID)
+	assert.Equal(t, []ClusterSlotRange{{0, 5460}, {5462, 5462}}, m.Slots)
+	assert.Equal(t, map[int]string{5461: "67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1"}, m.Migrating)
+	assert.Equal(t, map[int]string{5463: "292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f"}, m.Importing)
+
+	_, err = parseClusterNodes("abc 127.0.0.1:30001 master\n")
+	assert.NotNil(t, err)
+}
+
+func TestParseClusterShards(t *T) {
+	r := NewReply([]interface{}{
+		[]interface{}{
+			"slots", []interface{}{0, 5460, 5462, 5462},
+			"nodes", []interface{}{
+				[]interface{}{
+					"id", "e7d1", "port", 30001, "ip", "127.0.0.1",
+					"endpoint", "127.0.0.1", "role", "master",
+					"replication-offset", 72156, "health", "online",
+				},
+				[]interface{}{
+					"id", "07c3", "port", 30004, "ip", "127.0.0.1",
+					"endpoint", "127.0.0.1", "role", "replica",
+					"replication-offset", 72156, "health", "online",
+				},
+			},
+		},
+	})
+	shards, err := parseClusterShards(r)
+	assert.Nil(t, err)
+	assert.Equal(t, []ClusterShard{{
+		Slots: []ClusterSlotRange{{0, 5460}, {5462, 5462}},
+		Nodes: []ClusterShardNode{
+			{
+				ID: "e7d1", Endpoint: "127.0.0.1", IP: "127.0.0.1", Port: 30001,
+				Role: "master", Health: "online", ReplicationOffset: 72156,
+			},
+			{
+				ID: "07c3", Endpoint: "127.0.0.1", IP: "127.0.0.1", Port: 30004,
+				Role: "replica", Health: "online", ReplicationOffset: 72156,
+			},
+		},
+	}}, shards)
+}
+
+func TestParseClusterInfo(t *T) {
+	info := parseClusterInfo("cluster_state:ok\r\ncluster_slots_assigned:16384\r\n" +
+		"cluster_slots_ok:16384\r\ncluster_known_nodes:6\r\ncluster_size:3\r\n" +
+		"cluster_current_epoch:6\r\ncluster_my_epoch:2\r\n")
+	assert.Equal(t, "ok", info.State)
+	assert.Equal(t, 16384, info.SlotsAssigned)
+	assert.Equal(t, 6, info.KnownNodes)
+	assert.Equal(t, 3, info.Size)
+	assert.Equal(t, int64(2), info.MyEpoch)
+	assert.Equal(t, "16384", info.Fields["cluster_slots_ok"])
+}
+
+func TestClusterNodes(t *T) {
+	c := dial(t)
+	nodes, err := c.ClusterNodes()
+	if err != nil {
+		t.Skip("CLUSTER NODES not supported")
+	}
+	var myself *ClusterNode
+	for i := range nodes {
+		if nodes[i].HasFlag("myself") {
+			myself = &nodes[i]
+		}
+	}
+	assert.NotNil(t, myself)
+}
@@ -0,0 +1,177 @@
+package redis
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// CoalesceOptions are the options for NewCoalescer
+type CoalesceOptions struct {
+	// How long to wait after a GET or HGET for others to combine it with.
+	// Defaults to 200µs.
+	Window time.Duration
+
+	// The most commands combined into one. Once this many are waiting they're
+	// sent straight away. Defaults to 100.
+	MaxKeys int
+}
+
+// Coalescer combines GETs sent from different routines at around the same time
+// into a single MGET, and HGETs of the same hash into a single HMGET, handing
+// each caller the reply for its own key. Where many routines each read a few
+// keys this saves a great many round trips, at the cost of the wait for others
+// to combine with (see CoalesceOptions). All other commands are passed
+// straight through.
+//
+// The Commander wrapped must be safe to use from multiple routines, e.g. a Mux
+// or one of the extra clients. If a combined command fails with a CmdError,
+// e.g. CROSSSLOT on a cluster, each of the commands in it is sent separately
+// instead. Note that MGET gives nil for a key which doesn't hold a string,
+// where GET would have given a WRONGTYPE error. A Coalescer is a Commander,
+// and is safe to use from multiple routines at once.
+type Coalescer struct {
+	c    Commander
+	opts CoalesceOptions
+
+	// The GETs and HGETs (by key) waiting to be sent
+	mu    sync.Mutex
+	gets  *coalesced
+	hgets map[string]*coalesced
+}
+
+// coalesced is some commands waiting to be sent combined. done is closed once
+// replies has been set.
+type coalesced struct {
+	cmd     string
+	key     interface{}
+	args    []interface{}
+	timer   *time.Timer
+	done    chan struct{}
+	replies []*Reply
+}
+
+// NewCoalescer returns a Coalescer sending commands through c
+func NewCoalescer(c Commander, opts CoalesceOptions) *Coalescer {
+	if opts.Window <= 0 {
+		opts.Window = 200 * time.Microsecond
+	}
+	if opts.MaxKeys <= 0 {
+		opts.MaxKeys = 100
+	}
+	return &Coalescer{c: c, opts: opts, hgets: map[string]*coalesced{}}
+}
+
+// Cmd sends the given command, combined with others if it's a GET or HGET
+func (co *Coalescer) Cmd(cmd string, args ...interface{}) *Reply {
+	switch strings.ToUpper(cmd) {
+	case "GET":
+		if len(args) == 1 && isKey(args[0]) {
+			return co.add("MGET", nil, args[0])
+		}
+	case "HGET":
+		if len(args) == 2 && isKey(args[0]) && isKey(args[1]) {
+			return co.add("HMGET", args[0], args[1])
+		}
+	}
+	return co.c.Cmd(cmd, args...)
+}
+
+// Only single strings are combined, since anything else (e.g. a slice) could
+// be flattened into more than one argument
+func isKey(arg interface{}) bool {
+	switch arg.(type) {
+	case string, []byte:
+		return true
+	}
+	return false
+}
+
+// add adds arg to the commands waiting to be combined into cmd, on key for
+// HMGET, and waits for its reply
+func (co *Coalescer) add(cmd string, key, arg interface{}) *Reply {
+	co.mu.Lock()
+	b := co.gets
+	if key != nil {
+		b = co.hgets[argString(key)]
+	}
+	if b == nil {
+		b = &coalesced{cmd: cmd, key: key, done: make(chan struct{})}
+		co.setBatch(b)
+		b.timer = time.AfterFunc(co.opts.Window, func() { co.flush(b) })
+	}
+	i := len(b.args)
+	b.args = append(b.args, arg)
+	full := len(b.args) >= co.opts.MaxKeys
+	co.mu.Unlock()
+
+	if full && b.timer.Stop() {
+		co.flush(b)
+	}
+	<-b.done
+	return b.replies[i]
+}
+
+// setBatch makes b the batch its commands are added to, and unsetBatch stops
+// them being added to it, if it's still the one being added to. co.mu must be
+// held for both.
+func (co *Coalescer) setBatch(b *coalesced) {
+	if b.key == nil {
+		co.gets = b
+	} else {
+		co.hgets[argString(b.key)] = b
+	}
+}
+
+func (co *Coalescer) unsetBatch(b *coalesced) {
+	if b.key == nil {
+		if co.gets == b {
+			co.gets = nil
+		}
+	} else if k := argString(b.key); co.hgets[k] == b {
+		delete(co.hgets, k)
+	}
+}
+
+// flush sends the batch's combined command, and gives each caller its reply
+func (co *Coalescer) flush(b *coalesced) {
+	co.mu.Lock()
+	co.unsetBatch(b)
+	co.mu.Unlock()
+	defer close(b.done)
+
+	args := b.args
+	single := "GET"
+	if b.key != nil {
+		args = append([]interface{}{b.key}, b.args...)
+		single = "HGET"
+	}
+	if len(b.args) == 1 {
+		b.replies = []*Reply{co.c.Cmd(single, args...)}
+		return
+	}
+
+	r := co.c.Cmd(b.cmd, args...)
+	if r.Type == MultiReply && len(r.Elems) == len(b.args) {
+		b.replies = r.Elems
+		return
+	}
+	if _, ok := r.Err.(*CmdError); r.Type == ErrorReply && !ok {
+		// A connection error would fail them all anyway
+		b.replies = make([]*Reply, len(b.args))
+		for i := range b.replies {
+			b.replies[i] = r
+		}
+		return
+	}
+
+	// The combined command didn't work, so try them one at a time
+	b.replies = make([]*Reply, len(b.args))
+	for i, arg := range b.args {
+		if b.key != nil {
+			b.replies[i] = co.c.Cmd(single, b.key, arg)
+		} else {
+			b.replies[i] = co.c.Cmd(single, arg)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package redis
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	. "testing"
+	"time"
+)
+
+// recordingCommander records the commands sent through it
+type recordingCommander struct {
+	Commander
+	mu   sync.Mutex
+	cmds []string
+}
+
+func (rc *recordingCommander) Cmd(cmd string, args ...interface{}) *Reply {
+	rc.mu.Lock()
+	rc.cmds = append(rc.cmds, strings.ToUpper(cmd))
+	rc.mu.Unlock()
+	return rc.Commander.Cmd(cmd, args...)
+}
+
+func (rc *recordingCommander) sent() []string {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	cmds := rc.cmds
+	rc.cmds = nil
+	return cmds
+}
+
+func TestCoalescer(t *T) {
+	m := dialMux(t)
+	defer m.Close()
+	for i := 0; i < 10; i++ {
+		m.Cmd("SET", "coalesce:"+strconv.Itoa(i), i)
+	}
+	m.Cmd("DEL", "coalesce:missing", "coalesce:hash")
+	m.Cmd("HMSET", "coalesce:hash", "a", "1", "b", "2")
+
+	rc := &recordingCommander{Commander: m}
+	co := NewCoalescer(rc, CoalesceOptions{Window: 50 * time.Millisecond})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s, err := co.Cmd("GET", "coalesce:"+strconv.Itoa(i)).Str()
+			assert.Nil(t, err)
+			assert.Equal(t, strconv.Itoa(i), s)
+		}(i)
+	}
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		assert.Equal(t, NilReply, co.Cmd("GET", []byte("coalesce:missing")).Type)
+	}()
+	for _, f := range []string{"a", "b"} {
+		go func(f string) {
+			defer wg.Done()
+			s, err := co.Cmd("hget", "coalesce:hash", f).Str()
+			assert.Nil(t, err)
+			assert.Equal(t, map[string]string{"a": "1", "b": "2"}[f], s)
+		}(f)
+	}
+	wg.Wait()
+	sent := rc.sent()
+	sort.Strings(sent)
+	assert.Equal(t, []string{"HMGET", "MGET"}, sent)
+
+	// A lone GET is sent as it is, as is everything else
+	assert.Nil(t, co.Cmd("GET", "coalesce:1").Err)
+	assert.Nil(t, co.Cmd("PING").Err)
+	assert.Equal(t, []string{"GET", "PING"}, rc.sent())
+}
+
+func TestCoalescerMaxKeys(t *T) {
+	m := dialMux(t)
+	defer m.Close()
+	co := NewCoalescer(m, CoalesceOptions{Window: time.Minute, MaxKeys: 3})
+
+	// The window is never waited out, since the batch fills up first
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, co.Cmd("GET", "coalesce:0").Err)
+		}()
+	}
+	wg.Wait()
+}
+
+// crossSlotCommander fails multi-key commands, like a cluster would for keys
+// in different slots
+type crossSlotCommander struct {
+	recordingCommander
+}
+
+func (cc *crossSlotCommander) Cmd(cmd string, args ...interface{}) *Reply {
+	if cmd == "MGET" {
+		cc.recordingCommander.Cmd(cmd)
+		return &Reply{Type: ErrorReply, Err: &CmdError{errors.New("CROSSSLOT Keys don't hash to the same slot")}}
+	}
+	return cc.recordingCommander.Cmd(cmd, args...)
+}
+
+func TestCoalescerFallback(t *T) {
+	m := dialMux(t)
+	defer m.Close()
+	m.Cmd("SET", "coalesce:0", "0")
+	m.Cmd("DEL", "coalesce:list")
+	m.Cmd("RPUSH", "coalesce:list", "foo")
+
+	cc := &crossSlotCommander{recordingCommander{Commander: m}}
+	co := NewCoalescer(cc, CoalesceOptions{Window: 50 * time.Millisecond})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		s, err := co.Cmd("GET", "coalesce:0").Str()
+		assert.Nil(t, err)
+		assert.Equal(t, "0", s)
+	}()
+	go func() {
+		defer wg.Done()
+		err := co.Cmd("GET", "coalesce:list").Err
+		assert.NotNil(t, err)
+	}()
+	wg.Wait()
+	assert.Equal(t, []string{"MGET", "GET", "GET"}, cc.sent())
+}
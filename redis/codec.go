@@ -0,0 +1,128 @@
+package redis
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+)
+
+// A Codec converts go values to and from the bytes which are stored in redis.
+// JSONCodec and GobCodec are provided, and FuncCodec and BinaryCodec adapt
+// other serialization libraries (e.g. msgpack or protobuf) without this package
+// depending on them. A Client uses one for SetObject and GetObject (see
+// SetCodec), and the session store in extra/session takes one for the values
+// kept in sessions.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(b []byte, v interface{}) error
+}
+
+// JSONCodec is a Codec using encoding/json. It is the default Codec used by a
+// Client.
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(b []byte, v interface{}) error {
+	return json.Unmarshal(b, v)
+}
+
+// GobCodec is a Codec using encoding/gob. Each value is encoded on its own, so
+// type information is included with every one of them.
+type GobCodec struct{}
+
+func (GobCodec) Marshal(v interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(b []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(b)).Decode(v)
+}
+
+// FuncCodec is a Codec made from a pair of functions. Most serialization
+// libraries have functions with the right signatures already, for example with
+// github.com/vmihailenco/msgpack:
+//
+//	codec := redis.FuncCodec{MarshalFunc: msgpack.Marshal, UnmarshalFunc: msgpack.Unmarshal}
+type FuncCodec struct {
+	MarshalFunc   func(v interface{}) ([]byte, error)
+	UnmarshalFunc func(b []byte, v interface{}) error
+}
+
+func (f FuncCodec) Marshal(v interface{}) ([]byte, error) {
+	return f.MarshalFunc(v)
+}
+
+func (f FuncCodec) Unmarshal(b []byte, v interface{}) error {
+	return f.UnmarshalFunc(b, v)
+}
+
+// BinaryCodec is a Codec for values which encode themselves, by implementing
+// encoding.BinaryMarshaler and (through a pointer) encoding.BinaryUnmarshaler.
+// Generated protobuf messages can be used through a FuncCodec wrapping
+// proto.Marshal and proto.Unmarshal, or directly with BinaryCodec if their
+// generator adds MarshalBinary and UnmarshalBinary methods.
+type BinaryCodec struct{}
+
+func (BinaryCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(encoding.BinaryMarshaler)
+	if !ok {
+		return nil, fmt.Errorf("%T does not implement encoding.BinaryMarshaler", v)
+	}
+	return m.MarshalBinary()
+}
+
+func (BinaryCodec) Unmarshal(b []byte, v interface{}) error {
+	u, ok := v.(encoding.BinaryUnmarshaler)
+	if !ok {
+		return fmt.Errorf("%T does not implement encoding.BinaryUnmarshaler", v)
+	}
+	return u.UnmarshalBinary(b)
+}
+
+// SetCodec sets the Codec used by SetObject and GetObject. A nil Codec resets
+// it to JSONCodec.
+func (c *Client) SetCodec(codec Codec) {
+	c.codec = codec
+}
+
+func (c *Client) getCodec() Codec {
+	if c.codec == nil {
+		return JSONCodec{}
+	}
+	return c.codec
+}
+
+// SetObject marshals v using the client's Codec and SETs it at the given key.
+// Any extra arguments (e.g. "EX", 10) are passed through to SET.
+func (c *Client) SetObject(key string, v interface{}, args ...interface{}) error {
+	b, err := c.getCodec().Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.Cmd("SET", key, b, args).Err
+}
+
+// GetObject GETs the given key and unmarshals its value into v using the
+// client's Codec
+func (c *Client) GetObject(key string, v interface{}) error {
+	return c.Cmd("GET", key).Object(c.getCodec(), v)
+}
+
+// Object unmarshals the reply's value into v using the given Codec. The reply
+// type must be StatusReply or BulkReply.
+func (r *Reply) Object(codec Codec, v interface{}) error {
+	b, err := r.Bytes()
+	if err != nil {
+		return err
+	}
+	return codec.Unmarshal(b, v)
+}
@@ -0,0 +1,69 @@
+package redis
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	. "testing"
+)
+
+type codecTestObj struct {
+	Name  string
+	Count int
+	Tags  []string
+}
+
+func TestObject(t *T) {
+	c := dial(t)
+	in := codecTestObj{"foo", 5, []string{"bar", "baz"}}
+
+	for _, codec := range []Codec{nil, JSONCodec{}, GobCodec{}} {
+		c.SetCodec(codec)
+		assert.Nil(t, c.SetObject("codec:obj", in, "EX", 10))
+
+		var out codecTestObj
+		assert.Nil(t, c.GetObject("codec:obj", &out))
+		assert.Equal(t, in, out)
+	}
+
+	c.SetCodec(nil)
+	assert.Nil(t, c.SetObject("codec:obj", in))
+	s, _ := c.Cmd("GET", "codec:obj").Str()
+	assert.Equal(t, `{"Name":"foo","Count":5,"Tags":["bar","baz"]}`, s)
+
+	c.Cmd("DEL", "codec:obj")
+	var out codecTestObj
+	assert.NotNil(t, c.GetObject("codec:obj", &out))
+}
+
+type binaryTestObj struct {
+	Name string
+}
+
+func (o binaryTestObj) MarshalBinary() ([]byte, error) {
+	return []byte("name=" + o.Name), nil
+}
+
+func (o *binaryTestObj) UnmarshalBinary(b []byte) error {
+	o.Name = strings.TrimPrefix(string(b), "name=")
+	return nil
+}
+
+func TestCodecAdapters(t *T) {
+	c := dial(t)
+
+	c.SetCodec(BinaryCodec{})
+	assert.Nil(t, c.SetObject("codec:obj", binaryTestObj{"foo"}))
+	s, _ := c.Cmd("GET", "codec:obj").Str()
+	assert.Equal(t, "name=foo", s)
+	var out binaryTestObj
+	assert.Nil(t, c.GetObject("codec:obj", &out))
+	assert.Equal(t, "foo", out.Name)
+	assert.NotNil(t, c.SetObject("codec:obj", 5))
+
+	c.SetCodec(FuncCodec{MarshalFunc: json.Marshal, UnmarshalFunc: json.Unmarshal})
+	assert.Nil(t, c.SetObject("codec:obj", []int{1, 2}))
+	var ints []int
+	assert.Nil(t, c.GetObject("codec:obj", &ints))
+	assert.Equal(t, []int{1, 2}, ints)
+}
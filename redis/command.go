@@ -0,0 +1,119 @@
+package redis
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/fzzy/radix/redis/resp"
+)
+
+// CommandInfo describes a command, as returned by COMMAND
+type CommandInfo struct {
+	Name string
+
+	// The number of arguments the command takes, including the command name
+	// itself. A negative arity means at least that many.
+	Arity int
+
+	// e.g. "write", "readonly" or "fast"
+	Flags []string
+
+	// The positions of the command's first and last keys, where the command
+	// name is position zero and a negative last key counts back from the end,
+	// and the step between keys. A first key of zero means the command takes
+	// no keys or finds them specially (e.g. EVAL).
+	FirstKey, LastKey, KeyStep int
+}
+
+// CommandTable holds the commands a server supports, by their upper case
+// names, see Client.CommandTable
+type CommandTable map[string]CommandInfo
+
+// CommandTable returns all the commands the server supports, using COMMAND
+func (c *Client) CommandTable() (CommandTable, error) {
+	r := c.Cmd("COMMAND")
+	if r.Type == ErrorReply {
+		return nil, r.Err
+	}
+	return parseCommandTable(r)
+}
+
+func parseCommandTable(r *Reply) (CommandTable, error) {
+	if r.Type != MultiReply {
+		return nil, errors.New("malformed COMMAND reply")
+	}
+	t := CommandTable{}
+	for _, cr := range r.Elems {
+		if cr.Type != MultiReply || len(cr.Elems) < 6 {
+			return nil, errors.New("malformed COMMAND entry")
+		}
+		var info CommandInfo
+		var err error
+		if info.Name, err = cr.Elems[0].Str(); err != nil {
+			return nil, err
+		}
+		if info.Arity, err = cr.Elems[1].Int(); err != nil {
+			return nil, err
+		}
+		for _, fr := range cr.Elems[2].Elems {
+			f, _ := fr.Str()
+			info.Flags = append(info.Flags, f)
+		}
+		if info.FirstKey, err = cr.Elems[3].Int(); err != nil {
+			return nil, err
+		}
+		if info.LastKey, err = cr.Elems[4].Int(); err != nil {
+			return nil, err
+		}
+		if info.KeyStep, err = cr.Elems[5].Int(); err != nil {
+			return nil, err
+		}
+		t[strings.ToUpper(info.Name)] = info
+	}
+	return t, nil
+}
+
+// Validate checks the given command against the table, returning the same
+// *CmdError redis would for an unknown command, or for the wrong number of
+// arguments (including a key without its value in commands like MSET). A nil
+// table allows everything.
+func (t CommandTable) Validate(cmd string, args ...interface{}) error {
+	if t == nil {
+		return nil
+	}
+	info, ok := t[strings.ToUpper(cmd)]
+	if !ok {
+		return &CmdError{errors.New("ERR unknown command '" + cmd + "'")}
+	}
+	n := len(resp.Flatten(args)) + 1
+	wrong := (info.Arity > 0 && n != info.Arity) || (info.Arity < 0 && n < -info.Arity)
+	if !wrong && info.FirstKey > 0 && info.LastKey == -1 && info.KeyStep > 1 {
+		// Every key must be followed by the rest of its group, e.g. MSET
+		wrong = (n-info.FirstKey)%info.KeyStep != 0
+	}
+	if wrong {
+		return &CmdError{errors.New(
+			"ERR wrong number of arguments for '" + strings.ToLower(cmd) + "' command",
+		)}
+	}
+	return nil
+}
+
+// SetCommandTable has the client check every command against the given table
+// (see CommandTable.Validate) before sending it, so that obviously malformed
+// commands fail straight away without a round trip to redis. Since nothing is
+// sent for them, the connection is still usable afterwards. A nil table turns
+// this off.
+//
+//	t, err := conn.CommandTable()
+//	if err != nil {
+//		return err
+//	}
+//	conn.SetCommandTable(t)
+//	conn.Cmd("GET") // ERR wrong number of arguments for 'get' command
+//
+// The table can be shared by any number of clients, see
+// pool.CommandTableDialFunc.
+func (c *Client) SetCommandTable(t CommandTable) {
+	c.cmdTable = t
+}
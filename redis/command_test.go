@@ -0,0 +1,58 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func TestCommandTable(t *T) {
+	c := dial(t)
+	table, err := c.CommandTable()
+	assert.Nil(t, err)
+	get := table["GET"]
+	assert.Equal(t, "get", get.Name)
+	assert.Equal(t, 2, get.Arity)
+	assert.Equal(t, 1, get.FirstKey)
+
+	assert.Nil(t, table.Validate("get", "foo"))
+	assert.Nil(t, table.Validate("MSET", "a", 1, "b", 2))
+	assert.Nil(t, table.Validate("DEL", []string{"a", "b"}))
+	assert.Nil(t, CommandTable(nil).Validate("NOTACOMMAND"))
+	for _, args := range [][]interface{}{
+		{"GET"},
+		{"GET", "a", "b"},
+		{"MSET", "a", 1, "b"},
+		{"DEL"},
+		{"NOTACOMMAND", "a"},
+	} {
+		err := table.Validate(args[0].(string), args[1:]...)
+		_, ok := err.(*CmdError)
+		assert.True(t, ok, args)
+	}
+}
+
+func TestSetCommandTable(t *T) {
+	c := dial(t)
+	table, err := c.CommandTable()
+	assert.Nil(t, err)
+	c.SetCommandTable(table)
+
+	r := c.Cmd("GET")
+	assert.Equal(t, "ERR wrong number of arguments for 'get' command", r.Err.Error())
+	assert.Nil(t, c.Cmd("SET", "command:key", "foo").Err)
+
+	// Pipelined commands are checked too, without holding up the others
+	c.Append("GET", "command:key")
+	c.Append("MSET", "command:key")
+	c.Append("DEL", "command:key")
+	s, _ := c.GetReply().Str()
+	assert.Equal(t, "foo", s)
+	_, ok := c.GetReply().Err.(*CmdError)
+	assert.True(t, ok)
+	n, _ := c.GetReply().Int()
+	assert.Equal(t, 1, n)
+
+	c.SetCommandTable(nil)
+	_, ok = c.Cmd("GET").Err.(*CmdError)
+	assert.True(t, ok)
+}
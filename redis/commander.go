@@ -0,0 +1,9 @@
+package redis
+
+// Commander is anything which can run redis commands. Client implements it, as
+// do the clients in the extra packages which spread commands across several
+// connections (cluster, shard and replica), so code which only needs to run
+// commands can accept a Commander and work with any of them.
+type Commander interface {
+	Cmd(cmd string, args ...interface{}) *Reply
+}
@@ -0,0 +1,24 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+var _ Commander = &Client{}
+
+// echoTwice is a helper written against Commander
+func echoTwice(c Commander, s string) (string, error) {
+	return c.Cmd("ECHO", s+s).Str()
+}
+
+func TestCommander(t *T) {
+	c := dial(t)
+	s, err := echoTwice(c, "foo")
+	assert.Nil(t, err)
+	assert.Equal(t, "foofoo", s)
+
+	s, err = echoTwice(c.WithOptions(Options{KeyPrefix: "cmdr:"}), "bar")
+	assert.Nil(t, err)
+	assert.Equal(t, "barbar", s)
+}
@@ -0,0 +1,221 @@
+package redis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// cmdInfo describes which arguments of a command are keys, the same way the
+// COMMAND command does: the positions of the first and last key (where 1 is
+// the first argument after the command name and negative positions count back
+// from the last argument), and the step between keys. A firstKey of zero means
+// the command takes no keys, or that its keys have to be found specially (see
+// keyIndexes).
+type cmdInfo struct {
+	firstKey, lastKey, keyStep int
+}
+
+var (
+	oneKey      = cmdInfo{1, 1, 1}
+	twoKeys     = cmdInfo{1, 2, 1}
+	allKeys     = cmdInfo{1, -1, 1}
+	noKeys      = cmdInfo{}
+	blockingPop = cmdInfo{1, -2, 1}
+)
+
+var commands = map[string]cmdInfo{
+	// keys
+	"DEL": allKeys, "DUMP": oneKey, "EXISTS": allKeys, "EXPIRE": oneKey,
+	"EXPIREAT": oneKey, "EXPIRETIME": oneKey, "PERSIST": oneKey,
+	"PEXPIRE": oneKey, "PEXPIREAT": oneKey, "PEXPIRETIME": oneKey,
+	"PTTL": oneKey, "RENAME": twoKeys, "RENAMENX": twoKeys, "RESTORE": oneKey,
+	"SORT": oneKey, "SORT_RO": oneKey, "TOUCH": allKeys, "TTL": oneKey,
+	"TYPE": oneKey, "UNLINK": allKeys, "COPY": twoKeys, "MOVE": oneKey,
+	"OBJECT": {2, 2, 1}, "MEMORY": {2, 2, 1}, "WATCH": allKeys,
+
+	// strings
+	"APPEND": oneKey, "BITCOUNT": oneKey, "BITFIELD": oneKey,
+	"BITFIELD_RO": oneKey, "BITOP": {2, -1, 1}, "BITPOS": oneKey,
+	"DECR": oneKey, "DECRBY": oneKey, "GET": oneKey, "GETBIT": oneKey,
+	"GETDEL": oneKey, "GETEX": oneKey, "GETRANGE": oneKey, "GETSET": oneKey,
+	"INCR": oneKey, "INCRBY": oneKey, "INCRBYFLOAT": oneKey, "MGET": allKeys,
+	"MSET": {1, -1, 2}, "MSETNX": {1, -1, 2}, "PSETEX": oneKey, "SET": oneKey,
+	"SETBIT": oneKey, "SETEX": oneKey, "SETNX": oneKey, "SETRANGE": oneKey,
+	"STRLEN": oneKey, "SUBSTR": oneKey,
+
+	// hashes
+	"HDEL": oneKey, "HEXISTS": oneKey, "HGET": oneKey, "HGETALL": oneKey,
+	"HINCRBY": oneKey, "HINCRBYFLOAT": oneKey, "HKEYS": oneKey, "HLEN": oneKey,
+	"HMGET": oneKey, "HMSET": oneKey, "HRANDFIELD": oneKey, "HSCAN": oneKey,
+	"HSET": oneKey, "HSETNX": oneKey, "HSTRLEN": oneKey, "HVALS": oneKey,
+
+	// lists
+	"BLMOVE": twoKeys, "BLPOP": blockingPop, "BRPOP": blockingPop,
+	"BRPOPLPUSH": twoKeys, "LINDEX": oneKey, "LINSERT": oneKey, "LLEN": oneKey,
+	"LMOVE": twoKeys, "LPOP": oneKey, "LPOS": oneKey, "LPUSH": oneKey,
+	"LPUSHX": oneKey, "LRANGE": oneKey, "LREM": oneKey, "LSET": oneKey,
+	"LTRIM": oneKey, "RPOP": oneKey, "RPOPLPUSH": twoKeys, "RPUSH": oneKey,
+	"RPUSHX": oneKey,
+
+	// sets
+	"SADD": oneKey, "SCARD": oneKey, "SDIFF": allKeys, "SDIFFSTORE": allKeys,
+	"SINTER": allKeys, "SINTERSTORE": allKeys, "SISMEMBER": oneKey,
+	"SMEMBERS": oneKey, "SMISMEMBER": oneKey, "SMOVE": twoKeys, "SPOP": oneKey,
+	"SRANDMEMBER": oneKey, "SREM": oneKey, "SSCAN": oneKey, "SUNION": allKeys,
+	"SUNIONSTORE": allKeys,
+
+	// sorted sets
+	"BZPOPMAX": blockingPop, "BZPOPMIN": blockingPop, "ZADD": oneKey,
+	"ZCARD": oneKey, "ZCOUNT": oneKey, "ZINCRBY": oneKey, "ZLEXCOUNT": oneKey,
+	"ZMSCORE": oneKey, "ZPOPMAX": oneKey, "ZPOPMIN": oneKey,
+	"ZRANDMEMBER": oneKey, "ZRANGE": oneKey, "ZRANGEBYLEX": oneKey,
+	"ZRANGEBYSCORE": oneKey, "ZRANGESTORE": twoKeys, "ZRANK": oneKey,
+	"ZREM": oneKey, "ZREMRANGEBYLEX": oneKey, "ZREMRANGEBYRANK": oneKey,
+	"ZREMRANGEBYSCORE": oneKey, "ZREVRANGE": oneKey, "ZREVRANGEBYLEX": oneKey,
+	"ZREVRANGEBYSCORE": oneKey, "ZREVRANK": oneKey, "ZSCAN": oneKey,
+	"ZSCORE": oneKey,
+
+	// hyperloglog
+	"PFADD": oneKey, "PFCOUNT": allKeys, "PFMERGE": allKeys,
+
+	// geo
+	"GEOADD": oneKey, "GEODIST": oneKey, "GEOHASH": oneKey, "GEOPOS": oneKey,
+	"GEORADIUS": oneKey, "GEORADIUS_RO": oneKey, "GEORADIUSBYMEMBER": oneKey,
+	"GEORADIUSBYMEMBER_RO": oneKey, "GEOSEARCH": oneKey,
+	"GEOSEARCHSTORE": twoKeys,
+
+	// streams
+	"XACK": oneKey, "XADD": oneKey, "XAUTOCLAIM": oneKey, "XCLAIM": oneKey,
+	"XDEL": oneKey, "XGROUP": {2, 2, 1}, "XINFO": {2, 2, 1}, "XLEN": oneKey,
+	"XPENDING": oneKey, "XRANGE": oneKey, "XREVRANGE": oneKey, "XTRIM": oneKey,
+
+	// Commands whose keys are found specially by keyIndexes
+	"EVAL": noKeys, "EVALSHA": noKeys, "EVAL_RO": noKeys, "EVALSHA_RO": noKeys,
+	"ZUNIONSTORE": noKeys, "ZINTERSTORE": noKeys, "ZDIFFSTORE": noKeys,
+	"ZUNION": noKeys, "ZINTER": noKeys, "ZDIFF": noKeys, "ZINTERCARD": noKeys,
+	"SINTERCARD": noKeys, "LMPOP": noKeys, "BLMPOP": noKeys, "ZMPOP": noKeys,
+	"BZMPOP": noKeys, "XREAD": noKeys, "XREADGROUP": noKeys,
+}
+
+// numKeysArg returns the integer value of a command's numkeys argument
+func numKeysArg(arg interface{}) int {
+	var s string
+	if b, ok := arg.([]byte); ok {
+		s = string(b)
+	} else {
+		s = fmt.Sprint(arg)
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// keyIndexes returns the indexes of the arguments to the given command which
+// are keys. args must already be flattened. Commands which aren't known about
+// are assumed to not have any keys.
+func keyIndexes(cmd string, args []interface{}) []int {
+	cmd = strings.ToUpper(cmd)
+
+	// numkeysAt returns the indexes of the keys of a command which has a
+	// numkeys argument at position i, followed by the keys themselves
+	numkeysAt := func(i int) []int {
+		if i >= len(args) {
+			return nil
+		}
+		n := numKeysArg(args[i])
+		is := make([]int, 0, n)
+		for j := i + 1; j <= i+n && j < len(args); j++ {
+			is = append(is, j)
+		}
+		return is
+	}
+
+	switch cmd {
+	case "EVAL", "EVALSHA", "EVAL_RO", "EVALSHA_RO", "ZUNION", "ZINTER", "ZDIFF",
+		"ZINTERCARD", "SINTERCARD":
+		if cmd[0] == 'E' {
+			return numkeysAt(1)
+		}
+		return numkeysAt(0)
+	case "ZUNIONSTORE", "ZINTERSTORE", "ZDIFFSTORE":
+		if len(args) == 0 {
+			return nil
+		}
+		return append([]int{0}, numkeysAt(1)...)
+	case "LMPOP", "ZMPOP":
+		return numkeysAt(0)
+	case "BLMPOP", "BZMPOP":
+		return numkeysAt(1)
+	case "XREAD", "XREADGROUP":
+		// The keys are the first half of everything after STREAMS
+		for i := range args {
+			if s, ok := args[i].(string); ok && strings.EqualFold(s, "STREAMS") {
+				rest := len(args) - i - 1
+				is := make([]int, 0, rest/2)
+				for j := i + 1; j <= i+rest/2; j++ {
+					is = append(is, j)
+				}
+				return is
+			}
+		}
+		return nil
+	}
+
+	info, ok := commands[cmd]
+	if !ok || info.firstKey == 0 {
+		return nil
+	}
+	first, last := info.firstKey-1, info.lastKey-1
+	if info.lastKey < 0 {
+		last = len(args) + info.lastKey
+	}
+	if last >= len(args) {
+		last = len(args) - 1
+	}
+	if first > last {
+		return nil
+	}
+	is := make([]int, 0, last-first+1)
+	for i := first; i <= last; i += info.keyStep {
+		is = append(is, i)
+	}
+	return is
+}
+
+// readOnlyCommands are the commands which never modify data, and so can be
+// sent to replicas
+var readOnlyCommands = map[string]bool{
+	"BITCOUNT": true, "BITPOS": true, "DBSIZE": true, "DUMP": true,
+	"EXISTS": true, "EXPIRETIME": true, "GEODIST": true, "GEOHASH": true,
+	"GEOPOS": true, "GEOSEARCH": true, "GET": true, "GETBIT": true,
+	"GETRANGE": true, "HEXISTS": true, "HGET": true, "HGETALL": true,
+	"HKEYS": true, "HLEN": true, "HMGET": true, "HRANDFIELD": true,
+	"HSCAN": true, "HSTRLEN": true, "HVALS": true, "KEYS": true,
+	"LINDEX": true, "LLEN": true, "LPOS": true, "LRANGE": true, "MGET": true,
+	"OBJECT": true, "PEXPIRETIME": true, "PFCOUNT": true, "PTTL": true,
+	"RANDOMKEY": true, "SCAN": true, "SCARD": true, "SDIFF": true,
+	"SINTER": true, "SINTERCARD": true, "SISMEMBER": true, "SMEMBERS": true,
+	"SMISMEMBER": true, "SRANDMEMBER": true, "SSCAN": true, "STRLEN": true,
+	"SUBSTR": true, "SUNION": true, "TOUCH": true, "TTL": true, "TYPE": true,
+	"XINFO": true, "XLEN": true, "XPENDING": true, "XRANGE": true,
+	"XREAD": true, "XREVRANGE": true, "ZCARD": true, "ZCOUNT": true,
+	"ZDIFF": true, "ZINTER": true, "ZINTERCARD": true, "ZLEXCOUNT": true,
+	"ZMSCORE": true, "ZRANDMEMBER": true, "ZRANGE": true, "ZRANGEBYLEX": true,
+	"ZRANGEBYSCORE": true, "ZRANK": true, "ZREVRANGE": true,
+	"ZREVRANGEBYLEX": true, "ZREVRANGEBYSCORE": true, "ZREVRANK": true,
+	"ZSCAN": true, "ZSCORE": true, "ZUNION": true,
+
+	// the read-only variants of commands which can otherwise write
+	"BITFIELD_RO": true, "EVAL_RO": true, "EVALSHA_RO": true,
+	"GEORADIUS_RO": true, "GEORADIUSBYMEMBER_RO": true, "SORT_RO": true,
+}
+
+// ReadOnlyCommand returns whether the given command is known to never modify
+// any data, meaning it can safely be sent to a replica. Commands which aren't
+// known about are assumed to not be read-only.
+func ReadOnlyCommand(cmd string) bool {
+	return readOnlyCommands[strings.ToUpper(cmd)]
+}
@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func TestKeyIndexes(t *T) {
+	tests := []struct {
+		cmd  string
+		args []interface{}
+		is   []int
+	}{
+		{"GET", []interface{}{"a"}, []int{0}},
+		{"set", []interface{}{"a", "b", "EX", 10}, []int{0}},
+		{"DEL", []interface{}{"a", "b", "c"}, []int{0, 1, 2}},
+		{"MSET", []interface{}{"a", 1, "b", 2}, []int{0, 2}},
+		{"BLPOP", []interface{}{"a", "b", 0}, []int{0, 1}},
+		{"RPOPLPUSH", []interface{}{"a", "b"}, []int{0, 1}},
+		{"BITOP", []interface{}{"AND", "dst", "a", "b"}, []int{1, 2, 3}},
+		{"OBJECT", []interface{}{"ENCODING", "a"}, []int{1}},
+		{"EVAL", []interface{}{"return 1", 2, "a", "b", "arg"}, []int{2, 3}},
+		{"EVALSHA", []interface{}{"abc", "0", "arg"}, []int{}},
+		{"ZUNIONSTORE", []interface{}{"dst", 2, "a", "b", "WEIGHTS", 1, 2}, []int{0, 2, 3}},
+		{"LMPOP", []interface{}{2, "a", "b", "LEFT"}, []int{1, 2}},
+		{"BZMPOP", []interface{}{0, 1, "a", "MIN"}, []int{2}},
+		{"XREAD", []interface{}{"COUNT", 2, "STREAMS", "a", "b", "0", "0"}, []int{3, 4}},
+		{"PING", nil, nil},
+		{"NOTACOMMAND", []interface{}{"a"}, nil},
+		{"GET", nil, nil},
+	}
+
+	for _, test := range tests {
+		is := keyIndexes(test.cmd, test.args)
+		assert.Equal(t, len(test.is), len(is), test.cmd)
+		for i := range test.is {
+			assert.Equal(t, test.is[i], is[i], test.cmd)
+		}
+	}
+}
+
+func TestReadOnlyCommand(t *T) {
+	assert.True(t, ReadOnlyCommand("GET"))
+	assert.True(t, ReadOnlyCommand("zrangebyscore"))
+	assert.False(t, ReadOnlyCommand("SET"))
+	assert.False(t, ReadOnlyCommand("NOTACOMMAND"))
+}
@@ -0,0 +1,153 @@
+package redis
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"strings"
+
+	"github.com/fzzy/radix/redis/resp"
+)
+
+// A Compressor compresses and decompresses values. A Client can be given one
+// with SetCompressor, after which values written with SET-family commands are
+// transparently compressed and values read with GET-family commands are
+// transparently decompressed. Implementations for algorithms outside the
+// standard library (snappy, zstd, etc...) only need to satisfy this interface.
+type Compressor interface {
+	Compress(b []byte) ([]byte, error)
+	Decompress(b []byte) ([]byte, error)
+}
+
+// GzipCompressor is a Compressor which uses compress/gzip with the given
+// compression level. The zero value uses gzip.DefaultCompression.
+type GzipCompressor struct {
+	Level int
+}
+
+func (g GzipCompressor) Compress(b []byte) ([]byte, error) {
+	level := g.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	buf := new(bytes.Buffer)
+	w, err := gzip.NewWriterLevel(buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(b); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (g GzipCompressor) Decompress(b []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// compressedPrefix is put in front of every value the client compresses. Values
+// without it are returned as-is, so keys written before compression was turned
+// on (or by other clients) can still be read.
+var compressedPrefix = []byte("\x00RDXZ")
+
+// SetCompressor has the client compress any value of at least threshold bytes
+// which is written using SET, SETNX, SETEX, PSETEX, GETSET, MSET or MSETNX.
+// Values read back using GET, GETSET, GETDEL, GETEX, MGET or SET with the GET
+// option (including inside a MULTI block) which were compressed are
+// decompressed before being returned. Passing a nil
+// Compressor turns compression off, but compressed values will no longer be
+// decompressed either.
+func (c *Client) SetCompressor(comp Compressor, threshold int) {
+	c.compressor = comp
+	c.compressMin = threshold
+}
+
+// valueArg returns whether the given (flattened) argument index of a command
+// holds a value which may be compressed
+func valueArg(cmd string, i int) bool {
+	switch strings.ToUpper(cmd) {
+	case "SET", "SETNX", "GETSET":
+		return i == 1
+	case "SETEX", "PSETEX":
+		return i == 2
+	case "MSET", "MSETNX":
+		return i%2 == 1
+	}
+	return false
+}
+
+func (c *Client) compressArgs(cmd string, args []interface{}) ([]interface{}, error) {
+	if !valueArg(cmd, 1) && !valueArg(cmd, 2) {
+		return args, nil
+	}
+
+	flat := resp.Flatten(args)
+	for i := range flat {
+		if !valueArg(cmd, i) {
+			continue
+		}
+		var b []byte
+		switch v := flat[i].(type) {
+		case []byte:
+			b = v
+		case string:
+			b = []byte(v)
+		default:
+			continue
+		}
+		if len(b) < c.compressMin {
+			continue
+		}
+		cb, err := c.compressor.Compress(b)
+		if err != nil {
+			return nil, err
+		}
+		flat[i] = append(compressedPrefix[:len(compressedPrefix):len(compressedPrefix)], cb...)
+	}
+	return flat, nil
+}
+
+// decompressReply decompresses the values in the reply to the given command,
+// if any of them were compressed. queued is only used when cmd is EXEC.
+func (c *Client) decompressReply(cmd string, queued []string, r *Reply) {
+	switch strings.ToUpper(cmd) {
+	case "GET", "GETSET", "GETDEL", "GETEX":
+		c.decompress(r)
+	case "SET":
+		// The reply is only a bulk reply, the old value, if the GET option
+		// was given; decompress leaves status and nil replies alone
+		c.decompress(r)
+	case "MGET":
+		for _, e := range r.Elems {
+			c.decompress(e)
+		}
+	case "EXEC":
+		for i, e := range r.Elems {
+			if i < len(queued) {
+				c.decompressReply(queued[i], nil, e)
+			}
+		}
+	}
+}
+
+func (c *Client) decompress(r *Reply) {
+	if r.Type != BulkReply || !bytes.HasPrefix(r.buf, compressedPrefix) {
+		return
+	}
+	b, err := c.compressor.Decompress(r.buf[len(compressedPrefix):])
+	if err != nil {
+		r.Type = ErrorReply
+		r.Err = err
+		r.buf = nil
+		return
+	}
+	r.buf = b
+}
@@ -0,0 +1,95 @@
+package redis
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	. "testing"
+	"time"
+)
+
+func TestCompression(t *T) {
+	c := dial(t)
+	c.SetCompressor(GzipCompressor{}, 64)
+	small := "foo"
+	big := strings.Repeat("foo", 100)
+
+	assert.Nil(t, c.Cmd("SET", "compress:small", small).Err)
+	assert.Nil(t, c.Cmd("SET", "compress:big", big).Err)
+	assert.Nil(t, c.Cmd("MSET", "compress:big2", []byte(big), "compress:small2", small).Err)
+
+	v, err := c.Cmd("GET", "compress:big").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, big, v)
+
+	l, err := c.Cmd("MGET", "compress:small", "compress:big", "compress:big2", "compress:small2").List()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{small, big, big, small}, l)
+
+	c.Cmd("MULTI")
+	c.Cmd("GET", "compress:big")
+	c.Cmd("STRLEN", "compress:big")
+	r := c.Cmd("EXEC")
+	assert.Nil(t, r.Err)
+	v, _ = r.Elems[0].Str()
+	assert.Equal(t, big, v)
+
+	// Without a compressor the raw, compressed value is visible, small values
+	// are never compressed
+	c.SetCompressor(nil, 0)
+	b, _ := c.Cmd("GET", "compress:big").Bytes()
+	assert.True(t, bytes.HasPrefix(b, compressedPrefix))
+	assert.True(t, len(b) < len(big))
+	v, _ = c.Cmd("GET", "compress:small").Str()
+	assert.Equal(t, small, v)
+}
+
+func TestCompressionGetDel(t *T) {
+	c := dial(t)
+	c.SetCompressor(GzipCompressor{}, 64)
+	big := strings.Repeat("foo", 100)
+
+	assert.Nil(t, c.Cmd("SET", "compress:getdel", big).Err)
+	v, err := c.GetDel("compress:getdel").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, big, v)
+
+	// ConsumeToken uses GETDEL too
+	assert.Nil(t, c.Cmd("SET", "compress:token", big).Err)
+	v, err = c.ConsumeToken("compress:token")
+	assert.Nil(t, err)
+	assert.Equal(t, big, v)
+}
+
+func TestCompressionGetEx(t *T) {
+	c := dial(t)
+	c.SetCompressor(GzipCompressor{}, 64)
+	big := strings.Repeat("foo", 100)
+
+	assert.Nil(t, c.Cmd("SET", "compress:getex", big).Err)
+	v, err := c.GetEx("compress:getex", GetExOpts{TTL: time.Minute}).Str()
+	assert.Nil(t, err)
+	assert.Equal(t, big, v)
+}
+
+func TestCompressionSetGet(t *T) {
+	c := dial(t)
+	c.SetCompressor(GzipCompressor{}, 64)
+	big := strings.Repeat("foo", 100)
+
+	assert.Nil(t, c.Cmd("SET", "compress:setget", big).Err)
+	v, err := c.SetWithOptions("compress:setget", "bar", SetOpts{Get: true}).Str()
+	assert.Nil(t, err)
+	assert.Equal(t, big, v)
+
+	// Without GET the status reply is left alone
+	r := c.Cmd("SET", "compress:setget", big)
+	assert.Equal(t, StatusReply, r.Type)
+
+	c.Cmd("MULTI")
+	c.SetWithOptions("compress:setget", "bar", SetOpts{Get: true})
+	r = c.Cmd("EXEC")
+	assert.Nil(t, r.Err)
+	v, _ = r.Elems[0].Str()
+	assert.Equal(t, big, v)
+}
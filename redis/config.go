@@ -0,0 +1,203 @@
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fzzy/radix/redis/resp"
+)
+
+// NoTimeout can be used for any of the timeouts in Config or Options to
+// explicitly disable that timeout. This is mostly useful with WithOptions, for
+// calling a command which blocks indefinitely (e.g. BLPOP with a timeout of 0)
+// on a client which normally has a timeout.
+const NoTimeout time.Duration = -1
+
+// Config describes how to connect to a redis server, and the connection state
+// which is set up as soon as the connection is made. The same state is set up
+// again whenever the connection is re-established, see Reconnect.
+type Config struct {
+	Network string
+	Addr    string
+
+	// The timeouts for establishing the connection, and for reading and
+	// writing each command on it. Zero or NoTimeout means no timeout.
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	// If Password is set, AUTH is called with it. If Username is also set the
+	// redis 6 ACL form of AUTH is used, authenticating as that user.
+	Username string
+	Password string
+
+	// If set, this is called every time a connection is made to get the
+	// username and password to AUTH with, instead of using Username and
+	// Password. This allows short lived credentials (e.g. IAM tokens) to be
+	// rotated without recreating the client. An empty username means the
+	// pre-ACL form of AUTH is used, and an empty password means no AUTH.
+	CredentialsProvider func() (user, pass string)
+
+	// If not zero, SELECT is called with this database
+	DB int
+
+	// If set, CLIENT SETNAME is called with this name
+	Name string
+
+	// If true, a connection which was closed due to an error is re-established
+	// before the next command is sent on it. The command which hit the error
+	// is not retried.
+	Reconnect bool
+
+	// See SetMaxPipelineSize
+	MaxPipelineSize int
+}
+
+// DialConfig connects to the redis server described by cfg, and sets up the
+// connection state it asks for. If any part of that fails the connection is
+// closed and the error is returned.
+func DialConfig(cfg Config) (*Client, error) {
+	c := &Client{connState: &connState{cfg: cfg}}
+	c.readTimeout = cfg.ReadTimeout
+	c.writeTimeout = cfg.WriteTimeout
+	c.maxPipeline = cfg.MaxPipelineSize
+	if err := c.Reconnect(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// dialLike connects to the server described by cfg like DialConfig, returning a
+// client with the same settings as c (timeouts, key prefix, compressor, codec,
+// hooks, and so on) but a connection, and connection state, of its own. Its
+// large values are counted separately from c's.
+func (c *Client) dialLike(cfg Config) (*Client, error) {
+	nc, err := DialConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	d := *c
+	d.Conn, d.connState, d.parent = nc.Conn, nc.connState, nil
+	d.LargeWrites, d.LargeReads = 0, 0
+	return &d, nil
+}
+
+// Reconnect closes the client's connection, if it's still open, and connects
+// again to the same server. Before returning it replays AUTH, SELECT and CLIENT
+// SETNAME as needed, so the new connection has the same state as the old one.
+// Successful AUTH, SELECT and CLIENT SETNAME commands sent through the client
+// are remembered for this, so the connection ends up on the same database even
+// if it was changed after dialing. If the Config has a CredentialsProvider it's
+// called again for the new connection. Pipelined commands which haven't been
+// sent yet are kept, but any open MULTI block is lost along with the old
+// connection.
+func (c *Client) Reconnect() error {
+	var conn net.Conn
+	var err error
+	if c.cfg.DialTimeout > 0 {
+		conn, err = net.DialTimeout(c.cfg.Network, c.cfg.Addr, c.cfg.DialTimeout)
+	} else {
+		conn, err = net.Dial(c.cfg.Network, c.cfg.Addr)
+	}
+	if err != nil {
+		return connError(err)
+	}
+
+	nc := &Client{Conn: conn, connState: &connState{}}
+	nc.readTimeout, nc.writeTimeout = c.readTimeout, c.writeTimeout
+	nc.reader = bufio.NewReaderSize(conn, bufSize)
+	nc.limits = c.limits
+	if err = nc.restore(c.cfg); err != nil {
+		conn.Close()
+		return err
+	}
+
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	c.Conn, c.conn, c.reader = conn, conn, nc.reader
+	c.broken = false
+	c.multi, c.queued = false, nil
+	return nil
+}
+
+// restore sets up the connection state described by cfg
+func (c *Client) restore(cfg Config) error {
+	user, pass := cfg.Username, cfg.Password
+	if cfg.CredentialsProvider != nil {
+		user, pass = cfg.CredentialsProvider()
+	}
+	if pass != "" {
+		args := []interface{}{pass}
+		if user != "" {
+			args = []interface{}{user, pass}
+		}
+		if err := c.Cmd("AUTH", args...).Err; err != nil {
+			return err
+		}
+	}
+	if cfg.DB != 0 {
+		if err := c.Cmd("SELECT", cfg.DB).Err; err != nil {
+			return err
+		}
+	}
+	if cfg.Name != "" {
+		if err := c.Cmd("CLIENT", "SETNAME", cfg.Name).Err; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prepare makes sure the client is using its connection's current net.Conn,
+// since a client derived with WithOptions may not have seen a reconnect, and
+// reconnects first if the connection is broken and the Config asks for it
+func (c *Client) prepare() error {
+	if c.conn != nil && c.Conn != c.conn {
+		c.Conn = c.conn
+	}
+	if c.broken && c.cfg.Reconnect {
+		return c.Reconnect()
+	}
+	return nil
+}
+
+// fail closes the client's connection after an error which leaves it unusable
+func (c *Client) fail() {
+	c.broken = true
+	c.Close()
+}
+
+// trackState remembers the connection state changed by a successful AUTH,
+// SELECT or CLIENT SETNAME, so that Reconnect can restore it. Commands queued
+// in a MULTI block aren't tracked.
+func (c *Client) trackState(req *request, r *Reply) {
+	if r.Type != StatusReply || c.multi {
+		return
+	}
+	flat := resp.Flatten(req.args)
+	switch {
+	case strings.EqualFold(req.cmd, "AUTH") && len(flat) == 1:
+		c.cfg.Username, c.cfg.Password = "", argString(flat[0])
+	case strings.EqualFold(req.cmd, "AUTH") && len(flat) == 2:
+		c.cfg.Username, c.cfg.Password = argString(flat[0]), argString(flat[1])
+	case strings.EqualFold(req.cmd, "SELECT") && len(flat) == 1:
+		if db, err := strconv.Atoi(argString(flat[0])); err == nil {
+			c.cfg.DB = db
+		}
+	case strings.EqualFold(req.cmd, "CLIENT") && len(flat) == 2 &&
+		strings.EqualFold(argString(flat[0]), "SETNAME"):
+		c.cfg.Name = argString(flat[1])
+	}
+}
+
+func argString(arg interface{}) string {
+	if b, ok := arg.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(arg)
+}
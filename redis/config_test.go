@@ -0,0 +1,91 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+func TestDialConfig(t *T) {
+	c, err := DialConfig(Config{
+		Network:     "tcp",
+		Addr:        "127.0.0.1:6379",
+		ReadTimeout: 10 * time.Second,
+		DB:          3,
+		Name:        "radix-test",
+	})
+	assert.Nil(t, err)
+	defer c.Close()
+
+	name, err := c.Cmd("CLIENT", "GETNAME").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "radix-test", name)
+
+	assert.Nil(t, c.Cmd("SET", "config:db", "3").Err)
+	assert.Nil(t, c.Reconnect())
+	s, err := c.Cmd("GET", "config:db").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "3", s)
+
+	// The database selected after dialing is the one restored
+	assert.Nil(t, c.Cmd("SELECT", 4).Err)
+	assert.Equal(t, 4, c.cfg.DB)
+	assert.Nil(t, c.Cmd("SET", "config:db", "4").Err)
+	assert.Nil(t, c.Reconnect())
+	s, err = c.Cmd("GET", "config:db").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "4", s)
+	name, err = c.Cmd("CLIENT", "GETNAME").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "radix-test", name)
+
+	_, err = DialConfig(Config{Network: "tcp", Addr: "127.0.0.1:6379", DB: -1})
+	assert.NotNil(t, err)
+}
+
+func TestAutoReconnect(t *T) {
+	c, err := DialConfig(Config{
+		Network:   "tcp",
+		Addr:      "127.0.0.1:6379",
+		DB:        5,
+		Reconnect: true,
+	})
+	assert.Nil(t, err)
+	defer c.Close()
+	assert.Nil(t, c.Cmd("SET", "config:db", "5").Err)
+
+	// A derived client sees the new connection as well
+	d := c.WithOptions(Options{KeyPrefix: "config:"})
+
+	c.Conn.Close()
+	assert.NotNil(t, c.Cmd("GET", "config:db").Err)
+
+	s, err := c.Cmd("GET", "config:db").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "5", s)
+	s, err = d.Cmd("GET", "db").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "5", s)
+}
+
+func TestCredentialsProvider(t *T) {
+	calls := 0
+	cfg := Config{
+		Network: "tcp",
+		Addr:    "127.0.0.1:6379",
+		CredentialsProvider: func() (string, string) {
+			calls++
+			return "", ""
+		},
+	}
+	c, err := DialConfig(cfg)
+	assert.Nil(t, err)
+	defer c.Close()
+	assert.Nil(t, c.Reconnect())
+	assert.Equal(t, 2, calls)
+
+	// The test server has no password, so AUTH with one fails
+	cfg.CredentialsProvider = func() (string, string) { return "", "wrong" }
+	_, err = DialConfig(cfg)
+	assert.NotNil(t, err)
+}
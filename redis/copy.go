@@ -0,0 +1,21 @@
+package redis
+
+// Copy copies the value at src to dst in the same database, including its TTL,
+// returning false if it wasn't copied because src doesn't exist or dst already
+// does. With replace an existing dst is overwritten instead. Redis 6.2 and up.
+func (c *Client) Copy(src, dst string, replace bool) (bool, error) {
+	return c.copy(src, dst, replace)
+}
+
+// CopyToDB is like Copy, but copies src into the given database of the same
+// server. dst may have the same name as src.
+func (c *Client) CopyToDB(src string, db int, dst string, replace bool) (bool, error) {
+	return c.copy(src, dst, replace, "DB", db)
+}
+
+func (c *Client) copy(src, dst string, replace bool, args ...interface{}) (bool, error) {
+	if replace {
+		args = append(args, "REPLACE")
+	}
+	return c.Cmd("COPY", src, dst, args).Bool()
+}
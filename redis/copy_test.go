@@ -0,0 +1,52 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func TestCopy(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "copy:src", "copy:dst")
+	c.Cmd("SET", "copy:src", "foo", "EX", 60)
+
+	ok, err := c.Copy("copy:src", "copy:dst", false)
+	if isUnknownCommand(err) {
+		t.Skip("COPY is not supported")
+	}
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ttl, _ := c.Cmd("TTL", "copy:dst").Int()
+	assert.True(t, ttl > 0)
+
+	c.Cmd("SET", "copy:src", "bar")
+	ok, err = c.Copy("copy:src", "copy:dst", false)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	ok, err = c.Copy("copy:src", "copy:dst", true)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	s, _ := c.Cmd("GET", "copy:dst").Str()
+	assert.Equal(t, "bar", s)
+
+	ok, err = c.Copy("copy:missing", "copy:dst", true)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestCopyToDB(t *T) {
+	c, other := dial(t), dial(t)
+	other.Cmd("SELECT", 1)
+	c.Cmd("SET", "copy:db", "foo")
+	other.Cmd("DEL", "copy:db")
+
+	ok, err := c.CopyToDB("copy:db", 1, "copy:db", false)
+	if isUnknownCommand(err) {
+		t.Skip("COPY is not supported")
+	}
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	s, _ := other.Cmd("GET", "copy:db").Str()
+	assert.Equal(t, "foo", s)
+	other.Cmd("DEL", "copy:db")
+}
@@ -13,6 +13,18 @@
 //		// handle err
 //	}
 //
+// DialConfig can be used instead to also authenticate, select a database and
+// set a client name, all of which are restored if the connection is
+// re-established (see Config and Reconnect):
+//
+//	client, err := redis.DialConfig(redis.Config{
+//		Network:   "tcp",
+//		Addr:      "localhost:6379",
+//		Password:  "secret",
+//		DB:        2,
+//		Reconnect: true,
+//	})
+//
 // Make sure to call Close on the client if you want to clean it up before the
 // end of the program.
 //
@@ -36,6 +48,21 @@
 //		// handle err
 //	}
 //
+// Arguments
+//
+// Command arguments can be strings, []byte, bools, or any kind of number.
+// Slices and maps are flattened into the command, so these are the same:
+//
+//	client.Cmd("HMSET", "myhash", map[string]int{"a": 1, "b": 2})
+//	client.Cmd("HMSET", "myhash", "a", 1, "b", 2)
+//
+// Values implementing Marshaler or encoding.TextMarshaler are encoded using
+// them. A time.Duration is sent in whatever unit the command expects it in,
+// e.g. milliseconds after PX in a SET, or seconds for an EXPIRE:
+//
+//	client.Cmd("SET", "foo", "bar", "PX", 1500*time.Millisecond)
+//	client.Cmd("EXPIRE", "foo", time.Minute)
+//
 // Multi Replies
 //
 // The elements to Multi replies can be accessed as strings using List or
@@ -0,0 +1,88 @@
+package redis
+
+import (
+	"time"
+)
+
+// RestoreOpts holds the options of RESTORE, see Restore
+type RestoreOpts struct {
+	// If set, the restored key expires after this long. Otherwise it doesn't
+	// expire. Ignored if ExpireAt is set.
+	TTL time.Duration
+
+	// If set, the restored key expires at this time (ABSTTL, redis 5.0 and up)
+	ExpireAt time.Time
+
+	// Replace the key if it already exists, instead of failing with BUSYKEY
+	Replace bool
+
+	// If set, the key's idle time for LRU eviction (IDLETIME), or its access
+	// frequency for LFU eviction (FREQ). Redis 5.0 and up.
+	IdleTime time.Duration
+	Freq     int
+}
+
+// ttl returns the TTL argument of RESTORE for the options
+func (o RestoreOpts) ttl() int64 {
+	if !o.ExpireAt.IsZero() {
+		return o.ExpireAt.UnixNano() / int64(time.Millisecond)
+	}
+	return int64(o.TTL / time.Millisecond)
+}
+
+// flags returns the arguments of RESTORE which follow the value
+func (o RestoreOpts) flags() []interface{} {
+	var args []interface{}
+	if o.Replace {
+		args = append(args, "REPLACE")
+	}
+	if !o.ExpireAt.IsZero() {
+		args = append(args, "ABSTTL")
+	}
+	if o.IdleTime > 0 {
+		args = append(args, "IDLETIME", int64(o.IdleTime/time.Second))
+	}
+	if o.Freq > 0 {
+		args = append(args, "FREQ", o.Freq)
+	}
+	return args
+}
+
+// Dump returns the value at key serialized by DUMP, for use with Restore. If
+// the key doesn't exist NilReplyError is returned.
+func (c *Client) Dump(key string) ([]byte, error) {
+	return c.Cmd("DUMP", key).Bytes()
+}
+
+// Restore creates the key from a value serialized by DUMP, using the given
+// options
+func (c *Client) Restore(key string, data []byte, opts RestoreOpts) error {
+	return c.Cmd("RESTORE", key, opts.ttl(), data, opts.flags()).Err
+}
+
+// CopyKeyTo copies the key and its TTL from this client's server to dst's,
+// using DUMP and RESTORE. The key must not exist on dst already. If it doesn't
+// exist here NilReplyError is returned. Both servers must use compatible RDB
+// versions, which is usually the case as long as dst's redis is no older.
+//
+// c must not have any commands pipelined with Append whose replies haven't
+// been read yet.
+func (c *Client) CopyKeyTo(dst *Client, key string) error {
+	var b Batch
+	b.Add("DUMP", key)
+	b.Add("PTTL", key)
+	replies := b.Pipeline(c)
+	data, err := replies[0].Bytes()
+	if err != nil {
+		return err
+	}
+	ttl, err := replies[1].Int64()
+	if err != nil {
+		return err
+	}
+	var opts RestoreOpts
+	if ttl > 0 {
+		opts.TTL = time.Duration(ttl) * time.Millisecond
+	}
+	return dst.Restore(key, data, opts)
+}
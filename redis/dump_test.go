@@ -0,0 +1,59 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+func TestRestoreOptsArgs(t *T) {
+	assert.Equal(t, int64(0), RestoreOpts{}.ttl())
+	assert.Nil(t, RestoreOpts{}.flags())
+
+	opts := RestoreOpts{
+		TTL: 1500 * time.Millisecond, Replace: true, IdleTime: time.Minute, Freq: 5,
+	}
+	assert.Equal(t, int64(1500), opts.ttl())
+	assert.Equal(t, []interface{}{"REPLACE", "IDLETIME", int64(60), "FREQ", 5}, opts.flags())
+
+	opts = RestoreOpts{ExpireAt: time.Unix(1700000000, 0)}
+	assert.Equal(t, int64(1700000000000), opts.ttl())
+	assert.Equal(t, []interface{}{"ABSTTL"}, opts.flags())
+}
+
+func TestDumpRestore(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "dump:src", "dump:dst")
+	c.Cmd("SET", "dump:src", "foo", "PX", 60000)
+
+	data, err := c.Dump("dump:src")
+	assert.Nil(t, err)
+	assert.Nil(t, c.Restore("dump:dst", data, RestoreOpts{TTL: time.Minute}))
+	s, _ := c.Cmd("GET", "dump:dst").Str()
+	assert.Equal(t, "foo", s)
+	ttl, _ := c.Cmd("PTTL", "dump:dst").Int()
+	assert.True(t, ttl > 50000)
+
+	assert.NotNil(t, c.Restore("dump:dst", data, RestoreOpts{}))
+	assert.Nil(t, c.Restore("dump:dst", data, RestoreOpts{Replace: true}))
+
+	_, err = c.Dump("dump:missing")
+	assert.Equal(t, NilReplyError, err)
+}
+
+func TestCopyKeyTo(t *T) {
+	src, dst := dial(t), dial(t)
+	dst.Cmd("SELECT", 1)
+	src.Cmd("DEL", "dump:copy")
+	dst.Cmd("DEL", "dump:copy")
+	src.Cmd("SET", "dump:copy", "bar", "PX", 60000)
+
+	assert.Nil(t, src.CopyKeyTo(dst, "dump:copy"))
+	s, _ := dst.Cmd("GET", "dump:copy").Str()
+	assert.Equal(t, "bar", s)
+	ttl, _ := dst.Cmd("PTTL", "dump:copy").Int()
+	assert.True(t, ttl > 50000 && ttl <= 60000)
+
+	assert.Equal(t, NilReplyError, src.CopyKeyTo(dst, "dump:missing"))
+	dst.Cmd("DEL", "dump:copy")
+}
@@ -0,0 +1,163 @@
+package redis
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+)
+
+// These can be used with errors.Is to tell what kind of problem a connection
+// error (a *ConnError) is. Every connection error is a NetworkError, and it's
+// also a TimeoutError if it's due to a read or write timing out, or a
+// ProtocolError if the server sent something which couldn't be parsed (or
+// which went over the limits set with SetReplyLimits). If the error left the
+// connection out of sync, with part or all of a reply left unread, it's also a
+// DesyncError.
+//
+//	r := conn.Cmd("GET", "foo")
+//	if errors.Is(r.Err, redis.TimeoutError) {
+//		// Retry with a longer timeout
+//	}
+var (
+	NetworkError  = errors.New("network error")
+	TimeoutError  = errors.New("timeout")
+	ProtocolError = errors.New("protocol error")
+	DesyncError   = errors.New("connection out of sync")
+)
+
+// ConnError is the error returned for any problem with the connection to redis,
+// as opposed to an error returned by redis itself (a *CmdError). The
+// connection is closed after a ConnError (see Client.Broken), unless it's a
+// timeout from ReadReply which happened before any of the reply arrived. In
+// particular a command whose reply times out closes the connection, since the
+// reply would otherwise be read as the reply to the next command. The original
+// error can be retrieved using errors.As or errors.Unwrap.
+//
+// ConnError implements net.Error, so code checking for timeouts using
+// net.Error's Timeout method continues to work.
+type ConnError struct {
+	Err error
+
+	protocol, desync bool
+}
+
+func (e *ConnError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConnError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout returns whether the error is due to a read or write timing out
+func (e *ConnError) Timeout() bool {
+	nerr, ok := e.Err.(net.Error)
+	return ok && nerr.Timeout()
+}
+
+// Temporary returns the same as Timeout
+func (e *ConnError) Temporary() bool {
+	return e.Timeout()
+}
+
+// Is makes errors.Is match NetworkError for every ConnError, TimeoutError for
+// those which are timeouts, ProtocolError for those which are protocol errors
+// and DesyncError for those which left the connection out of sync
+func (e *ConnError) Is(target error) bool {
+	switch target {
+	case NetworkError:
+		return true
+	case TimeoutError:
+		return e.Timeout()
+	case ProtocolError:
+		return e.protocol
+	case DesyncError:
+		return e.desync
+	}
+	return false
+}
+
+// connError wraps an error from reading or writing the connection in a
+// ConnError, working out whether it's a protocol error
+func connError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*ConnError); ok {
+		return err
+	}
+	_, isNet := err.(net.Error)
+	protocol := !isNet && err != io.EOF && err != io.ErrUnexpectedEOF &&
+		!errors.Is(err, net.ErrClosed)
+	return &ConnError{Err: err, protocol: protocol}
+}
+
+// desyncError is like connError, for an error which happened part way through
+// reading a reply
+func desyncError(err error) error {
+	cerr := connError(err).(*ConnError)
+	cerr.desync = true
+	return cerr
+}
+
+// Sentinels for the error codes redis prefixes its errors with, for use with
+// errors.Is. A *CmdError matches the sentinel for its code, see CmdError.Code.
+//
+//	if errors.Is(r.Err, redis.WrongTypeError) {
+//		// The key holds a different type
+//	}
+var (
+	GenericError     = codeError("ERR")
+	WrongTypeError   = codeError("WRONGTYPE")
+	OOMError         = codeError("OOM")
+	ReadOnlyError    = codeError("READONLY")
+	NoScriptError    = codeError("NOSCRIPT")
+	BusyError        = codeError("BUSY")
+	NoAuthError      = codeError("NOAUTH")
+	NoPermError      = codeError("NOPERM")
+	WrongPassError   = codeError("WRONGPASS")
+	ExecAbortError   = codeError("EXECABORT")
+	MovedError       = codeError("MOVED")
+	AskError         = codeError("ASK")
+	TryAgainError    = codeError("TRYAGAIN")
+	ClusterDownError = codeError("CLUSTERDOWN")
+	MasterDownError  = codeError("MASTERDOWN")
+)
+
+func codeError(code string) *CmdError {
+	return &CmdError{errors.New(code)}
+}
+
+// Code returns the error code redis prefixed the error with, e.g. "WRONGTYPE"
+// or "ERR", or an empty string if it didn't have one
+func (cerr *CmdError) Code() string {
+	msg := cerr.Error()
+	if i := strings.IndexByte(msg, ' '); i >= 0 {
+		msg = msg[:i]
+	}
+	if msg == "" {
+		return ""
+	}
+	for _, r := range msg {
+		if (r < 'A' || r > 'Z') && r != '_' {
+			return ""
+		}
+	}
+	return msg
+}
+
+// Is makes errors.Is match a CmdError against the sentinel for its code (e.g.
+// WrongTypeError)
+func (cerr *CmdError) Is(target error) bool {
+	t, ok := target.(*CmdError)
+	if !ok || strings.IndexByte(t.Error(), ' ') >= 0 {
+		return false
+	}
+	code := cerr.Code()
+	return code != "" && code == t.Error()
+}
+
+func (cerr *CmdError) Unwrap() error {
+	return cerr.Err
+}
@@ -0,0 +1,109 @@
+package redis
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"github.com/fzzy/radix/redis/resp"
+	"github.com/stretchr/testify/assert"
+	"net"
+	. "testing"
+	"time"
+)
+
+func TestCmdErrorCode(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "errors:list")
+	c.Cmd("LPUSH", "errors:list", "foo")
+
+	err := c.Cmd("GET", "errors:list").Err
+	assert.Equal(t, "WRONGTYPE", err.(*CmdError).Code())
+	assert.True(t, errors.Is(err, WrongTypeError))
+	assert.False(t, errors.Is(err, GenericError))
+	assert.False(t, errors.Is(err, NetworkError))
+
+	err = c.Cmd("NOTACOMMAND").Err
+	assert.Equal(t, "ERR", err.(*CmdError).Code())
+	assert.True(t, errors.Is(err, GenericError))
+
+	var cerr *CmdError
+	assert.True(t, errors.As(err, &cerr))
+
+	assert.Equal(t, "", (&CmdError{errors.New("bad command, no key")}).Code())
+	assert.True(t, errors.Is(NestedMultiError, GenericError))
+	assert.False(t, errors.Is(GenericError, NestedMultiError))
+}
+
+func TestConnError(t *T) {
+	c := dial(t)
+	c.Close()
+	err := c.Cmd("PING").Err
+	assert.True(t, errors.Is(err, NetworkError))
+	assert.False(t, errors.Is(err, TimeoutError))
+	assert.False(t, errors.Is(err, ProtocolError))
+	_, ok := err.(*CmdError)
+	assert.False(t, ok)
+
+	_, err = DialTimeout("tcp", "127.0.0.1:1", time.Second)
+	assert.True(t, errors.Is(err, NetworkError))
+
+	c = dial(t)
+	err = c.WithTimeout(time.Millisecond).Cmd("BLPOP", "errors:nothing", 1).Err
+	assert.True(t, errors.Is(err, NetworkError))
+	assert.True(t, errors.Is(err, TimeoutError))
+	nerr, ok := err.(net.Error)
+	assert.True(t, ok)
+	assert.True(t, nerr.Timeout())
+
+	c = dial(t)
+	c.reader = bufio.NewReader(bytes.NewBufferString("?what\r\n"))
+	err = c.parse().Err
+	assert.True(t, errors.Is(err, NetworkError))
+	assert.True(t, errors.Is(err, ProtocolError))
+
+	c = dial(t)
+	c.SetReplyLimits(1, 0)
+	c.reader = bufio.NewReader(bytes.NewBufferString("*1\r\n*1\r\n:1\r\n"))
+	err = c.parse().Err
+	assert.True(t, errors.Is(err, ProtocolError))
+	assert.True(t, errors.Is(err, resp.DepthLimitError))
+}
+
+func TestDesync(t *T) {
+	// A command whose reply times out leaves the connection out of sync, so
+	// it's closed
+	c := dial(t)
+	err := c.WithTimeout(10*time.Millisecond).Cmd("BLPOP", "errors:nothing", 1).Err
+	assert.True(t, errors.Is(err, TimeoutError))
+	assert.True(t, errors.Is(err, DesyncError))
+	assert.True(t, c.Broken())
+	assert.NotNil(t, c.Cmd("PING").Err)
+
+	// With Reconnect the next command gets a new connection, rather than the
+	// late reply
+	c, err = DialConfig(Config{Network: "tcp", Addr: "127.0.0.1:6379", Reconnect: true})
+	assert.Nil(t, err)
+	c.Cmd("DEL", "errors:desync")
+	err = c.WithTimeout(10*time.Millisecond).Cmd("BLPOP", "errors:desync", 1).Err
+	assert.True(t, errors.Is(err, DesyncError))
+	c.Cmd("RPUSH", "errors:desync", "late")
+	s, err := c.Cmd("ECHO", "hi").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "hi", s)
+	assert.False(t, c.Broken())
+
+	// Waiting for a reply which hasn't been asked for can time out cleanly
+	c = dial(t)
+	err = c.WithTimeout(10 * time.Millisecond).ReadReply().Err
+	assert.True(t, errors.Is(err, TimeoutError))
+	assert.False(t, errors.Is(err, DesyncError))
+	assert.False(t, c.Broken())
+	assert.Nil(t, c.Cmd("PING").Err)
+
+	// But not once part of a reply has been read
+	c = dial(t)
+	c.reader = bufio.NewReader(bytes.NewBufferString("$10\r\nabc"))
+	err = c.parse().Err
+	assert.True(t, errors.Is(err, DesyncError))
+	assert.True(t, c.Broken())
+}
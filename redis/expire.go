@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"time"
+)
+
+// ExpireCond is a condition for Expire and ExpireAt to only change a key's
+// expiry in some cases. The conditions need redis 7.0 and up.
+type ExpireCond string
+
+const (
+	// Always set the expiry
+	ExpireAlways ExpireCond = ""
+
+	// Only if the key doesn't have an expiry yet
+	ExpireNX ExpireCond = "NX"
+
+	// Only if the key already has an expiry
+	ExpireXX ExpireCond = "XX"
+
+	// Only if the new expiry is later (GT) or earlier (LT) than the current
+	// one. A key without an expiry is treated as expiring never.
+	ExpireGT ExpireCond = "GT"
+	ExpireLT ExpireCond = "LT"
+)
+
+func (cond ExpireCond) args() []interface{} {
+	if cond == ExpireAlways {
+		return nil
+	}
+	return []interface{}{string(cond)}
+}
+
+// Expire has the key expire after ttl, to the millisecond, if cond allows it.
+// It returns false if the key doesn't exist or cond stopped the expiry from
+// being set.
+func (c *Client) Expire(key string, ttl time.Duration, cond ExpireCond) (bool, error) {
+	return c.Cmd("PEXPIRE", key, ttl, cond.args()).Bool()
+}
+
+// ExpireAt is like Expire, but has the key expire at the given time
+func (c *Client) ExpireAt(key string, at time.Time, cond ExpireCond) (bool, error) {
+	ms := at.UnixNano() / int64(time.Millisecond)
+	return c.Cmd("PEXPIREAT", key, ms, cond.args()).Bool()
+}
+
+// ExpireTime returns the time at which the key will expire, to the
+// millisecond. If the key exists but has no expiry the time is zero, and if it
+// doesn't exist exists is false. Redis 7.0 and up.
+func (c *Client) ExpireTime(key string) (t time.Time, exists bool, err error) {
+	ms, err := c.Cmd("PEXPIRETIME", key).Int64()
+	switch {
+	case err != nil:
+		return time.Time{}, false, err
+	case ms == -2:
+		return time.Time{}, false, nil
+	case ms == -1:
+		return time.Time{}, true, nil
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)), true, nil
+}
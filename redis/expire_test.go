@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+func TestExpireCond(t *T) {
+	c := dial(t)
+	key := "expire:key"
+	c.Cmd("SET", key, "foo")
+
+	ok, err := c.Expire(key, time.Minute, ExpireXX)
+	if err != nil {
+		t.Skip("EXPIRE conditions are not supported")
+	}
+	assert.False(t, ok)
+	ok, err = c.Expire(key, time.Minute, ExpireNX)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, err = c.Expire(key, time.Second, ExpireGT)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	ok, err = c.Expire(key, time.Second, ExpireLT)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = c.Expire("expire:missing", time.Minute, ExpireAlways)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestExpireTime(t *T) {
+	c := dial(t)
+	key := "expire:time"
+	c.Cmd("SET", key, "foo")
+
+	at := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	ok, err := c.ExpireAt(key, at, ExpireAlways)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	got, exists, err := c.ExpireTime(key)
+	if isUnknownCommand(err) {
+		t.Skip("PEXPIRETIME is not supported")
+	}
+	assert.Nil(t, err)
+	assert.True(t, exists)
+	assert.True(t, at.Equal(got), got)
+
+	c.Cmd("PERSIST", key)
+	got, exists, err = c.ExpireTime(key)
+	assert.Nil(t, err)
+	assert.True(t, exists)
+	assert.True(t, got.IsZero())
+
+	_, exists, err = c.ExpireTime("expire:missing")
+	assert.Nil(t, err)
+	assert.False(t, exists)
+}
@@ -0,0 +1,289 @@
+package redis
+
+import (
+	"errors"
+	"strconv"
+)
+
+// GeoUnit is a unit of distance used by the GEO commands
+type GeoUnit string
+
+const (
+	Meters     GeoUnit = "m"
+	Kilometers GeoUnit = "km"
+	Miles      GeoUnit = "mi"
+	Feet       GeoUnit = "ft"
+)
+
+// GeoCoord is a position on the earth, in degrees
+type GeoCoord struct {
+	Longitude, Latitude float64
+}
+
+// GeoMember is a named position, as added to a geo set by GeoAdd
+type GeoMember struct {
+	Name string
+	GeoCoord
+}
+
+// GeoLocation is a member found by GeoSearch. Dist, Hash and Coord are only
+// filled in if the search asked for them.
+type GeoLocation struct {
+	Name  string
+	Dist  float64
+	Hash  int64
+	Coord GeoCoord
+}
+
+// GeoSearchOpts describes a GEOSEARCH. Exactly one of FromMember and FromCoord
+// must be set for the center of the search, and either Radius (BYRADIUS) or
+// Width and Height (BYBOX) for its shape, both in Unit (meters by default).
+type GeoSearchOpts struct {
+	FromMember string
+	FromCoord  *GeoCoord
+
+	Radius        float64
+	Width, Height float64
+	Unit          GeoUnit
+
+	// Sort the results nearest first (ASC), or furthest first (DESC).
+	// Otherwise they're in no particular order.
+	Asc, Desc bool
+
+	// If not zero, return at most this many results. With Any, redis stops as
+	// soon as it's found enough of them, so they may not be the nearest.
+	Count int
+	Any   bool
+
+	// Fill in the Coord, Dist and Hash of each GeoLocation
+	WithCoord, WithDist, WithHash bool
+}
+
+func (o GeoSearchOpts) args() ([]interface{}, error) {
+	var args []interface{}
+	switch {
+	case o.FromMember != "" && o.FromCoord != nil:
+		return nil, errors.New("only one of FromMember and FromCoord can be set")
+	case o.FromMember != "":
+		args = append(args, "FROMMEMBER", o.FromMember)
+	case o.FromCoord != nil:
+		args = append(args, "FROMLONLAT", o.FromCoord.Longitude, o.FromCoord.Latitude)
+	default:
+		return nil, errors.New("one of FromMember and FromCoord must be set")
+	}
+
+	unit := o.Unit
+	if unit == "" {
+		unit = Meters
+	}
+	box := o.Width != 0 || o.Height != 0
+	switch {
+	case o.Radius != 0 && box:
+		return nil, errors.New("only one of Radius and Width/Height can be set")
+	case o.Radius > 0:
+		args = append(args, "BYRADIUS", o.Radius, string(unit))
+	case o.Width > 0 && o.Height > 0:
+		args = append(args, "BYBOX", o.Width, o.Height, string(unit))
+	default:
+		return nil, errors.New("a positive Radius, or Width and Height, must be set")
+	}
+
+	if o.Asc && o.Desc {
+		return nil, errors.New("Asc and Desc can not both be set")
+	} else if o.Asc {
+		args = append(args, "ASC")
+	} else if o.Desc {
+		args = append(args, "DESC")
+	}
+	if o.Count > 0 {
+		args = append(args, "COUNT", o.Count)
+		if o.Any {
+			args = append(args, "ANY")
+		}
+	} else if o.Any {
+		return nil, errors.New("Any can only be set with Count")
+	}
+	return args, nil
+}
+
+// GeoAdd adds the members to the geo set at key, or updates their positions if
+// they're already in it, and returns the number added
+func (c *Client) GeoAdd(key string, members ...GeoMember) (int, error) {
+	args := make([]interface{}, 0, 1+len(members)*3)
+	args = append(args, key)
+	for _, m := range members {
+		args = append(args, m.Longitude, m.Latitude, m.Name)
+	}
+	return c.Cmd("GEOADD", args...).Int()
+}
+
+// GeoDist returns the distance between two members of the geo set at key, in
+// the given unit (meters if empty). ok is false if either member is missing.
+func (c *Client) GeoDist(key, member1, member2 string, unit GeoUnit) (
+	dist float64, ok bool, err error,
+) {
+	if unit == "" {
+		unit = Meters
+	}
+	r := c.Cmd("GEODIST", key, member1, member2, string(unit))
+	if r.Type == NilReply {
+		return 0, false, nil
+	}
+	s, err := r.Str()
+	if err != nil {
+		return 0, false, err
+	}
+	if dist, err = strconv.ParseFloat(s, 64); err != nil {
+		return 0, false, err
+	}
+	return dist, true, nil
+}
+
+// GeoPos returns the positions of the given members of the geo set at key, in
+// the same order. The position of a missing member is nil.
+func (c *Client) GeoPos(key string, members ...string) ([]*GeoCoord, error) {
+	r := c.Cmd("GEOPOS", key, members)
+	if r.Type == ErrorReply {
+		return nil, r.Err
+	}
+	if r.Type != MultiReply {
+		return nil, errors.New("reply type is not MultiReply")
+	}
+	coords := make([]*GeoCoord, len(r.Elems))
+	for i, e := range r.Elems {
+		if e.Type == NilReply {
+			continue
+		}
+		coord, err := parseGeoCoord(e)
+		if err != nil {
+			return nil, err
+		}
+		coords[i] = &coord
+	}
+	return coords, nil
+}
+
+func parseGeoCoord(r *Reply) (GeoCoord, error) {
+	l, err := r.List()
+	if err != nil {
+		return GeoCoord{}, err
+	}
+	if len(l) != 2 {
+		return GeoCoord{}, errors.New("position does not have 2 elements")
+	}
+	var coord GeoCoord
+	if coord.Longitude, err = strconv.ParseFloat(l[0], 64); err != nil {
+		return GeoCoord{}, err
+	}
+	if coord.Latitude, err = strconv.ParseFloat(l[1], 64); err != nil {
+		return GeoCoord{}, err
+	}
+	return coord, nil
+}
+
+// GeoSearch returns the members of the geo set at key within the area
+// described by opts. Redis 6.2 and up.
+func (c *Client) GeoSearch(key string, opts GeoSearchOpts) ([]GeoLocation, error) {
+	args, err := opts.args()
+	if err != nil {
+		return nil, err
+	}
+	args = append([]interface{}{key}, args...)
+	if opts.WithCoord {
+		args = append(args, "WITHCOORD")
+	}
+	if opts.WithDist {
+		args = append(args, "WITHDIST")
+	}
+	if opts.WithHash {
+		args = append(args, "WITHHASH")
+	}
+	return parseGeoLocations(c.Cmd("GEOSEARCH", args...), opts)
+}
+
+// parseGeoLocations parses a GEOSEARCH reply. When any of the WITH options are
+// given each location is a multi bulk reply of the name followed by the
+// distance, hash and coordinates, in that order, leaving out the ones which
+// weren't asked for.
+func parseGeoLocations(r *Reply, opts GeoSearchOpts) ([]GeoLocation, error) {
+	if r.Type == ErrorReply {
+		return nil, r.Err
+	}
+	if r.Type != MultiReply {
+		return nil, errors.New("reply type is not MultiReply")
+	}
+	with := opts.WithCoord || opts.WithDist || opts.WithHash
+	locs := make([]GeoLocation, len(r.Elems))
+	for i, e := range r.Elems {
+		var err error
+		if !with {
+			if locs[i].Name, err = e.Str(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if e.Type != MultiReply || len(e.Elems) == 0 {
+			return nil, errors.New("malformed location")
+		}
+		if locs[i].Name, err = e.Elems[0].Str(); err != nil {
+			return nil, err
+		}
+		rest := e.Elems[1:]
+		next := func() (*Reply, error) {
+			if len(rest) == 0 {
+				return nil, errors.New("location is missing elements")
+			}
+			r := rest[0]
+			rest = rest[1:]
+			return r, nil
+		}
+		if opts.WithDist {
+			dr, err := next()
+			if err != nil {
+				return nil, err
+			}
+			s, err := dr.Str()
+			if err != nil {
+				return nil, err
+			}
+			if locs[i].Dist, err = strconv.ParseFloat(s, 64); err != nil {
+				return nil, err
+			}
+		}
+		if opts.WithHash {
+			hr, err := next()
+			if err != nil {
+				return nil, err
+			}
+			if locs[i].Hash, err = hr.Int64(); err != nil {
+				return nil, err
+			}
+		}
+		if opts.WithCoord {
+			cr, err := next()
+			if err != nil {
+				return nil, err
+			}
+			if locs[i].Coord, err = parseGeoCoord(cr); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return locs, nil
+}
+
+// GeoSearchStore is like GeoSearch, but stores the members found in the geo set
+// at dest and returns how many there were. If storeDist is set, dest is
+// instead a plain sorted set of the members scored by their distance. The WITH
+// options of opts are not used. Redis 6.2 and up.
+func (c *Client) GeoSearchStore(dest, src string, opts GeoSearchOpts, storeDist bool) (int, error) {
+	args, err := opts.args()
+	if err != nil {
+		return 0, err
+	}
+	args = append([]interface{}{dest, src}, args...)
+	if storeDist {
+		args = append(args, "STOREDIST")
+	}
+	return c.Cmd("GEOSEARCHSTORE", args...).Int()
+}
@@ -0,0 +1,118 @@
+package redis
+
+import (
+	"math"
+
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+var sicily = []GeoMember{
+	{"Palermo", GeoCoord{13.361389, 38.115556}},
+	{"Catania", GeoCoord{15.087269, 37.502669}},
+}
+
+func TestGeoSearchOptsArgs(t *T) {
+	args, err := GeoSearchOpts{
+		FromCoord: &GeoCoord{15, 37}, Radius: 200, Unit: Kilometers,
+		Asc: true, Count: 1, Any: true,
+	}.args()
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{
+		"FROMLONLAT", 15.0, 37.0, "BYRADIUS", 200.0, "km", "ASC", "COUNT", 1, "ANY",
+	}, args)
+
+	for _, opts := range []GeoSearchOpts{
+		{Radius: 1},
+		{FromMember: "a", FromCoord: &GeoCoord{}, Radius: 1},
+		{FromMember: "a"},
+		{FromMember: "a", Radius: 1, Width: 1, Height: 1},
+		{FromMember: "a", Width: 1},
+		{FromMember: "a", Radius: 1, Asc: true, Desc: true},
+		{FromMember: "a", Radius: 1, Any: true},
+	} {
+		_, err := opts.args()
+		assert.NotNil(t, err, opts)
+	}
+}
+
+func TestParseGeoLocations(t *T) {
+	r := NewReply([]interface{}{
+		[]interface{}{"Catania", "56.4413", int64(3479447370796909),
+			[]interface{}{"15.087267", "37.502668"}},
+	})
+	locs, err := parseGeoLocations(r, GeoSearchOpts{
+		WithCoord: true, WithDist: true, WithHash: true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []GeoLocation{{
+		Name:  "Catania",
+		Dist:  56.4413,
+		Hash:  3479447370796909,
+		Coord: GeoCoord{15.087267, 37.502668},
+	}}, locs)
+
+	locs, err = parseGeoLocations(NewReply([]interface{}{"a", "b"}), GeoSearchOpts{})
+	assert.Nil(t, err)
+	assert.Equal(t, []GeoLocation{{Name: "a"}, {Name: "b"}}, locs)
+
+	_, err = parseGeoLocations(
+		NewReply([]interface{}{[]interface{}{"a"}}), GeoSearchOpts{WithDist: true},
+	)
+	assert.NotNil(t, err)
+}
+
+func TestGeo(t *T) {
+	c := dial(t)
+	key := "geo:sicily"
+	c.Cmd("DEL", key)
+
+	n, err := c.GeoAdd(key, sicily...)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+
+	dist, ok, err := c.GeoDist(key, "Palermo", "Catania", Kilometers)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.True(t, dist > 166 && dist < 167)
+	_, ok, err = c.GeoDist(key, "Palermo", "Rome", "")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	coords, err := c.GeoPos(key, "Palermo", "Rome")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(coords))
+	assert.True(t, math.Abs(coords[0].Longitude-13.361389) < 0.0001)
+	assert.Nil(t, coords[1])
+
+	locs, err := c.GeoSearch(key, GeoSearchOpts{
+		FromCoord: &GeoCoord{15, 37}, Radius: 200, Unit: Kilometers,
+		Asc: true, WithDist: true, WithCoord: true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(locs))
+	assert.Equal(t, "Catania", locs[0].Name)
+	assert.True(t, locs[0].Dist < locs[1].Dist)
+	assert.True(t, math.Abs(locs[1].Coord.Latitude-38.115556) < 0.0001)
+
+	locs, err = c.GeoSearch(key, GeoSearchOpts{
+		FromMember: "Palermo", Width: 400, Height: 400, Unit: Kilometers,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(locs))
+}
+
+func TestGeoSearchStore(t *T) {
+	c := dial(t)
+	key := "geo:sicily"
+	c.Cmd("DEL", key, "geo:near")
+	c.GeoAdd(key, sicily...)
+
+	opts := GeoSearchOpts{FromCoord: &GeoCoord{15, 37}, Radius: 100, Unit: Kilometers}
+	n, err := c.GeoSearchStore("geo:near", key, opts, true)
+	if isUnknownCommand(err) {
+		t.Skip("GEOSEARCHSTORE is not supported")
+	}
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+}
@@ -0,0 +1,52 @@
+package redis
+
+import (
+	"errors"
+	"time"
+)
+
+// GetExOpts holds the options of GETEX, see GetEx. At most one may be set.
+type GetExOpts struct {
+	// Have the key expire after this long (PX)
+	TTL time.Duration
+
+	// Have the key expire at this time (PXAT)
+	ExpireAt time.Time
+
+	// Remove any TTL the key has (PERSIST)
+	Persist bool
+}
+
+func (o GetExOpts) args() ([]interface{}, error) {
+	switch {
+	case (o.TTL != 0 && (!o.ExpireAt.IsZero() || o.Persist)) ||
+		(!o.ExpireAt.IsZero() && o.Persist):
+		return nil, errors.New("only one of TTL, ExpireAt and Persist can be set")
+	case o.TTL < 0:
+		return nil, errors.New("TTL can not be negative")
+	case o.TTL != 0:
+		return []interface{}{"PX", o.TTL}, nil
+	case !o.ExpireAt.IsZero():
+		return []interface{}{"PXAT", o.ExpireAt.UnixNano() / int64(time.Millisecond)}, nil
+	case o.Persist:
+		return []interface{}{"PERSIST"}, nil
+	}
+	return nil, nil
+}
+
+// GetEx returns the value at key, like GET, while atomically changing its
+// expiry as given by opts. The reply is a NilReply if the key doesn't exist.
+// Redis 6.2 and up.
+func (c *Client) GetEx(key string, opts GetExOpts) *Reply {
+	args, err := opts.args()
+	if err != nil {
+		return &Reply{Type: ErrorReply, Err: err}
+	}
+	return c.Cmd("GETEX", key, args)
+}
+
+// GetDel returns the value at key, like GET, and atomically deletes the key.
+// The reply is a NilReply if the key doesn't exist. Redis 6.2 and up.
+func (c *Client) GetDel(key string) *Reply {
+	return c.Cmd("GETDEL", key)
+}
@@ -0,0 +1,66 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+func TestGetExOptsArgs(t *T) {
+	at := time.Unix(1700000000, 0)
+	for _, test := range []struct {
+		opts GetExOpts
+		args []interface{}
+	}{
+		{GetExOpts{}, nil},
+		{GetExOpts{TTL: time.Second}, []interface{}{"PX", time.Second}},
+		{GetExOpts{ExpireAt: at}, []interface{}{"PXAT", int64(1700000000000)}},
+		{GetExOpts{Persist: true}, []interface{}{"PERSIST"}},
+	} {
+		args, err := test.opts.args()
+		assert.Nil(t, err)
+		assert.Equal(t, test.args, args)
+	}
+
+	for _, opts := range []GetExOpts{
+		{TTL: time.Second, Persist: true},
+		{TTL: time.Second, ExpireAt: at},
+		{ExpireAt: at, Persist: true},
+		{TTL: -time.Second},
+	} {
+		_, err := opts.args()
+		assert.NotNil(t, err, opts)
+	}
+}
+
+func TestGetEx(t *T) {
+	c := dial(t)
+	key := "getex:key"
+	c.Cmd("SET", key, "foo")
+
+	s, err := c.GetEx(key, GetExOpts{TTL: time.Minute}).Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", s)
+	ttl, _ := c.Cmd("PTTL", key).Int()
+	assert.True(t, ttl > 50000 && ttl <= 60000)
+
+	s, err = c.GetEx(key, GetExOpts{Persist: true}).Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", s)
+	ttl, _ = c.Cmd("PTTL", key).Int()
+	assert.Equal(t, -1, ttl)
+
+	assert.Equal(t, NilReply, c.GetEx("getex:missing", GetExOpts{}).Type)
+	assert.Equal(t, ErrorReply, c.GetEx(key, GetExOpts{TTL: -1}).Type)
+}
+
+func TestGetDel(t *T) {
+	c := dial(t)
+	key := "getdel:key"
+	c.Cmd("SET", key, "foo")
+
+	s, err := c.GetDel(key).Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", s)
+	assert.Equal(t, NilReply, c.GetDel(key).Type)
+}
@@ -0,0 +1,80 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// HealthReport is the result of HealthCheck, in a form which can be returned
+// from a readiness probe
+type HealthReport struct {
+	// Whether all the checks passed. If not, Err says why.
+	Healthy bool
+	Err     error
+
+	// The round trip time of a PING
+	Latency time.Duration
+
+	// The server's role as reported by ROLE: "master", "slave" or "sentinel",
+	// or empty if the server doesn't support ROLE
+	Role string
+
+	// For a replica, the state of its link to the master (e.g. "connected" or
+	// "sync")
+	ReplicaState string
+}
+
+// HealthCheck PINGs the server and checks its role, returning a report of how
+// it went. If role isn't empty the server's role must match it, e.g. "master"
+// to make sure writes can be made. The checks are bounded by ctx's deadline, if
+// it has one, and not run at all if ctx is already done.
+func (c *Client) HealthCheck(ctx context.Context, role string) HealthReport {
+	var report HealthReport
+	if report.Err = ctx.Err(); report.Err != nil {
+		return report
+	}
+	d := c
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout := deadline.Sub(time.Now())
+		if timeout <= 0 {
+			report.Err = context.DeadlineExceeded
+			return report
+		}
+		d = c.WithOptions(Options{ReadTimeout: timeout, WriteTimeout: timeout})
+	}
+
+	start := time.Now()
+	if report.Err = d.Cmd("PING").Err; report.Err != nil {
+		return report
+	}
+	report.Latency = time.Since(start)
+
+	r := d.Cmd("ROLE")
+	if r.Err != nil && !isUnknownCommand(r.Err) {
+		report.Err = r.Err
+		return report
+	} else if r.Err == nil && len(r.Elems) > 0 {
+		report.Role, _ = r.Elems[0].Str()
+		if report.Role == "slave" && len(r.Elems) > 3 {
+			report.ReplicaState, _ = r.Elems[3].Str()
+		}
+	}
+
+	if role != "" && report.Role != role {
+		if report.Role == "" {
+			report.Err = errors.New("server role could not be determined")
+		} else {
+			report.Err = fmt.Errorf("server role is %q, expected %q", report.Role, role)
+		}
+		return report
+	}
+	if report.Role == "slave" && report.ReplicaState != "connected" {
+		report.Err = fmt.Errorf("replica link to master is %q", report.ReplicaState)
+		return report
+	}
+
+	report.Healthy = true
+	return report
+}
@@ -0,0 +1,31 @@
+package redis
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+func TestHealthCheck(t *T) {
+	c := dial(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	report := c.HealthCheck(ctx, "")
+	assert.True(t, report.Healthy)
+	assert.Nil(t, report.Err)
+	assert.True(t, report.Latency > 0)
+
+	if report.Role != "" {
+		assert.True(t, c.HealthCheck(ctx, report.Role).Healthy)
+	}
+	report = c.HealthCheck(ctx, "sentinel")
+	assert.False(t, report.Healthy)
+	assert.NotNil(t, report.Err)
+
+	cancel()
+	report = c.HealthCheck(ctx, "")
+	assert.False(t, report.Healthy)
+	assert.Equal(t, context.Canceled, report.Err)
+}
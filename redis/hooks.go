@@ -0,0 +1,141 @@
+package redis
+
+import (
+	"context"
+	"errors"
+)
+
+// Metadata is a set of values attached to a context using WithMetadata, such as
+// request or tenant IDs, which is passed along with a command to every Hook
+// and ends up in the command's Reply
+type Metadata map[string]string
+
+type metadataKey struct{}
+
+// WithMetadata returns a copy of ctx with the given metadata value set, keeping
+// any metadata ctx already has
+func WithMetadata(ctx context.Context, key, value string) context.Context {
+	old := MetadataFrom(ctx)
+	md := make(Metadata, len(old)+1)
+	for k, v := range old {
+		md[k] = v
+	}
+	md[key] = value
+	return context.WithValue(ctx, metadataKey{}, md)
+}
+
+// MetadataFrom returns the metadata attached to ctx, or nil if there isn't any.
+// The returned Metadata must not be modified.
+func MetadataFrom(ctx context.Context) Metadata {
+	md, _ := ctx.Value(metadataKey{}).(Metadata)
+	return md
+}
+
+// MetadataError is the error of a Reply from CmdContext whose context had
+// metadata. It has the same message as the error it wraps, which errors.As and
+// errors.Is see through, so e.g. a *CmdError can still be found using
+// errors.As.
+type MetadataError struct {
+	Err      error
+	Metadata Metadata
+}
+
+func (e *MetadataError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error the command failed with
+func (e *MetadataError) Unwrap() error {
+	return e.Err
+}
+
+// MetadataFromError returns the metadata of the command which err came from, if
+// it (or an error it wraps) is a *MetadataError, or nil if not
+func MetadataFromError(err error) Metadata {
+	var merr *MetadataError
+	if errors.As(err, &merr) {
+		return merr.Metadata
+	}
+	return nil
+}
+
+// HookFunc runs a command, see Hook
+type HookFunc func(ctx context.Context, cmd string, args []interface{}) *Reply
+
+// Hook wraps the running of every command on a client which it was added to
+// with AddHook. It's given the command and next, which runs the command (or the
+// next hook); a hook may change any of them before calling next, or not call
+// it at all and return its own reply instead. In particular a hook can attach
+// metadata to the context using WithMetadata, which the hooks after it will
+// see:
+//
+//	client.AddHook(func(
+//		ctx context.Context, cmd string, args []interface{}, next redis.HookFunc,
+//	) *redis.Reply {
+//		ctx = redis.WithMetadata(ctx, "tenant", tenantOf(args))
+//		return next(ctx, cmd, args)
+//	})
+type Hook func(
+	ctx context.Context, cmd string, args []interface{}, next HookFunc,
+) *Reply
+
+// AddHook adds a hook which every command run by Cmd or CmdContext passes
+// through. Hooks run in the order they were added, the first one added being
+// the outermost. Pipelined commands (see Append) don't pass through hooks.
+// Clients derived using WithOptions keep the hooks the client had when they
+// were derived.
+func (c *Client) AddHook(h Hook) {
+	c.hooks = append(c.hooks[:len(c.hooks):len(c.hooks)], h)
+}
+
+// CmdContext calls the given command like Cmd does, passing ctx to the hooks
+// (see AddHook). The Metadata in the context which finally reaches redis,
+// including anything added to it by hooks, is set on the Reply. If there is
+// any, and the command fails, the reply's error is wrapped in a *MetadataError
+// carrying it too, so code further up which is only passed the error can still
+// get it:
+//
+//	ctx := redis.WithMetadata(ctx, "request_id", reqID)
+//	if err := client.CmdContext(ctx, "GET", "foo").Err; err != nil {
+//		log.Printf("request %s: %s", redis.MetadataFromError(err)["request_id"], err)
+//	}
+//
+// The wrapped error must therefore be checked using errors.As or errors.Is
+// (e.g. for a *CmdError) rather than by type.
+//
+// If ctx is already done the command isn't sent, and the reply has ctx's
+// error. ctx isn't otherwise used to cancel the command once it's been sent,
+// use the client's timeouts (see WithTimeout) for that.
+func (c *Client) CmdContext(
+	ctx context.Context, cmd string, args ...interface{},
+) *Reply {
+	r := c.hookedCmd(ctx, cmd, args)
+	if r.Err != nil && len(r.Metadata) > 0 {
+		r.Err = &MetadataError{Err: r.Err, Metadata: r.Metadata}
+	}
+	return r
+}
+
+// hookedCmd runs the command through the client's hooks, setting the metadata
+// of the context which reaches redis on the reply
+func (c *Client) hookedCmd(
+	ctx context.Context, cmd string, args []interface{},
+) *Reply {
+	fn := func(ctx context.Context, cmd string, args []interface{}) *Reply {
+		var r *Reply
+		if err := ctx.Err(); err != nil {
+			r = &Reply{Type: ErrorReply, Err: err}
+		} else {
+			r = c.retryCmd(cmd, args)
+		}
+		r.Metadata = MetadataFrom(ctx)
+		return r
+	}
+	for i := len(c.hooks) - 1; i >= 0; i-- {
+		h, next := c.hooks[i], fn
+		fn = func(ctx context.Context, cmd string, args []interface{}) *Reply {
+			return h(ctx, cmd, args, next)
+		}
+	}
+	return fn(ctx, cmd, args)
+}
@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+var errNotAllowed = errors.New("ERR not allowed")
+
+func TestHooks(t *T) {
+	c := dial(t)
+	var calls []string
+	c.AddHook(func(
+		ctx context.Context, cmd string, args []interface{}, next HookFunc,
+	) *Reply {
+		calls = append(calls, "outer "+cmd)
+		return next(WithMetadata(ctx, "tenant", "acme"), cmd, args)
+	})
+	c.AddHook(func(
+		ctx context.Context, cmd string, args []interface{}, next HookFunc,
+	) *Reply {
+		calls = append(calls, "inner "+MetadataFrom(ctx)["tenant"])
+		if cmd == "BLOCKED" {
+			return &Reply{Type: ErrorReply, Err: &CmdError{errNotAllowed}}
+		}
+		return next(ctx, cmd, args)
+	})
+
+	ctx := WithMetadata(context.Background(), "request_id", "123")
+	r := c.CmdContext(ctx, "ECHO", "foo")
+	s, err := r.Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", s)
+	assert.Equal(t, Metadata{"request_id": "123", "tenant": "acme"}, r.Metadata)
+	assert.Equal(t, []string{"outer ECHO", "inner acme"}, calls)
+
+	// The metadata isn't added to the caller's context
+	assert.Equal(t, Metadata{"request_id": "123"}, MetadataFrom(ctx))
+
+	// Plain Cmd goes through the hooks too
+	calls = nil
+	r = c.Cmd("BLOCKED")
+	assert.Equal(t, errNotAllowed, r.Err.(*CmdError).Err)
+	assert.Equal(t, []string{"outer BLOCKED", "inner acme"}, calls)
+
+	// Errors carry the metadata as well, while still being found by errors.As
+	r = c.CmdContext(ctx, "NOTACOMMAND")
+	assert.Equal(t, "123", r.Metadata["request_id"])
+	assert.Equal(t, "123", MetadataFromError(r.Err)["request_id"])
+	var cerr *CmdError
+	assert.True(t, errors.As(r.Err, &cerr))
+	wrapped := fmt.Errorf("get: %w", r.Err)
+	assert.Equal(t, "acme", MetadataFromError(wrapped)["tenant"])
+	assert.True(t, errors.As(wrapped, &cerr))
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	r = c.CmdContext(cctx, "PING")
+	assert.True(t, errors.Is(r.Err, context.Canceled))
+	assert.Equal(t, "acme", r.Metadata["tenant"])
+	assert.Equal(t, "acme", MetadataFromError(r.Err)["tenant"])
+
+	// Plain Cmd leaves its errors as they are
+	r = c.Cmd("NOTACOMMAND")
+	assert.Equal(t, "acme", r.Metadata["tenant"])
+	_, ok := r.Err.(*CmdError)
+	assert.True(t, ok)
+	assert.Nil(t, MetadataFromError(r.Err))
+
+	// Derived clients keep the hooks, without sharing ones added later
+	d := c.WithTimeout(NoTimeout)
+	d.AddHook(func(
+		ctx context.Context, cmd string, args []interface{}, next HookFunc,
+	) *Reply {
+		return NewStatusReply("derived")
+	})
+	calls = nil
+	s, _ = c.Cmd("ECHO", "bar").Str()
+	assert.Equal(t, "bar", s)
+	s, _ = d.Cmd("ECHO", "bar").Str()
+	assert.Equal(t, "derived", s)
+	assert.Equal(t, 4, len(calls))
+}
@@ -0,0 +1,19 @@
+package redis
+
+// PFAdd adds the elements to the HyperLogLog at key, creating it if needed. It
+// returns whether the estimated cardinality changed as a result.
+func (c *Client) PFAdd(key string, elements ...interface{}) (bool, error) {
+	return c.Cmd("PFADD", key, elements).Bool()
+}
+
+// PFCount returns the estimated cardinality of the HyperLogLog at key, or of
+// the union of them if more than one key is given
+func (c *Client) PFCount(keys ...string) (int64, error) {
+	return c.Cmd("PFCOUNT", keys).Int64()
+}
+
+// PFMerge stores the union of the HyperLogLogs at the source keys in dest,
+// including dest's own elements if it already exists
+func (c *Client) PFMerge(dest string, sources ...string) error {
+	return c.Cmd("PFMERGE", dest, sources).Err
+}
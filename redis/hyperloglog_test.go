@@ -0,0 +1,32 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func TestHyperLogLog(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "hll:a", "hll:b", "hll:ab")
+
+	changed, err := c.PFAdd("hll:a", "x", "y", "z")
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	changed, err = c.PFAdd("hll:a", "x")
+	assert.Nil(t, err)
+	assert.False(t, changed)
+	c.PFAdd("hll:b", []string{"z", "w"})
+
+	n, err := c.PFCount("hll:a")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), n)
+	// Counts are estimates, which some servers are looser with for unions
+	n, err = c.PFCount("hll:a", "hll:b")
+	assert.Nil(t, err)
+	assert.True(t, n >= 3 && n <= 5, n)
+
+	assert.Nil(t, c.PFMerge("hll:ab", "hll:a", "hll:b"))
+	n, err = c.PFCount("hll:ab")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), n)
+}
@@ -0,0 +1,207 @@
+package redis
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InfoMap calls INFO for the given section (or the default sections if section
+// is empty, or "all" or "everything" for more), and returns the fields it
+// returned by section and then by name. Section names are lowercased, e.g.
+//
+//	info, err := client.InfoMap("")
+//	used := info["memory"]["used_memory"]
+func (c *Client) InfoMap(section string) (map[string]map[string]string, error) {
+	var args []interface{}
+	if section != "" {
+		args = append(args, section)
+	}
+	s, err := c.Cmd("INFO", args...).Str()
+	if err != nil {
+		return nil, err
+	}
+	return parseInfo(s), nil
+}
+
+func parseInfo(s string) map[string]map[string]string {
+	info := map[string]map[string]string{}
+	var fields map[string]string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if line[0] == '#' {
+			fields = map[string]string{}
+			info[strings.ToLower(strings.TrimSpace(line[1:]))] = fields
+			continue
+		}
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		if fields == nil {
+			fields = map[string]string{}
+			info[""] = fields
+		}
+		fields[kv[0]] = kv[1]
+	}
+	return info
+}
+
+// Info holds the most commonly used fields returned by INFO, see InfoStruct.
+// Fields whose section wasn't returned, or which the server doesn't have, are
+// left as their zero value.
+type Info struct {
+	Server      InfoServer
+	Clients     InfoClients
+	Memory      InfoMemory
+	Replication InfoReplication
+	Stats       InfoStats
+
+	// The keys in each database, by database number. Databases without any
+	// keys aren't included.
+	Keyspace map[int]InfoKeyspace
+}
+
+// InfoServer holds fields from the server section of INFO
+type InfoServer struct {
+	Version string
+	Mode    string // e.g. "standalone" or "cluster"
+	Uptime  time.Duration
+}
+
+// InfoClients holds fields from the clients section of INFO
+type InfoClients struct {
+	ConnectedClients int64
+	BlockedClients   int64
+}
+
+// InfoMemory holds fields from the memory section of INFO. Sizes are in bytes.
+type InfoMemory struct {
+	UsedMemory         int64
+	UsedMemoryRSS      int64
+	UsedMemoryPeak     int64
+	MaxMemory          int64
+	MaxMemoryPolicy    string
+	FragmentationRatio float64
+}
+
+// InfoReplication holds fields from the replication section of INFO
+type InfoReplication struct {
+	Role string // "master" or "slave"
+
+	// The master this server replicates from, and whether the link to it is
+	// up, if it's a replica
+	MasterHost       string
+	MasterPort       int
+	MasterLinkStatus string
+
+	ConnectedReplicas int64
+	MasterReplOffset  int64
+}
+
+// InfoStats holds fields from the stats section of INFO
+type InfoStats struct {
+	TotalConnectionsReceived int64
+	TotalCommandsProcessed   int64
+	InstantaneousOpsPerSec   int64
+	RejectedConnections      int64
+	ExpiredKeys              int64
+	EvictedKeys              int64
+	KeyspaceHits             int64
+	KeyspaceMisses           int64
+}
+
+// InfoKeyspace holds the fields for a database from the keyspace section of
+// INFO
+type InfoKeyspace struct {
+	Keys    int64
+	Expires int64
+	AvgTTL  time.Duration
+}
+
+// InfoStruct calls INFO and returns the most commonly used fields it returned,
+// parsed into an Info. Use InfoMap for anything else.
+func (c *Client) InfoStruct() (*Info, error) {
+	m, err := c.InfoMap("")
+	if err != nil {
+		return nil, err
+	}
+	return infoStruct(m), nil
+}
+
+func infoStruct(m map[string]map[string]string) *Info {
+	s, cl, mem := m["server"], m["clients"], m["memory"]
+	repl, st := m["replication"], m["stats"]
+	info := &Info{
+		Server: InfoServer{
+			Version: s["redis_version"],
+			Mode:    s["redis_mode"],
+			Uptime:  time.Duration(infoInt(s, "uptime_in_seconds")) * time.Second,
+		},
+		Clients: InfoClients{
+			ConnectedClients: infoInt(cl, "connected_clients"),
+			BlockedClients:   infoInt(cl, "blocked_clients"),
+		},
+		Memory: InfoMemory{
+			UsedMemory:         infoInt(mem, "used_memory"),
+			UsedMemoryRSS:      infoInt(mem, "used_memory_rss"),
+			UsedMemoryPeak:     infoInt(mem, "used_memory_peak"),
+			MaxMemory:          infoInt(mem, "maxmemory"),
+			MaxMemoryPolicy:    mem["maxmemory_policy"],
+			FragmentationRatio: infoFloat(mem, "mem_fragmentation_ratio"),
+		},
+		Replication: InfoReplication{
+			Role:              repl["role"],
+			MasterHost:        repl["master_host"],
+			MasterPort:        int(infoInt(repl, "master_port")),
+			MasterLinkStatus:  repl["master_link_status"],
+			ConnectedReplicas: infoInt(repl, "connected_slaves"),
+			MasterReplOffset:  infoInt(repl, "master_repl_offset"),
+		},
+		Stats: InfoStats{
+			TotalConnectionsReceived: infoInt(st, "total_connections_received"),
+			TotalCommandsProcessed:   infoInt(st, "total_commands_processed"),
+			InstantaneousOpsPerSec:   infoInt(st, "instantaneous_ops_per_sec"),
+			RejectedConnections:      infoInt(st, "rejected_connections"),
+			ExpiredKeys:              infoInt(st, "expired_keys"),
+			EvictedKeys:              infoInt(st, "evicted_keys"),
+			KeyspaceHits:             infoInt(st, "keyspace_hits"),
+			KeyspaceMisses:           infoInt(st, "keyspace_misses"),
+		},
+		Keyspace: map[int]InfoKeyspace{},
+	}
+	for name, v := range m["keyspace"] {
+		if !strings.HasPrefix(name, "db") {
+			continue
+		}
+		db, err := strconv.Atoi(name[2:])
+		if err != nil {
+			continue
+		}
+		fields := map[string]string{}
+		for _, kv := range strings.Split(v, ",") {
+			if parts := strings.SplitN(kv, "=", 2); len(parts) == 2 {
+				fields[parts[0]] = parts[1]
+			}
+		}
+		info.Keyspace[db] = InfoKeyspace{
+			Keys:    infoInt(fields, "keys"),
+			Expires: infoInt(fields, "expires"),
+			AvgTTL:  time.Duration(infoInt(fields, "avg_ttl")) * time.Millisecond,
+		}
+	}
+	return info
+}
+
+func infoInt(fields map[string]string, name string) int64 {
+	n, _ := strconv.ParseInt(fields[name], 10, 64)
+	return n
+}
+
+func infoFloat(fields map[string]string, name string) float64 {
+	f, _ := strconv.ParseFloat(fields[name], 64)
+	return f
+}
@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+const testInfo = "# Server\r\nredis_version:7.2.4\r\nredis_mode:standalone\r\n" +
+	"uptime_in_seconds:3600\r\n\r\n" +
+	"# Clients\r\nconnected_clients:3\r\nblocked_clients:1\r\n\r\n" +
+	"# Memory\r\nused_memory:1048576\r\nmaxmemory:0\r\n" +
+	"maxmemory_policy:noeviction\r\nmem_fragmentation_ratio:1.25\r\n\r\n" +
+	"# Replication\r\nrole:slave\r\nmaster_host:10.0.0.1\r\nmaster_port:6379\r\n" +
+	"master_link_status:up\r\nconnected_slaves:0\r\n\r\n" +
+	"# Stats\r\nkeyspace_hits:10\r\nkeyspace_misses:2\r\n\r\n" +
+	"# Keyspace\r\ndb0:keys=5,expires=1,avg_ttl=2000\r\ndb3:keys=1,expires=0,avg_ttl=0\r\n"
+
+func TestParseInfo(t *T) {
+	m := parseInfo(testInfo)
+	assert.Equal(t, "7.2.4", m["server"]["redis_version"])
+	assert.Equal(t, "1.25", m["memory"]["mem_fragmentation_ratio"])
+	assert.Equal(t, "keys=5,expires=1,avg_ttl=2000", m["keyspace"]["db0"])
+
+	info := infoStruct(m)
+	assert.Equal(t, "standalone", info.Server.Mode)
+	assert.Equal(t, time.Hour, info.Server.Uptime)
+	assert.Equal(t, int64(3), info.Clients.ConnectedClients)
+	assert.Equal(t, int64(1048576), info.Memory.UsedMemory)
+	assert.Equal(t, "noeviction", info.Memory.MaxMemoryPolicy)
+	assert.Equal(t, 1.25, info.Memory.FragmentationRatio)
+	assert.Equal(t, "slave", info.Replication.Role)
+	assert.Equal(t, 6379, info.Replication.MasterPort)
+	assert.Equal(t, "up", info.Replication.MasterLinkStatus)
+	assert.Equal(t, int64(2), info.Stats.KeyspaceMisses)
+	assert.Equal(t, map[int]InfoKeyspace{
+		0: {Keys: 5, Expires: 1, AvgTTL: 2 * time.Second},
+		3: {Keys: 1},
+	}, info.Keyspace)
+}
+
+func TestInfo(t *T) {
+	c := dial(t)
+	m, err := c.InfoMap("")
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", m["clients"]["connected_clients"])
+
+	info, err := c.InfoStruct()
+	assert.Nil(t, err)
+	assert.True(t, info.Clients.ConnectedClients > 0)
+}
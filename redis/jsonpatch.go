@@ -0,0 +1,215 @@
+package redis
+
+import (
+	"encoding/json"
+)
+
+// The lua shared by both kinds of patch. It decodes the value at KEYS[1],
+// passes it to patch(), and sets the result back, keeping the key's TTL. Since
+// lua doesn't distinguish between empty objects and empty arrays, an empty
+// array in the document may be written back as an empty object.
+const jsonPatchLib = `
+local function isarray(t)
+	if type(t) ~= 'table' then return false end
+	local n = 0
+	for _ in pairs(t) do n = n + 1 end
+	return n > 0 and n == #t
+end
+
+local function deepequal(a, b)
+	if type(a) ~= type(b) then return false end
+	if type(a) ~= 'table' then return a == b end
+	for k, v in pairs(a) do
+		if not deepequal(v, b[k]) then return false end
+	end
+	for k in pairs(b) do
+		if a[k] == nil then return false end
+	end
+	return true
+end
+
+local function deepcopy(v)
+	if type(v) ~= 'table' then return v end
+	local t = {}
+	for k, e in pairs(v) do t[k] = deepcopy(e) end
+	return t
+end
+
+local function run(patch)
+	local doc = nil
+	local raw = redis.call('GET', KEYS[1])
+	if raw then doc = cjson.decode(raw) end
+	local ok, res = pcall(patch, doc, cjson.decode(ARGV[1]))
+	if not ok then return redis.error_reply('ERR ' .. tostring(res)) end
+	local out = cjson.encode(res)
+	local ttl = redis.call('PTTL', KEYS[1])
+	redis.call('SET', KEYS[1], out)
+	if ttl > 0 then redis.call('PEXPIRE', KEYS[1], ttl) end
+	return out
+end
+`
+
+var jsonMergePatchScript = NewScript(jsonPatchLib + `
+local function merge(target, patch)
+	if type(patch) ~= 'table' or isarray(patch) then return patch end
+	if type(target) ~= 'table' or isarray(target) then target = {} end
+	for k, v in pairs(patch) do
+		if v == cjson.null then
+			target[k] = nil
+		else
+			target[k] = merge(target[k], v)
+		end
+	end
+	return target
+end
+
+return run(merge)
+`)
+
+var jsonPatchScript = NewScript(jsonPatchLib + `
+local function tokens(path)
+	local t = {}
+	if path == '' then return t end
+	if string.sub(path, 1, 1) ~= '/' then error('invalid path ' .. path, 0) end
+	for tok in string.gmatch(string.sub(path, 2) .. '/', '([^/]*)/') do
+		tok = (string.gsub(tok, '~1', '/'))
+		tok = (string.gsub(tok, '~0', '~'))
+		table.insert(t, tok)
+	end
+	return t
+end
+
+-- index returns the lua index into an array for a path token, or nil if the
+-- container isn't being treated as an array
+local function index(parent, tok)
+	if isarray(parent) or (next(parent) == nil and (tok == '-' or tonumber(tok))) then
+		if tok == '-' then return #parent + 1 end
+		local i = tonumber(tok)
+		if not i or i < 0 or i ~= math.floor(i) then
+			error('invalid array index ' .. tok, 0)
+		end
+		return i + 1
+	end
+	return nil
+end
+
+-- resolve returns the container holding path and the last token of it
+local function resolve(doc, path)
+	local toks = tokens(path)
+	local parent = doc
+	for i = 1, #toks - 1 do
+		if type(parent) ~= 'table' then error('path not found ' .. path, 0) end
+		local idx = index(parent, toks[i])
+		if idx then parent = parent[idx] else parent = parent[toks[i]] end
+	end
+	if type(parent) ~= 'table' then error('path not found ' .. path, 0) end
+	return parent, toks[#toks]
+end
+
+local function get(doc, path)
+	if path == '' then return doc end
+	local parent, tok = resolve(doc, path)
+	local idx = index(parent, tok)
+	local v
+	if idx then v = parent[idx] else v = parent[tok] end
+	if v == nil then error('path not found ' .. path, 0) end
+	return v
+end
+
+local function add(doc, path, value)
+	if path == '' then return value end
+	local parent, tok = resolve(doc, path)
+	local idx = index(parent, tok)
+	if idx then
+		if idx > #parent + 1 then error('array index out of range ' .. path, 0) end
+		table.insert(parent, idx, value)
+	else
+		parent[tok] = value
+	end
+	return doc
+end
+
+local function remove(doc, path)
+	if path == '' then error('can not remove the whole document', 0) end
+	get(doc, path)
+	local parent, tok = resolve(doc, path)
+	local idx = index(parent, tok)
+	if idx then table.remove(parent, idx) else parent[tok] = nil end
+	return doc
+end
+
+local function apply(doc, ops)
+	for _, op in ipairs(ops) do
+		local path = op['path']
+		if type(path) ~= 'string' then error('operation has no path', 0) end
+		if op['op'] == 'add' then
+			doc = add(doc, path, op['value'])
+		elseif op['op'] == 'remove' then
+			doc = remove(doc, path)
+		elseif op['op'] == 'replace' then
+			doc = add(remove(doc, path), path, op['value'])
+		elseif op['op'] == 'move' then
+			local v = get(doc, op['from'])
+			doc = add(remove(doc, op['from']), path, v)
+		elseif op['op'] == 'copy' then
+			doc = add(doc, path, deepcopy(get(doc, op['from'])))
+		elseif op['op'] == 'test' then
+			if not deepequal(get(doc, path), op['value']) then
+				error('test failed at ' .. path, 0)
+			end
+		else
+			error('unknown operation ' .. tostring(op['op']), 0)
+		end
+	end
+	return doc
+end
+
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return redis.error_reply('ERR no such key')
+end
+return run(apply)
+`)
+
+// JSONMergePatch atomically applies an RFC 7386 merge patch to the JSON
+// document stored at key, returning the patched document. A key which doesn't
+// exist is treated as null, so the patch creates it. The key's TTL is kept.
+func (c *Client) JSONMergePatch(key string, patch interface{}) ([]byte, error) {
+	b, err := jsonArg(patch)
+	if err != nil {
+		return nil, err
+	}
+	return jsonMergePatchScript.Cmd(c, []string{key}, b).Bytes()
+}
+
+// JSONPatchOp is a single operation of an RFC 6902 JSON patch
+type JSONPatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value"`
+}
+
+// JSONPatch atomically applies the RFC 6902 JSON patch operations to the JSON
+// document stored at key, returning the patched document. If any operation
+// fails (including a "test") nothing is changed, and the error is returned as
+// a *CmdError. The key must exist, and its TTL is kept.
+func (c *Client) JSONPatch(key string, ops ...JSONPatchOp) ([]byte, error) {
+	b, err := json.Marshal(ops)
+	if err != nil {
+		return nil, err
+	}
+	return jsonPatchScript.Cmd(c, []string{key}, b).Bytes()
+}
+
+// jsonArg returns v as JSON, leaving it as is if it's already encoded
+func jsonArg(v interface{}) ([]byte, error) {
+	switch vt := v.(type) {
+	case []byte:
+		return vt, nil
+	case string:
+		return []byte(vt), nil
+	case json.RawMessage:
+		return vt, nil
+	}
+	return json.Marshal(v)
+}
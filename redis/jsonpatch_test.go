@@ -0,0 +1,74 @@
+package redis
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+// assertJSON checks that two JSON documents are equivalent, ignoring key order
+func assertJSON(t *T, expect string, b []byte) {
+	var e, a interface{}
+	assert.Nil(t, json.Unmarshal([]byte(expect), &e))
+	assert.Nil(t, json.Unmarshal(b, &a), string(b))
+	assert.Equal(t, e, a)
+}
+
+func TestJSONMergePatch(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "jsonpatch:doc")
+
+	b, err := c.JSONMergePatch("jsonpatch:doc", map[string]interface{}{"a": 1})
+	assert.Nil(t, err)
+	assertJSON(t, `{"a":1}`, b)
+
+	// Some lua implementations can't tell null apart from a missing value
+	if hasNull, _ := c.Cmd("EVAL", "return cjson.null ~= nil", 0).Bool(); !hasNull {
+		t.Skip("cjson.null not supported")
+	}
+	c.Cmd("SET", "jsonpatch:doc", `{"a":"b","c":{"d":"e","f":"g"},"l":[1,2]}`)
+	c.Cmd("EXPIRE", "jsonpatch:doc", 100)
+	b, err = c.JSONMergePatch("jsonpatch:doc", `{"a":"z","c":{"f":null},"l":[3]}`)
+	assert.Nil(t, err)
+	assertJSON(t, `{"a":"z","c":{"d":"e"},"l":[3]}`, b)
+
+	s, _ := c.Cmd("GET", "jsonpatch:doc").Bytes()
+	assertJSON(t, `{"a":"z","c":{"d":"e"},"l":[3]}`, s)
+	ttl, _ := c.Cmd("TTL", "jsonpatch:doc").Int()
+	assert.True(t, ttl > 0)
+}
+
+func TestJSONPatch(t *T) {
+	c := dial(t)
+	c.Cmd("SET", "jsonpatch:doc", `{"a":{"b":"c"},"l":["x","y"]}`)
+
+	b, err := c.JSONPatch("jsonpatch:doc",
+		JSONPatchOp{Op: "test", Path: "/a/b", Value: "c"},
+		JSONPatchOp{Op: "add", Path: "/l/1", Value: "w"},
+		JSONPatchOp{Op: "add", Path: "/l/-", Value: "z"},
+		JSONPatchOp{Op: "replace", Path: "/a/b", Value: 5},
+		JSONPatchOp{Op: "copy", From: "/a", Path: "/a~1copy"},
+		JSONPatchOp{Op: "move", From: "/l/0", Path: "/first"},
+		JSONPatchOp{Op: "remove", Path: "/l/2"},
+	)
+	assert.Nil(t, err)
+	assertJSON(t, `{"a":{"b":5},"a/copy":{"b":5},"l":["w","y"],"first":"x"}`, b)
+
+	// A failed operation leaves the document as it was
+	_, err = c.JSONPatch("jsonpatch:doc",
+		JSONPatchOp{Op: "remove", Path: "/first"},
+		JSONPatchOp{Op: "test", Path: "/a/b", Value: 6},
+	)
+	assert.NotNil(t, err)
+	_, ok := err.(*CmdError)
+	assert.True(t, ok)
+	s, _ := c.Cmd("GET", "jsonpatch:doc").Bytes()
+	assertJSON(t, `{"a":{"b":5},"a/copy":{"b":5},"l":["w","y"],"first":"x"}`, s)
+
+	_, err = c.JSONPatch("jsonpatch:doc", JSONPatchOp{Op: "remove", Path: "/nope"})
+	assert.NotNil(t, err)
+
+	c.Cmd("DEL", "jsonpatch:doc")
+	_, err = c.JSONPatch("jsonpatch:doc", JSONPatchOp{Op: "add", Path: "/a", Value: 1})
+	assert.NotNil(t, err)
+}
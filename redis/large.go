@@ -0,0 +1,124 @@
+package redis
+
+import (
+	"strings"
+
+	"github.com/fzzy/radix/redis/resp"
+)
+
+// LargeValue describes a value written to or read from redis which was at
+// least as large as a client's threshold, see SetLargeValueThreshold. Key is the
+// key the value is stored at: for a write the last key given before the value
+// (e.g. the key before it in MSET), and for a reply to MGET the key of that
+// element. The replies of other commands with several keys (e.g. SUNION) can't
+// be tied to one of them, so their first key is used. For commands which
+// aren't known to have keys it's the first argument.
+type LargeValue struct {
+	Cmd   string
+	Key   string // The key holding the value, see above
+	Size  int    // The size of the value in bytes, as sent over the wire
+	Write bool   // Whether the value was being written or read
+}
+
+// SetLargeValueThreshold has the client keep track of every value it writes or
+// reads which is at least size bytes long. Each one increments the LargeWrites
+// or LargeReads field, and is passed to hook if it's not nil. Values are
+// checked individually, so a large reply made up of many small values is not
+// counted. A size of zero turns this off.
+//
+// This can be used to find accidentally huge keys before they start affecting
+// latency:
+//
+//	client.SetLargeValueThreshold(1<<20, func(v redis.LargeValue) {
+//		log.Printf("%d byte value used by %s %s", v.Size, v.Cmd, v.Key)
+//	})
+func (c *Client) SetLargeValueThreshold(size int, hook func(LargeValue)) {
+	c.largeMin = size
+	c.largeHook = hook
+}
+
+func (c *Client) largeValue(req *request, key string, size int, write bool) {
+	root := c
+	for root.parent != nil {
+		root = root.parent
+	}
+	if write {
+		root.LargeWrites++
+	} else {
+		root.LargeReads++
+	}
+	if c.largeHook == nil {
+		return
+	}
+	c.largeHook(LargeValue{Cmd: req.cmd, Key: key, Size: size, Write: write})
+}
+
+// argKey returns the key which the flattened argument at index i belongs to
+func argKey(cmd string, flat []interface{}, i int) string {
+	keys := keyIndexes(cmd, flat)
+	if len(keys) == 0 {
+		if len(flat) == 0 {
+			return ""
+		}
+		return argString(flat[0])
+	}
+	key := keys[0]
+	for _, k := range keys {
+		if k <= i {
+			key = k
+		}
+	}
+	return argString(flat[key])
+}
+
+// replyKey returns the key which element i of the reply to the given command
+// came from. i is -1 for a reply which isn't a multi bulk reply.
+func replyKey(cmd string, flat []interface{}, i int) string {
+	if i >= 0 && strings.EqualFold(cmd, "MGET") && i < len(flat) {
+		return argString(flat[i])
+	}
+	return argKey(cmd, flat, 0)
+}
+
+func (c *Client) checkLargeArgs(req *request) {
+	flat := resp.Flatten(req.args)
+	for i, arg := range flat {
+		var size int
+		switch a := arg.(type) {
+		case string:
+			size = len(a)
+		case []byte:
+			size = len(a)
+		case *resp.LenReader:
+			size = int(a.Len)
+		default:
+			continue
+		}
+		if size >= c.largeMin {
+			c.largeValue(req, argKey(req.cmd, flat, i), size, true)
+		}
+	}
+}
+
+func (c *Client) checkLargeReply(req *request, r *Reply) {
+	c.checkLargeElem(req, r, -1)
+}
+
+// checkLargeElem checks r, which is element i of the reply to req, or the whole
+// reply if i is -1
+func (c *Client) checkLargeElem(req *request, r *Reply, i int) {
+	switch r.Type {
+	case BulkReply:
+		if len(r.buf) >= c.largeMin {
+			c.largeValue(req, replyKey(req.cmd, resp.Flatten(req.args), i), len(r.buf), false)
+		}
+	case MultiReply:
+		for j, e := range r.Elems {
+			if i >= 0 {
+				// Nested elements belong to the same key as their parent
+				j = i
+			}
+			c.checkLargeElem(req, e, j)
+		}
+	}
+}
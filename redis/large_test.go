@@ -0,0 +1,38 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	. "testing"
+)
+
+func TestLargeValues(t *T) {
+	c := dial(t)
+	var seen []LargeValue
+	c.SetLargeValueThreshold(100, func(v LargeValue) {
+		seen = append(seen, v)
+	})
+
+	big := strings.Repeat("a", 100)
+	assert.Nil(t, c.Cmd("SET", "large:small", "a").Err)
+	assert.Nil(t, c.Cmd("SET", "large:big", big).Err)
+	assert.Nil(t, c.Cmd("MGET", "large:small", "large:big").Err)
+	assert.Nil(t, c.Cmd("MSET", "large:small", "a", "large:big2", big).Err)
+	assert.Nil(t, c.Cmd("DEL", "large:hash").Err)
+	assert.Nil(t, c.Cmd("HSET", "large:hash", "small", "a", "big", big).Err)
+	assert.Nil(t, c.Cmd("HGETALL", "large:hash").Err)
+
+	assert.Equal(t, uint64(3), c.LargeWrites)
+	assert.Equal(t, uint64(2), c.LargeReads)
+	assert.Equal(t, []LargeValue{
+		{Cmd: "SET", Key: "large:big", Size: 100, Write: true},
+		{Cmd: "MGET", Key: "large:big", Size: 100, Write: false},
+		{Cmd: "MSET", Key: "large:big2", Size: 100, Write: true},
+		{Cmd: "HSET", Key: "large:hash", Size: 100, Write: true},
+		{Cmd: "HGETALL", Key: "large:hash", Size: 100, Write: false},
+	}, seen)
+
+	c.SetLargeValueThreshold(0, nil)
+	assert.Nil(t, c.Cmd("GET", "large:big").Err)
+	assert.Equal(t, uint64(2), c.LargeReads)
+}
@@ -0,0 +1,103 @@
+package redis
+
+import (
+	"errors"
+	"time"
+)
+
+// LatencyEvent is the latest latency spike recorded for an event by the
+// server's latency monitor, as returned by LATENCY LATEST
+type LatencyEvent struct {
+	Name string // e.g. "command" or "fork"
+
+	// When the latest spike happened and how long it was, and the longest
+	// spike recorded for the event
+	Time    time.Time
+	Latency time.Duration
+	Max     time.Duration
+}
+
+// LatencySample is a single latency spike, as returned by LATENCY HISTORY
+type LatencySample struct {
+	Time    time.Time
+	Latency time.Duration
+}
+
+// LatencyLatest returns the latest latency spike of every event the server's
+// latency monitor has recorded, using LATENCY LATEST. The monitor is only
+// enabled once latency-monitor-threshold is set (see ConfigSetDuration).
+func (c *Client) LatencyLatest() ([]LatencyEvent, error) {
+	r := c.Cmd("LATENCY", "LATEST")
+	if r.Type == ErrorReply {
+		return nil, r.Err
+	}
+	return parseLatencyLatest(r)
+}
+
+func parseLatencyLatest(r *Reply) ([]LatencyEvent, error) {
+	if r.Type != MultiReply {
+		return nil, errors.New("malformed LATENCY LATEST reply")
+	}
+	events := make([]LatencyEvent, len(r.Elems))
+	for i, er := range r.Elems {
+		if er.Type != MultiReply || len(er.Elems) < 4 {
+			return nil, errors.New("malformed LATENCY LATEST entry")
+		}
+		var ts, latest, max int64
+		var err error
+		if events[i].Name, err = er.Elems[0].Str(); err != nil {
+			return nil, err
+		}
+		if ts, err = er.Elems[1].Int64(); err != nil {
+			return nil, err
+		}
+		if latest, err = er.Elems[2].Int64(); err != nil {
+			return nil, err
+		}
+		if max, err = er.Elems[3].Int64(); err != nil {
+			return nil, err
+		}
+		events[i].Time = time.Unix(ts, 0)
+		events[i].Latency = time.Duration(latest) * time.Millisecond
+		events[i].Max = time.Duration(max) * time.Millisecond
+	}
+	return events, nil
+}
+
+// LatencyHistory returns the latency spikes recorded for the given event,
+// oldest first, using LATENCY HISTORY
+func (c *Client) LatencyHistory(event string) ([]LatencySample, error) {
+	r := c.Cmd("LATENCY", "HISTORY", event)
+	if r.Type == ErrorReply {
+		return nil, r.Err
+	}
+	return parseLatencyHistory(r)
+}
+
+func parseLatencyHistory(r *Reply) ([]LatencySample, error) {
+	if r.Type != MultiReply {
+		return nil, errors.New("malformed LATENCY HISTORY reply")
+	}
+	samples := make([]LatencySample, len(r.Elems))
+	for i, sr := range r.Elems {
+		if sr.Type != MultiReply || len(sr.Elems) < 2 {
+			return nil, errors.New("malformed LATENCY HISTORY entry")
+		}
+		ts, err := sr.Elems[0].Int64()
+		if err != nil {
+			return nil, err
+		}
+		ms, err := sr.Elems[1].Int64()
+		if err != nil {
+			return nil, err
+		}
+		samples[i] = LatencySample{time.Unix(ts, 0), time.Duration(ms) * time.Millisecond}
+	}
+	return samples, nil
+}
+
+// LatencyReset clears the latency spikes recorded for the given events, or for
+// all of them if none are given, returning how many events were cleared
+func (c *Client) LatencyReset(events ...string) (int, error) {
+	return c.Cmd("LATENCY", "RESET", events).Int()
+}
@@ -0,0 +1,50 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+func TestParseLatency(t *T) {
+	events, err := parseLatencyLatest(NewReply([]interface{}{
+		[]interface{}{"command", int64(1405067976), int64(251), int64(1001)},
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, []LatencyEvent{{
+		Name:    "command",
+		Time:    time.Unix(1405067976, 0),
+		Latency: 251 * time.Millisecond,
+		Max:     1001 * time.Millisecond,
+	}}, events)
+
+	samples, err := parseLatencyHistory(NewReply([]interface{}{
+		[]interface{}{int64(1405067822), int64(251)},
+		[]interface{}{int64(1405067941), int64(1001)},
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, []LatencySample{
+		{time.Unix(1405067822, 0), 251 * time.Millisecond},
+		{time.Unix(1405067941, 0), 1001 * time.Millisecond},
+	}, samples)
+
+	_, err = parseLatencyLatest(NewReply([]interface{}{[]interface{}{"command"}}))
+	assert.NotNil(t, err)
+	_, err = parseLatencyHistory(NewStatusReply("OK"))
+	assert.NotNil(t, err)
+}
+
+func TestLatency(t *T) {
+	c := dial(t)
+	if isUnknownCommand(c.Cmd("LATENCY", "LATEST").Err) {
+		t.Skip("LATENCY not supported")
+	}
+	_, err := c.LatencyReset()
+	assert.Nil(t, err)
+	events, err := c.LatencyLatest()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(events))
+	samples, err := c.LatencyHistory("command")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(samples))
+}
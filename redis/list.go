@@ -0,0 +1,160 @@
+package redis
+
+import (
+	"errors"
+	"time"
+)
+
+// ListSide is an end of a list, for BLMove
+type ListSide string
+
+const (
+	ListLeft  ListSide = "LEFT"
+	ListRight ListSide = "RIGHT"
+)
+
+// BLPop removes and returns the first element of the first non-empty list of
+// the given keys, along with the key it came from. If they're all empty it
+// blocks until an element is pushed or the timeout (zero meaning forever) is
+// reached, in which case key is empty. The client's read timeout is extended
+// to cover the wait.
+func (c *Client) BLPop(timeout time.Duration, keys ...string) (key, val string, err error) {
+	return bpop(c.blockingCmd(timeout, "BLPOP", keys))
+}
+
+// BRPop is like BLPop, but for the last element of the list
+func (c *Client) BRPop(timeout time.Duration, keys ...string) (key, val string, err error) {
+	return bpop(c.blockingCmd(timeout, "BRPOP", keys))
+}
+
+func bpop(r *Reply) (string, string, error) {
+	if r.Type == NilReply {
+		return "", "", nil
+	}
+	l, err := r.List()
+	if err != nil {
+		return "", "", err
+	}
+	if len(l) != 2 {
+		return "", "", errors.New("reply does not have 2 elements")
+	}
+	return l[0], l[1], nil
+}
+
+// BLMove atomically moves an element from the given side of the list at src to
+// the given side of the list at dst, returning it. If src is empty it blocks
+// until an element is pushed or the timeout (zero meaning forever) is reached,
+// in which case ok is false. Redis 6.2 and up.
+func (c *Client) BLMove(
+	src, dst string, srcSide, dstSide ListSide, timeout time.Duration,
+) (
+	val string, ok bool, err error,
+) {
+	return bmove(c.blockingCmd(
+		timeout, "BLMOVE", src, dst, string(srcSide), string(dstSide),
+	))
+}
+
+// BRPopLPush is BLMove from the right of src to the left of dst, for redis
+// versions before 6.2
+func (c *Client) BRPopLPush(src, dst string, timeout time.Duration) (
+	val string, ok bool, err error,
+) {
+	return bmove(c.blockingCmd(timeout, "BRPOPLPUSH", src, dst))
+}
+
+func bmove(r *Reply) (string, bool, error) {
+	if r.Type == NilReply {
+		return "", false, nil
+	}
+	s, err := r.Str()
+	if err != nil {
+		return "", false, err
+	}
+	return s, true, nil
+}
+
+// LPosOpts holds the options of LPOS, see LPos
+type LPosOpts struct {
+	// Skip the first Rank-1 matches (RANK), or with a negative Rank search from
+	// the end of the list instead, skipping the last -Rank-1 matches. Zero
+	// means the first match.
+	Rank int
+
+	// If not zero, only look at this many elements of the list (MAXLEN)
+	MaxLen int
+}
+
+func (o LPosOpts) args() []interface{} {
+	var args []interface{}
+	if o.Rank != 0 {
+		args = append(args, "RANK", o.Rank)
+	}
+	if o.MaxLen > 0 {
+		args = append(args, "MAXLEN", o.MaxLen)
+	}
+	return args
+}
+
+// LPos returns the index of the first element equal to elem in the list at
+// key, as chosen by opts. ok is false if there are no matching elements.
+// Redis 6.0.6 and up.
+func (c *Client) LPos(key string, elem interface{}, opts LPosOpts) (
+	idx int64, ok bool, err error,
+) {
+	r := c.Cmd("LPOS", key, elem, opts.args())
+	if r.Type == NilReply {
+		return 0, false, nil
+	}
+	if idx, err = r.Int64(); err != nil {
+		return 0, false, err
+	}
+	return idx, true, nil
+}
+
+// LPosCount is like LPos, but returns the indexes of up to count matching
+// elements, or all of them if count is zero
+func (c *Client) LPosCount(key string, elem interface{}, count int, opts LPosOpts) (
+	[]int64, error,
+) {
+	r := c.Cmd("LPOS", key, elem, opts.args(), "COUNT", count)
+	if r.Type == ErrorReply {
+		return nil, r.Err
+	}
+	if r.Type != MultiReply {
+		return nil, errors.New("reply type is not MultiReply")
+	}
+	idxs := make([]int64, len(r.Elems))
+	for i, e := range r.Elems {
+		var err error
+		if idxs[i], err = e.Int64(); err != nil {
+			return nil, err
+		}
+	}
+	return idxs, nil
+}
+
+// LMPop pops up to count elements from the given side of the first non-empty
+// list of the given keys, returning them along with the key they came from. If
+// the lists are all empty key is empty. Redis 7.0 and up.
+func (c *Client) LMPop(side ListSide, count int, keys ...string) (
+	key string, vals []string, err error,
+) {
+	r := c.Cmd("LMPOP", len(keys), keys, string(side), "COUNT", count)
+	if r.Type == NilReply {
+		return "", nil, nil
+	}
+	if r.Type == ErrorReply {
+		return "", nil, r.Err
+	}
+	if r.Type != MultiReply || len(r.Elems) != 2 {
+		return "", nil, errors.New("reply is not a multi bulk of 2 elements")
+	}
+	if key, err = r.Elems[0].Str(); err != nil {
+		return "", nil, err
+	}
+	if vals, err = r.Elems[1].List(); err != nil {
+		return "", nil, err
+	}
+	return key, vals, nil
+}
@@ -0,0 +1,106 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+func TestBPop(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "list:a", "list:b")
+	c.Cmd("RPUSH", "list:b", "1", "2", "3")
+
+	key, val, err := c.BLPop(time.Second, "list:a", "list:b")
+	assert.Nil(t, err)
+	assert.Equal(t, "list:b", key)
+	assert.Equal(t, "1", val)
+
+	key, val, err = c.BRPop(time.Second, "list:b")
+	assert.Nil(t, err)
+	assert.Equal(t, "list:b", key)
+	assert.Equal(t, "3", val)
+
+	// No read timeout, but the deadline is still set just past redis' own
+	key, _, err = c.BLPop(100*time.Millisecond, "list:a")
+	assert.Nil(t, err)
+	assert.Equal(t, "", key)
+}
+
+func TestBLMove(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "list:src", "list:dst")
+	c.Cmd("RPUSH", "list:src", "a", "b")
+
+	val, ok, err := c.BLMove("list:src", "list:dst", ListLeft, ListRight, time.Second)
+	if isUnknownCommand(err) {
+		t.Skip("BLMOVE is not supported")
+	}
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "a", val)
+
+	val, ok, err = c.BRPopLPush("list:src", "list:dst", time.Second)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "b", val)
+
+	l, _ := c.Cmd("LRANGE", "list:dst", 0, -1).List()
+	assert.Equal(t, []string{"b", "a"}, l)
+
+	_, ok, err = c.BRPopLPush("list:src", "list:dst", 100*time.Millisecond)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestLPos(t *T) {
+	c := dial(t)
+	key := "list:lpos"
+	c.Cmd("DEL", key)
+	c.Cmd("RPUSH", key, "a", "b", "a", "c", "a")
+
+	idx, ok, err := c.LPos(key, "a", LPosOpts{})
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(0), idx)
+
+	idx, ok, err = c.LPos(key, "a", LPosOpts{Rank: -1})
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(4), idx)
+
+	_, ok, err = c.LPos(key, "z", LPosOpts{})
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	idxs, err := c.LPosCount(key, "a", 0, LPosOpts{})
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{0, 2, 4}, idxs)
+
+	idxs, err = c.LPosCount(key, "a", 2, LPosOpts{Rank: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{2, 4}, idxs)
+
+	idxs, err = c.LPosCount(key, "z", 0, LPosOpts{})
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{}, idxs)
+}
+
+func TestLMPop(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "list:a", "list:b")
+	c.Cmd("RPUSH", "list:b", "1", "2", "3")
+
+	key, vals, err := c.LMPop(ListRight, 2, "list:a", "list:b")
+	if isUnknownCommand(err) {
+		t.Skip("LMPOP is not supported")
+	}
+	assert.Nil(t, err)
+	assert.Equal(t, "list:b", key)
+	assert.Equal(t, []string{"3", "2"}, vals)
+
+	key, vals, err = c.LMPop(ListLeft, 1, "list:a")
+	assert.Nil(t, err)
+	assert.Equal(t, "", key)
+	assert.Nil(t, vals)
+}
@@ -0,0 +1,346 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	mrand "math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LockNotHeldError is returned by Unlock when the lock isn't held by the Lock
+// it's called on, either because it expired or because it was never taken
+var LockNotHeldError = errors.New("lock is not held")
+
+// Deletes the lock's key, but only if it still holds our token
+var unlockScript = NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Extends the lock's TTL, but only if it still holds our token
+var refreshScript = NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// The longest Lock waits between attempts to take the lock. The actual wait is
+// random, so competing routines don't keep colliding.
+const lockRetryMax = 100 * time.Millisecond
+
+// Lock is a distributed mutual exclusion lock, held by setting a key to a
+// random token with SET NX PX. Since the key has a TTL the lock is released
+// automatically if its holder goes away without calling Unlock.
+//
+// A Lock can be held on a single server, or on a redis cluster (a
+// cluster.Cluster is a Commander), or across several independent servers using
+// the Redlock algorithm, see NewRedlock. A Lock is not thread-safe, apart from
+// the refreshing done by KeepAlive; each routine wanting the lock should have
+// its own.
+type Lock struct {
+	nodes []Commander
+	key   string
+	ttl   time.Duration
+
+	// token and until are also used by KeepAlive's routine, which is stopped
+	// by closing stop, and closes stopped once it's no longer using the nodes
+	mu      sync.Mutex
+	token   string
+	until   time.Time
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// NewLock returns a Lock with the given name, held on c for at most ttl.
+//
+// The lock's key is "lock:{name}". The braces make the name a hash tag, so on
+// a redis cluster the lock lives in the same slot as any keys using the same
+// hash tag (e.g. "{name}:data"), allowing scripts and MULTI blocks to touch the
+// lock and the data it protects together. If the name already contains a hash
+// tag it's used as it is, giving a key of "lock:name".
+func NewLock(c Commander, name string, ttl time.Duration) *Lock {
+	return NewRedlock([]Commander{c}, name, ttl)
+}
+
+// NewRedlock returns a Lock using the Redlock algorithm across the given nodes,
+// which must be independent primaries (not replicas of each other, or nodes of
+// the same cluster). The lock is only taken if it can be set on a majority of
+// the nodes, quickly enough that it's still valid afterwards. See
+// https://redis.io/topics/distlock
+func NewRedlock(nodes []Commander, name string, ttl time.Duration) *Lock {
+	return &Lock{nodes: nodes, key: lockKey(name), ttl: ttl}
+}
+
+// DialRedlockNodes connects to each of the given servers so they can be passed
+// to NewRedlock. If any of them can't be connected to the ones which were are
+// closed again and the error is returned.
+func DialRedlockNodes(cfgs []Config) ([]Commander, error) {
+	nodes := make([]Commander, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		c, err := DialConfig(cfg)
+		if err != nil {
+			for _, n := range nodes {
+				n.(*Client).Close()
+			}
+			return nil, err
+		}
+		nodes = append(nodes, c)
+	}
+	return nodes, nil
+}
+
+func lockKey(name string) string {
+	if start := strings.Index(name, "{"); start >= 0 {
+		if end := strings.Index(name[start+1:], "}"); end > 0 {
+			return "lock:" + name
+		}
+	}
+	return "lock:{" + name + "}"
+}
+
+// Key returns the key the lock is held in
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// Valid returns how much longer the lock is held for, or zero if it's not held
+func (l *Lock) Valid() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.token == "" {
+		return 0
+	}
+	if d := l.until.Sub(time.Now()); d > 0 {
+		return d
+	}
+	return 0
+}
+
+func newLockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// TryLock attempts to take the lock once, returning whether it was taken. An
+// error is only returned if the lock wasn't taken because of one (rather than
+// because someone else holds it).
+func (l *Lock) TryLock() (bool, error) {
+	token, err := newLockToken()
+	if err != nil {
+		return false, err
+	}
+
+	start := time.Now()
+	held := 0
+	var lastErr error
+	for _, n := range l.nodes {
+		r := n.Cmd("SET", l.key, token, "NX", "PX", int64(l.ttl/time.Millisecond))
+		if r.Err != nil {
+			lastErr = r.Err
+		} else if r.Type == StatusReply {
+			held++
+		}
+	}
+
+	until := l.validUntil(start)
+	if held > len(l.nodes)/2 && time.Now().Before(until) {
+		l.mu.Lock()
+		l.token, l.until = token, until
+		l.mu.Unlock()
+		return true, nil
+	}
+
+	// Release whatever was set, so the nodes which did take it don't block
+	// others until it expires
+	l.release(token)
+	return false, lastErr
+}
+
+// validUntil returns when a lock set on the nodes starting at the given time is
+// valid until. This allows for clock drift between the nodes, as the Redlock
+// algorithm suggests.
+func (l *Lock) validUntil(start time.Time) time.Time {
+	drift := l.ttl/100 + 2*time.Millisecond
+	return start.Add(l.ttl - drift)
+}
+
+// Lock takes the lock, retrying every so often while someone else holds it
+// until ctx is done, in which case ctx's error is returned. Other errors are
+// retried as well; the last one seen is returned instead of ctx's, if there was
+// one.
+func (l *Lock) Lock(ctx context.Context) error {
+	var lastErr error
+	for {
+		ok, err := l.TryLock()
+		if ok {
+			return nil
+		} else if err != nil {
+			lastErr = err
+		}
+
+		wait := time.Duration(mrand.Int63n(int64(lockRetryMax))) + time.Millisecond
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return lastErr
+			}
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Refresh extends the lock so it's held for its full TTL again, for holders
+// which need it for longer than they expected to. If the lock isn't held by
+// this Lock anymore, on a majority of the nodes, LockNotHeldError is returned
+// and the lock should be treated as lost.
+func (l *Lock) Refresh() error {
+	l.mu.Lock()
+	token, until := l.token, l.until
+	l.mu.Unlock()
+	if token == "" || !time.Now().Before(until) {
+		return LockNotHeldError
+	}
+
+	start := time.Now()
+	refreshed := 0
+	var lastErr error
+	for _, n := range l.nodes {
+		i, err := refreshScript.Cmd(
+			n, []string{l.key}, token, int64(l.ttl/time.Millisecond),
+		).Int()
+		if err != nil {
+			lastErr = err
+		} else if i == 1 {
+			refreshed++
+		}
+	}
+	if refreshed <= len(l.nodes)/2 {
+		if lastErr != nil {
+			return lastErr
+		}
+		return LockNotHeldError
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.token != token {
+		// Unlocked in the meantime
+		return LockNotHeldError
+	}
+	l.until = l.validUntil(start)
+	return nil
+}
+
+// KeepAlive refreshes the lock in the background every interval (a third of
+// the lock's TTL if zero), so it stays held for as long as it's needed:
+//
+//	if err := l.Lock(ctx); err != nil {
+//		return err
+//	}
+//	lost := l.KeepAlive(ctx, 0)
+//	defer l.Unlock()
+//	select {
+//	case <-done:
+//	case err := <-lost:
+//		// stop what we were doing, someone else may have the lock
+//	}
+//
+// The refreshing stops once the lock is unlocked, or ctx is done, at which
+// point the returned channel is closed. If a refresh fails the error is sent on
+// the channel before it's closed; by then the lock may have been lost.
+// KeepAlive must only be called while the lock is held, and only once for each
+// time it's taken.
+func (l *Lock) KeepAlive(ctx context.Context, interval time.Duration) <-chan error {
+	if interval <= 0 {
+		interval = l.ttl / 3
+	}
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	l.mu.Lock()
+	l.stop, l.stopped = stop, stopped
+	l.mu.Unlock()
+
+	lost := make(chan error, 1)
+	go func() {
+		defer close(lost)
+		defer close(stopped)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-stop:
+				return
+			case <-t.C:
+			}
+			if err := l.Refresh(); err != nil {
+				select {
+				case <-stop:
+				default:
+					lost <- err
+				}
+				return
+			}
+		}
+	}()
+	return lost
+}
+
+// Unlock releases the lock, stopping KeepAlive if it was used. If it wasn't
+// held, or had expired, LockNotHeldError is returned.
+func (l *Lock) Unlock() error {
+	l.mu.Lock()
+	token, until := l.token, l.until
+	l.token = ""
+	stopped := l.stopped
+	if l.stop != nil {
+		close(l.stop)
+		l.stop, l.stopped = nil, nil
+	}
+	l.mu.Unlock()
+
+	// The nodes may not be thread-safe, so wait for a refresh which is under
+	// way to finish before using them
+	if stopped != nil {
+		<-stopped
+	}
+	if token == "" {
+		return LockNotHeldError
+	}
+	expired := !time.Now().Before(until)
+	released, err := l.release(token)
+	if err != nil && released == 0 {
+		return err
+	}
+	if expired || released <= len(l.nodes)/2 {
+		return LockNotHeldError
+	}
+	return nil
+}
+
+// release deletes the lock from every node on which it holds token, returning
+// how many it was deleted from and the last error seen
+func (l *Lock) release(token string) (int, error) {
+	released := 0
+	var lastErr error
+	for _, n := range l.nodes {
+		i, err := unlockScript.Cmd(n, []string{l.key}, token).Int()
+		if err != nil {
+			lastErr = err
+		} else if i == 1 {
+			released++
+		}
+	}
+	return released, lastErr
+}
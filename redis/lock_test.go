@@ -0,0 +1,166 @@
+package redis
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+func TestLockKey(t *T) {
+	assert.Equal(t, "lock:{foo}", lockKey("foo"))
+	assert.Equal(t, "lock:{user:1}", lockKey("user:1"))
+	assert.Equal(t, "lock:{user}:1", lockKey("{user}:1"))
+	assert.Equal(t, "lock:{{}", lockKey("{"))
+}
+
+func TestLock(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "lock:{locktest}")
+
+	l := NewLock(c, "locktest", 10*time.Second)
+	assert.Equal(t, "lock:{locktest}", l.Key())
+	assert.Equal(t, time.Duration(0), l.Valid())
+	ok, err := l.TryLock()
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.True(t, l.Valid() > 9*time.Second)
+
+	l2 := NewLock(dial(t), "locktest", 10*time.Second)
+	ok, err = l2.TryLock()
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, LockNotHeldError, l2.Unlock())
+
+	assert.Nil(t, l.Unlock())
+	assert.Equal(t, LockNotHeldError, l.Unlock())
+
+	ok, err = l2.TryLock()
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// Someone else's lock isn't released by an expired holder
+	c.Cmd("SET", "lock:{locktest}", "someone else")
+	assert.Equal(t, LockNotHeldError, l2.Unlock())
+	s, _ := c.Cmd("GET", "lock:{locktest}").Str()
+	assert.Equal(t, "someone else", s)
+	c.Cmd("DEL", "lock:{locktest}")
+}
+
+func TestLockContext(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "lock:{lockctx}")
+
+	l := NewLock(c, "lockctx", 10*time.Second)
+	assert.Nil(t, l.Lock(context.Background()))
+
+	// Waits for the other holder to let go
+	l2 := NewLock(dial(t), "lockctx", 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, l2.Lock(ctx))
+
+	time.AfterFunc(100*time.Millisecond, func() { l.Unlock() })
+	start := time.Now()
+	assert.Nil(t, l2.Lock(context.Background()))
+	assert.True(t, time.Since(start) >= 90*time.Millisecond)
+	assert.Nil(t, l2.Unlock())
+}
+
+func TestLockRefresh(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "lock:{lockrefresh}")
+
+	l := NewLock(c, "lockrefresh", time.Second)
+	assert.Equal(t, LockNotHeldError, l.Refresh())
+	ok, _ := l.TryLock()
+	assert.True(t, ok)
+
+	c.Cmd("PEXPIRE", "lock:{lockrefresh}", 100)
+	assert.Nil(t, l.Refresh())
+	ttl, _ := c.Cmd("PTTL", "lock:{lockrefresh}").Int()
+	assert.True(t, ttl > 900)
+	assert.True(t, l.Valid() > 900*time.Millisecond)
+
+	c.Cmd("SET", "lock:{lockrefresh}", "someone else")
+	assert.Equal(t, LockNotHeldError, l.Refresh())
+	c.Cmd("DEL", "lock:{lockrefresh}")
+}
+
+func TestLockKeepAlive(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "lock:{lockalive}")
+
+	l := NewLock(dial(t), "lockalive", 200*time.Millisecond)
+	ok, _ := l.TryLock()
+	assert.True(t, ok)
+	lost := l.KeepAlive(context.Background(), 50*time.Millisecond)
+	time.Sleep(400 * time.Millisecond)
+	assert.True(t, l.Valid() > 0)
+	n, _ := c.Cmd("EXISTS", "lock:{lockalive}").Int()
+	assert.Equal(t, 1, n)
+
+	// Unlocking stops it without an error
+	assert.Nil(t, l.Unlock())
+	err, open := <-lost
+	assert.Nil(t, err)
+	assert.False(t, open)
+
+	// Losing the lock is reported
+	ok, _ = l.TryLock()
+	assert.True(t, ok)
+	lost = l.KeepAlive(context.Background(), 20*time.Millisecond)
+	c.Cmd("SET", "lock:{lockalive}", "someone else")
+	select {
+	case err := <-lost:
+		assert.Equal(t, LockNotHeldError, err)
+	case <-time.After(time.Second):
+		t.Fatal("lost lock not reported")
+	}
+	c.Cmd("DEL", "lock:{lockalive}")
+}
+
+// Databases on the same server stand in for independent nodes
+func redlockNodes(t *T) []Commander {
+	var cfgs []Config
+	for db := 1; db <= 3; db++ {
+		cfgs = append(cfgs, Config{Network: "tcp", Addr: "127.0.0.1:6379", DB: db})
+	}
+	nodes, err := DialRedlockNodes(cfgs)
+	assert.Nil(t, err)
+	for _, n := range nodes {
+		n.Cmd("DEL", "lock:{redlocktest}")
+	}
+	return nodes
+}
+
+func TestRedlock(t *T) {
+	nodes := redlockNodes(t)
+
+	l := NewRedlock(nodes, "redlocktest", 10*time.Second)
+	ok, err := l.TryLock()
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Nil(t, l.Unlock())
+
+	// With a minority of the nodes held by someone else it can still be taken
+	nodes[0].Cmd("SET", "lock:{redlocktest}", "someone else")
+	ok, err = l.TryLock()
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Nil(t, l.Unlock())
+
+	// But not with a majority, and the node it did get is released again
+	nodes[1].Cmd("SET", "lock:{redlocktest}", "someone else")
+	ok, err = l.TryLock()
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	n, _ := nodes[2].Cmd("EXISTS", "lock:{redlocktest}").Int()
+	assert.Equal(t, 0, n)
+
+	_, err = DialRedlockNodes([]Config{
+		{Network: "tcp", Addr: "127.0.0.1:6379"},
+		{Network: "tcp", Addr: "127.0.0.1:1"},
+	})
+	assert.NotNil(t, err)
+}
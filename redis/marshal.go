@@ -0,0 +1,162 @@
+package redis
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Marshaler is implemented by types which know how to encode themselves as a
+// single command argument. It takes precedence over encoding.TextMarshaler,
+// which is also used for arguments if implemented.
+type Marshaler interface {
+	MarshalRedis() ([]byte, error)
+}
+
+// Commands whose bare duration arguments are in milliseconds, or whole seconds,
+// rather than the fractional seconds the blocking commands take, and the
+// arguments which are followed by a duration in milliseconds or whole seconds
+// whatever the command
+var (
+	millisCmds = map[string]bool{
+		"PEXPIRE": true, "PSETEX": true, "WAIT": true, "WAITAOF": true,
+		"RESTORE": true, "MIGRATE": true, "CLIENT": true, "XCLAIM": true,
+		"XAUTOCLAIM": true,
+	}
+	secondsCmds = map[string]bool{"EXPIRE": true, "SETEX": true}
+	millisArgs  = map[string]bool{"PX": true, "BLOCK": true, "IDLE": true}
+	secondsArgs = map[string]bool{"EX": true, "IDLETIME": true}
+)
+
+// marshalArgs flattens the arguments for the given command the same way
+// resp.Flatten does, while encoding the values resp doesn't know about:
+// Marshalers and TextMarshalers, named types (e.g. a type MyInt int) as their
+// underlying type, and time.Durations in the unit the command expects. A
+// Duration following a PX, BLOCK (of XREAD and XREADGROUP) or IDLE (of XCLAIM)
+// argument is sent in milliseconds, as is one given to PEXPIRE, PSETEX, WAIT,
+// WAITAOF, RESTORE (its TTL), MIGRATE (its timeout), CLIENT PAUSE, XCLAIM or
+// XAUTOCLAIM (their min-idle-time). One following EX or IDLETIME, or given to
+// EXPIRE or SETEX, is sent in whole seconds, and any other in seconds with as
+// much precision as is needed (e.g. for the timeout of BLPOP). Durations sent
+// in milliseconds or whole seconds are rounded up, so a short but positive TTL
+// never becomes 0, which would delete the key (or be refused, for SET and
+// SETEX), and a short timeout never becomes 0, which would mean forever.
+func marshalArgs(cmd string, args []interface{}) ([]interface{}, error) {
+	m := argMarshaler{cmd: strings.ToUpper(cmd), out: make([]interface{}, 0, len(args))}
+	for _, arg := range args {
+		if err := m.add(arg); err != nil {
+			return nil, err
+		}
+	}
+	return m.out, nil
+}
+
+type argMarshaler struct {
+	cmd string
+	out []interface{}
+}
+
+func (m *argMarshaler) add(arg interface{}) error {
+	switch at := arg.(type) {
+	case nil, string, []byte, bool, int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, float32, float64:
+		m.out = append(m.out, arg)
+		return nil
+	case time.Duration:
+		m.out = append(m.out, m.duration(at))
+		return nil
+	case Marshaler:
+		b, err := at.MarshalRedis()
+		if err != nil {
+			return err
+		}
+		m.out = append(m.out, b)
+		return nil
+	case encoding.TextMarshaler:
+		b, err := at.MarshalText()
+		if err != nil {
+			return err
+		}
+		m.out = append(m.out, b)
+		return nil
+	}
+
+	v := reflect.ValueOf(arg)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			// e.g. net.IP, which isn't a []byte as far as resp is concerned
+			if v.Kind() == reflect.Slice {
+				m.out = append(m.out, v.Bytes())
+			} else {
+				b := make([]byte, v.Len())
+				reflect.Copy(reflect.ValueOf(b), v)
+				m.out = append(m.out, b)
+			}
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := m.add(v.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			if err := m.add(k.Interface()); err != nil {
+				return err
+			}
+			if err := m.add(v.MapIndex(k).Interface()); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		m.out = append(m.out, v.String())
+	case reflect.Bool:
+		m.out = append(m.out, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		m.out = append(m.out, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		// resp would overflow a uint64 above the maximum int64
+		m.out = append(m.out, strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		f := strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits())
+		m.out = append(m.out, f)
+	default:
+		m.out = append(m.out, arg)
+	}
+	return nil
+}
+
+// duration formats d in the unit the argument before it, or failing that the
+// command, calls for
+func (m *argMarshaler) duration(d time.Duration) string {
+	var prev string
+	if n := len(m.out); n > 0 {
+		if s, ok := m.out[n-1].(string); ok {
+			prev = strings.ToUpper(s)
+		}
+	}
+	switch {
+	case millisArgs[prev]:
+		return strconv.FormatInt(roundUp(d, time.Millisecond), 10)
+	case secondsArgs[prev]:
+		return strconv.FormatInt(roundUp(d, time.Second), 10)
+	case millisCmds[m.cmd]:
+		return strconv.FormatInt(roundUp(d, time.Millisecond), 10)
+	case secondsCmds[m.cmd]:
+		return strconv.FormatInt(roundUp(d, time.Second), 10)
+	}
+	return formatSeconds(d)
+}
+
+// roundUp returns how many of unit there are in d, rounding up any remainder
+// if d is positive
+func roundUp(d, unit time.Duration) int64 {
+	n := int64(d / unit)
+	if d > 0 && d%unit != 0 {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,120 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+type point struct{ x, y int }
+
+func (p point) MarshalRedis() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d,%d", p.x, p.y)), nil
+}
+
+type badArg struct{}
+
+func (badArg) MarshalRedis() ([]byte, error) {
+	return nil, errors.New("bad arg")
+}
+
+type level int
+type flag bool
+
+func TestMarshalArgs(t *T) {
+	args, err := marshalArgs("RPUSH", []interface{}{
+		"list",
+		[]interface{}{1, []string{"a", "b"}},
+		point{1, 2},
+		net.ParseIP("10.0.0.1"),
+		level(3),
+		flag(true),
+		uint64(1 << 63),
+		float32(0.1),
+		time.Unix(0, 0).UTC(),
+		[]byte("raw"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{
+		"list",
+		1, "a", "b",
+		[]byte("1,2"),
+		[]byte("10.0.0.1"),
+		int64(3),
+		true,
+		"9223372036854775808",
+		float32(0.1),
+		[]byte("1970-01-01T00:00:00Z"),
+		[]byte("raw"),
+	}, args)
+
+	_, err = marshalArgs("SET", []interface{}{"foo", badArg{}})
+	assert.Equal(t, "bad arg", err.Error())
+}
+
+func TestMarshalDurations(t *T) {
+	d := 1500 * time.Millisecond
+	for _, test := range []struct {
+		cmd  string
+		args []interface{}
+		out  string
+	}{
+		{"set", []interface{}{"k", "v", "px", d}, "1500"},
+		{"SET", []interface{}{"k", "v", "EX", d}, "2"},
+		{"PEXPIRE", []interface{}{"k", d}, "1500"},
+		{"EXPIRE", []interface{}{"k", d}, "2"},
+		{"BLPOP", []interface{}{"k", d}, "1.5"},
+		{"WAIT", []interface{}{1, d}, "1500"},
+		{"WAITAOF", []interface{}{1, 1, d}, "1500"},
+		{"XREAD", []interface{}{"BLOCK", d}, "1500"},
+		{"XREADGROUP", []interface{}{"GROUP", "g", "c", "BLOCK", d}, "1500"},
+		{"RESTORE", []interface{}{"k", d}, "1500"},
+		{"RESTORE", []interface{}{"k", 0, "v", "IDLETIME", d}, "2"},
+		{"MIGRATE", []interface{}{"h", 6379, "k", 0, d}, "1500"},
+		{"CLIENT", []interface{}{"PAUSE", d}, "1500"},
+		{"XCLAIM", []interface{}{"k", "g", "c", d}, "1500"},
+		{"XCLAIM", []interface{}{"k", "g", "c", 0, "0-1", "IDLE", d}, "1500"},
+		{"XAUTOCLAIM", []interface{}{"k", "g", "c", d}, "1500"},
+
+		// Durations under the unit are rounded up rather than down to 0,
+		// which would delete the key
+		{"EXPIRE", []interface{}{"k", 500 * time.Millisecond}, "1"},
+		{"SET", []interface{}{"k", "v", "EX", time.Millisecond}, "1"},
+		{"PEXPIRE", []interface{}{"k", 500 * time.Microsecond}, "1"},
+		{"EXPIRE", []interface{}{"k", 3 * time.Second}, "3"},
+		{"EXPIRE", []interface{}{"k", -1500 * time.Millisecond}, "-1"},
+		{"WAIT", []interface{}{1, time.Microsecond}, "1"},
+	} {
+		args, err := marshalArgs(test.cmd, test.args)
+		assert.Nil(t, err)
+		assert.Equal(t, test.out, args[len(args)-1], test.cmd)
+	}
+}
+
+func TestCmdMarshal(t *T) {
+	c := dial(t)
+	assert.Nil(t, c.Cmd("SET", "marshal:key", point{3, 4}, "PX", time.Minute).Err)
+	s, err := c.Cmd("GET", "marshal:key").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "3,4", s)
+	ttl, err := c.Cmd("PTTL", "marshal:key").Int()
+	assert.Nil(t, err)
+	assert.True(t, ttl > 50000 && ttl <= 60000)
+
+	// A sub-second expiry doesn't delete the key
+	assert.Nil(t, c.Cmd("EXPIRE", "marshal:key", 500*time.Millisecond).Err)
+	n, _ := c.Cmd("EXISTS", "marshal:key").Int()
+	assert.Equal(t, 1, n)
+	assert.Nil(t, c.Cmd("SETEX", "marshal:key", time.Millisecond, "v").Err)
+	n, _ = c.Cmd("EXISTS", "marshal:key").Int()
+	assert.Equal(t, 1, n)
+
+	// A marshalling error means nothing is sent
+	r := c.Cmd("SET", "marshal:key", badArg{})
+	assert.Equal(t, "bad arg", r.Err.Error())
+	assert.Nil(t, c.Cmd("PING").Err)
+}
@@ -0,0 +1,116 @@
+package redis
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MemoryUsage returns the number of bytes the given key and its value take up
+// in memory, using MEMORY USAGE. For nested values only samples of their
+// elements are measured and the total estimated from them; zero means the
+// server's default of 5, and a negative number means all of them. If the key
+// doesn't exist NilReplyError is returned.
+func (c *Client) MemoryUsage(key string, samples int) (int64, error) {
+	args := []interface{}{"USAGE", key}
+	if samples < 0 {
+		args = append(args, "SAMPLES", 0)
+	} else if samples > 0 {
+		args = append(args, "SAMPLES", samples)
+	}
+	return c.Cmd("MEMORY", args...).Int64()
+}
+
+// MemoryStats describes the server's memory usage, as returned by MEMORY
+// STATS. Sizes are in bytes.
+type MemoryStats struct {
+	PeakAllocated      int64
+	TotalAllocated     int64
+	StartupAllocated   int64
+	ReplicationBacklog int64
+	ClientsReplicas    int64
+	ClientsNormal      int64
+	AOFBuffer          int64
+	LuaCaches          int64
+	OverheadTotal      int64
+	KeysCount          int64
+	KeysBytesPerKey    int64
+	DatasetBytes       int64
+
+	// As percentages, e.g. 92.5
+	DatasetPercentage float64
+	PeakPercentage    float64
+
+	Fragmentation float64
+
+	// The overhead of each database's hash tables, by database number
+	DBs map[int]MemoryStatsDB
+
+	// All the fields MEMORY STATS returned which aren't per database,
+	// including those above, as strings
+	Fields map[string]string
+}
+
+// MemoryStatsDB is the memory overhead of a single database, see MemoryStats
+type MemoryStatsDB struct {
+	MainHashtable    int64
+	ExpiresHashtable int64
+}
+
+// MemoryStats returns the server's memory usage, using MEMORY STATS
+func (c *Client) MemoryStats() (*MemoryStats, error) {
+	r := c.Cmd("MEMORY", "STATS")
+	if r.Type == ErrorReply {
+		return nil, r.Err
+	}
+	return parseMemoryStats(r)
+}
+
+func parseMemoryStats(r *Reply) (*MemoryStats, error) {
+	ms := &MemoryStats{DBs: map[int]MemoryStatsDB{}, Fields: map[string]string{}}
+	err := pairs(r, func(name string, v *Reply) error {
+		if strings.HasPrefix(name, "db.") {
+			db, err := strconv.Atoi(name[3:])
+			if err != nil {
+				return err
+			}
+			var dbStats MemoryStatsDB
+			err = pairs(v, func(name string, v *Reply) error {
+				n, err := v.Int64()
+				switch name {
+				case "overhead.hashtable.main":
+					dbStats.MainHashtable = n
+				case "overhead.hashtable.expires":
+					dbStats.ExpiresHashtable = n
+				}
+				return err
+			})
+			ms.DBs[db] = dbStats
+			return err
+		}
+		if v.Type == IntegerReply || v.Type == BulkReply || v.Type == StatusReply {
+			ms.Fields[name] = v.String()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	f := ms.Fields
+	ms.PeakAllocated = infoInt(f, "peak.allocated")
+	ms.TotalAllocated = infoInt(f, "total.allocated")
+	ms.StartupAllocated = infoInt(f, "startup.allocated")
+	ms.ReplicationBacklog = infoInt(f, "replication.backlog")
+	ms.ClientsReplicas = infoInt(f, "clients.slaves")
+	ms.ClientsNormal = infoInt(f, "clients.normal")
+	ms.AOFBuffer = infoInt(f, "aof.buffer")
+	ms.LuaCaches = infoInt(f, "lua.caches")
+	ms.OverheadTotal = infoInt(f, "overhead.total")
+	ms.KeysCount = infoInt(f, "keys.count")
+	ms.KeysBytesPerKey = infoInt(f, "keys.bytes-per-key")
+	ms.DatasetBytes = infoInt(f, "dataset.bytes")
+	ms.DatasetPercentage = infoFloat(f, "dataset.percentage")
+	ms.PeakPercentage = infoFloat(f, "peak.percentage")
+	ms.Fragmentation = infoFloat(f, "fragmentation")
+	return ms, nil
+}
@@ -0,0 +1,58 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	. "testing"
+)
+
+func TestMemoryUsage(t *T) {
+	c := dial(t)
+	c.Cmd("SET", "memory:key", strings.Repeat("x", 1000))
+	n, err := c.MemoryUsage("memory:key", 0)
+	assert.Nil(t, err)
+	assert.True(t, n >= 1000)
+
+	c.Cmd("DEL", "memory:key")
+	_, err = c.MemoryUsage("memory:key", 0)
+	assert.Equal(t, NilReplyError, err)
+}
+
+func TestParseMemoryStats(t *T) {
+	r := NewReply([]interface{}{
+		"peak.allocated", 1048576,
+		"total.allocated", 965312,
+		"clients.normal", 20496,
+		"db.0", []interface{}{
+			"overhead.hashtable.main", 72,
+			"overhead.hashtable.expires", 32,
+		},
+		"keys.count", 3,
+		"dataset.percentage", "12.5",
+		"fragmentation", "1.5",
+	})
+	ms, err := parseMemoryStats(r)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1048576), ms.PeakAllocated)
+	assert.Equal(t, int64(965312), ms.TotalAllocated)
+	assert.Equal(t, int64(20496), ms.ClientsNormal)
+	assert.Equal(t, int64(3), ms.KeysCount)
+	assert.Equal(t, 12.5, ms.DatasetPercentage)
+	assert.Equal(t, 1.5, ms.Fragmentation)
+	assert.Equal(t, map[int]MemoryStatsDB{0: {72, 32}}, ms.DBs)
+	assert.Equal(t, "3", ms.Fields["keys.count"])
+
+	_, err = parseMemoryStats(NewReply([]interface{}{"peak.allocated"}))
+	assert.NotNil(t, err)
+}
+
+func TestMemoryStats(t *T) {
+	c := dial(t)
+	if r := c.Cmd("MEMORY", "STATS"); r.Err != nil &&
+		strings.Contains(r.Err.Error(), "unknown") {
+		t.Skip("MEMORY STATS not supported")
+	}
+	ms, err := c.MemoryStats()
+	assert.Nil(t, err)
+	assert.True(t, ms.TotalAllocated > 0)
+}
@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+// MigrateOpts holds the options of MIGRATE, see Migrate
+type MigrateOpts struct {
+	// Leave the keys on this server as well (COPY), and replace any which
+	// already exist on the destination (REPLACE)
+	Copy, Replace bool
+
+	// If Password is set the destination is authenticated with, using the
+	// redis 6 ACL form (AUTH2) if Username is also set
+	Username, Password string
+
+	// The longest any single step of the transfer may take, one second if
+	// zero. The client's read timeout is extended to cover it.
+	Timeout time.Duration
+}
+
+func (o MigrateOpts) timeout() time.Duration {
+	if o.Timeout <= 0 {
+		return time.Second
+	}
+	return o.Timeout
+}
+
+// migrateArgs returns the arguments of MIGRATE for moving keys to the given
+// database of the redis instance at addr
+func migrateArgs(addr string, db int, opts MigrateOpts, keys []string) ([]interface{}, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no keys to migrate")
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	key := keys[0]
+	if len(keys) > 1 {
+		key = ""
+	}
+	ms := int64(opts.timeout() / time.Millisecond)
+	args := []interface{}{host, port, key, db, ms}
+	if opts.Copy {
+		args = append(args, "COPY")
+	}
+	if opts.Replace {
+		args = append(args, "REPLACE")
+	}
+	if opts.Password != "" {
+		if opts.Username != "" {
+			args = append(args, "AUTH2", opts.Username, opts.Password)
+		} else {
+			args = append(args, "AUTH", opts.Password)
+		}
+	}
+	if len(keys) > 1 {
+		args = append(args, "KEYS", keys)
+	}
+	return args, nil
+}
+
+// Migrate atomically moves the keys to the given database of the redis instance
+// at addr ("host:port"), using MIGRATE with the given options. Keys which don't
+// exist are skipped; ok is false if none of them did. Moving more than one key
+// at once requires redis 3.0.6 or up.
+func (c *Client) Migrate(addr string, db int, opts MigrateOpts, keys ...string) (
+	ok bool, err error,
+) {
+	args, err := migrateArgs(addr, db, opts, keys)
+	if err != nil {
+		return false, err
+	}
+	r := c.blockingCmdArgs(opts.timeout(), "MIGRATE", args...)
+	if r.Err != nil {
+		return false, r.Err
+	}
+	s, err := r.Str()
+	if err != nil {
+		return false, err
+	}
+	return s != "NOKEY", nil
+}
@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+func TestMigrateArgs(t *T) {
+	args, err := migrateArgs("10.0.0.1:6380", 2, MigrateOpts{}, []string{"foo"})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"10.0.0.1", "6380", "foo", 2, int64(1000)}, args)
+
+	args, err = migrateArgs("[::1]:6379", 0, MigrateOpts{
+		Copy: true, Replace: true, Username: "user", Password: "pass",
+		Timeout: 5 * time.Second,
+	}, []string{"a", "b"})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{
+		"::1", "6379", "", 0, int64(5000), "COPY", "REPLACE",
+		"AUTH2", "user", "pass", "KEYS", []string{"a", "b"},
+	}, args)
+
+	args, err = migrateArgs("host:1", 0, MigrateOpts{Password: "pass"}, []string{"a"})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"host", "1", "a", 0, int64(1000), "AUTH", "pass"}, args)
+
+	_, err = migrateArgs("host", 0, MigrateOpts{}, []string{"a"})
+	assert.NotNil(t, err)
+	_, err = migrateArgs("host:1", 0, MigrateOpts{}, nil)
+	assert.NotNil(t, err)
+}
+
+func TestMigrate(t *T) {
+	c := dial(t)
+	c.Cmd("SET", "migrate:key", "foo")
+	ok, err := c.Migrate("127.0.0.1:6379", 1, MigrateOpts{Copy: true}, "migrate:key")
+	if isUnknownCommand(err) {
+		t.Skip("MIGRATE is not supported")
+	}
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = c.Migrate("127.0.0.1:6379", 1, MigrateOpts{}, "migrate:missing")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,169 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MonitorEntry is a single command seen by MONITOR
+type MonitorEntry struct {
+	Time time.Time
+	DB   int
+
+	// The address of the client which ran the command, "lua" for a command run
+	// by a script, or "unix:" followed by the socket path for a client
+	// connected over a unix socket
+	Addr string
+
+	Cmd  string
+	Args []string
+}
+
+// How often Monitor checks whether its context is done while waiting for
+// commands
+var monitorPollInterval = 100 * time.Millisecond
+
+// Monitor makes a new connection to the same server as c, with the same
+// settings, and calls MONITOR on it. Every command the server runs is then
+// passed to handler, until ctx is done or there's an error. The connection is
+// closed before returning. Monitor always returns a non-nil error, which is
+// ctx's error if it's done.
+//
+// Keep in mind that MONITOR slows the server down considerably, so it's best
+// used for short periods of debugging, e.g. finding hot keys or unexpected
+// traffic:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	counts := map[string]int{}
+//	client.Monitor(ctx, func(e redis.MonitorEntry) {
+//		if len(e.Args) > 0 {
+//			counts[e.Args[0]]++
+//		}
+//	})
+func (c *Client) Monitor(ctx context.Context, handler func(MonitorEntry)) error {
+	mc, err := DialConfig(c.cfg)
+	if err != nil {
+		return err
+	}
+	defer mc.Close()
+	if err = mc.Cmd("MONITOR").Err; err != nil {
+		return err
+	}
+
+	rc := mc.WithTimeout(monitorPollInterval)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r := rc.ReadReply()
+		if r.Err != nil {
+			if errors.Is(r.Err, TimeoutError) {
+				continue
+			}
+			return r.Err
+		}
+		line, err := r.Str()
+		if err != nil {
+			return err
+		}
+		if e, ok := parseMonitorLine(line); ok {
+			handler(e)
+		}
+	}
+}
+
+// parseMonitorLine parses a line of MONITOR output, which looks like:
+//
+//	1339518083.107412 [0 127.0.0.1:60866] "SET" "foo" "bar"
+func parseMonitorLine(line string) (MonitorEntry, bool) {
+	var e MonitorEntry
+	i := strings.Index(line, " [")
+	j := strings.Index(line, "] ")
+	if i < 0 || j < i {
+		return e, false
+	}
+
+	ts := strings.SplitN(line[:i], ".", 2)
+	sec, err := strconv.ParseInt(ts[0], 10, 64)
+	if err != nil {
+		return e, false
+	}
+	var usec int64
+	if len(ts) == 2 {
+		if usec, err = strconv.ParseInt(ts[1], 10, 64); err != nil {
+			return e, false
+		}
+	}
+	e.Time = time.Unix(sec, usec*1e3)
+
+	src := strings.SplitN(line[i+2:j], " ", 2)
+	if e.DB, err = strconv.Atoi(src[0]); err != nil {
+		return e, false
+	}
+	if len(src) == 2 {
+		e.Addr = src[1]
+	}
+
+	args, ok := parseQuoted(line[j+2:])
+	if !ok || len(args) == 0 {
+		return e, false
+	}
+	e.Cmd, e.Args = args[0], args[1:]
+	return e, true
+}
+
+// parseQuoted parses a list of space separated strings, each in double quotes
+// and escaped the way redis escapes them for MONITOR
+func parseQuoted(s string) ([]string, bool) {
+	var strs []string
+	for {
+		s = strings.TrimLeft(s, " ")
+		if s == "" {
+			return strs, true
+		}
+		if s[0] != '"' {
+			return nil, false
+		}
+		var b []byte
+		i := 1
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] != '\\' || i+1 >= len(s) {
+				b = append(b, s[i])
+				continue
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				b = append(b, '\n')
+			case 'r':
+				b = append(b, '\r')
+			case 't':
+				b = append(b, '\t')
+			case 'a':
+				b = append(b, '\a')
+			case 'b':
+				b = append(b, '\b')
+			case 'x':
+				if i+2 < len(s) {
+					if n, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+						b = append(b, byte(n))
+						i += 2
+						continue
+					}
+				}
+				b = append(b, 'x')
+			default:
+				b = append(b, s[i])
+			}
+		}
+		if i >= len(s) {
+			return nil, false
+		}
+		strs = append(strs, string(b))
+		s = s[i+1:]
+	}
+}
@@ -0,0 +1,58 @@
+package redis
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+func TestParseMonitorLine(t *T) {
+	e, ok := parseMonitorLine(
+		`1339518083.107412 [2 127.0.0.1:60866] "SET" "foo" "a \"b\"\n\x00\\"`,
+	)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1339518083), e.Time.Unix())
+	assert.Equal(t, 107412000, e.Time.Nanosecond())
+	assert.Equal(t, 2, e.DB)
+	assert.Equal(t, "127.0.0.1:60866", e.Addr)
+	assert.Equal(t, "SET", e.Cmd)
+	assert.Equal(t, []string{"foo", "a \"b\"\n\x00\\"}, e.Args)
+
+	e, ok = parseMonitorLine(`1339518083.107412 [0 lua] "get" ""`)
+	assert.True(t, ok)
+	assert.Equal(t, "lua", e.Addr)
+	assert.Equal(t, []string{""}, e.Args)
+
+	for _, line := range []string{
+		"OK",
+		`1339518083.1 [x 127.0.0.1:1] "GET"`,
+		`1339518083.1 [0 127.0.0.1:1] "GET`,
+		`1339518083.1 [0 127.0.0.1:1] GET`,
+	} {
+		_, ok = parseMonitorLine(line)
+		assert.False(t, ok, line)
+	}
+}
+
+func TestMonitor(t *T) {
+	c := dial(t)
+	if isUnknownCommand(c.Cmd("MONITOR").Err) {
+		t.Skip("MONITOR not supported")
+	}
+	c = dial(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		dial(t).Cmd("GET", "monitor:key")
+	}()
+	err := c.Monitor(ctx, func(e MonitorEntry) {
+		if e.Cmd == "GET" || e.Cmd == "get" {
+			assert.Equal(t, []string{"monitor:key"}, e.Args)
+			cancel()
+		}
+	})
+	assert.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,216 @@
+package redis
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// MuxUnsupportedError is the error in the reply of a command sent through a Mux
+// which needs a connection of its own, see Mux.Cmd
+var MuxUnsupportedError = errors.New("command can not be sent on a shared connection")
+
+// MuxClosedError is the error in the reply of a command sent through a Mux
+// after it was closed
+var MuxClosedError = errors.New("mux is closed")
+
+// Commands which change the state of the connection in a way which would
+// affect the commands other routines send on it, or which take it over
+// altogether. Blocking commands would hold up everyone else, so they aren't
+// allowed either.
+var muxUnsupported = map[string]bool{
+	"MULTI": true, "EXEC": true, "DISCARD": true, "WATCH": true,
+	"UNWATCH": true, "SUBSCRIBE": true, "PSUBSCRIBE": true,
+	"SSUBSCRIBE": true, "MONITOR": true, "RESET": true, "QUIT": true,
+}
+
+// How many commands may be waiting for their replies on a Mux at once. Once
+// there are this many Cmd waits for the oldest to be read before sending more.
+const muxMaxPending = 4096
+
+// Mux is an alternative to a pool: a single connection shared by any number of
+// routines at once. Commands are written as soon as they're given, without
+// waiting for the replies to the ones sent before them, and since redis
+// replies in order each reply is matched up with its command by a routine
+// reading them off the connection. This keeps the number of connections to the
+// server at one, while still allowing many commands in flight.
+//
+// If the connection fails, every command waiting for a reply gets the error,
+// and a new connection is made using the same Config for the next command. A
+// Mux is a Commander, and is safe to use from multiple routines at once.
+type Mux struct {
+	cfg Config
+
+	// Held while sending a command, so that commands are queued in the same
+	// order they're written. conn is nil if the last attempt to reconnect
+	// failed.
+	mu     sync.Mutex
+	conn   *muxConn
+	closed bool
+}
+
+// muxConn is a single connection used by a Mux. It has a Client for writing
+// and one for reading, sharing the same net.Conn but with their own state, so
+// that they can be used from different routines. The reading routine closes
+// failed once the connection has failed, and done once it's stopped.
+type muxConn struct {
+	w, r    *Client
+	pending chan *muxCall
+	failed  chan struct{}
+	done    chan struct{}
+}
+
+type muxCall struct {
+	req   *request
+	reply chan *Reply
+}
+
+// NewMux connects to the redis server described by cfg, see DialConfig, and
+// returns a Mux using the connection
+func NewMux(cfg Config) (*Mux, error) {
+	m := &Mux{cfg: cfg}
+	mc, err := m.dial()
+	if err != nil {
+		return nil, err
+	}
+	m.conn = mc
+	return m, nil
+}
+
+func (m *Mux) dial() (*muxConn, error) {
+	c, err := DialConfig(m.cfg)
+	if err != nil {
+		return nil, err
+	}
+	r := &Client{Conn: c.Conn, connState: &connState{
+		reader: c.reader,
+		limits: c.limits,
+		cfg:    c.cfg,
+		conn:   c.conn,
+	}}
+	r.readTimeout = c.readTimeout
+	mc := &muxConn{
+		w:       c,
+		r:       r,
+		pending: make(chan *muxCall, muxMaxPending),
+		failed:  make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go mc.read()
+	return mc, nil
+}
+
+// read reads the replies to the commands sent, in order, until the connection
+// fails or the mux is closed, after which every command still waiting gets the
+// error
+func (mc *muxConn) read() {
+	defer close(mc.done)
+	var err error
+	for call := range mc.pending {
+		if err != nil {
+			call.reply <- &Reply{Type: ErrorReply, Err: err}
+			continue
+		}
+		r := mc.r.readRequestReply(call.req)
+		call.reply <- r
+		if mc.r.broken {
+			err = r.Err
+			close(mc.failed)
+		}
+	}
+}
+
+// broken returns whether the connection has failed, either while writing or
+// reading. Only the routine sending commands may call it.
+func (mc *muxConn) broken() bool {
+	if mc.w.broken {
+		return true
+	}
+	select {
+	case <-mc.failed:
+		return true
+	default:
+		return false
+	}
+}
+
+// stop waits for the reading routine to read the replies to all the commands
+// which were sent, and stop
+func (mc *muxConn) stop() {
+	close(mc.pending)
+	<-mc.done
+}
+
+// Cmd sends the given command on the shared connection and waits for its
+// reply. Commands which need a connection of their own (transactions, pubsub,
+// MONITOR and blocking commands like BLPOP) get MuxUnsupportedError, since
+// they'd affect every other routine using the Mux. SELECT and AUTH do work,
+// but change the connection for everyone; the Config given to NewMux is a
+// better place for them.
+func (m *Mux) Cmd(cmd string, args ...interface{}) *Reply {
+	if muxUnsupported[strings.ToUpper(cmd)] || BlockingCommand(cmd, args...) {
+		return &Reply{Type: ErrorReply, Err: MuxUnsupportedError}
+	}
+	call, err := m.send(cmd, args)
+	if err != nil {
+		return &Reply{Type: ErrorReply, Err: err}
+	}
+	return <-call.reply
+}
+
+// send writes the command, and returns the call its reply will be given to
+func (m *Mux) send(cmd string, args []interface{}) (*muxCall, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, MuxClosedError
+	}
+	if m.conn != nil && m.conn.broken() {
+		// The reading routine is done with the old connection once it's
+		// stopped, so its state (e.g. a SELECT sent through the mux) can be
+		// carried over to the new one
+		m.conn.stop()
+		m.cfg = m.conn.r.cfg
+		m.conn = nil
+	}
+	if m.conn == nil {
+		mc, err := m.dial()
+		if err != nil {
+			return nil, err
+		}
+		m.conn = mc
+	}
+
+	mc := m.conn
+	req := mc.w.newRequest(cmd, args)
+	if req.err != nil {
+		return nil, req.err
+	}
+	call := &muxCall{req: req, reply: make(chan *Reply, 1)}
+	mc.pending <- call
+
+	// If the write fails the connection is closed, so the reading routine
+	// fails the call
+	mc.w.writeRequest(req)
+	return call, nil
+}
+
+// Close waits for the replies to the commands already sent, and then closes
+// the connection. Commands sent afterwards get MuxClosedError.
+func (m *Mux) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil
+	}
+	m.closed = true
+	if m.conn == nil {
+		return nil
+	}
+	m.conn.stop()
+	if m.conn.broken() {
+		// Already closed
+		return nil
+	}
+	return m.conn.w.Close()
+}
@@ -0,0 +1,87 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"sync"
+	. "testing"
+)
+
+func dialMux(t *T) *Mux {
+	m, err := NewMux(Config{Network: "tcp", Addr: "127.0.0.1:6379"})
+	assert.Nil(t, err)
+	return m
+}
+
+func TestMux(t *T) {
+	m := dialMux(t)
+	defer m.Close()
+	m.Cmd("DEL", "mux:counter")
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := map[int64]bool{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				n, err := m.Cmd("INCR", "mux:counter").Int64()
+				assert.Nil(t, err)
+				mu.Lock()
+				seen[n] = true
+				mu.Unlock()
+
+				// Each routine gets its own replies
+				s := strconv.Itoa(i*100 + j)
+				r, err := m.Cmd("ECHO", s).Str()
+				assert.Nil(t, err)
+				assert.Equal(t, s, r)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 400, len(seen))
+
+	assert.NotNil(t, m.Cmd("NOTACOMMAND").Err)
+	assert.Nil(t, m.Cmd("PING").Err)
+
+	var c Commander = m
+	assert.Nil(t, c.Cmd("PING").Err)
+}
+
+func TestMuxUnsupported(t *T) {
+	m := dialMux(t)
+	defer m.Close()
+	for _, cmd := range [][]interface{}{
+		{"MULTI"}, {"exec"}, {"WATCH", "foo"}, {"SUBSCRIBE", "foo"},
+		{"BLPOP", "foo", 0}, {"MONITOR"},
+	} {
+		r := m.Cmd(cmd[0].(string), cmd[1:]...)
+		assert.Equal(t, MuxUnsupportedError, r.Err)
+	}
+	assert.Nil(t, m.Cmd("PING").Err)
+}
+
+func TestMuxReconnect(t *T) {
+	m := dialMux(t)
+	assert.Nil(t, m.Cmd("SELECT", 1).Err)
+	m.Cmd("SET", "mux:db", "one")
+
+	m.mu.Lock()
+	m.conn.w.Conn.Close()
+	m.mu.Unlock()
+	err := m.Cmd("PING").Err
+	assert.NotNil(t, err)
+	_, ok := err.(*ConnError)
+	assert.True(t, ok)
+
+	// The new connection is on the same database
+	s, err := m.Cmd("GET", "mux:db").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "one", s)
+
+	assert.Nil(t, m.Close())
+	assert.Equal(t, MuxClosedError, m.Cmd("PING").Err)
+	assert.Nil(t, m.Close())
+}
@@ -0,0 +1,33 @@
+package redis
+
+import (
+	"time"
+)
+
+// ObjectEncoding returns the internal encoding redis uses for the value at key,
+// e.g. "listpack", "hashtable" or "int". If the key doesn't exist
+// NilReplyError is returned, as it is by the other Object methods.
+func (c *Client) ObjectEncoding(key string) (string, error) {
+	return c.Cmd("OBJECT", "ENCODING", key).Str()
+}
+
+// ObjectIdleTime returns how long it's been since the value at key was last
+// read or written, to the second. Redis returns an error for this if the
+// server's eviction policy is one of the LFU ones.
+func (c *Client) ObjectIdleTime(key string) (time.Duration, error) {
+	secs, err := c.Cmd("OBJECT", "IDLETIME", key).Int64()
+	return time.Duration(secs) * time.Second, err
+}
+
+// ObjectFreq returns the logarithmic access frequency counter of the value at
+// key. Redis returns an error for this unless the server's eviction policy is
+// one of the LFU ones.
+func (c *Client) ObjectFreq(key string) (int, error) {
+	return c.Cmd("OBJECT", "FREQ", key).Int()
+}
+
+// ObjectRefCount returns the number of references to the value at key, which
+// is mostly useful for debugging
+func (c *Client) ObjectRefCount(key string) (int, error) {
+	return c.Cmd("OBJECT", "REFCOUNT", key).Int()
+}
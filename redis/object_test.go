@@ -0,0 +1,30 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	. "testing"
+)
+
+func TestObjectSubcommands(t *T) {
+	c := dial(t)
+	c.Cmd("SET", "object:key", 12345)
+	enc, err := c.ObjectEncoding("object:key")
+	if err != nil && strings.Contains(err.Error(), "unknown") {
+		t.Skip("OBJECT ENCODING not supported")
+	}
+	assert.Nil(t, err)
+	assert.Equal(t, "int", enc)
+
+	_, err = c.ObjectEncoding("object:missing")
+	assert.Equal(t, NilReplyError, err)
+
+	refs, err := c.ObjectRefCount("object:key")
+	assert.Nil(t, err)
+	assert.True(t, refs > 0)
+
+	// Only one of these works, depending on the eviction policy
+	_, idleErr := c.ObjectIdleTime("object:key")
+	_, freqErr := c.ObjectFreq("object:key")
+	assert.True(t, (idleErr == nil) != (freqErr == nil))
+}
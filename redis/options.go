@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"time"
+)
+
+// Options override parts of a Client's behavior, see WithOptions. Fields left
+// as their zero value keep the behavior of the client being derived from.
+// There's no read preference, since a Client only talks to the server it
+// dialed; see WithReadPreference in extra/replica for overriding that.
+type Options struct {
+	// The read and write timeouts used when communicating with redis, see
+	// Config. Use NoTimeout to disable a timeout the client normally has.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	// See SetKeyPrefix
+	KeyPrefix string
+
+	// See SetCodec
+	Codec Codec
+
+	// See SetRetryPolicy. The policy is only overridden if Retries isn't
+	// zero, use NoRetries to disable retrying.
+	Retry RetryPolicy
+}
+
+// WithOptions returns a lightweight client derived from this one, which shares
+// its connection (including pipeline and MULTI state), but overrides some of
+// its behavior using the given Options. This allows tuning individual commands
+// without needing another connection:
+//
+//	// BLPOP for up to 30 seconds, even though the client normally uses a one
+//	// second timeout
+//	r := client.WithOptions(redis.Options{ReadTimeout: 31 * time.Second}).
+//		Cmd("BLPOP", "queue", 30)
+//
+// Any settings not overridden, including those changed later on, are the same
+// as this client's at the moment of deriving. Closing either client closes the
+// connection for both. Values counted by SetLargeValueThreshold on a derived
+// client are added to the counts of the client it was derived from.
+func (c *Client) WithOptions(opts Options) *Client {
+	d := *c
+	d.parent = c
+	d.LargeWrites, d.LargeReads = 0, 0
+	if opts.ReadTimeout != 0 {
+		d.readTimeout = opts.ReadTimeout
+	}
+	if opts.WriteTimeout != 0 {
+		d.writeTimeout = opts.WriteTimeout
+	}
+	if opts.KeyPrefix != "" {
+		d.keyPrefix = opts.KeyPrefix
+	}
+	if opts.Codec != nil {
+		d.codec = opts.Codec
+	}
+	if opts.Retry.Retries != 0 {
+		d.retry = opts.Retry
+	}
+	return &d
+}
+
+// WithTimeout returns a client derived from this one (see WithOptions) which
+// uses the given read timeout, for a single slow command such as a BLPOP or a
+// long running script:
+//
+//	r := client.WithTimeout(30 * time.Second).Cmd("BLPOP", "queue", 25)
+//
+// Use NoTimeout to wait forever.
+func (c *Client) WithTimeout(timeout time.Duration) *Client {
+	return c.WithOptions(Options{ReadTimeout: timeout})
+}
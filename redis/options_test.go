@@ -0,0 +1,69 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+func TestWithOptions(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "opts:foo")
+	d := c.WithOptions(Options{ReadTimeout: time.Second, KeyPrefix: "opts:"})
+	assert.Equal(t, time.Second, d.readTimeout)
+	assert.Equal(t, 10*time.Second, d.writeTimeout)
+	assert.Equal(t, 10*time.Second, c.readTimeout)
+
+	assert.Nil(t, d.Cmd("SET", "foo", "bar").Err)
+	v, err := c.Cmd("GET", "opts:foo").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "bar", v)
+
+	// The parent and derived clients share the same pipeline, but each
+	// request keeps the options of the client it was made with
+	c.Append("ECHO", "foo")
+	d.Append("KEYS", "fo*")
+	v, _ = d.GetReply().Str()
+	assert.Equal(t, "foo", v)
+	keys, err := c.GetReply().List()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"foo"}, keys)
+
+	// Large value counts go to the parent
+	d.SetLargeValueThreshold(1, nil)
+	d.Cmd("GET", "foo")
+	assert.Equal(t, uint64(1), c.LargeReads)
+	assert.Equal(t, uint64(0), d.LargeReads)
+}
+
+func TestNoTimeout(t *T) {
+	c, err := DialTimeout("tcp", "127.0.0.1:6379", 50*time.Millisecond)
+	assert.Nil(t, err)
+	c.Cmd("DEL", "opts:list")
+
+	// The client's own timeout is hit before BLPOP's
+	r := c.Cmd("BLPOP", "opts:list", 0.2)
+	assert.NotNil(t, r.Err)
+
+	c, err = DialTimeout("tcp", "127.0.0.1:6379", 50*time.Millisecond)
+	assert.Nil(t, err)
+	d := c.WithOptions(Options{ReadTimeout: NoTimeout})
+	r = d.Cmd("BLPOP", "opts:list", 0.2)
+	assert.Nil(t, r.Err)
+	assert.Equal(t, NilReply, r.Type)
+
+	// The parent's timeout still applies to it afterwards
+	assert.Nil(t, c.Cmd("PING").Err)
+	assert.NotNil(t, c.Cmd("BLPOP", "opts:list", 0.2).Err)
+}
+
+func TestWithTimeout(t *T) {
+	c, err := DialTimeout("tcp", "127.0.0.1:6379", 50*time.Millisecond)
+	assert.Nil(t, err)
+	c.Cmd("DEL", "opts:list")
+
+	r := c.WithTimeout(time.Second).Cmd("BLPOP", "opts:list", 0.2)
+	assert.Nil(t, r.Err)
+	assert.Equal(t, NilReply, r.Type)
+	assert.Equal(t, 50*time.Millisecond, c.readTimeout)
+}
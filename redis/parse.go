@@ -0,0 +1,153 @@
+package redis
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+)
+
+// The fast path for parsing replies, used for status, integer and bulk replies,
+// which are the replies to almost every command. Rather than going through
+// resp.Message (which keeps a copy of the raw message as well as its value),
+// these are read straight off the connection's reader, and their Reply and
+// data are carved out of larger blocks which are allocated every so often, so
+// most replies don't need any allocations of their own. Errors and multi bulk
+// replies take the slow path.
+//
+// The cost is that a Reply which is kept around keeps the rest of its blocks
+// from being garbage collected, which is at most a few kilobytes.
+
+const (
+	// How many Replies are allocated at once
+	replyBlockSize = 32
+
+	// How many bytes of reply data are allocated at once. Data larger than a
+	// quarter of this is allocated on its own.
+	dataBlockSize = 4096
+)
+
+// scratch holds what's left of the blocks the fast path is carving replies out
+// of
+type scratch struct {
+	replies []Reply
+	data    []byte
+}
+
+func (s *scratch) reply() *Reply {
+	if len(s.replies) == 0 {
+		s.replies = make([]Reply, replyBlockSize)
+	}
+	r := &s.replies[0]
+	s.replies = s.replies[1:]
+	return r
+}
+
+// bytes returns a slice of length n. Its capacity is also n, so appending to
+// it can't overwrite the data of another reply.
+func (s *scratch) bytes(n int) []byte {
+	if n > dataBlockSize/4 {
+		return make([]byte, n)
+	}
+	if n > len(s.data) {
+		s.data = make([]byte, dataBlockSize)
+	}
+	b := s.data[:n:n]
+	s.data = s.data[n:]
+	return b
+}
+
+// parseFast parses the next reply if it's one the fast path handles, which the
+// first byte of it, already peeked, tells. ok is false if it isn't, in which
+// case nothing has been read.
+func (c *Client) parseFast(typ byte) (r *Reply, ok bool, err error) {
+	switch typ {
+	case '+', ':', '$':
+	default:
+		return nil, false, nil
+	}
+
+	line, err := readLine(c.reader)
+	if err != nil {
+		return nil, true, err
+	}
+	r = c.scratch.reply()
+	switch typ {
+	case '+':
+		r.Type = StatusReply
+		r.buf = c.scratch.bytes(len(line) - 1)
+		copy(r.buf, line[1:])
+		return r, true, nil
+	case ':':
+		r.Type = IntegerReply
+		if r.int, ok = parseInt(line[1:]); !ok {
+			return nil, true, strconv.ErrSyntax
+		}
+		return r, true, nil
+	}
+
+	n, ok := parseInt(line[1:])
+	if !ok {
+		return nil, true, strconv.ErrSyntax
+	}
+	if n < 0 {
+		r.Type = NilReply
+		return r, true, nil
+	}
+	r.Type = BulkReply
+	r.buf = c.scratch.bytes(int(n))
+	if _, err = io.ReadFull(c.reader, r.buf); err != nil {
+		return nil, true, err
+	}
+	if _, err = c.reader.Discard(2); err != nil {
+		return nil, true, err
+	}
+	return r, true, nil
+}
+
+// readLine returns the next line from r without its \r\n. The line is only
+// valid until r is next read from.
+func readLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		// Too long for the buffer, which is very unusual for the lines the
+		// fast path reads, so it's fine to allocate. The start of the line
+		// must be copied before reading on overwrites it.
+		start := append([]byte(nil), line...)
+		rest, err := r.ReadBytes('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = append(start, rest...)
+	} else if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, strconv.ErrSyntax
+	}
+	return line[:len(line)-2], nil
+}
+
+// parseInt parses a decimal integer without allocating, unlike strconv
+func parseInt(b []byte) (int64, bool) {
+	neg := len(b) > 0 && b[0] == '-'
+	if neg {
+		b = b[1:]
+	}
+	if len(b) == 0 || len(b) > 19 {
+		return 0, false
+	}
+	var n int64
+	for _, d := range b {
+		if d < '0' || d > '9' {
+			return 0, false
+		}
+		n = n*10 + int64(d-'0')
+		if n < 0 {
+			return 0, false
+		}
+	}
+	if neg {
+		n = -n
+	}
+	return n, true
+}
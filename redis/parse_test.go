@@ -0,0 +1,77 @@
+package redis
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	. "testing"
+)
+
+func parseClient(s string) *Client {
+	c := &Client{connState: &connState{}}
+	c.reader = bufio.NewReaderSize(strings.NewReader(s), 16)
+	return c
+}
+
+func TestParseFast(t *T) {
+	long := strings.Repeat("x", 100)
+	c := parseClient("+OK\r\n:-42\r\n$5\r\nhe\r\no\r\n$-1\r\n$0\r\n\r\n+" + long + "\r\n")
+
+	r := c.parse()
+	assert.Equal(t, StatusReply, r.Type)
+	s, _ := r.Str()
+	assert.Equal(t, "OK", s)
+
+	r = c.parse()
+	assert.Equal(t, IntegerReply, r.Type)
+	i, _ := r.Int64()
+	assert.Equal(t, int64(-42), i)
+
+	r = c.parse()
+	assert.Equal(t, BulkReply, r.Type)
+	b, _ := r.Bytes()
+	assert.Equal(t, []byte("he\r\no"), b)
+
+	// Appending to one reply's data mustn't touch another's
+	b = append(b, '!')
+	assert.Equal(t, NilReply, c.parse().Type)
+	r = c.parse()
+	assert.Equal(t, BulkReply, r.Type)
+	b, _ = r.Bytes()
+	assert.Equal(t, 0, len(b))
+
+	// A line longer than the reader's buffer
+	s, _ = c.parse().Str()
+	assert.Equal(t, long, s)
+}
+
+func TestParseInt(t *T) {
+	for _, s := range []string{"0", "7", "-1", "9223372036854775807", "-9223372036854775807"} {
+		n, ok := parseInt([]byte(s))
+		assert.True(t, ok, s)
+		assert.Equal(t, s, argString(n))
+	}
+	for _, s := range []string{"", "-", "1a", "9223372036854775808", "+1"} {
+		_, ok := parseInt([]byte(s))
+		assert.False(t, ok, s)
+	}
+}
+
+func TestParseFastAllocs(t *T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	var buf bytes.Buffer
+	for i := 0; i < 1100; i++ {
+		buf.WriteString("+OK\r\n:12345\r\n$5\r\nhello\r\n")
+	}
+	c := &Client{connState: &connState{}}
+	c.reader = bufio.NewReader(&buf)
+	allocs := AllocsPerRun(1000, func() {
+		c.parse()
+		c.parse()
+		c.parse()
+	})
+	assert.True(t, allocs < 1, allocs)
+}
@@ -0,0 +1,113 @@
+package redis
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/fzzy/radix/redis/resp"
+)
+
+// SetKeyPrefix has the client put the given prefix in front of every key
+// argument of every command it sends, so that multiple applications or tenants
+// can safely share the same database. An empty prefix turns this off.
+//
+// The patterns given to KEYS and SCAN are prefixed as well (SCAN is given a
+// MATCH argument if it doesn't have one), and the prefix is removed from the
+// keys they return, and from the key returned by the popping commands which
+// say which key they popped from (e.g. BLPOP and LMPOP). Keys which are hidden
+// inside other arguments, like the BY and GET patterns of SORT or keys built
+// up inside of lua scripts, are not prefixed, and neither are the arguments of
+// commands the client doesn't know about.
+func (c *Client) SetKeyPrefix(prefix string) {
+	c.keyPrefix = prefix
+}
+
+func (c *Client) prefixKey(key interface{}) interface{} {
+	switch k := key.(type) {
+	case string:
+		return c.keyPrefix + k
+	case []byte:
+		b := make([]byte, 0, len(c.keyPrefix)+len(k))
+		b = append(b, c.keyPrefix...)
+		return append(b, k...)
+	default:
+		return c.keyPrefix + fmt.Sprint(k)
+	}
+}
+
+// globEscape escapes all characters in s which have a special meaning in a
+// KEYS or SCAN pattern
+func globEscape(s string) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '*', '?', '[', ']', '\\':
+			buf.WriteByte('\\')
+		}
+		buf.WriteByte(s[i])
+	}
+	return buf.String()
+}
+
+func (c *Client) prefixArgs(cmd string, args []interface{}) []interface{} {
+	flat := resp.Flatten(args)
+	switch {
+	case strings.EqualFold(cmd, "KEYS"):
+		if len(flat) > 0 {
+			flat[0] = globEscape(c.keyPrefix) + fmt.Sprint(flat[0])
+		}
+		return flat
+	case strings.EqualFold(cmd, "SCAN"):
+		for i := 1; i < len(flat)-1; i++ {
+			if s, ok := flat[i].(string); ok && strings.EqualFold(s, "MATCH") {
+				flat[i+1] = globEscape(c.keyPrefix) + fmt.Sprint(flat[i+1])
+				return flat
+			}
+		}
+		return append(flat, "MATCH", globEscape(c.keyPrefix)+"*")
+	}
+
+	for _, i := range keyIndexes(cmd, flat) {
+		flat[i] = c.prefixKey(flat[i])
+	}
+	return flat
+}
+
+// unprefixReply removes the key prefix from any keys in the reply to the given
+// command, for those commands which return keys. queued is only used when cmd
+// is EXEC.
+func (c *Client) unprefixReply(cmd string, queued []string, r *Reply) {
+	switch strings.ToUpper(cmd) {
+	case "KEYS":
+		c.unprefixElems(r)
+	case "SCAN":
+		if len(r.Elems) == 2 {
+			c.unprefixElems(r.Elems[1])
+		}
+	case "BLPOP", "BRPOP", "BZPOPMIN", "BZPOPMAX",
+		"LMPOP", "BLMPOP", "ZMPOP", "BZMPOP":
+		// The key which was popped from comes first
+		if len(r.Elems) > 0 {
+			c.unprefix(r.Elems[0])
+		}
+	case "EXEC":
+		for i, e := range r.Elems {
+			if i < len(queued) {
+				c.unprefixReply(queued[i], nil, e)
+			}
+		}
+	}
+}
+
+func (c *Client) unprefixElems(r *Reply) {
+	for _, e := range r.Elems {
+		c.unprefix(e)
+	}
+}
+
+func (c *Client) unprefix(r *Reply) {
+	if r.Type == BulkReply {
+		r.buf = bytes.TrimPrefix(r.buf, []byte(c.keyPrefix))
+	}
+}
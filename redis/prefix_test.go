@@ -0,0 +1,67 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sort"
+	. "testing"
+	"time"
+)
+
+func TestKeyPrefix(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "prefix:a", "prefix:b", "prefix:c")
+	c.SetKeyPrefix("prefix:")
+
+	assert.Nil(t, c.Cmd("SET", "a", "foo").Err)
+	assert.Nil(t, c.Cmd("MSET", map[string]string{"b": "bar", "c": "baz"}).Err)
+	l, err := c.Cmd("MGET", "a", []string{"b", "c"}).List()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"foo", "bar", "baz"}, l)
+
+	keys, err := c.Cmd("KEYS", "*").List()
+	assert.Nil(t, err)
+	sort.Strings(keys)
+	assert.Equal(t, []string{"a", "b", "c"}, keys)
+
+	r := c.Cmd("SCAN", 0, "COUNT", 1000)
+	assert.Nil(t, r.Err)
+	keys, err = r.Elems[1].List()
+	assert.Nil(t, err)
+	sort.Strings(keys)
+	assert.Equal(t, []string{"a", "b", "c"}, keys)
+
+	c.SetKeyPrefix("")
+	v, err := c.Cmd("GET", "prefix:a").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", v)
+}
+
+func TestKeyPrefixPops(t *T) {
+	c := dial(t)
+	c.SetKeyPrefix("prefixpops:")
+	c.Cmd("DEL", "list", "zset")
+	defer c.Cmd("DEL", "list", "zset")
+
+	c.Cmd("RPUSH", "list", "1", "2", "3", "4")
+	key, _, err := c.BLPop(time.Second, "list")
+	assert.Nil(t, err)
+	assert.Equal(t, "list", key)
+	key, _, err = c.BRPop(time.Second, "list")
+	assert.Nil(t, err)
+	assert.Equal(t, "list", key)
+
+	c.Cmd("ZADD", "zset", 1, "a", 2, "b")
+	key, _, err = c.BZPopMin(time.Second, "zset")
+	assert.Nil(t, err)
+	assert.Equal(t, "zset", key)
+	key, _, err = c.BZPopMax(time.Second, "zset")
+	assert.Nil(t, err)
+	assert.Equal(t, "zset", key)
+
+	key, _, err = c.LMPop(ListLeft, 1, "list")
+	if isUnknownCommand(err) {
+		t.Skip("LMPOP is not supported")
+	}
+	assert.Nil(t, err)
+	assert.Equal(t, "list", key)
+}
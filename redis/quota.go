@@ -0,0 +1,120 @@
+package redis
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Quota is a soft limit on the keys sharing a prefix, see CheckQuotas. A limit
+// of zero means no limit.
+type Quota struct {
+	Keys  int64
+	Bytes int64
+}
+
+// QuotaViolation is passed to the callback given to CheckQuotas for a prefix
+// which is over its quota
+type QuotaViolation struct {
+	Prefix string
+	Quota  Quota
+
+	// The estimated usage of the prefix
+	Usage PrefixUsage
+}
+
+// QuotaOptions are the options for CheckQuotas and WatchQuotas
+type QuotaOptions struct {
+	// The quota for each prefix. Prefixes are split out of keys the same way
+	// as for MemoryReport, using Delim and Depth.
+	Quotas map[string]Quota
+	Delim  string
+	Depth  int
+
+	// How many keys to sample on each check. Defaults to 1000.
+	SampleSize int
+
+	// How often WatchQuotas checks the quotas. Defaults to a minute.
+	Interval time.Duration
+}
+
+// CheckQuotas estimates the usage of every prefix in the current database, and
+// calls fn for each one in opts.Quotas which is over its quota. The estimates
+// are made by measuring the MEMORY USAGE of a random sample of keys (see
+// SampleKeys) and scaling it up to the size of the database, so the check is
+// cheap even for large databases but is only approximate; prefixes with few
+// keys may not be seen at all. If the database has no more keys than the
+// sample size the usage is exact. The estimated usages of all the prefixes
+// seen are returned, sorted by Bytes, largest first.
+//
+// Nothing is enforced by redis, it's up to fn to decide what to do about a
+// prefix which is over its quota (e.g. alerting, or refusing writes).
+func (c *Client) CheckQuotas(opts QuotaOptions, fn func(QuotaViolation)) (
+	[]PrefixUsage, error,
+) {
+	n := opts.SampleSize
+	if n <= 0 {
+		n = 1000
+	}
+	size, err := c.Cmd("DBSIZE").Int64()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := c.SampleKeys(n)
+	if err != nil {
+		return nil, err
+	}
+	usages := map[string]*PrefixUsage{}
+	if err = c.addUsage(usages, keys, opts.Delim, opts.Depth); err != nil {
+		return nil, err
+	}
+
+	scale := 1.0
+	if len(keys) > 0 && size > int64(len(keys)) {
+		scale = float64(size) / float64(len(keys))
+	}
+	ret := make([]PrefixUsage, 0, len(usages))
+	for _, u := range usages {
+		u.Keys = int64(float64(u.Keys)*scale + 0.5)
+		u.Bytes = int64(float64(u.Bytes)*scale + 0.5)
+		for typ := range u.Types {
+			u.Types[typ] = int64(float64(u.Types[typ])*scale + 0.5)
+		}
+		ret = append(ret, *u)
+	}
+	sort.Sort(usagesByBytes(ret))
+
+	for _, u := range ret {
+		q, ok := opts.Quotas[u.Prefix]
+		if !ok {
+			continue
+		}
+		if (q.Keys > 0 && u.Keys > q.Keys) || (q.Bytes > 0 && u.Bytes > q.Bytes) {
+			fn(QuotaViolation{Prefix: u.Prefix, Quota: q, Usage: u})
+		}
+	}
+	return ret, nil
+}
+
+// WatchQuotas calls CheckQuotas every opts.Interval until ctx is done, so fn is
+// called for each prefix which is over its quota on every check it's over it
+// for. It blocks, returning ctx's error once it's done, or the first error
+// from CheckQuotas.
+func (c *Client) WatchQuotas(
+	ctx context.Context, opts QuotaOptions, fn func(QuotaViolation),
+) error {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	for {
+		if _, err := c.CheckQuotas(opts, fn); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
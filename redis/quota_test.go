@@ -0,0 +1,82 @@
+package redis
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"strings"
+	. "testing"
+	"time"
+)
+
+func quotaClient(t *T) *Client {
+	c := dial(t)
+	assert.Nil(t, c.Cmd("SELECT", 7).Err)
+	c.Cmd("FLUSHDB")
+	for i := 0; i < 20; i++ {
+		c.Cmd("SET", "tenant:a:"+strconv.Itoa(i), strings.Repeat("x", 100))
+	}
+	for i := 0; i < 5; i++ {
+		c.Cmd("SET", "tenant:b:"+strconv.Itoa(i), "x")
+	}
+	return c
+}
+
+func TestCheckQuotas(t *T) {
+	c := quotaClient(t)
+	opts := QuotaOptions{
+		Quotas: map[string]Quota{
+			"tenant:a": {Keys: 10},
+			"tenant:b": {Keys: 10, Bytes: 1 << 20},
+		},
+		Delim: ":",
+		Depth: 2,
+	}
+
+	var violations []QuotaViolation
+	usages, err := c.CheckQuotas(opts, func(v QuotaViolation) {
+		violations = append(violations, v)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(usages))
+	assert.Equal(t, "tenant:a", usages[0].Prefix)
+	assert.Equal(t, int64(20), usages[0].Keys)
+	assert.Equal(t, int64(5), usages[1].Keys)
+
+	assert.Equal(t, 1, len(violations))
+	assert.Equal(t, "tenant:a", violations[0].Prefix)
+	assert.Equal(t, int64(10), violations[0].Quota.Keys)
+	assert.Equal(t, int64(20), violations[0].Usage.Keys)
+
+	// With a sample the usage is scaled up to the whole database
+	opts.SampleSize = 5
+	usages, err = c.CheckQuotas(opts, func(QuotaViolation) {})
+	assert.Nil(t, err)
+	var keys int64
+	for _, u := range usages {
+		keys += u.Keys
+	}
+	assert.Equal(t, int64(25), keys)
+	c.Cmd("FLUSHDB")
+}
+
+func TestWatchQuotas(t *T) {
+	c := quotaClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	opts := QuotaOptions{
+		Quotas:   map[string]Quota{"tenant:a": {Bytes: 100}},
+		Delim:    ":",
+		Depth:    2,
+		Interval: time.Millisecond,
+	}
+	checks := 0
+	err := c.WatchQuotas(ctx, opts, func(v QuotaViolation) {
+		assert.Equal(t, "tenant:a", v.Prefix)
+		if checks++; checks == 3 {
+			cancel()
+		}
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 3, checks)
+	c.Cmd("FLUSHDB")
+}
@@ -0,0 +1,83 @@
+package redis
+
+// BitfieldGet is a single GET operation of BITFIELD_RO: the integer of the
+// given type (e.g. "u8" or "i16") at the given bit offset
+type BitfieldGet struct {
+	Type   string
+	Offset int64
+}
+
+// BitfieldRO calls BITFIELD_RO with the given GET operations, returning the
+// resulting integers in the same order. Unlike BITFIELD it never writes, so it
+// can be sent to a replica.
+func (c *Client) BitfieldRO(key string, gets ...BitfieldGet) ([]int64, error) {
+	args := make([]interface{}, 0, 1+len(gets)*3)
+	args = append(args, key)
+	for _, g := range gets {
+		args = append(args, "GET", g.Type, g.Offset)
+	}
+	r := c.Cmd("BITFIELD_RO", args...)
+	if r.Type == ErrorReply {
+		return nil, r.Err
+	}
+	is := make([]int64, len(r.Elems))
+	for i, e := range r.Elems {
+		var err error
+		if is[i], err = e.Int64(); err != nil {
+			return nil, err
+		}
+	}
+	return is, nil
+}
+
+// evalArgs builds the arguments to EVAL and its variants
+func evalArgs(script string, keys []string, args []interface{}) []interface{} {
+	a := make([]interface{}, 0, 2+len(keys)+len(args))
+	a = append(a, script, len(keys))
+	for _, k := range keys {
+		a = append(a, k)
+	}
+	return append(a, args...)
+}
+
+// EvalRO calls the given script with EVAL_RO, which redis refuses to run if
+// the script tries to write. This means it can be sent to a replica.
+func (c *Client) EvalRO(script string, keys []string, args ...interface{}) *Reply {
+	return c.Cmd("EVAL_RO", evalArgs(script, keys, args)...)
+}
+
+// EvalShaRO is like EvalRO, but calls an already loaded script by its SHA1
+// digest using EVALSHA_RO
+func (c *Client) EvalShaRO(sha string, keys []string, args ...interface{}) *Reply {
+	return c.Cmd("EVALSHA_RO", evalArgs(sha, keys, args)...)
+}
+
+// SortRO calls SORT_RO on the given key, with any extra arguments (e.g. "BY",
+// "weight_*", "LIMIT", 0, 10) passed through. It can't STORE the result, but
+// can be sent to a replica.
+func (c *Client) SortRO(key string, args ...interface{}) ([]string, error) {
+	return c.Cmd("SORT_RO", key, args).List()
+}
+
+// GeoRadiusRO calls GEORADIUS_RO, returning the members within radius (in the
+// given unit, e.g. "m" or "km") of the given longitude and latitude. Extra
+// arguments such as "COUNT", 10 or "ASC" are passed through; options which
+// change the shape of the reply, like WITHCOORD, should be used with Cmd
+// instead.
+func (c *Client) GeoRadiusRO(
+	key string, lon, lat, radius float64, unit string, args ...interface{},
+) (
+	[]string, error,
+) {
+	return c.Cmd("GEORADIUS_RO", key, lon, lat, radius, unit, args).List()
+}
+
+// GeoRadiusByMemberRO is like GeoRadiusRO, but centered on an existing member
+// using GEORADIUSBYMEMBER_RO
+func (c *Client) GeoRadiusByMemberRO(
+	key, member string, radius float64, unit string, args ...interface{},
+) (
+	[]string, error,
+) {
+	return c.Cmd("GEORADIUSBYMEMBER_RO", key, member, radius, unit, args).List()
+}
@@ -0,0 +1,57 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func TestReadOnlyVariants(t *T) {
+	c := dial(t)
+	for _, cmd := range []string{
+		"BITFIELD_RO", "EVAL_RO", "EVALSHA_RO", "SORT_RO", "GEORADIUS_RO",
+	} {
+		assert.True(t, ReadOnlyCommand(cmd))
+	}
+
+	c.Cmd("DEL", "ro:geo")
+	c.Cmd("GEOADD", "ro:geo", 13.361389, 38.115556, "Palermo")
+	c.Cmd("GEOADD", "ro:geo", 15.087269, 37.502669, "Catania")
+	l, err := c.GeoRadiusRO("ro:geo", 15, 37, 200, "km", "ASC")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Catania", "Palermo"}, l)
+	l, err = c.GeoRadiusByMemberRO("ro:geo", "Palermo", 100, "km")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Palermo"}, l)
+
+	s, err := c.EvalRO("return KEYS[1]..ARGV[1]", []string{"foo"}, "bar").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "foobar", s)
+}
+
+func TestSortRO(t *T) {
+	c := dial(t)
+	// SORT_RO may not be supported by the test server
+	if isUnknownCommand(c.Cmd("SORT_RO", "ro:sort").Err) {
+		t.Skip("SORT_RO not supported")
+	}
+	c.Cmd("DEL", "ro:sort")
+	c.Cmd("RPUSH", "ro:sort", 3, 1, 2)
+	l, err := c.SortRO("ro:sort")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "2", "3"}, l)
+	l, err = c.SortRO("ro:sort", "DESC", "LIMIT", 0, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"3", "2"}, l)
+}
+
+func TestBitfieldRO(t *T) {
+	c := dial(t)
+	// BITFIELD_RO may not be supported by the test server
+	if isUnknownCommand(c.Cmd("BITFIELD_RO", "ro:bits", "GET", "u8", 0).Err) {
+		t.Skip("BITFIELD_RO not supported")
+	}
+	c.Cmd("SET", "ro:bits", "\x01\x02")
+	is, err := c.BitfieldRO("ro:bits", BitfieldGet{"u8", 0}, BitfieldGet{"u8", 8})
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{1, 2}, is)
+}
@@ -2,6 +2,9 @@ package redis
 
 import (
 	"errors"
+	"fmt"
+	"math"
+	"reflect"
 	"strconv"
 )
 
@@ -27,6 +30,17 @@ func (cerr *CmdError) Error() string {
 	return cerr.Err.Error()
 }
 
+// NilReplyError is returned by the methods of Reply which convert it to a value
+// (Str, Int, List, etc...) when it's a NilReply, e.g. from a GET of a key which
+// doesn't exist. The OrDefault methods (e.g. StrOrDefault) return a default
+// value instead.
+//
+//	s, err := conn.Cmd("GET", "foo").Str()
+//	if err == redis.NilReplyError {
+//		// foo doesn't exist
+//	}
+var NilReplyError = errors.New("reply is nil")
+
 //* Reply
 
 /*
@@ -59,6 +73,9 @@ type Reply struct {
 	Err   error     // Reply error
 	buf   []byte
 	int   int64
+
+	// The metadata of the context the command was run with, see CmdContext
+	Metadata Metadata
 }
 
 // Bytes returns the reply value as a byte string or
@@ -67,6 +84,9 @@ func (r *Reply) Bytes() ([]byte, error) {
 	if r.Type == ErrorReply {
 		return nil, r.Err
 	}
+	if r.Type == NilReply {
+		return nil, NilReplyError
+	}
 	if !(r.Type == StatusReply || r.Type == BulkReply) {
 		return nil, errors.New("string value is not available for this reply type")
 	}
@@ -91,6 +111,9 @@ func (r *Reply) Int64() (int64, error) {
 	if r.Type == ErrorReply {
 		return 0, r.Err
 	}
+	if r.Type == NilReply {
+		return 0, NilReplyError
+	}
 	if r.Type != IntegerReply {
 		s, err := r.Str()
 		if err == nil {
@@ -124,6 +147,9 @@ func (r *Reply) Bool() (bool, error) {
 	if r.Type == ErrorReply {
 		return false, r.Err
 	}
+	if r.Type == NilReply {
+		return false, NilReplyError
+	}
 	i, err := r.Int()
 	if err == nil {
 		if i == 0 {
@@ -145,6 +171,53 @@ func (r *Reply) Bool() (bool, error) {
 	return false, errors.New("boolean value is not available for this reply type")
 }
 
+// BytesOrDefault is like Bytes, but returns def instead of NilReplyError if the
+// reply is nil
+func (r *Reply) BytesOrDefault(def []byte) ([]byte, error) {
+	if r.Type == NilReply {
+		return def, nil
+	}
+	return r.Bytes()
+}
+
+// StrOrDefault is like Str, but returns def instead of NilReplyError if the
+// reply is nil
+//
+//	name, err := conn.Cmd("HGET", "user:1", "name").StrOrDefault("anonymous")
+func (r *Reply) StrOrDefault(def string) (string, error) {
+	if r.Type == NilReply {
+		return def, nil
+	}
+	return r.Str()
+}
+
+// Int64OrDefault is like Int64, but returns def instead of NilReplyError if the
+// reply is nil
+func (r *Reply) Int64OrDefault(def int64) (int64, error) {
+	if r.Type == NilReply {
+		return def, nil
+	}
+	return r.Int64()
+}
+
+// IntOrDefault is like Int, but returns def instead of NilReplyError if the
+// reply is nil
+func (r *Reply) IntOrDefault(def int) (int, error) {
+	if r.Type == NilReply {
+		return def, nil
+	}
+	return r.Int()
+}
+
+// BoolOrDefault is like Bool, but returns def instead of NilReplyError if the
+// reply is nil
+func (r *Reply) BoolOrDefault(def bool) (bool, error) {
+	if r.Type == NilReply {
+		return def, nil
+	}
+	return r.Bool()
+}
+
 // List returns a multi bulk reply as a slice of strings or an error.
 // The reply type must be MultiReply and its elements' types must all be either BulkReply or NilReply.
 // Nil elements are returned as empty strings.
@@ -155,6 +228,9 @@ func (r *Reply) List() ([]string, error) {
 	if r.Type == ErrorReply {
 		return nil, r.Err
 	}
+	if r.Type == NilReply {
+		return nil, NilReplyError
+	}
 	if r.Type != MultiReply {
 		return nil, errors.New("reply type is not MultiReply")
 	}
@@ -181,6 +257,9 @@ func (r *Reply) ListBytes() ([][]byte, error) {
 	if r.Type == ErrorReply {
 		return nil, r.Err
 	}
+	if r.Type == NilReply {
+		return nil, NilReplyError
+	}
 	if r.Type != MultiReply {
 		return nil, errors.New("reply type is not MultiReply")
 	}
@@ -210,6 +289,9 @@ func (r *Reply) Hash() (map[string]string, error) {
 	if r.Type == ErrorReply {
 		return nil, r.Err
 	}
+	if r.Type == NilReply {
+		return nil, NilReplyError
+	}
 	rmap := map[string]string{}
 
 	if r.Type != MultiReply {
@@ -241,6 +323,78 @@ func (r *Reply) Hash() (map[string]string, error) {
 	return rmap, nil
 }
 
+// NewReply returns a Reply holding the given value, as if it had been read from
+// the server. This is mostly useful for faking replies in tests. nil becomes a
+// NilReply, an error an ErrorReply, and bools and integers of any size
+// (including named types like a type MyInt int32) an IntegerReply, except for
+// unsigned values too large for an int64, which become a BulkReply of their
+// decimal form. Slices and arrays, other than of bytes, become a MultiReply of
+// their elements, and anything else a BulkReply of its string form. A *Reply is
+// returned as is.
+func NewReply(v interface{}) *Reply {
+	switch vt := v.(type) {
+	case nil:
+		return &Reply{Type: NilReply}
+	case *Reply:
+		return vt
+	case error:
+		return &Reply{Type: ErrorReply, Err: vt}
+	case []byte:
+		return &Reply{Type: BulkReply, buf: vt}
+	case string:
+		return &Reply{Type: BulkReply, buf: []byte(vt)}
+	case []string:
+		r := &Reply{Type: MultiReply, Elems: make([]*Reply, len(vt))}
+		for i := range vt {
+			r.Elems[i] = NewReply(vt[i])
+		}
+		return r
+	case []interface{}:
+		r := &Reply{Type: MultiReply, Elems: make([]*Reply, len(vt))}
+		for i := range vt {
+			r.Elems[i] = NewReply(vt[i])
+		}
+		return r
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		if rv.Bool() {
+			return &Reply{Type: IntegerReply, int: 1}
+		}
+		return &Reply{Type: IntegerReply, int: 0}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Reply{Type: IntegerReply, int: rv.Int()}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Uintptr:
+		u := rv.Uint()
+		if u <= math.MaxInt64 {
+			return &Reply{Type: IntegerReply, int: int64(u)}
+		}
+		return &Reply{Type: BulkReply, buf: []byte(strconv.FormatUint(u, 10))}
+	case reflect.String:
+		return &Reply{Type: BulkReply, buf: []byte(rv.String())}
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return &Reply{Type: BulkReply, buf: b}
+		}
+		r := &Reply{Type: MultiReply, Elems: make([]*Reply, rv.Len())}
+		for i := range r.Elems {
+			r.Elems[i] = NewReply(rv.Index(i).Interface())
+		}
+		return r
+	}
+	return &Reply{Type: BulkReply, buf: []byte(fmt.Sprint(v))}
+}
+
+// NewStatusReply returns a StatusReply holding the given status, e.g. "OK"
+func NewStatusReply(status string) *Reply {
+	return &Reply{Type: StatusReply, buf: []byte(status)}
+}
+
 // String returns a string representation of the reply and its sub-replies.
 // This method is for debugging.
 // Use method Reply.Str() for reading string reply.
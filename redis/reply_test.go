@@ -2,6 +2,7 @@ package redis
 
 import (
 	"github.com/stretchr/testify/assert"
+	"math"
 	. "testing"
 )
 
@@ -123,3 +124,100 @@ func TestHash(t *T) {
 	assert.Equal(t, "", h["b"])
 	assert.Equal(t, "2", h["c"])
 }
+
+func TestNewReply(t *T) {
+	assert.Equal(t, NilReply, NewReply(nil).Type)
+
+	s, err := NewReply("foo").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", s)
+
+	i, err := NewReply(5).Int()
+	assert.Nil(t, err)
+	assert.Equal(t, 5, i)
+
+	b, err := NewReply(true).Bool()
+	assert.Nil(t, err)
+	assert.True(t, b)
+
+	l, err := NewReply([]interface{}{"a", nil, []byte("b")}).List()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "", "b"}, l)
+
+	r := NewReply([]interface{}{1, []string{"a"}})
+	assert.Equal(t, IntegerReply, r.Elems[0].Type)
+	assert.Equal(t, MultiReply, r.Elems[1].Type)
+
+	// Every width of integer, including named ones
+	type myInt int16
+	for _, v := range []interface{}{
+		int8(7), int16(7), int32(7), int64(7), uint(7), uint8(7), uint16(7),
+		uint32(7), uint64(7), myInt(7),
+	} {
+		r = NewReply(v)
+		assert.Equal(t, IntegerReply, r.Type, "%T", v)
+		i, _ = r.Int()
+		assert.Equal(t, 7, i, "%T", v)
+	}
+	r = NewReply(uint64(math.MaxUint64))
+	assert.Equal(t, BulkReply, r.Type)
+	s, _ = r.Str()
+	assert.Equal(t, "18446744073709551615", s)
+
+	// As are slices of anything
+	r = NewReply([]int{1, 2})
+	assert.Equal(t, MultiReply, r.Type)
+	assert.Equal(t, 2, len(r.Elems))
+	assert.Equal(t, IntegerReply, r.Elems[1].Type)
+	r = NewReply([2]byte{'h', 'i'})
+	s, _ = r.Str()
+	assert.Equal(t, "hi", s)
+
+	r = NewReply(LoadingError)
+	assert.Equal(t, ErrorReply, r.Type)
+	assert.Equal(t, LoadingError, r.Err)
+
+	r = NewStatusReply("OK")
+	assert.Equal(t, StatusReply, r.Type)
+	s, _ = r.Str()
+	assert.Equal(t, "OK", s)
+}
+
+func TestNilReply(t *T) {
+	r := &Reply{Type: NilReply}
+	_, err := r.Str()
+	assert.Equal(t, NilReplyError, err)
+	_, err = r.Int()
+	assert.Equal(t, NilReplyError, err)
+	_, err = r.Bool()
+	assert.Equal(t, NilReplyError, err)
+	_, err = r.List()
+	assert.Equal(t, NilReplyError, err)
+	_, err = r.Hash()
+	assert.Equal(t, NilReplyError, err)
+
+	s, err := r.StrOrDefault("foo")
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", s)
+	b, err := r.BytesOrDefault([]byte("foo"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("foo"), b)
+	i, err := r.IntOrDefault(5)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, i)
+	i64, err := r.Int64OrDefault(6)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(6), i64)
+	ok, err := r.BoolOrDefault(true)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// Values and errors are returned as they would be normally
+	s, err = NewReply("bar").StrOrDefault("foo")
+	assert.Nil(t, err)
+	assert.Equal(t, "bar", s)
+	_, err = NewReply(LoadingError).IntOrDefault(5)
+	assert.Equal(t, LoadingError, err)
+	_, err = NewReply([]string{"a"}).IntOrDefault(5)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,141 @@
+package redis
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// PrefixUsage is the memory used by all the keys sharing a prefix, as returned
+// by MemoryReport
+type PrefixUsage struct {
+	Prefix string
+	Keys   int64
+
+	// The total of MEMORY USAGE for the keys
+	Bytes int64
+
+	// The number of keys of each type (e.g. "string", "hash")
+	Types map[string]int64
+}
+
+// How many keys are requested per SCAN call by MemoryReport
+const reportScanCount = 1000
+
+// MemoryReport SCANs all the keys matching pattern ("*" for everything), and
+// sums their MEMORY USAGE by prefix. A key's prefix is its first depth parts
+// when split on delim, so with a delim of ":" and depth of 1 the keys
+// "user:1:name" and "user:2:email" are both counted under "user". Keys with no
+// more than depth parts are counted under the whole key. The returned usages
+// are sorted by Bytes, largest first.
+//
+// Keys which are deleted while the report is being made are skipped. Since
+// SCAN is used the report doesn't block the server, but on a large dataset it
+// may take a while.
+func (c *Client) MemoryReport(pattern, delim string, depth int) (
+	[]PrefixUsage, error,
+) {
+	usages := map[string]*PrefixUsage{}
+	cursor := "0"
+	for {
+		r := c.Cmd("SCAN", cursor, "MATCH", pattern, "COUNT", reportScanCount)
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		if len(r.Elems) != 2 {
+			return nil, errors.New("malformed SCAN reply")
+		}
+		var err error
+		if cursor, err = r.Elems[0].Str(); err != nil {
+			return nil, err
+		}
+		keys, err := r.Elems[1].List()
+		if err != nil {
+			return nil, err
+		}
+		if err = c.addUsage(usages, keys, delim, depth); err != nil {
+			return nil, err
+		}
+		if cursor == "0" {
+			break
+		}
+	}
+
+	ret := make([]PrefixUsage, 0, len(usages))
+	for _, u := range usages {
+		ret = append(ret, *u)
+	}
+	sort.Sort(usagesByBytes(ret))
+	return ret, nil
+}
+
+// addUsage gets the MEMORY USAGE and TYPE of the given keys in a single
+// pipeline, and adds them to the usages of their prefixes
+func (c *Client) addUsage(
+	usages map[string]*PrefixUsage, keys []string, delim string, depth int,
+) error {
+	b := make(Batch, 0, len(keys)*2)
+	for _, key := range keys {
+		b.Add("MEMORY", "USAGE", key)
+		b.Add("TYPE", key)
+	}
+	replies := b.Pipeline(c)
+	var firstErr error
+	for i, key := range keys {
+		sr, tr := replies[2*i], replies[2*i+1]
+		if firstErr != nil {
+			continue
+		}
+		if sr.Err != nil {
+			firstErr = sr.Err
+			continue
+		} else if tr.Err != nil {
+			firstErr = tr.Err
+			continue
+		}
+
+		// The key was deleted since the SCAN returned it
+		typ, _ := tr.Str()
+		if sr.Type == NilReply || typ == "none" {
+			continue
+		}
+		size, err := sr.Int64()
+		if err != nil {
+			firstErr = err
+			continue
+		}
+
+		prefix := keyPrefixPart(key, delim, depth)
+		u, ok := usages[prefix]
+		if !ok {
+			u = &PrefixUsage{Prefix: prefix, Types: map[string]int64{}}
+			usages[prefix] = u
+		}
+		u.Keys++
+		u.Bytes += size
+		u.Types[typ]++
+	}
+	return firstErr
+}
+
+func keyPrefixPart(key, delim string, depth int) string {
+	if delim == "" {
+		return key
+	}
+	parts := strings.SplitN(key, delim, depth+1)
+	if len(parts) <= depth {
+		return key
+	}
+	return strings.Join(parts[:depth], delim)
+}
+
+type usagesByBytes []PrefixUsage
+
+func (u usagesByBytes) Len() int      { return len(u) }
+func (u usagesByBytes) Swap(i, j int) { u[i], u[j] = u[j], u[i] }
+func (u usagesByBytes) Less(i, j int) bool {
+	if u[i].Bytes != u[j].Bytes {
+		return u[i].Bytes > u[j].Bytes
+	}
+	return u[i].Prefix < u[j].Prefix
+}
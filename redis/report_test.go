@@ -0,0 +1,44 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func TestKeyPrefixPart(t *T) {
+	assert.Equal(t, "user", keyPrefixPart("user:1:name", ":", 1))
+	assert.Equal(t, "user:1", keyPrefixPart("user:1:name", ":", 2))
+	assert.Equal(t, "user:1:name", keyPrefixPart("user:1:name", ":", 3))
+	assert.Equal(t, "counter", keyPrefixPart("counter", ":", 1))
+	assert.Equal(t, "a.b", keyPrefixPart("a.b", "", 1))
+}
+
+func TestMemoryReport(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "report:user:1", "report:user:2", "report:item:1")
+	c.Cmd("SET", "report:user:1", "foo")
+	c.Cmd("HSET", "report:user:2", "name", "bar")
+	c.Cmd("SET", "report:item:1", "a much longer value than the others")
+
+	usages, err := c.MemoryReport("report:*", ":", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(usages))
+	assert.True(t, usages[0].Bytes >= usages[1].Bytes)
+
+	byPrefix := map[string]PrefixUsage{}
+	for _, u := range usages {
+		assert.True(t, u.Bytes > 0)
+		byPrefix[u.Prefix] = u
+	}
+	assert.Equal(t, int64(2), byPrefix["report:user"].Keys)
+	assert.Equal(t, int64(1), byPrefix["report:user"].Types["string"])
+	assert.Equal(t, int64(1), byPrefix["report:user"].Types["hash"])
+	assert.Equal(t, int64(1), byPrefix["report:item"].Keys)
+
+	// Commands the caller has pipelined keep their replies
+	c.Append("ECHO", "pending")
+	_, err = c.MemoryReport("report:*", ":", 2)
+	assert.Nil(t, err)
+	s, _ := c.GetReply().Str()
+	assert.Equal(t, "pending", s)
+}
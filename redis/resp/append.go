@@ -0,0 +1,91 @@
+package resp
+
+import (
+	"strconv"
+)
+
+// The Append functions encode into a buffer given by the caller, rather than
+// allocating one for every value like the Write functions do, so a buffer can
+// be reused across many commands.
+
+// AppendArrayHeader appends the header of an Array of n elements to b, and
+// returns the extended buffer. The n elements must be appended after it.
+func AppendArrayHeader(b []byte, n int) []byte {
+	b = append(b, arrayPrefix)
+	b = strconv.AppendInt(b, int64(n), 10)
+	return append(b, delim...)
+}
+
+// AppendBulkStrHeader appends the header of a BulkStr of n bytes to b, and
+// returns the extended buffer. The n bytes, followed by "\r\n", must be written
+// after it.
+func AppendBulkStrHeader(b []byte, n int64) []byte {
+	b = append(b, bulkStrPrefix)
+	b = strconv.AppendInt(b, n, 10)
+	return append(b, delim...)
+}
+
+// AppendArbitraryAsString appends the encoded form of m to b the same way
+// WriteArbitraryAsString would write it, and returns the extended buffer.
+// Strings, byte slices, bools, numbers and nil are encoded without allocating.
+func AppendArbitraryAsString(b []byte, m interface{}) []byte {
+	switch mt := m.(type) {
+	case []byte:
+		return appendBulkStr(b, mt)
+	case string:
+		b = AppendBulkStrHeader(b, int64(len(mt)))
+		b = append(b, mt...)
+		return append(b, delim...)
+	case bool:
+		if mt {
+			return append(b, "$1\r\n1\r\n"...)
+		}
+		return append(b, "$1\r\n0\r\n"...)
+	case nil:
+		return append(b, "$0\r\n\r\n"...)
+	case int:
+		return appendIntStr(b, int64(mt))
+	case int8:
+		return appendIntStr(b, int64(mt))
+	case int16:
+		return appendIntStr(b, int64(mt))
+	case int32:
+		return appendIntStr(b, int64(mt))
+	case int64:
+		return appendIntStr(b, mt)
+	case uint:
+		return appendIntStr(b, int64(mt))
+	case uint8:
+		return appendIntStr(b, int64(mt))
+	case uint16:
+		return appendIntStr(b, int64(mt))
+	case uint32:
+		return appendIntStr(b, int64(mt))
+	case uint64:
+		return appendIntStr(b, int64(mt))
+	case float32:
+		return appendFloatStr(b, float64(mt), 32)
+	case float64:
+		return appendFloatStr(b, mt, 64)
+	}
+	return append(b, format(m, true)...)
+}
+
+func appendBulkStr(b, s []byte) []byte {
+	b = AppendBulkStrHeader(b, int64(len(s)))
+	b = append(b, s...)
+	return append(b, delim...)
+}
+
+// Room for any int64 formatted in decimal, and most float64s
+const numLen = 32
+
+func appendIntStr(b []byte, i int64) []byte {
+	var num [numLen]byte
+	return appendBulkStr(b, strconv.AppendInt(num[:0], i, 10))
+}
+
+func appendFloatStr(b []byte, f float64, bits int) []byte {
+	var num [numLen]byte
+	return appendBulkStr(b, strconv.AppendFloat(num[:0], f, 'f', -1, bits))
+}
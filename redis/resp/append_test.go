@@ -0,0 +1,38 @@
+package resp
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func TestAppend(t *T) {
+	vals := []interface{}{
+		"ohey", "", []byte("ohey"), []byte{}, true, false, nil,
+		1, int8(-2), int16(3), int32(-4), int64(5), uint(6), uint8(7),
+		uint16(8), uint32(9), uint64(10), float32(1.5), float64(-2.25),
+		errors.New("ohey"), NewSimpleString("ohey"), struct{}{},
+	}
+	for _, v := range vals {
+		assert.Equal(t, format(v, true), AppendArbitraryAsString(nil, v), "%#v", v)
+	}
+
+	b := AppendArrayHeader([]byte("x"), 2)
+	b = AppendBulkStrHeader(b, 3)
+	assert.Equal(t, "x*2\r\n$3\r\n", string(b))
+}
+
+func TestAppendAllocs(t *T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	b := make([]byte, 0, 1024)
+	vals := []interface{}{"ohey", []byte("ohey"), true, nil, 123, int64(-5), 1.5}
+	allocs := AllocsPerRun(100, func() {
+		b = AppendArrayHeader(b[:0], len(vals))
+		for _, v := range vals {
+			b = AppendArbitraryAsString(b, v)
+		}
+	})
+	assert.Equal(t, float64(0), allocs)
+}
@@ -0,0 +1,253 @@
+package resp
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+// UnmarshalTypeError is returned when a Message can't be unmarshalled into the
+// value given, e.g. an Array into an int, or a BulkStr which isn't a number
+// into an int
+var UnmarshalTypeError = errors.New("message can not be unmarshalled into the given type")
+
+// InvalidUnmarshalError is returned when the value to unmarshal into isn't a
+// non-nil pointer
+var InvalidUnmarshalError = errors.New("can only unmarshal into a non-nil pointer")
+
+// Marshal returns the encoded form of any primitive golang value, or Message,
+// inferring types the same way WriteArbitrary does. To encode a command, as
+// redis expects it, see Encoder.EncodeCommand.
+func Marshal(v interface{}) []byte {
+	return format(v, false)
+}
+
+// Unmarshal parses a single encoded message and stores its value in the value
+// pointed to by v, see Message.Unmarshal
+func Unmarshal(b []byte, v interface{}) error {
+	m, err := NewMessage(b)
+	if err != nil {
+		return err
+	}
+	return m.Unmarshal(v)
+}
+
+// Value returns the Message's value as a plain golang value: a string for a
+// SimpleStr, a []byte for a BulkStr, an int64 for an Int, an error for an Err,
+// nil for a Nil, and a []interface{} of the elements' values for an Array
+func (m *Message) Value() interface{} {
+	switch m.Type {
+	case SimpleStr:
+		return string(m.val.([]byte))
+	case Err:
+		return errors.New(string(m.val.([]byte)))
+	case Array:
+		arr := m.val.([]*Message)
+		vals := make([]interface{}, len(arr))
+		for i := range arr {
+			vals[i] = arr[i].Value()
+		}
+		return vals
+	}
+	return m.val
+}
+
+// Unmarshal stores the Message's value in the value pointed to by v, which may
+// be a string, []byte, bool or any kind of number, a slice of any of those for
+// an Array (or a map, for an Array of alternating keys and values, like the
+// reply to HGETALL), a pointer to any of those, or an interface{} (which gets
+// the value returned by Value). A *Message pointer is set to m itself. A
+// SimpleStr or BulkStr can be unmarshalled into a number if it is one, and an
+// Int into a string. A Nil sets v to its zero value. An Err (even one inside an
+// Array) isn't unmarshalled, except into an interface{}; its error is returned
+// instead, so the caller can tell a server error apart from the other errors
+// Unmarshal returns, which are UnmarshalTypeError and InvalidUnmarshalError.
+func (m *Message) Unmarshal(v interface{}) error {
+	if mp, ok := v.(**Message); ok {
+		*mp = m
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return InvalidUnmarshalError
+	}
+	return m.unmarshal(rv.Elem())
+}
+
+func (m *Message) unmarshal(rv reflect.Value) error {
+	if m.Type == Nil {
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	}
+	if rv.Kind() == reflect.Interface && rv.NumMethod() == 0 {
+		rv.Set(reflect.ValueOf(m.Value()))
+		return nil
+	}
+	if m.Type == Err {
+		err, _ := m.Err()
+		return err
+	}
+
+	// The textual form of the value, for everything other than an Array
+	var s []byte
+	switch m.Type {
+	case SimpleStr, BulkStr:
+		s = m.val.([]byte)
+	case Int:
+		s = strconv.AppendInt(nil, m.val.(int64), 10)
+	}
+
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return m.unmarshal(rv.Elem())
+	}
+
+	if m.Type == Array {
+		return m.unmarshalArray(rv)
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(string(s))
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() != reflect.Uint8 {
+			return UnmarshalTypeError
+		}
+		rv.SetBytes(append([]byte(nil), s...))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(string(s))
+		if err != nil {
+			return UnmarshalTypeError
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(string(s), 10, rv.Type().Bits())
+		if err != nil {
+			return UnmarshalTypeError
+		}
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(string(s), 10, rv.Type().Bits())
+		if err != nil {
+			return UnmarshalTypeError
+		}
+		rv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(string(s), rv.Type().Bits())
+		if err != nil {
+			return UnmarshalTypeError
+		}
+		rv.SetFloat(f)
+	default:
+		return UnmarshalTypeError
+	}
+	return nil
+}
+
+func (m *Message) unmarshalArray(rv reflect.Value) error {
+	arr := m.val.([]*Message)
+	switch rv.Kind() {
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return UnmarshalTypeError
+		}
+		sl := reflect.MakeSlice(rv.Type(), len(arr), len(arr))
+		for i := range arr {
+			if err := arr[i].unmarshal(sl.Index(i)); err != nil {
+				return err
+			}
+		}
+		rv.Set(sl)
+	case reflect.Map:
+		if len(arr)%2 != 0 {
+			return UnmarshalTypeError
+		}
+		mp := reflect.MakeMap(rv.Type())
+		for i := 0; i < len(arr); i += 2 {
+			k := reflect.New(rv.Type().Key()).Elem()
+			if err := arr[i].unmarshal(k); err != nil {
+				return err
+			}
+			v := reflect.New(rv.Type().Elem()).Elem()
+			if err := arr[i+1].unmarshal(v); err != nil {
+				return err
+			}
+			mp.SetMapIndex(k, v)
+		}
+		rv.Set(mp)
+	default:
+		return UnmarshalTypeError
+	}
+	return nil
+}
+
+// Decoder reads a stream of messages from an io.Reader. Unlike calling
+// ReadMessage repeatedly, it keeps whatever it has buffered from the reader
+// between messages, so it can be used for reading every message sent on a
+// connection, e.g. the commands sent to a fake server or a proxy.
+type Decoder struct {
+	r *bufio.Reader
+	l *limitState
+}
+
+// NewDecoder returns a Decoder reading from r
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// SetLimits makes the Decoder return DepthLimitError or ElemLimitError for
+// messages which go over the given Limits, see ReadMessageLimits
+func (d *Decoder) SetLimits(l Limits) {
+	d.l = &limitState{Limits: l}
+}
+
+// ReadMessage reads the next message. A message which is read in full is
+// returned even if it's an Err; any error means the stream can't be read from
+// any further.
+func (d *Decoder) ReadMessage() (*Message, error) {
+	if d.l != nil {
+		d.l.depth, d.l.elems = 0, 0
+	}
+	return bufioReadMessage(d.r, d.l)
+}
+
+// Decode reads the next message and unmarshals it into v, see
+// Message.Unmarshal. If the message can't be unmarshalled (or is an Err) the
+// stream can still be read from, but if reading it failed it can't.
+func (d *Decoder) Decode(v interface{}) error {
+	m, err := d.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return m.Unmarshal(v)
+}
+
+// Encoder writes messages to an io.Writer. Each message is written with a
+// single call to Write, so wrapping the writer in a bufio.Writer is worthwhile
+// when writing many small messages.
+type Encoder struct {
+	w io.Writer
+}
+
+// NewEncoder returns an Encoder writing to w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the encoded form of v, see Marshal. This is what a server
+// writes for its replies.
+func (e *Encoder) Encode(v interface{}) error {
+	_, err := e.w.Write(Marshal(v))
+	return err
+}
+
+// EncodeCommand writes the command and its arguments as an Array of BulkStrs,
+// which is what redis expects to be sent, flattening the arguments as
+// WriteArbitraryAsFlattenedStrings does
+func (e *Encoder) EncodeCommand(cmd string, args ...interface{}) error {
+	return WriteArbitraryAsFlattenedStrings(e.w, []interface{}{cmd, args})
+}
@@ -0,0 +1,103 @@
+package resp
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func TestMarshal(t *T) {
+	assert.Equal(t, []byte("$3\r\nfoo\r\n"), Marshal("foo"))
+	assert.Equal(t, []byte(":5\r\n"), Marshal(5))
+	assert.Equal(t, []byte("-ERR bad\r\n"), Marshal(errors.New("ERR bad")))
+	assert.Equal(t, []byte("$-1\r\n"), Marshal(nil))
+	assert.Equal(t, []byte("+OK\r\n"), Marshal(NewSimpleString("OK")))
+	assert.Equal(t, []byte("*2\r\n$1\r\na\r\n:1\r\n"), Marshal([]interface{}{"a", 1}))
+}
+
+func TestUnmarshal(t *T) {
+	var s string
+	assert.Nil(t, Unmarshal([]byte("+OK\r\n"), &s))
+	assert.Equal(t, "OK", s)
+	assert.Nil(t, Unmarshal([]byte(":12\r\n"), &s))
+	assert.Equal(t, "12", s)
+
+	var i int
+	assert.Nil(t, Unmarshal([]byte("$2\r\n42\r\n"), &i))
+	assert.Equal(t, 42, i)
+	assert.Equal(t, UnmarshalTypeError, Unmarshal([]byte("$2\r\nno\r\n"), &i))
+	assert.Nil(t, Unmarshal([]byte("$-1\r\n"), &i))
+	assert.Equal(t, 0, i)
+
+	var b []byte
+	assert.Nil(t, Unmarshal([]byte("$3\r\n\x00\x01\x02\r\n"), &b))
+	assert.Equal(t, []byte{0, 1, 2}, b)
+
+	var f float64
+	assert.Nil(t, Unmarshal([]byte("$3\r\n1.5\r\n"), &f))
+	assert.Equal(t, 1.5, f)
+
+	var ok bool
+	assert.Nil(t, Unmarshal([]byte(":1\r\n"), &ok))
+	assert.True(t, ok)
+
+	var ss []string
+	assert.Nil(t, Unmarshal([]byte("*2\r\n$1\r\na\r\n:2\r\n"), &ss))
+	assert.Equal(t, []string{"a", "2"}, ss)
+	assert.Equal(t, UnmarshalTypeError, Unmarshal([]byte("*1\r\n:1\r\n"), &i))
+
+	var h map[string]int
+	assert.Nil(t, Unmarshal([]byte("*4\r\n$1\r\na\r\n:1\r\n$1\r\nb\r\n$1\r\n2\r\n"), &h))
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, h)
+
+	var p *string
+	assert.Nil(t, Unmarshal([]byte("+hi\r\n"), &p))
+	assert.Equal(t, "hi", *p)
+
+	var v interface{}
+	assert.Nil(t, Unmarshal([]byte("*3\r\n+a\r\n:1\r\n$-1\r\n"), &v))
+	assert.Equal(t, []interface{}{"a", int64(1), nil}, v)
+
+	var m *Message
+	assert.Nil(t, Unmarshal([]byte(":1\r\n"), &m))
+	assert.Equal(t, Int, m.Type)
+
+	// A server error is returned as it is
+	err := Unmarshal([]byte("-ERR nope\r\n"), &s)
+	assert.Equal(t, "ERR nope", err.Error())
+	err = Unmarshal([]byte("*1\r\n-ERR nope\r\n"), &ss)
+	assert.Equal(t, "ERR nope", err.Error())
+
+	assert.Equal(t, InvalidUnmarshalError, Unmarshal([]byte(":1\r\n"), i))
+}
+
+func TestEncoderDecoder(t *T) {
+	buf := bytes.NewBuffer(nil)
+	e := NewEncoder(buf)
+	assert.Nil(t, e.EncodeCommand("SET", "foo", []string{"bar", "EX"}, 10))
+	assert.Nil(t, e.Encode(NewSimpleString("OK")))
+	assert.Nil(t, e.Encode(7))
+	assert.Equal(t,
+		"*5\r\n$3\r\nSET\r\n$3\r\nfoo\r\n$3\r\nbar\r\n$2\r\nEX\r\n$2\r\n10\r\n+OK\r\n:7\r\n",
+		buf.String())
+
+	// The messages are all read, even though the first read buffers them all
+	d := NewDecoder(buf)
+	var cmd []string
+	assert.Nil(t, d.Decode(&cmd))
+	assert.Equal(t, []string{"SET", "foo", "bar", "EX", "10"}, cmd)
+	m, err := d.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, SimpleStr, m.Type)
+	var i int64
+	assert.Nil(t, d.Decode(&i))
+	assert.Equal(t, int64(7), i)
+	_, err = d.ReadMessage()
+	assert.NotNil(t, err)
+
+	d = NewDecoder(bytes.NewBufferString("*2\r\n:1\r\n:2\r\n:3\r\n"))
+	d.SetLimits(Limits{MaxElems: 2})
+	assert.Nil(t, d.Decode(&cmd))
+	assert.Nil(t, d.Decode(&i))
+}
@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package resp
+
+// The race detector's instrumentation allocates, so tests which count
+// allocations are skipped when it's on
+const raceEnabled = true
@@ -43,6 +43,32 @@ var (
 	parseErr = errors.New("parse error")
 )
 
+// Returned by ReadMessageLimits when a message goes over one of its Limits
+var (
+	DepthLimitError = errors.New("message is nested too deeply")
+	ElemLimitError  = errors.New("message has too many elements")
+)
+
+// Limits bound the shape of the messages which ReadMessageLimits will read, in
+// order to protect against malicious or buggy servers sending pathological
+// messages. A limit of zero means no limit.
+type Limits struct {
+	// The maximum number of arrays which may be nested inside each other. A
+	// message which isn't an array has a depth of zero.
+	MaxDepth int
+
+	// The maximum number of elements which may be in a message, counting all
+	// the elements of all of its arrays
+	MaxElems int
+}
+
+// limitState keeps track of how much of its Limits a message being read has
+// used up so far
+type limitState struct {
+	Limits
+	depth, elems int
+}
+
 type Message struct {
 	Type
 	val interface{}
@@ -79,10 +105,20 @@ func NewSimpleString(s string) *Message {
 // it, and return a Message struct representing it
 func ReadMessage(reader io.Reader) (*Message, error) {
 	r := bufio.NewReader(reader)
-	return bufioReadMessage(r)
+	return bufioReadMessage(r, nil)
+}
+
+// ReadMessageLimits is like ReadMessage, but returns DepthLimitError or
+// ElemLimitError if the message read goes over the given Limits. The check is
+// made as soon as an array's header is read, so no memory is allocated for an
+// array which would go over them. Since the rest of the message is left unread
+// the stream should not be read from after such an error.
+func ReadMessageLimits(reader io.Reader, l Limits) (*Message, error) {
+	r := bufio.NewReader(reader)
+	return bufioReadMessage(r, &limitState{Limits: l})
 }
 
-func bufioReadMessage(r *bufio.Reader) (*Message, error) {
+func bufioReadMessage(r *bufio.Reader, l *limitState) (*Message, error) {
 	b, err := r.Peek(1)
 	if err != nil {
 		return nil, err
@@ -97,7 +133,7 @@ func bufioReadMessage(r *bufio.Reader) (*Message, error) {
 	case bulkStrPrefix:
 		return readBulkStr(r)
 	case arrayPrefix:
-		return readArray(r)
+		return readArray(r, l)
 	default:
 		return nil, badType
 	}
@@ -172,7 +208,7 @@ func readBulkStr(r *bufio.Reader) (*Message, error) {
 	return &Message{Type: BulkStr, val: total, raw: raw}, nil
 }
 
-func readArray(r *bufio.Reader) (*Message, error) {
+func readArray(r *bufio.Reader, l *limitState) (*Message, error) {
 	b, err := r.ReadBytes(delimEnd)
 	if err != nil {
 		return nil, err
@@ -185,9 +221,21 @@ func readArray(r *bufio.Reader) (*Message, error) {
 		return &Message{Type: Nil, raw: b}, nil
 	}
 
+	if l != nil {
+		if l.MaxDepth > 0 && l.depth+1 > l.MaxDepth {
+			return nil, DepthLimitError
+		}
+		if l.MaxElems > 0 && int64(l.elems)+size > int64(l.MaxElems) {
+			return nil, ElemLimitError
+		}
+		l.depth++
+		l.elems += int(size)
+		defer func() { l.depth-- }()
+	}
+
 	arr := make([]*Message, size)
 	for i := range arr {
-		m, err := bufioReadMessage(r)
+		m, err := bufioReadMessage(r, l)
 		if err != nil {
 			return nil, err
 		}
@@ -280,11 +328,70 @@ func WriteArbitraryAsString(w io.Writer, m interface{}) error {
 //
 // Note that if a Message type is found it will *not* be encoded to a BulkStr,
 // but will simply be passed through as whatever type it already represents.
+//
+// Elements which are a *LenReader are streamed straight from their reader to w,
+// rather than being read into memory first.
 func WriteArbitraryAsFlattenedStrings(w io.Writer, m interface{}) error {
 	fm := flatten(m)
+	for i := range fm {
+		if _, ok := fm[i].(*LenReader); ok {
+			return writeStreaming(w, fm)
+		}
+	}
 	return WriteArbitraryAsString(w, fm)
 }
 
+// LenReader is a value which is read from an io.Reader as it's being written,
+// for passing in large values without needing to hold them in memory. Since
+// the length of a BulkStr comes before its data, the exact number of bytes
+// which will be read must be known up front. LenReaders are only supported by
+// WriteArbitraryAsFlattenedStrings.
+type LenReader struct {
+	R   io.Reader
+	Len int64
+}
+
+// NewLenReader returns a LenReader which will read exactly n bytes from r
+func NewLenReader(r io.Reader, n int64) *LenReader {
+	return &LenReader{R: r, Len: n}
+}
+
+// ShortReaderError is returned when a LenReader's reader ends before its length
+// has been read. The data written up to that point can not be taken back, so
+// whatever was being written to should not be used anymore.
+var ShortReaderError = errors.New("LenReader ended before its length was read")
+
+// writeStreaming writes the flattened array fm as BulkStrs, streaming the
+// elements which are a *LenReader
+func writeStreaming(w io.Writer, fm []interface{}) error {
+	b := make([]byte, 0, 1024)
+	b = append(b, arrayPrefix)
+	b = append(b, []byte(strconv.Itoa(len(fm)))...)
+	b = append(b, delim...)
+	for i := range fm {
+		lr, ok := fm[i].(*LenReader)
+		if !ok {
+			b = append(b, format(fm[i], true)...)
+			continue
+		}
+		b = append(b, bulkStrPrefix)
+		b = append(b, []byte(strconv.FormatInt(lr.Len, 10))...)
+		b = append(b, delim...)
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+		n, err := io.CopyN(w, lr.R, lr.Len)
+		if err == io.EOF || (err == nil && n < lr.Len) {
+			return ShortReaderError
+		} else if err != nil {
+			return err
+		}
+		b = append(b[:0], delim...)
+	}
+	_, err := w.Write(b)
+	return err
+}
+
 func format(m interface{}, forceString bool) []byte {
 	switch mt := m.(type) {
 	case []byte:
@@ -391,6 +498,13 @@ func format(m interface{}, forceString bool) []byte {
 
 var typeOfBytes = reflect.TypeOf([]byte(nil))
 
+// Flatten returns the given value as a single flat slice, with any embedded
+// slices and maps (other than byte slices) flattened into it the same way
+// WriteArbitraryAsFlattenedStrings would do it
+func Flatten(m interface{}) []interface{} {
+	return flatten(m)
+}
+
 func flatten(m interface{}) []interface{} {
 	t := reflect.TypeOf(m)
 
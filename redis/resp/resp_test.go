@@ -157,6 +157,29 @@ var arbitraryAsFlattenedStringsTests = []arbitraryTest{
 	},
 }
 
+func TestReadLimits(t *T) {
+	b := []byte("*2\r\n*2\r\n:1\r\n:2\r\n*1\r\n*1\r\n:3\r\n")
+
+	m, err := ReadMessageLimits(bytes.NewReader(b), Limits{MaxDepth: 3, MaxElems: 6})
+	assert.Nil(t, err)
+	assert.Equal(t, Array, m.Type)
+
+	_, err = ReadMessageLimits(bytes.NewReader(b), Limits{MaxDepth: 2})
+	assert.Equal(t, DepthLimitError, err)
+
+	_, err = ReadMessageLimits(bytes.NewReader(b), Limits{MaxElems: 5})
+	assert.Equal(t, ElemLimitError, err)
+
+	// The check happens before anything is allocated for the array
+	_, err = ReadMessageLimits(bytes.NewReader([]byte("*1000000000000\r\n")), Limits{MaxElems: 10})
+	assert.Equal(t, ElemLimitError, err)
+
+	// Limits don't apply to non-array messages
+	m, err = ReadMessageLimits(bytes.NewReader([]byte("+OK\r\n")), Limits{MaxDepth: 1, MaxElems: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, SimpleStr, m.Type)
+}
+
 func TestWriteArbitrary(t *T) {
 	var err error
 	buf := bytes.NewBuffer([]byte{})
@@ -193,6 +216,27 @@ func TestWriteArbitraryAsFlattenedStrings(t *T) {
 	}
 }
 
+func TestWriteLenReader(t *T) {
+	buf := bytes.NewBuffer([]byte{})
+	lr := NewLenReader(bytes.NewBufferString("barbaz"), 6)
+	err := WriteArbitraryAsFlattenedStrings(buf, []interface{}{"SET", "foo", lr})
+	assert.Nil(t, err)
+	expect := "*3\r\n$3\r\nSET\r\n$3\r\nfoo\r\n$6\r\nbarbaz\r\n"
+	assert.Equal(t, expect, buf.String())
+
+	// Only Len bytes are read
+	buf.Reset()
+	lr = NewLenReader(bytes.NewBufferString("barbaz"), 3)
+	err = WriteArbitraryAsFlattenedStrings(buf, []interface{}{lr, 1})
+	assert.Nil(t, err)
+	assert.Equal(t, "*2\r\n$3\r\nbar\r\n$1\r\n1\r\n", buf.String())
+
+	buf.Reset()
+	lr = NewLenReader(bytes.NewBufferString("bar"), 6)
+	err = WriteArbitraryAsFlattenedStrings(buf, lr)
+	assert.Equal(t, ShortReaderError, err)
+}
+
 func TestMessageWrite(t *T) {
 	var err error
 	var m *Message
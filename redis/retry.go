@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"time"
+)
+
+// NoRetries can be used as the Retries of the RetryPolicy in Options to stop a
+// derived client retrying commands, when the client it's derived from does
+const NoRetries = -1
+
+// RetryPolicy decides whether a command which failed due to a problem with
+// the connection is tried again, see SetRetryPolicy
+type RetryPolicy struct {
+	// How many more times a failed command is tried
+	Retries int
+
+	// How long to wait before the first retry, doubling for each one after it
+	Backoff time.Duration
+}
+
+// SetRetryPolicy makes Cmd retry read-only commands (see ReadOnlyCommand)
+// which fail with a *ConnError, reconnecting before each retry, up to the
+// number of times given by the policy. Since a dead connection is only
+// replaced if the client's Config has Reconnect set, the policy has no effect
+// without it. Other commands are never retried, since the server may have run
+// them before the connection failed, and nor are commands sent inside a MULTI
+// block, which is lost along with the connection. The zero RetryPolicy, the
+// default, disables retrying.
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	c.retry = policy
+}
+
+// retryCmd runs the command like cmd does, retrying it as allowed by the
+// client's RetryPolicy
+func (c *Client) retryCmd(cmd string, args []interface{}) *Reply {
+	retries := c.retry.Retries
+	if !c.cfg.Reconnect || c.multi || !ReadOnlyCommand(cmd) {
+		retries = 0
+	}
+	r := c.cmd(cmd, args)
+	backoff := c.retry.Backoff
+	for i := 0; i < retries; i++ {
+		if _, ok := r.Err.(*ConnError); !ok {
+			break
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+		r = c.cmd(cmd, args)
+	}
+	return r
+}
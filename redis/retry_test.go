@@ -0,0 +1,38 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+func TestRetryPolicy(t *T) {
+	c, err := DialConfig(Config{Network: "tcp", Addr: "127.0.0.1:6379", Reconnect: true})
+	assert.Nil(t, err)
+	defer c.Close()
+	c.SetRetryPolicy(RetryPolicy{Retries: 2, Backoff: time.Millisecond})
+	assert.Nil(t, c.Cmd("SET", "retry:key", "v").Err)
+
+	// A read-only command is retried on a new connection
+	c.Conn.Close()
+	s, err := c.Cmd("GET", "retry:key").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "v", s)
+
+	// Other commands aren't, since they may have been run already
+	c.Conn.Close()
+	assert.NotNil(t, c.Cmd("SET", "retry:key", "v").Err)
+	assert.Nil(t, c.Cmd("SET", "retry:key", "v").Err)
+
+	// A derived client can turn retrying off
+	d := c.WithOptions(Options{Retry: RetryPolicy{Retries: NoRetries}})
+	c.Conn.Close()
+	assert.NotNil(t, d.Cmd("GET", "retry:key").Err)
+	assert.Nil(t, c.Cmd("GET", "retry:key").Err)
+
+	// Without Reconnect nothing is retried
+	nc := dial(t)
+	nc.SetRetryPolicy(RetryPolicy{Retries: 2})
+	nc.Conn.Close()
+	assert.NotNil(t, nc.Cmd("GET", "retry:key").Err)
+}
@@ -0,0 +1,171 @@
+package redis
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+)
+
+// The most SCAN calls SampleKeys and SampleMembers make per sample wanted,
+// before giving up and returning fewer than asked for
+const sampleRoundsPerItem = 4
+
+// The COUNT used for each SCAN call made while sampling
+const sampleScanCount = 20
+
+// SampleKeys returns up to n distinct keys chosen at random from the current
+// database, without using KEYS or a full SCAN of the database. See
+// SampleMembers for how the sample is made.
+func (c *Client) SampleKeys(n int) ([]string, error) {
+	size, err := c.Cmd("DBSIZE").Int64()
+	if err != nil {
+		return nil, err
+	}
+	return c.sample("SCAN", "", size, n, 1)
+}
+
+// SampleMembers returns up to n distinct members chosen at random from the set,
+// sorted set or hash (in which case the fields are returned) at key. Unlike
+// SRANDMEMBER it works for all three types, and never returns a member twice.
+//
+// The sample is made by SCANning small batches starting from random cursors,
+// and taking a few members at random from each batch, so it doesn't block the
+// server even for very large collections. If the collection has no more than n
+// members all of them are returned. Otherwise fewer than n may be returned if
+// the batches keep turning up members which were already picked. Since members
+// which are next to each other in a batch are more likely to be picked
+// together the sample isn't perfectly uniform, but it's good enough for things
+// like monitoring jobs estimating the makeup of a collection.
+func (c *Client) SampleMembers(key string, n int) ([]string, error) {
+	typ, err := c.Cmd("TYPE", key).Str()
+	if err != nil {
+		return nil, err
+	}
+	var sizeCmd, scanCmd string
+	stride := 1
+	switch typ {
+	case "none":
+		return []string{}, nil
+	case "set":
+		sizeCmd, scanCmd = "SCARD", "SSCAN"
+	case "zset":
+		sizeCmd, scanCmd, stride = "ZCARD", "ZSCAN", 2
+	case "hash":
+		sizeCmd, scanCmd, stride = "HLEN", "HSCAN", 2
+	default:
+		return nil, errors.New("can't sample members of a " + typ)
+	}
+	size, err := c.Cmd(sizeCmd, key).Int64()
+	if err != nil {
+		return nil, err
+	}
+	return c.sample(scanCmd, key, size, n, stride)
+}
+
+// sample implements SampleKeys and SampleMembers. size is the number of items
+// being sampled from, and stride is 2 if the scan returns pairs (e.g. ZSCAN)
+// of which only the first is wanted.
+func (c *Client) sample(
+	scanCmd, key string, size int64, n, stride int,
+) (
+	[]string, error,
+) {
+	seen := map[string]bool{}
+	ret := make([]string, 0, n)
+	if n <= 0 {
+		return ret, nil
+	}
+
+	// With few enough items just take them all
+	if size <= int64(n) {
+		cursor := "0"
+		for {
+			next, items, err := c.sampleScan(scanCmd, key, cursor, stride)
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range items {
+				if !seen[item] {
+					seen[item] = true
+					ret = append(ret, item)
+				}
+			}
+			if cursor = next; cursor == "0" {
+				return ret, nil
+			}
+		}
+	}
+
+	// Redis's hash tables are sized to the power of two at or above the number
+	// of items in them, so cursors below that cover the whole table
+	tableSize := int64(1)
+	for tableSize < size {
+		tableSize <<= 1
+	}
+	perBatch := (n + sampleRoundsPerItem - 1) / sampleRoundsPerItem
+	next := ""
+	for rounds := n * sampleRoundsPerItem; rounds > 0 && len(ret) < n; rounds-- {
+		// If the last batch was empty carry on from where it left off, rather
+		// than from another random cursor. This also covers servers which only
+		// support cursors they've returned themselves, which give an empty
+		// batch and a cursor of 0 for a random one.
+		cursor := next
+		if cursor == "" {
+			cursor = strconv.FormatInt(rand.Int63n(tableSize), 10)
+		}
+		var items []string
+		var err error
+		if next, items, err = c.sampleScan(scanCmd, key, cursor, stride); err != nil {
+			return nil, err
+		}
+		if len(items) > 0 {
+			next = ""
+		}
+		taken := 0
+		for _, i := range rand.Perm(len(items)) {
+			if taken == perBatch || len(ret) == n {
+				break
+			}
+			if !seen[items[i]] {
+				seen[items[i]] = true
+				ret = append(ret, items[i])
+				taken++
+			}
+		}
+	}
+	return ret, nil
+}
+
+// sampleScan makes a single SCAN (or SSCAN, etc...) call, returning the next
+// cursor and the items found
+func (c *Client) sampleScan(
+	scanCmd, key, cursor string, stride int,
+) (
+	string, []string, error,
+) {
+	var r *Reply
+	if key == "" {
+		r = c.Cmd(scanCmd, cursor, "COUNT", sampleScanCount)
+	} else {
+		r = c.Cmd(scanCmd, key, cursor, "COUNT", sampleScanCount)
+	}
+	if r.Err != nil {
+		return "", nil, r.Err
+	}
+	if len(r.Elems) != 2 {
+		return "", nil, errors.New("malformed " + scanCmd + " reply")
+	}
+	next, err := r.Elems[0].Str()
+	if err != nil {
+		return "", nil, err
+	}
+	l, err := r.Elems[1].List()
+	if err != nil {
+		return "", nil, err
+	}
+	items := make([]string, 0, len(l)/stride)
+	for i := 0; i < len(l); i += stride {
+		items = append(items, l[i])
+	}
+	return next, items, nil
+}
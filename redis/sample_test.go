@@ -0,0 +1,80 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	. "testing"
+)
+
+func assertDistinct(t *T, l []string) {
+	seen := map[string]bool{}
+	for _, s := range l {
+		assert.False(t, seen[s], s)
+		seen[s] = true
+	}
+}
+
+func TestSampleMembers(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "sample:set", "sample:zset", "sample:hash", "sample:str")
+	for i := 0; i < 100; i++ {
+		c.Cmd("SADD", "sample:set", i)
+		c.Cmd("ZADD", "sample:zset", i, "m"+strconv.Itoa(i))
+		c.Cmd("HSET", "sample:hash", "f"+strconv.Itoa(i), i)
+	}
+
+	l, err := c.SampleMembers("sample:set", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, len(l))
+	assertDistinct(t, l)
+	for _, m := range l {
+		ok, _ := c.Cmd("SISMEMBER", "sample:set", m).Bool()
+		assert.True(t, ok, m)
+	}
+
+	l, err = c.SampleMembers("sample:zset", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, len(l))
+	assertDistinct(t, l)
+	for _, m := range l {
+		assert.Equal(t, "m", m[:1])
+	}
+
+	l, err = c.SampleMembers("sample:hash", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, len(l))
+	assertDistinct(t, l)
+	for _, m := range l {
+		assert.Equal(t, "f", m[:1])
+	}
+
+	// Asking for more than there are returns everything
+	l, err = c.SampleMembers("sample:set", 200)
+	assert.Nil(t, err)
+	assert.Equal(t, 100, len(l))
+	assertDistinct(t, l)
+
+	l, err = c.SampleMembers("sample:nothing", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(l))
+
+	c.Cmd("SET", "sample:str", "foo")
+	_, err = c.SampleMembers("sample:str", 10)
+	assert.NotNil(t, err)
+}
+
+func TestSampleKeys(t *T) {
+	c := dial(t)
+	for i := 0; i < 50; i++ {
+		c.Cmd("SET", "sample:key:"+strconv.Itoa(i), i)
+	}
+
+	l, err := c.SampleKeys(5)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(l))
+	assertDistinct(t, l)
+	for _, k := range l {
+		n, _ := c.Cmd("EXISTS", k).Int()
+		assert.Equal(t, 1, n, k)
+	}
+}
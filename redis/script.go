@@ -0,0 +1,36 @@
+package redis
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+)
+
+// Script is a lua script which is called using EVALSHA, so the script itself
+// only has to be sent to redis the first time it's used on a server. If redis
+// doesn't have the script cached yet (a NOSCRIPT error) it's sent with EVAL
+// instead, which caches it for next time.
+type Script struct {
+	src string
+	sha string
+}
+
+// NewScript returns a Script for the given lua source
+func NewScript(src string) *Script {
+	sum := sha1.Sum([]byte(src))
+	return &Script{src: src, sha: hex.EncodeToString(sum[:])}
+}
+
+// SHA returns the SHA1 digest redis knows the script by
+func (s *Script) SHA() string {
+	return s.sha
+}
+
+// Cmd calls the script with the given keys and arguments, using any Commander
+func (s *Script) Cmd(c Commander, keys []string, args ...interface{}) *Reply {
+	r := c.Cmd("EVALSHA", evalArgs(s.sha, keys, args)...)
+	if errors.Is(r.Err, NoScriptError) {
+		r = c.Cmd("EVAL", evalArgs(s.src, keys, args)...)
+	}
+	return r
+}
@@ -0,0 +1,22 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func TestScript(t *T) {
+	c := dial(t)
+	s := NewScript("return 'script:' .. KEYS[1] .. ARGV[1]")
+	assert.Equal(t, 40, len(s.SHA()))
+
+	c.Cmd("SCRIPT", "FLUSH")
+	v, err := s.Cmd(c, []string{"foo"}, "bar").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "script:foobar", v)
+
+	// The script is cached now, so EVALSHA alone works
+	v, err = c.Cmd("EVALSHA", s.SHA(), 1, "foo", "baz").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "script:foobaz", v)
+}
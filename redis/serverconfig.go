@@ -0,0 +1,119 @@
+package redis
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// ConfigGet returns the server's configuration parameters matching the given
+// glob-style pattern, using CONFIG GET
+func (c *Client) ConfigGet(pattern string) (map[string]string, error) {
+	return c.Cmd("CONFIG", "GET", pattern).Hash()
+}
+
+// ConfigGetAll returns all of the server's configuration parameters
+func (c *Client) ConfigGetAll() (map[string]string, error) {
+	return c.ConfigGet("*")
+}
+
+// ConfigSet sets a configuration parameter using CONFIG SET. The value is sent
+// as is, see ConfigSetMaxMemory and ConfigSetDuration for typed values.
+func (c *Client) ConfigSet(param string, value interface{}) error {
+	return c.Cmd("CONFIG", "SET", param, value).Err
+}
+
+// ConfigRewrite rewrites the server's config file to reflect its current
+// configuration, using CONFIG REWRITE
+func (c *Client) ConfigRewrite() error {
+	return c.Cmd("CONFIG", "REWRITE").Err
+}
+
+// ConfigResetStat resets the statistics reported by INFO, using CONFIG
+// RESETSTAT
+func (c *Client) ConfigResetStat() error {
+	return c.Cmd("CONFIG", "RESETSTAT").Err
+}
+
+// configOne returns the value of a single configuration parameter
+func (c *Client) configOne(param string) (string, error) {
+	m, err := c.ConfigGet(param)
+	if err != nil {
+		return "", err
+	}
+	v, ok := m[param]
+	if !ok {
+		return "", errors.New("unknown config parameter " + param)
+	}
+	return v, nil
+}
+
+// ConfigMaxMemory returns the server's maxmemory setting in bytes, zero meaning
+// no limit
+func (c *Client) ConfigMaxMemory() (int64, error) {
+	v, err := c.configOne("maxmemory")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(v, 10, 64)
+}
+
+// ConfigSetMaxMemory sets the server's maxmemory setting, in bytes
+func (c *Client) ConfigSetMaxMemory(bytes int64) error {
+	return c.ConfigSet("maxmemory", bytes)
+}
+
+// The units of the configuration parameters which ConfigDuration and
+// ConfigSetDuration know about
+var configDurationUnits = map[string]time.Duration{
+	"timeout":                   time.Second,
+	"tcp-keepalive":             time.Second,
+	"repl-timeout":              time.Second,
+	"repl-ping-replica-period":  time.Second,
+	"repl-backlog-ttl":          time.Second,
+	"cluster-node-timeout":      time.Millisecond,
+	"lua-time-limit":            time.Millisecond,
+	"busy-reply-threshold":      time.Millisecond,
+	"latency-monitor-threshold": time.Millisecond,
+	"slowlog-log-slower-than":   time.Microsecond,
+}
+
+func configDurationUnit(param string) (time.Duration, error) {
+	unit, ok := configDurationUnits[param]
+	if !ok {
+		return 0, errors.New("config parameter " + param + " is not a known duration")
+	}
+	return unit, nil
+}
+
+// ConfigDuration returns the value of a configuration parameter which is a
+// duration, such as "timeout" or "slowlog-log-slower-than", converting it from
+// whatever unit the server uses for it. An error is returned for parameters
+// which aren't known to be durations. Keep in mind that some parameters use
+// negative values to mean the setting is disabled.
+func (c *Client) ConfigDuration(param string) (time.Duration, error) {
+	unit, err := configDurationUnit(param)
+	if err != nil {
+		return 0, err
+	}
+	v, err := c.configOne(param)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// ConfigSetDuration sets a configuration parameter which is a duration (see
+// ConfigDuration), converting d to the unit the server uses for it. d is
+// truncated to that unit.
+func (c *Client) ConfigSetDuration(param string, d time.Duration) error {
+	unit, err := configDurationUnit(param)
+	if err != nil {
+		return err
+	}
+	return c.ConfigSet(param, int64(d/unit))
+}
@@ -0,0 +1,41 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+func TestConfigDurationUnits(t *T) {
+	c := dial(t)
+	_, err := c.ConfigDuration("maxmemory")
+	assert.NotNil(t, err)
+	assert.NotNil(t, c.ConfigSetDuration("maxmemory", time.Second))
+}
+
+func TestConfig(t *T) {
+	c := dial(t)
+	if isUnknownCommand(c.Cmd("CONFIG", "GET", "maxmemory").Err) {
+		t.Skip("CONFIG not supported")
+	}
+
+	all, err := c.ConfigGetAll()
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", all["maxmemory-policy"])
+
+	maxmem, err := c.ConfigMaxMemory()
+	assert.Nil(t, err)
+	assert.Nil(t, c.ConfigSetMaxMemory(100<<20))
+	n, _ := c.ConfigMaxMemory()
+	assert.Equal(t, int64(100<<20), n)
+	assert.Nil(t, c.ConfigSetMaxMemory(maxmem))
+
+	slower, err := c.ConfigDuration("slowlog-log-slower-than")
+	assert.Nil(t, err)
+	assert.Nil(t, c.ConfigSetDuration("slowlog-log-slower-than", 5*time.Millisecond))
+	d, _ := c.ConfigDuration("slowlog-log-slower-than")
+	assert.Equal(t, 5*time.Millisecond, d)
+	assert.Nil(t, c.ConfigSetDuration("slowlog-log-slower-than", slower))
+
+	assert.Nil(t, c.ConfigResetStat())
+}
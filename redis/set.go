@@ -0,0 +1,36 @@
+package redis
+
+import (
+	"errors"
+)
+
+// SMIsMember returns whether each of the members is in the set at key, in the
+// same order. Redis 6.2 and up.
+func (c *Client) SMIsMember(key string, members ...interface{}) ([]bool, error) {
+	r := c.Cmd("SMISMEMBER", key, members)
+	if r.Type == ErrorReply {
+		return nil, r.Err
+	}
+	if r.Type != MultiReply {
+		return nil, errors.New("reply type is not MultiReply")
+	}
+	in := make([]bool, len(r.Elems))
+	for i, e := range r.Elems {
+		var err error
+		if in[i], err = e.Bool(); err != nil {
+			return nil, err
+		}
+	}
+	return in, nil
+}
+
+// SInterCard returns the number of members in the intersection of the sets at
+// keys, without building the intersection itself. If limit isn't zero redis
+// stops counting once it reaches limit. Redis 7.0 and up.
+func (c *Client) SInterCard(limit int, keys ...string) (int64, error) {
+	var args []interface{}
+	if limit > 0 {
+		args = append(args, "LIMIT", limit)
+	}
+	return c.Cmd("SINTERCARD", len(keys), keys, args).Int64()
+}
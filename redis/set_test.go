@@ -0,0 +1,37 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func TestSMIsMember(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "set:a")
+	c.Cmd("SADD", "set:a", "x", "y")
+
+	in, err := c.SMIsMember("set:a", "x", "z", "y")
+	if isUnknownCommand(err) {
+		t.Skip("SMISMEMBER is not supported")
+	}
+	assert.Nil(t, err)
+	assert.Equal(t, []bool{true, false, true}, in)
+}
+
+func TestSInterCard(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "set:a", "set:b")
+	c.Cmd("SADD", "set:a", "x", "y", "z")
+	c.Cmd("SADD", "set:b", "x", "y", "w")
+
+	n, err := c.SInterCard(0, "set:a", "set:b")
+	if isUnknownCommand(err) {
+		t.Skip("SINTERCARD is not supported")
+	}
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+
+	n, err = c.SInterCard(1, "set:a", "set:b")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+}
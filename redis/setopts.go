@@ -0,0 +1,80 @@
+package redis
+
+import (
+	"errors"
+	"time"
+)
+
+// SetOpts holds the options of SET, see SetWithOptions
+type SetOpts struct {
+	// If set, the key expires after this long (PX)
+	TTL time.Duration
+
+	// If set, the key expires at this time (PXAT, redis 6.2 and up)
+	ExpireAt time.Time
+
+	// Keep the TTL the key already has, instead of clearing it (KEEPTTL,
+	// redis 6.0 and up)
+	KeepTTL bool
+
+	// Only set the key if it doesn't exist (NX), or only if it does (XX)
+	NX, XX bool
+
+	// Return the key's old value (GET, redis 6.2 and up)
+	Get bool
+}
+
+// args returns the SET arguments for the options, or an error if they can't be
+// used together
+func (o SetOpts) args() ([]interface{}, error) {
+	var expiries int
+	for _, b := range []bool{o.TTL != 0, !o.ExpireAt.IsZero(), o.KeepTTL} {
+		if b {
+			expiries++
+		}
+	}
+	if expiries > 1 {
+		return nil, errors.New("only one of TTL, ExpireAt and KeepTTL can be set")
+	}
+	if o.NX && o.XX {
+		return nil, errors.New("NX and XX can not both be set")
+	}
+	if o.TTL < 0 {
+		return nil, errors.New("TTL can not be negative")
+	}
+
+	var args []interface{}
+	switch {
+	case o.TTL != 0:
+		args = append(args, "PX", o.TTL)
+	case !o.ExpireAt.IsZero():
+		args = append(args, "PXAT", o.ExpireAt.UnixNano()/int64(time.Millisecond))
+	case o.KeepTTL:
+		args = append(args, "KEEPTTL")
+	}
+	if o.NX {
+		args = append(args, "NX")
+	} else if o.XX {
+		args = append(args, "XX")
+	}
+	if o.Get {
+		args = append(args, "GET")
+	}
+	return args, nil
+}
+
+// SetWithOptions calls SET with the given options, for example:
+//
+//	r := c.SetWithOptions("foo", "bar", redis.SetOpts{TTL: 10 * time.Second, NX: true})
+//
+// If opts.Get is set the reply is the key's old value, or a NilReply if it
+// didn't have one. Otherwise the reply is a StatusReply if the key was set, or
+// a NilReply if NX or XX stopped it from being set. Options which can't be used
+// together result in an ErrorReply without anything being sent.
+func (c *Client) SetWithOptions(key string, val interface{}, opts SetOpts) *Reply {
+	args, err := opts.args()
+	if err != nil {
+		return &Reply{Type: ErrorReply, Err: err}
+	}
+	return c.Cmd("SET", append([]interface{}{key, val}, args...)...)
+}
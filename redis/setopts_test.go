@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func TestSetOptsArgs(t *T) {
+	at := time.Unix(1700000000, 0)
+	for _, test := range []struct {
+		opts SetOpts
+		args []interface{}
+	}{
+		{SetOpts{}, nil},
+		{SetOpts{TTL: time.Second, NX: true}, []interface{}{"PX", time.Second, "NX"}},
+		{SetOpts{ExpireAt: at, XX: true, Get: true},
+			[]interface{}{"PXAT", int64(1700000000000), "XX", "GET"}},
+		{SetOpts{KeepTTL: true}, []interface{}{"KEEPTTL"}},
+	} {
+		args, err := test.opts.args()
+		assert.Nil(t, err)
+		assert.Equal(t, test.args, args)
+	}
+
+	for _, opts := range []SetOpts{
+		{TTL: time.Second, KeepTTL: true},
+		{TTL: time.Second, ExpireAt: at},
+		{NX: true, XX: true},
+		{TTL: -time.Second},
+	} {
+		_, err := opts.args()
+		assert.NotNil(t, err, opts)
+	}
+}
+
+func TestSetWithOptions(t *T) {
+	c := dial(t)
+	key := "setopts:key"
+	c.Cmd("DEL", key)
+
+	r := c.SetWithOptions(key, "a", SetOpts{TTL: time.Minute, NX: true})
+	assert.Equal(t, StatusReply, r.Type)
+	ttl, _ := c.Cmd("PTTL", key).Int()
+	assert.True(t, ttl > 50000 && ttl <= 60000)
+
+	r = c.SetWithOptions(key, "b", SetOpts{NX: true})
+	assert.Equal(t, NilReply, r.Type)
+
+	r = c.SetWithOptions(key, "c", SetOpts{XX: true, KeepTTL: true, Get: true})
+	s, err := r.Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "a", s)
+	ttl, _ = c.Cmd("PTTL", key).Int()
+	assert.True(t, ttl > 0)
+
+	r = c.SetWithOptions(key, "d", SetOpts{NX: true, XX: true})
+	assert.Equal(t, ErrorReply, r.Type)
+	s, _ = c.Cmd("GET", key).Str()
+	assert.Equal(t, "c", s)
+}
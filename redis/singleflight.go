@@ -0,0 +1,94 @@
+package redis
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/fzzy/radix/redis/resp"
+)
+
+// Read-only commands whose replies are random, so two routines sending the same
+// one at once expect different replies
+var randomCommands = map[string]bool{
+	"RANDOMKEY": true, "SRANDMEMBER": true, "HRANDFIELD": true,
+	"ZRANDMEMBER": true,
+}
+
+// Singleflight collapses identical reads sent from different routines at the
+// same time into one: while a read-only command (see ReadOnlyCommand) is
+// waiting for its reply, any other routine sending exactly the same command
+// waits for that reply too, rather than sending it again. This takes the load
+// off of hot keys which many routines read at once. Commands which write,
+// block or have a random reply are always sent as they are, as are commands
+// with arguments other than strings, byte slices and numbers.
+//
+// Every routine whose command was collapsed gets the very same Reply, so
+// replies from a Singleflight must not be modified. The Commander wrapped must
+// be safe to use from multiple routines, e.g. a Mux or one of the extra
+// clients. A Singleflight is a Commander, and is safe to use from multiple
+// routines at once.
+type Singleflight struct {
+	c Commander
+
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+// flight is a command which is waiting for its reply. done is closed once
+// reply has been set.
+type flight struct {
+	done  chan struct{}
+	reply *Reply
+}
+
+// NewSingleflight returns a Singleflight sending commands through c
+func NewSingleflight(c Commander) *Singleflight {
+	return &Singleflight{c: c, calls: map[string]*flight{}}
+}
+
+// Cmd sends the given command, unless the same command is already waiting for
+// its reply, in which case it waits for that reply and returns it
+func (s *Singleflight) Cmd(cmd string, args ...interface{}) *Reply {
+	key, ok := flightKey(cmd, args)
+	if !ok {
+		return s.c.Cmd(cmd, args...)
+	}
+
+	s.mu.Lock()
+	if f, ok := s.calls[key]; ok {
+		s.mu.Unlock()
+		<-f.done
+		return f.reply
+	}
+	f := &flight{done: make(chan struct{})}
+	s.calls[key] = f
+	s.mu.Unlock()
+
+	f.reply = s.c.Cmd(cmd, args...)
+	s.mu.Lock()
+	delete(s.calls, key)
+	s.mu.Unlock()
+	close(f.done)
+	return f.reply
+}
+
+// flightKey returns the key identifying the given command among those in
+// flight, or false if it mustn't be collapsed with others
+func flightKey(cmd string, args []interface{}) (string, bool) {
+	cmd = strings.ToUpper(cmd)
+	if !readOnlyCommands[cmd] || randomCommands[cmd] || BlockingCommand(cmd, args...) {
+		return "", false
+	}
+	b := resp.AppendArrayHeader(nil, len(args)+1)
+	b = resp.AppendArbitraryAsString(b, cmd)
+	for _, arg := range args {
+		switch arg.(type) {
+		case string, []byte, int, int8, int16, int32, int64,
+			uint, uint8, uint16, uint32, uint64, float32, float64:
+			b = resp.AppendArbitraryAsString(b, arg)
+		default:
+			return "", false
+		}
+	}
+	return string(b), true
+}
@@ -0,0 +1,72 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync"
+	. "testing"
+	"time"
+)
+
+// slowCommander holds up GETs until release is closed
+type slowCommander struct {
+	recordingCommander
+	release chan struct{}
+}
+
+func (sc *slowCommander) Cmd(cmd string, args ...interface{}) *Reply {
+	if cmd == "GET" {
+		<-sc.release
+	}
+	return sc.recordingCommander.Cmd(cmd, args...)
+}
+
+func TestSingleflight(t *T) {
+	m := dialMux(t)
+	defer m.Close()
+	m.Cmd("SET", "singleflight:foo", "bar")
+
+	sc := &slowCommander{recordingCommander{Commander: m}, make(chan struct{})}
+	s := NewSingleflight(sc)
+
+	var wg sync.WaitGroup
+	replies := make([]*Reply, 10)
+	for i := range replies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			replies[i] = s.Cmd("GET", "singleflight:foo")
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(sc.release)
+	wg.Wait()
+	for _, r := range replies {
+		str, err := r.Str()
+		assert.Nil(t, err)
+		assert.Equal(t, "bar", str)
+	}
+	assert.Equal(t, []string{"GET"}, sc.sent())
+
+	// Once the reply is in the next GET is sent again
+	s.Cmd("GET", "singleflight:foo")
+	assert.Equal(t, []string{"GET"}, sc.sent())
+}
+
+func TestFlightKey(t *T) {
+	a, ok := flightKey("get", []interface{}{"foo"})
+	assert.True(t, ok)
+	b, _ := flightKey("GET", []interface{}{[]byte("foo")})
+	assert.Equal(t, a, b)
+	b, _ = flightKey("GET", []interface{}{"fo", "o"})
+	assert.NotEqual(t, a, b)
+	b, _ = flightKey("STRLEN", []interface{}{"foo"})
+	assert.NotEqual(t, a, b)
+
+	for _, cmd := range [][]interface{}{
+		{"SET", "foo", "bar"}, {"RANDOMKEY"}, {"GET", []string{"foo"}},
+		{"XREAD", "BLOCK", 0, "STREAMS", "foo", "$"},
+	} {
+		_, ok = flightKey(cmd[0].(string), cmd[1:])
+		assert.False(t, ok, "%v", cmd)
+	}
+}
@@ -0,0 +1,78 @@
+package redis
+
+import (
+	"errors"
+	"time"
+)
+
+// SlowlogEntry is a command which was logged by the server for being slow, as
+// returned by SLOWLOG GET
+type SlowlogEntry struct {
+	ID        int64
+	Timestamp time.Time
+
+	// How long the command took to run, not including any time spent on I/O
+	Duration time.Duration
+
+	// The command and its arguments, which the server may have truncated
+	Args []string
+
+	// The client which ran the command. These are only set by redis 4.0 and
+	// above.
+	ClientAddr string
+	ClientName string
+}
+
+// SlowlogGet returns the n most recent entries in the slow log, newest first.
+// If n is negative all of them are returned.
+func (c *Client) SlowlogGet(n int) ([]SlowlogEntry, error) {
+	r := c.Cmd("SLOWLOG", "GET", n)
+	if r.Type == ErrorReply {
+		return nil, r.Err
+	}
+	return parseSlowlog(r)
+}
+
+// SlowlogLen returns the number of entries in the slow log
+func (c *Client) SlowlogLen() (int64, error) {
+	return c.Cmd("SLOWLOG", "LEN").Int64()
+}
+
+// SlowlogReset empties the slow log
+func (c *Client) SlowlogReset() error {
+	return c.Cmd("SLOWLOG", "RESET").Err
+}
+
+func parseSlowlog(r *Reply) ([]SlowlogEntry, error) {
+	if r.Type != MultiReply {
+		return nil, errors.New("malformed SLOWLOG GET reply")
+	}
+	entries := make([]SlowlogEntry, len(r.Elems))
+	for i, er := range r.Elems {
+		if er.Type != MultiReply || len(er.Elems) < 4 {
+			return nil, errors.New("malformed SLOWLOG GET entry")
+		}
+		e := &entries[i]
+		var ts, usec int64
+		var err error
+		if e.ID, err = er.Elems[0].Int64(); err != nil {
+			return nil, err
+		}
+		if ts, err = er.Elems[1].Int64(); err != nil {
+			return nil, err
+		}
+		if usec, err = er.Elems[2].Int64(); err != nil {
+			return nil, err
+		}
+		if e.Args, err = er.Elems[3].List(); err != nil {
+			return nil, err
+		}
+		e.Timestamp = time.Unix(ts, 0)
+		e.Duration = time.Duration(usec) * time.Microsecond
+		if len(er.Elems) >= 6 {
+			e.ClientAddr, _ = er.Elems[4].Str()
+			e.ClientName, _ = er.Elems[5].Str()
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+func TestParseSlowlog(t *T) {
+	r := NewReply([]interface{}{
+		[]interface{}{
+			int64(14), int64(1309448221), int64(15),
+			[]string{"ping"}, "127.0.0.1:58217", "worker-1",
+		},
+		[]interface{}{int64(13), int64(1309448128), int64(30), []string{"slowlog", "get", "100"}},
+	})
+	entries, err := parseSlowlog(r)
+	assert.Nil(t, err)
+	assert.Equal(t, []SlowlogEntry{
+		{
+			ID:         14,
+			Timestamp:  time.Unix(1309448221, 0),
+			Duration:   15 * time.Microsecond,
+			Args:       []string{"ping"},
+			ClientAddr: "127.0.0.1:58217",
+			ClientName: "worker-1",
+		},
+		{
+			ID:        13,
+			Timestamp: time.Unix(1309448128, 0),
+			Duration:  30 * time.Microsecond,
+			Args:      []string{"slowlog", "get", "100"},
+		},
+	}, entries)
+
+	_, err = parseSlowlog(NewReply([]interface{}{[]interface{}{int64(1)}}))
+	assert.NotNil(t, err)
+}
+
+func TestSlowlog(t *T) {
+	c := dial(t)
+	if isUnknownCommand(c.Cmd("SLOWLOG", "LEN").Err) {
+		t.Skip("SLOWLOG not supported")
+	}
+	assert.Nil(t, c.SlowlogReset())
+	entries, err := c.SlowlogGet(-1)
+	assert.Nil(t, err)
+	n, err := c.SlowlogLen()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(entries)), n)
+}
@@ -0,0 +1,146 @@
+package redis
+
+import (
+	"errors"
+	"strconv"
+)
+
+// NotMultiError is the error from a ReplyIter whose command's reply wasn't a
+// multi bulk reply
+var NotMultiError = errors.New("reply is not a multi bulk reply")
+
+// ReplyIter reads the elements of a multi bulk reply one at a time, as they
+// come off the connection, rather than holding all of them in memory at once.
+// Create one with CmdIter:
+//
+//	it := c.CmdIter("LRANGE", "biglist", 0, -1)
+//	defer it.Close()
+//	for it.Next() {
+//		s, _ := it.Reply().Str()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+//
+// The elements are passed on as they're read, without the key prefix,
+// compression or large value handling set on the client being applied to them.
+// The client must not be used for anything else until the iterator is done
+// with, i.e. Next has returned false or Close has been called.
+type ReplyIter struct {
+	c    *Client
+	n    int
+	left int
+	cur  *Reply
+	err  error
+}
+
+// CmdIter sends the given command, whose reply should be a multi bulk reply
+// (e.g. LRANGE, SMEMBERS or HGETALL), and returns a ReplyIter for reading its
+// elements
+func (c *Client) CmdIter(cmd string, args ...interface{}) *ReplyIter {
+	it := &ReplyIter{c: c, n: -1}
+	if it.err = c.prepare(); it.err != nil {
+		return it
+	}
+	req := c.newRequest(cmd, args)
+	if it.err = req.err; it.err != nil {
+		return it
+	}
+	if it.err = c.writeRequest(req); it.err != nil {
+		return it
+	}
+
+	c.setReadTimeout()
+	typ, err := c.reader.Peek(1)
+	if err != nil || typ[0] != '*' {
+		// The whole reply is read as usual, which takes care of an error
+		if r := c.readRequestReply(req); r.Err != nil {
+			it.err = r.Err
+		} else {
+			it.err = NotMultiError
+		}
+		return it
+	}
+	line, err := readLine(c.reader)
+	if err == nil {
+		n, ok := parseInt(line[1:])
+		if !ok {
+			err = strconv.ErrSyntax
+		}
+		it.n = int(n)
+	}
+	if err != nil {
+		c.fail()
+		it.err = desyncError(err)
+		return it
+	}
+	if it.n > 0 {
+		it.left = it.n
+	}
+	return it
+}
+
+// Len returns how many elements the reply has in total, or -1 if it's a nil
+// reply or the command failed
+func (it *ReplyIter) Len() int {
+	return it.n
+}
+
+// Next reads the next element of the reply, returning false if there are no
+// more or one couldn't be read, see Err
+func (it *ReplyIter) Next() bool {
+	if it.left == 0 || it.err != nil {
+		it.cur = nil
+		return false
+	}
+	it.c.setReadTimeout()
+	r := it.c.parse()
+	if cerr, ok := r.Err.(*ConnError); ok {
+		// The rest of the reply can't be read, and even if this element
+		// just timed out the connection is now out of sync
+		if !it.c.broken {
+			it.c.fail()
+			cerr.desync = true
+		}
+		it.err, it.left, it.cur = cerr, 0, nil
+		return false
+	}
+	it.left--
+	it.cur = r
+	return true
+}
+
+// Reply returns the element read by the last call to Next. An element may
+// itself be an error reply, e.g. in the reply to EXEC.
+func (it *ReplyIter) Reply() *Reply {
+	return it.cur
+}
+
+// Err returns the error which stopped the iterator early, or the command's
+// error if it failed, if there was one
+func (it *ReplyIter) Err() error {
+	return it.err
+}
+
+// Close reads and discards the elements which haven't been read yet, so that
+// the client can be used again, and returns Err
+func (it *ReplyIter) Close() error {
+	for it.Next() {
+	}
+	return it.err
+}
+
+// CmdStream sends the given command like CmdIter, and calls fn with each
+// element of its reply as it's read. If fn returns an error the rest of the
+// elements are discarded and the error is returned.
+func (c *Client) CmdStream(fn func(*Reply) error, cmd string, args ...interface{}) error {
+	it := c.CmdIter(cmd, args...)
+	for it.Next() {
+		if err := fn(it.Reply()); err != nil {
+			it.Close()
+			return err
+		}
+	}
+	return it.Err()
+}
@@ -0,0 +1,89 @@
+package redis
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	. "testing"
+)
+
+func TestCmdIter(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "stream:list")
+	for i := 0; i < 100; i++ {
+		c.Cmd("RPUSH", "stream:list", i)
+	}
+
+	it := c.CmdIter("LRANGE", "stream:list", 0, -1)
+	assert.Equal(t, 100, it.Len())
+	i := 0
+	for it.Next() {
+		s, err := it.Reply().Str()
+		assert.Nil(t, err)
+		assert.Equal(t, strconv.Itoa(i), s)
+		i++
+	}
+	assert.Equal(t, 100, i)
+	assert.Nil(t, it.Err())
+	assert.Nil(t, it.Reply())
+
+	// Closing part way through leaves the client ready for the next command
+	it = c.CmdIter("LRANGE", "stream:list", 0, -1)
+	assert.True(t, it.Next())
+	assert.Nil(t, it.Close())
+	s, err := c.Cmd("ECHO", "after").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "after", s)
+
+	// Empty and nil replies have no elements
+	it = c.CmdIter("LRANGE", "stream:nothing", 0, -1)
+	assert.Equal(t, 0, it.Len())
+	assert.False(t, it.Next())
+	assert.Nil(t, it.Err())
+
+	// Neither do ones which aren't multi bulk replies
+	it = c.CmdIter("GET", "stream:list")
+	assert.False(t, it.Next())
+	assert.True(t, errors.Is(it.Err(), WrongTypeError))
+	it = c.CmdIter("ECHO", "foo")
+	assert.Equal(t, NotMultiError, it.Err())
+	assert.Nil(t, c.Cmd("PING").Err)
+}
+
+func TestCmdIterBroken(t *T) {
+	c := dial(t)
+	c.reader = bufio.NewReader(bytes.NewBufferString(":1\r\n$5\r\nab"))
+	it := &ReplyIter{c: c, n: 3, left: 3}
+	assert.True(t, it.Next())
+	assert.False(t, it.Next())
+	assert.True(t, errors.Is(it.Err(), DesyncError))
+	assert.True(t, c.Broken())
+}
+
+func TestCmdStream(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "stream:hash")
+	c.Cmd("HMSET", "stream:hash", "a", "1", "b", "2")
+
+	var elems []string
+	err := c.CmdStream(func(r *Reply) error {
+		s, err := r.Str()
+		elems = append(elems, s)
+		return err
+	}, "HGETALL", "stream:hash")
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(elems))
+
+	// An error from the callback stops it early
+	stop := errors.New("stop")
+	n := 0
+	err = c.CmdStream(func(r *Reply) error {
+		n++
+		return stop
+	}, "HGETALL", "stream:hash")
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, n)
+	assert.Nil(t, c.Cmd("PING").Err)
+}
@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"errors"
+	"strings"
+)
+
+// Returned by ConsumeToken when the token doesn't exist, either because it was
+// never set, has expired, or was already consumed
+var TokenExpiredError error = errors.New("token does not exist or has expired")
+
+// ConsumeToken atomically gets and deletes the value at key, so that it can
+// only ever be retrieved once. This is useful for one-time tokens like password
+// reset links or CSRF nonces, which should be SET with an expiry. If there is
+// no value at key TokenExpiredError is returned.
+//
+// GETDEL is used if the server supports it (redis 6.2 and up), otherwise GET
+// and DEL are called in a MULTI block.
+func (c *Client) ConsumeToken(key string) (string, error) {
+	r := c.Cmd("GETDEL", key)
+	if isUnknownCommand(r.Err) {
+		r = c.getDelMulti(key)
+	}
+	if r.Type == NilReply {
+		return "", TokenExpiredError
+	}
+	return r.Str()
+}
+
+// getDelMulti is the equivalent of GETDEL for servers which don't have it
+func (c *Client) getDelMulti(key string) *Reply {
+	var b Batch
+	b.Add("GET", key)
+	b.Add("DEL", key)
+	replies, err := b.Multi(c)
+	if err != nil {
+		return &Reply{Type: ErrorReply, Err: err}
+	}
+	return replies[0]
+}
+
+// isUnknownCommand returns whether err is redis saying it doesn't know the
+// command which was sent
+func isUnknownCommand(err error) bool {
+	cerr, ok := err.(*CmdError)
+	return ok && strings.HasPrefix(cerr.Error(), "ERR unknown command")
+}
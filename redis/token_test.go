@@ -0,0 +1,38 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func TestConsumeToken(t *T) {
+	c := dial(t)
+	c.Cmd("SET", "token:foo", "bar", "EX", 60)
+
+	s, err := c.ConsumeToken("token:foo")
+	assert.Nil(t, err)
+	assert.Equal(t, "bar", s)
+
+	_, err = c.ConsumeToken("token:foo")
+	assert.Equal(t, TokenExpiredError, err)
+
+	// The fallback for servers without GETDEL
+	c.Cmd("SET", "token:foo", "baz", "EX", 60)
+	s, err = c.getDelMulti("token:foo").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "baz", s)
+	assert.Equal(t, NilReply, c.getDelMulti("token:foo").Type)
+
+	// The fallback doesn't read the replies of commands the caller has
+	// pipelined
+	c.Cmd("SET", "token:foo", "qux", "EX", 60)
+	c.Append("ECHO", "pending")
+	s, err = c.getDelMulti("token:foo").Str()
+	assert.Nil(t, err)
+	assert.Equal(t, "qux", s)
+	s, _ = c.GetReply().Str()
+	assert.Equal(t, "pending", s)
+
+	assert.True(t, isUnknownCommand(c.Cmd("NOTACOMMAND").Err))
+	assert.False(t, isUnknownCommand(c.Cmd("GET").Err))
+}
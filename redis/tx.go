@@ -0,0 +1,64 @@
+package redis
+
+// Transaction runs an optimistic transaction on the given keys. It WATCHes the
+// keys and calls fn, which can read them using the client and add the commands
+// which should be run atomically to the batch it's given. The batch is then
+// run in a MULTI/EXEC block (see Batch.Multi), and its replies returned. If
+// any of the keys were changed in the meantime the whole thing is tried again
+// from the start, up to retries more times, after which WatchConflictError is
+// returned.
+//
+// If fn returns an error, or adds nothing to the batch, the keys are
+// UNWATCHed and the error is returned without running anything.
+func (c *Client) Transaction(
+	keys []string, retries int, fn func(b *Batch) error,
+) (
+	[]*Reply, error,
+) {
+	args := make([]interface{}, len(keys))
+	for i := range keys {
+		args[i] = keys[i]
+	}
+	for attempt := 0; ; attempt++ {
+		if err := c.Cmd("WATCH", args...).Err; err != nil {
+			return nil, err
+		}
+		var b Batch
+		if err := fn(&b); err != nil || len(b) == 0 {
+			if uerr := c.Cmd("UNWATCH").Err; err == nil {
+				err = uerr
+			}
+			return nil, err
+		}
+		replies, err := b.Multi(c)
+		if err != WatchConflictError || attempt >= retries {
+			return replies, err
+		}
+	}
+}
+
+// Cas is a Transaction on a single string key, whose current value (or a
+// NilReply if it doesn't exist) is read with GET and given to fn. For example
+// to double a counter:
+//
+//	_, err := client.Cas("counter", 10, func(old *redis.Reply, b *redis.Batch) error {
+//		n, err := old.Int64OrDefault(0)
+//		if err != nil {
+//			return err
+//		}
+//		b.Add("SET", "counter", n*2)
+//		return nil
+//	})
+func (c *Client) Cas(
+	key string, retries int, fn func(old *Reply, b *Batch) error,
+) (
+	[]*Reply, error,
+) {
+	return c.Transaction([]string{key}, retries, func(b *Batch) error {
+		old := c.Cmd("GET", key)
+		if _, ok := old.Err.(*CmdError); old.Err != nil && !ok {
+			return old.Err
+		}
+		return fn(old, b)
+	})
+}
@@ -0,0 +1,87 @@
+package redis
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	. "testing"
+)
+
+func TestCas(t *T) {
+	c, other := dial(t), dial(t)
+	c.Cmd("DEL", "tx:counter")
+
+	double := func(old *Reply, b *Batch) error {
+		n, err := old.Int64OrDefault(1)
+		if err != nil {
+			return err
+		}
+		b.Add("SET", "tx:counter", n*2)
+		return nil
+	}
+	replies, err := c.Cas("tx:counter", 0, double)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(replies))
+	n, _ := c.Cmd("GET", "tx:counter").Int()
+	assert.Equal(t, 2, n)
+
+	// The key is changed by someone else on the first two attempts
+	attempts := 0
+	_, err = c.Cas("tx:counter", 2, func(old *Reply, b *Batch) error {
+		if attempts++; attempts <= 2 {
+			other.Cmd("INCR", "tx:counter")
+		}
+		return double(old, b)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, attempts)
+	n, _ = c.Cmd("GET", "tx:counter").Int()
+	assert.Equal(t, 8, n)
+
+	// Out of retries
+	attempts = 0
+	_, err = c.Cas("tx:counter", 1, func(old *Reply, b *Batch) error {
+		attempts++
+		other.Cmd("INCR", "tx:counter")
+		return double(old, b)
+	})
+	assert.Equal(t, WatchConflictError, err)
+	assert.Equal(t, 2, attempts)
+	n, _ = c.Cmd("GET", "tx:counter").Int()
+	assert.Equal(t, 10, n)
+
+	c.Cmd("DEL", "tx:counter")
+}
+
+func TestTransaction(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "tx:a", "tx:b")
+
+	replies, err := c.Transaction([]string{"tx:a", "tx:b"}, 0, func(b *Batch) error {
+		b.Add("SET", "tx:a", "1")
+		b.Add("INCR", "tx:b")
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(replies))
+	n, _ := replies[1].Int()
+	assert.Equal(t, 1, n)
+
+	// Errors from fn stop the transaction
+	fnErr := errors.New("nope")
+	_, err = c.Transaction([]string{"tx:a"}, 0, func(b *Batch) error {
+		b.Add("DEL", "tx:a")
+		return fnErr
+	})
+	assert.Equal(t, fnErr, err)
+	ok, _ := c.Cmd("EXISTS", "tx:a").Bool()
+	assert.True(t, ok)
+
+	// Nothing to do
+	replies, err = c.Transaction([]string{"tx:a"}, 0, func(b *Batch) error {
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, replies)
+
+	c.Cmd("DEL", "tx:a", "tx:b")
+}
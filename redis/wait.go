@@ -0,0 +1,48 @@
+package redis
+
+import (
+	"time"
+)
+
+// Wait blocks until all the writes sent on this connection before it have been
+// acknowledged by at least numReplicas replicas, or the timeout (zero meaning
+// forever) is reached. It returns how many replicas acknowledged the writes,
+// which may be fewer than numReplicas if the timeout was hit. The client's read
+// timeout is extended to cover the wait.
+//
+// This doesn't make redis strongly consistent, since a write which wasn't
+// acknowledged is still kept by the master and may still be replicated, but it
+// does narrow the window in which an acknowledged write can be lost.
+func (c *Client) Wait(numReplicas int, timeout time.Duration) (int, error) {
+	ms := int64(timeout / time.Millisecond)
+	if timeout > 0 && ms == 0 {
+		// Zero would mean waiting forever
+		ms = 1
+	}
+	return c.blockingCmdArgs(timeout, "WAIT", numReplicas, ms).Int()
+}
+
+// CmdWait calls the given write command, and then Wait with numReplicas and
+// timeout, returning the command's reply and how many replicas acknowledged it.
+// If the command fails Wait isn't called. A reply without an error but with
+// fewer acks than numReplicas means the write was made on the master, but may
+// not have been replicated.
+//
+//	r, acks, err := client.CmdWait(1, time.Second, "SET", "balance", 100)
+//	if err != nil {
+//		// handle err, either from the SET or the WAIT
+//	} else if acks < 1 {
+//		// the SET hasn't reached a replica
+//	}
+func (c *Client) CmdWait(
+	numReplicas int, timeout time.Duration, cmd string, args ...interface{},
+) (
+	r *Reply, acks int, err error,
+) {
+	r = c.Cmd(cmd, args...)
+	if r.Err != nil {
+		return r, 0, r.Err
+	}
+	acks, err = c.Wait(numReplicas, timeout)
+	return r, acks, err
+}
@@ -0,0 +1,28 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+func TestWait(t *T) {
+	c := dial(t)
+	n, err := c.Wait(0, 10*time.Millisecond)
+	if isUnknownCommand(err) {
+		t.Skip("WAIT is not supported")
+	}
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+
+	r, acks, err := c.CmdWait(0, 10*time.Millisecond, "SET", "wait:key", "foo")
+	assert.Nil(t, err)
+	assert.Equal(t, StatusReply, r.Type)
+	assert.Equal(t, 0, acks)
+
+	// The WAIT isn't sent if the write fails
+	r, acks, err = c.CmdWait(1, 0, "NOTACOMMAND")
+	assert.NotNil(t, err)
+	assert.Equal(t, ErrorReply, r.Type)
+	assert.Equal(t, 0, acks)
+}
@@ -0,0 +1,141 @@
+package redis
+
+import (
+	"io"
+	"net"
+	"sync"
+
+	"github.com/fzzy/radix/redis/resp"
+)
+
+// Commands are encoded into buffers taken from cmdWriterPool, and everything
+// sent at once (a single command, or a whole pipeline) goes out in a single
+// write. Arguments at least vectorMin bytes long aren't copied into the
+// buffer, but written straight from the caller's memory alongside it, using
+// writev where the connection supports it.
+const (
+	writeBufSize = 4096
+	vectorMin    = 16 * 1024
+
+	// Buffers which have grown past this aren't pooled again, so a single
+	// huge pipeline doesn't keep its memory around
+	writeBufMax = 64 * 1024
+)
+
+var cmdWriterPool = sync.Pool{
+	New: func() interface{} {
+		return &cmdWriter{b: make([]byte, 0, writeBufSize)}
+	},
+}
+
+// cmdWriter encodes commands to be written to w. Nothing is written until
+// flush is called, unless a command contains a *resp.LenReader, which has to
+// be streamed.
+type cmdWriter struct {
+	w io.Writer
+	b []byte
+
+	// The data waiting to be written: whole parts of b, and large arguments.
+	// b[start:] is the part of b which isn't in bufs yet.
+	bufs    net.Buffers
+	start   int
+	writing net.Buffers
+}
+
+// getCmdWriter returns a cmdWriter from the pool, which must be given back
+// with release once it's been flushed
+func getCmdWriter(w io.Writer) *cmdWriter {
+	cw := cmdWriterPool.Get().(*cmdWriter)
+	cw.w = w
+	return cw
+}
+
+// writeCommand adds the given command, whose args have already been through
+// marshalArgs, to what will be written
+func (cw *cmdWriter) writeCommand(cmd string, args []interface{}) error {
+	cw.b = resp.AppendArrayHeader(cw.b, len(args)+1)
+	cw.b = resp.AppendBulkStrHeader(cw.b, int64(len(cmd)))
+	cw.b = append(cw.b, cmd...)
+	cw.b = append(cw.b, "\r\n"...)
+	for _, arg := range args {
+		switch at := arg.(type) {
+		case []byte:
+			if len(at) >= vectorMin {
+				cw.appendLarge(at)
+				continue
+			}
+		case *resp.LenReader:
+			if err := cw.stream(at); err != nil {
+				return err
+			}
+			continue
+		}
+		cw.b = resp.AppendArbitraryAsString(cw.b, arg)
+	}
+	return nil
+}
+
+// appendLarge adds p to what will be written without copying it
+func (cw *cmdWriter) appendLarge(p []byte) {
+	cw.b = resp.AppendBulkStrHeader(cw.b, int64(len(p)))
+	cw.cut()
+	cw.bufs = append(cw.bufs, p)
+	cw.b = append(cw.b, "\r\n"...)
+}
+
+// stream writes everything so far, followed by the data read from lr
+func (cw *cmdWriter) stream(lr *resp.LenReader) error {
+	cw.b = resp.AppendBulkStrHeader(cw.b, lr.Len)
+	if err := cw.flush(); err != nil {
+		return err
+	}
+	n, err := io.CopyN(cw.w, lr.R, lr.Len)
+	if err == io.EOF || (err == nil && n < lr.Len) {
+		return resp.ShortReaderError
+	} else if err != nil {
+		return err
+	}
+	cw.b = append(cw.b, "\r\n"...)
+	return nil
+}
+
+// cut moves the part of b which isn't in bufs yet into it. Later appends to b
+// never overwrite what's been cut, since they either go after it or into a
+// new array.
+func (cw *cmdWriter) cut() {
+	if len(cw.b) > cw.start {
+		cw.bufs = append(cw.bufs, cw.b[cw.start:])
+		cw.start = len(cw.b)
+	}
+}
+
+// flush writes everything added so far
+func (cw *cmdWriter) flush() error {
+	var err error
+	if len(cw.bufs) == 0 {
+		if len(cw.b) > 0 {
+			_, err = cw.w.Write(cw.b)
+		}
+	} else {
+		cw.cut()
+		// WriteTo consumes the slice it's called on, so use a copy of it.
+		// That's kept in the cmdWriter, since a local would escape.
+		cw.writing = cw.bufs
+		_, err = cw.writing.WriteTo(cw.w)
+		for i := range cw.bufs {
+			cw.bufs[i] = nil
+		}
+	}
+	cw.b, cw.bufs, cw.start = cw.b[:0], cw.bufs[:0], 0
+	return err
+}
+
+// release returns the cmdWriter to the pool. It mustn't be used afterwards.
+func (cw *cmdWriter) release() {
+	cw.w = nil
+	if cap(cw.b) > writeBufMax {
+		return
+	}
+	cw.b, cw.bufs, cw.start = cw.b[:0], cw.bufs[:0], 0
+	cmdWriterPool.Put(cw)
+}
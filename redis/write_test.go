@@ -0,0 +1,93 @@
+package redis
+
+import (
+	"bytes"
+	"github.com/fzzy/radix/redis/resp"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"strings"
+	. "testing"
+)
+
+// countWriter counts how many writes are made to it
+type countWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countWriter) Write(b []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(b)
+}
+
+func encoded(cmds ...[]interface{}) string {
+	buf := new(bytes.Buffer)
+	for _, cmd := range cmds {
+		resp.WriteArbitraryAsFlattenedStrings(buf, cmd)
+	}
+	return buf.String()
+}
+
+func TestCmdWriter(t *T) {
+	w := new(countWriter)
+	cw := getCmdWriter(w)
+	assert.Nil(t, cw.writeCommand("SET", []interface{}{"foo", 1, 1.5, true, nil}))
+	assert.Nil(t, cw.writeCommand("GET", []interface{}{[]byte("foo")}))
+	assert.Equal(t, 0, w.writes)
+	assert.Nil(t, cw.flush())
+	assert.Equal(t, 1, w.writes)
+	assert.Equal(t, encoded(
+		[]interface{}{"SET", "foo", 1, 1.5, true, ""},
+		[]interface{}{"GET", []byte("foo")},
+	), w.String())
+
+	// Large arguments are written as they are, alongside the rest
+	large := bytes.Repeat([]byte("x"), vectorMin)
+	w.Reset()
+	assert.Nil(t, cw.writeCommand("SET", []interface{}{"a", large}))
+	assert.Nil(t, cw.writeCommand("SET", []interface{}{"b", large, "c"}))
+	assert.Nil(t, cw.flush())
+	assert.Equal(t, encoded(
+		[]interface{}{"SET", "a", large},
+		[]interface{}{"SET", "b", large, "c"},
+	), w.String())
+
+	// As are LenReaders, which are streamed
+	w.Reset()
+	lr := resp.NewLenReader(strings.NewReader("ohey"), 4)
+	assert.Nil(t, cw.writeCommand("SET", []interface{}{"a", lr}))
+	assert.Nil(t, cw.flush())
+	assert.Equal(t, encoded([]interface{}{"SET", "a", "ohey"}), w.String())
+
+	lr = resp.NewLenReader(strings.NewReader("oh"), 4)
+	assert.Equal(t, resp.ShortReaderError, cw.writeCommand("SET", []interface{}{"a", lr}))
+	cw.release()
+}
+
+func TestCmdWriterAllocs(t *T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	args := []interface{}{"foo", []byte("bar"), 123, bytes.Repeat([]byte("x"), vectorMin)}
+	allocs := AllocsPerRun(100, func() {
+		cw := getCmdWriter(ioutil.Discard)
+		cw.writeCommand("SET", args)
+		cw.writeCommand("GET", args[:1])
+		cw.flush()
+		cw.release()
+	})
+	assert.Equal(t, float64(0), allocs)
+}
+
+func TestWriteLarge(t *T) {
+	c := dial(t)
+	large := bytes.Repeat([]byte("0123456789"), vectorMin)
+	c.Append("SET", "write:large", large)
+	c.Append("GET", "write:large")
+	c.Append("DEL", "write:large")
+	assert.Nil(t, c.GetReply().Err)
+	b, err := c.GetReply().Bytes()
+	assert.Nil(t, err)
+	assert.Equal(t, large, b)
+	assert.Nil(t, c.GetReply().Err)
+}
@@ -0,0 +1,196 @@
+package redis
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Member is an element of a sorted set along with its score
+type Member struct {
+	Value string
+	Score float64
+}
+
+// Members returns a multi bulk reply of alternating values and scores, as
+// returned by ZPOPMIN or ZRANGE with WITHSCORES, as a slice of Members. A reply
+// of [value, score] pairs, as nested in the reply of ZMPOP, is also accepted.
+func (r *Reply) Members() ([]Member, error) {
+	if r.Type == MultiReply && len(r.Elems) > 0 && r.Elems[0].Type == MultiReply {
+		return r.memberPairs()
+	}
+	l, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+	if len(l)%2 != 0 {
+		return nil, errors.New("reply has odd number of elements")
+	}
+	ms := make([]Member, len(l)/2)
+	for i := range ms {
+		ms[i].Value = l[i*2]
+		if ms[i].Score, err = strconv.ParseFloat(l[i*2+1], 64); err != nil {
+			return nil, err
+		}
+	}
+	return ms, nil
+}
+
+func (r *Reply) memberPairs() ([]Member, error) {
+	ms := make([]Member, len(r.Elems))
+	for i, e := range r.Elems {
+		l, err := e.List()
+		if err != nil {
+			return nil, err
+		}
+		if len(l) != 2 {
+			return nil, errors.New("member does not have 2 elements")
+		}
+		ms[i].Value = l[0]
+		if ms[i].Score, err = strconv.ParseFloat(l[1], 64); err != nil {
+			return nil, err
+		}
+	}
+	return ms, nil
+}
+
+// ZRangeWithScores returns the members of the sorted set at key from index
+// start to stop inclusive, lowest score first. Negative indexes count back from
+// the end, as with ZRANGE.
+func (c *Client) ZRangeWithScores(key string, start, stop int) ([]Member, error) {
+	return c.Cmd("ZRANGE", key, start, stop, "WITHSCORES").Members()
+}
+
+// ZRevRangeWithScores is like ZRangeWithScores, but highest score first
+func (c *Client) ZRevRangeWithScores(key string, start, stop int) ([]Member, error) {
+	return c.Cmd("ZREVRANGE", key, start, stop, "WITHSCORES").Members()
+}
+
+// ZRangeByScoreWithScores returns the members of the sorted set at key with
+// scores between min and max, lowest first. The bounds are given as they are to
+// ZRANGEBYSCORE, so they can be exclusive (e.g. "(1") or infinite ("-inf").
+func (c *Client) ZRangeByScoreWithScores(key, min, max string) ([]Member, error) {
+	return c.Cmd("ZRANGEBYSCORE", key, min, max, "WITHSCORES").Members()
+}
+
+// ZPopMin removes and returns up to count members with the lowest scores from
+// the sorted set at key, lowest first
+func (c *Client) ZPopMin(key string, count int) ([]Member, error) {
+	return c.Cmd("ZPOPMIN", key, count).Members()
+}
+
+// ZPopMax removes and returns up to count members with the highest scores from
+// the sorted set at key, highest first
+func (c *Client) ZPopMax(key string, count int) ([]Member, error) {
+	return c.Cmd("ZPOPMAX", key, count).Members()
+}
+
+// BZPopMin removes and returns the member with the lowest score from the first
+// non-empty sorted set of the given keys, along with the key it came from. If
+// they're all empty it blocks until a member is added or the timeout (zero
+// meaning forever) is reached, in which case key is empty.
+func (c *Client) BZPopMin(timeout time.Duration, keys ...string) (
+	key string, m Member, err error,
+) {
+	return bzpop(c.blockingCmd(timeout, "BZPOPMIN", keys))
+}
+
+// BZPopMax is like BZPopMin, but for the member with the highest score
+func (c *Client) BZPopMax(timeout time.Duration, keys ...string) (
+	key string, m Member, err error,
+) {
+	return bzpop(c.blockingCmd(timeout, "BZPOPMAX", keys))
+}
+
+func bzpop(r *Reply) (string, Member, error) {
+	if r.Type == NilReply {
+		return "", Member{}, nil
+	}
+	l, err := r.List()
+	if err != nil {
+		return "", Member{}, err
+	}
+	if len(l) != 3 {
+		return "", Member{}, errors.New("reply does not have 3 elements")
+	}
+	score, err := strconv.ParseFloat(l[2], 64)
+	if err != nil {
+		return "", Member{}, err
+	}
+	return l[0], Member{Value: l[1], Score: score}, nil
+}
+
+// ZAddOpts holds the flags of ZADD, see ZAdd
+type ZAddOpts struct {
+	// Only add new members (NX), or only update existing ones (XX)
+	NX, XX bool
+
+	// Only update existing members if the new score is greater (GT) or less
+	// (LT) than the current one. New members are still added. Redis 6.2 and up.
+	GT, LT bool
+
+	// Count the members whose score changed, as well as the ones added (CH)
+	CH bool
+}
+
+func (o ZAddOpts) args() ([]interface{}, error) {
+	if o.NX && o.XX {
+		return nil, errors.New("NX and XX can not both be set")
+	}
+	if o.GT && o.LT {
+		return nil, errors.New("GT and LT can not both be set")
+	}
+	if o.NX && (o.GT || o.LT) {
+		return nil, errors.New("NX can not be set with GT or LT")
+	}
+	var args []interface{}
+	for _, f := range []struct {
+		set  bool
+		flag string
+	}{{o.NX, "NX"}, {o.XX, "XX"}, {o.GT, "GT"}, {o.LT, "LT"}, {o.CH, "CH"}} {
+		if f.set {
+			args = append(args, f.flag)
+		}
+	}
+	return args, nil
+}
+
+// ZAdd adds the members to the sorted set at key, or updates their scores if
+// they're already in it, as allowed by opts. It returns the number of members
+// added, or with opts.CH the number added or changed.
+func (c *Client) ZAdd(key string, opts ZAddOpts, members ...Member) (int, error) {
+	args, err := opts.args()
+	if err != nil {
+		return 0, err
+	}
+	args = append([]interface{}{key}, args...)
+	for _, m := range members {
+		args = append(args, m.Score, m.Value)
+	}
+	return c.Cmd("ZADD", args...).Int()
+}
+
+// ZAddIncr uses ZADD with INCR to add m.Score to the score of m.Value in the
+// sorted set at key, adding it if it's not in it yet, and returns the new
+// score. If opts stopped the member from being added or updated ok is false.
+func (c *Client) ZAddIncr(key string, opts ZAddOpts, m Member) (
+	score float64, ok bool, err error,
+) {
+	args, err := opts.args()
+	if err != nil {
+		return 0, false, err
+	}
+	args = append([]interface{}{key}, args...)
+	r := c.Cmd("ZADD", append(args, "INCR", m.Score, m.Value)...)
+	if r.Type == NilReply {
+		return 0, false, nil
+	}
+	s, err := r.Str()
+	if err != nil {
+		return 0, false, err
+	}
+	if score, err = strconv.ParseFloat(s, 64); err != nil {
+		return 0, false, err
+	}
+	return score, true, nil
+}
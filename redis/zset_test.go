@@ -0,0 +1,129 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	. "testing"
+	"time"
+)
+
+func TestZPop(t *T) {
+	c := dial(t)
+	c.Cmd("DEL", "zset:pop")
+	c.Cmd("ZADD", "zset:pop", 1, "a", 2, "b", 3, "c", 4.5, "d")
+
+	ms, err := c.ZPopMin("zset:pop", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []Member{{"a", 1}, {"b", 2}}, ms)
+
+	ms, err = c.ZPopMax("zset:pop", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []Member{{"d", 4.5}}, ms)
+
+	key, m, err := c.BZPopMin(time.Second, "zset:empty", "zset:pop")
+	assert.Nil(t, err)
+	assert.Equal(t, "zset:pop", key)
+	assert.Equal(t, Member{"c", 3}, m)
+
+	ms, err = c.ZPopMin("zset:pop", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []Member{}, ms)
+}
+
+func TestBZPopTimeout(t *T) {
+	c, err := DialTimeout("tcp", "127.0.0.1:6379", 100*time.Millisecond)
+	assert.Nil(t, err)
+	c.Cmd("DEL", "zset:empty")
+
+	// The read timeout is extended so redis' own timeout is hit first
+	key, _, err := c.BZPopMax(200*time.Millisecond, "zset:empty")
+	assert.Nil(t, err)
+	assert.Equal(t, "", key)
+	assert.Equal(t, 100*time.Millisecond, c.readTimeout)
+}
+
+func TestZAdd(t *T) {
+	c := dial(t)
+	key := "zset:zadd"
+	c.Cmd("DEL", key)
+
+	n, err := c.ZAdd(key, ZAddOpts{}, Member{"a", 1}, Member{"b", 2})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+
+	n, err = c.ZAdd(key, ZAddOpts{NX: true}, Member{"a", 5}, Member{"c", 3})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+
+	n, err = c.ZAdd(key, ZAddOpts{XX: true, CH: true}, Member{"a", 5}, Member{"d", 4})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+
+	n, err = c.ZAdd(key, ZAddOpts{GT: true, CH: true}, Member{"a", 4}, Member{"b", 6})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+
+	ms, err := c.Cmd("ZRANGE", key, 0, -1, "WITHSCORES").Members()
+	assert.Nil(t, err)
+	assert.Equal(t, []Member{{"c", 3}, {"a", 5}, {"b", 6}}, ms)
+
+	_, err = c.ZAdd(key, ZAddOpts{NX: true, GT: true}, Member{"a", 1})
+	assert.NotNil(t, err)
+}
+
+func TestZAddIncr(t *T) {
+	c := dial(t)
+	key := "zset:zaddincr"
+	c.Cmd("DEL", key)
+
+	score, ok, err := c.ZAddIncr(key, ZAddOpts{}, Member{"a", 1.5})
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1.5, score)
+
+	score, ok, err = c.ZAddIncr(key, ZAddOpts{XX: true}, Member{"a", 2})
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 3.5, score)
+
+	_, ok, err = c.ZAddIncr(key, ZAddOpts{NX: true}, Member{"a", 2})
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestMembers(t *T) {
+	flat := NewReply([]interface{}{"a", "1", "b", "2.5"})
+	ms, err := flat.Members()
+	assert.Nil(t, err)
+	assert.Equal(t, []Member{{"a", 1}, {"b", 2.5}}, ms)
+
+	paired := NewReply([]interface{}{
+		[]interface{}{"a", "1"}, []interface{}{"b", "2.5"},
+	})
+	ms, err = paired.Members()
+	assert.Nil(t, err)
+	assert.Equal(t, []Member{{"a", 1}, {"b", 2.5}}, ms)
+
+	_, err = NewReply([]interface{}{"a"}).Members()
+	assert.NotNil(t, err)
+	_, err = NewReply([]interface{}{[]interface{}{"a"}}).Members()
+	assert.NotNil(t, err)
+}
+
+func TestZRangeWithScores(t *T) {
+	c := dial(t)
+	key := "zset:range"
+	c.Cmd("DEL", key)
+	c.Cmd("ZADD", key, 1, "a", 2, "b", 3, "c")
+
+	ms, err := c.ZRangeWithScores(key, 0, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []Member{{"a", 1}, {"b", 2}}, ms)
+
+	ms, err = c.ZRevRangeWithScores(key, 0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []Member{{"c", 3}}, ms)
+
+	ms, err = c.ZRangeByScoreWithScores(key, "(1", "+inf")
+	assert.Nil(t, err)
+	assert.Equal(t, []Member{{"b", 2}, {"c", 3}}, ms)
+}